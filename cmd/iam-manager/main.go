@@ -66,6 +66,7 @@ This tool handles user creation, policy management, StackSet deployment, and cro
 	rootCmd.AddCommand(stacksetStatusCmd())
 	rootCmd.AddCommand(stacksetDeleteCmd())
 	rootCmd.AddCommand(statusCmd())
+	rootCmd.AddCommand(pricingRefreshCmd())
 
 	// Add Azure commands
 	azureCmd := &cobra.Command{
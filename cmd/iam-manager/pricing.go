@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/pricing"
+	"github.com/spf13/cobra"
+)
+
+// defaultPriceFile is where the refreshed price list is written: the first PRICE_LIST_FILES entry,
+// the state directory, or the working directory
+func defaultPriceFile() string {
+	if files := strings.Split(os.Getenv("PRICE_LIST_FILES"), ","); strings.TrimSpace(files[0]) != "" {
+		return strings.TrimSpace(files[0])
+	}
+	if stateDir := os.Getenv("STATE_DIR"); stateDir != "" {
+		return filepath.Join(stateDir, "aws-prices.json")
+	}
+	return "aws-prices.json"
+}
+
+func pricingRefreshCmd() *cobra.Command {
+	var regions, output string
+	var from []string
+
+	cmd := &cobra.Command{
+		Use:   "pricing-refresh",
+		Short: "Refresh the price list used for cost estimates",
+		Long: `Download the AWS Price List bulk offers for EC2 (including EBS and NAT gateways) and Elastic Load Balancing
+in the given regions and write the on-demand prices to a trimmed price file. Use --from to build the file
+from bulk JSON or CSV files downloaded beforehand. Reload the server's prices with POST /api/pricing/reload.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var prices *pricing.PriceList
+			var err error
+
+			if len(from) > 0 {
+				logInfo(fmt.Sprintf("Loading prices from %s", strings.Join(from, ", ")))
+				prices, err = pricing.Load(from...)
+			} else {
+				var regionList []string
+				for _, region := range strings.Split(regions, ",") {
+					if region = strings.TrimSpace(region); region != "" {
+						regionList = append(regionList, region)
+					}
+				}
+				logInfo(fmt.Sprintf("Downloading prices for %s", strings.Join(regionList, ", ")))
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+				defer cancel()
+				prices, err = pricing.Refresh(ctx, http.DefaultClient, regionList)
+			}
+			if err != nil {
+				logError(fmt.Sprintf("Failed to load prices: %v", err))
+				os.Exit(1)
+			}
+
+			if err := prices.Save(output); err != nil {
+				logError(fmt.Sprintf("Failed to save prices: %v", err))
+				os.Exit(1)
+			}
+
+			ec2Prices, ebsPrices, natPrices, lbPrices := prices.Counts()
+			logSuccess(fmt.Sprintf("Wrote %d EC2, %d EBS, %d NAT gateway and %d load balancer prices to %s",
+				ec2Prices, ebsPrices, natPrices, lbPrices, output))
+		},
+	}
+
+	cmd.Flags().StringVar(&regions, "regions", getEnvOrDefault("REGIONS", defaultRegions), "Comma-separated regions to download prices for")
+	cmd.Flags().StringVar(&output, "output", defaultPriceFile(), "Price file to write")
+	cmd.Flags().StringSliceVar(&from, "from", nil, "Bulk price list JSON or CSV files to read instead of downloading")
+	return cmd
+}
@@ -2,15 +2,44 @@ package config
 
 import (
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds application configuration
 type Config struct {
-	Port         string
-	AWSRegion    string
-	RoleName     string
+	Port          string
+	AWSRegion     string
+	RoleName      string
 	AdminUsername string
 	AdminPassword string
+	// Secret handoff settings for newly created access keys and passwords
+	SecretStoreDir      string
+	SecretEncryptionKey string
+	SecretHandoffTTL    time.Duration
+	// Directory for persistent state such as key rotations; empty keeps state in memory
+	StateDir string
+	// Staged access key rotation delays
+	KeyRotationDeactivateDelay time.Duration
+	KeyRotationDeleteDelay     time.Duration
+	// Unused role detection and staged cleanup
+	UnusedRoleDays            int
+	UnusedRoleMinAgeDays      int
+	RoleCleanupAllowlistTags  []string // "key" or "key=value"
+	RoleCleanupAllowlistPaths []string // Path prefixes
+	RoleCleanupGracePeriod    time.Duration
+	// Security group port risk overrides: "port=severity" or "from-to=severity[:service]"
+	SecurityGroupPortRisks []string
+	// Regions resource scanners cover: names or prefixes ending in "*"; empty scans every enabled region
+	RegionScope []string
+	// AWS Price List files (bulk JSON/CSV or the trimmed file written by pricing-refresh) used for cost estimates
+	PriceListFiles []string
+	// Days of CloudWatch metrics the EC2 utilization analysis covers by default
+	EC2UtilizationDays int
+	// Run start/stop schedules in this process; replicas sharing STATE_DIR elect one leader
+	SchedulerEnabled bool
 }
 
 // LoadConfig creates and returns application configuration from environment variables
@@ -40,11 +69,100 @@ func LoadConfig() Config {
 		adminPassword = "admin" // Default for development only
 	}
 
+	// Empty directory keeps handed-off secrets in memory only
+	secretStoreDir := os.Getenv("SECRET_STORE_DIR")
+
+	// Base64-encoded 32-byte key; a random per-process key is used if empty
+	secretEncryptionKey := os.Getenv("SECRET_ENCRYPTION_KEY")
+
+	secretHandoffTTL := 15 * time.Minute
+	if ttl, err := time.ParseDuration(os.Getenv("SECRET_HANDOFF_TTL")); err == nil && ttl > 0 {
+		secretHandoffTTL = ttl
+	}
+
+	stateDir := os.Getenv("STATE_DIR")
+
+	keyRotationDeactivateDelay := 24 * time.Hour
+	if delay, err := time.ParseDuration(os.Getenv("KEY_ROTATION_DEACTIVATE_DELAY")); err == nil && delay >= 0 {
+		keyRotationDeactivateDelay = delay
+	}
+
+	keyRotationDeleteDelay := 7 * 24 * time.Hour
+	if delay, err := time.ParseDuration(os.Getenv("KEY_ROTATION_DELETE_DELAY")); err == nil && delay >= 0 {
+		keyRotationDeleteDelay = delay
+	}
+
+	unusedRoleDays := 90
+	if days, err := strconv.Atoi(os.Getenv("UNUSED_ROLE_DAYS")); err == nil && days > 0 {
+		unusedRoleDays = days
+	}
+
+	unusedRoleMinAgeDays := 30
+	if days, err := strconv.Atoi(os.Getenv("UNUSED_ROLE_MIN_AGE_DAYS")); err == nil && days >= 0 {
+		unusedRoleMinAgeDays = days
+	}
+
+	roleCleanupAllowlistTags := []string{"iam-manager:keep"}
+	if tags := splitList(os.Getenv("ROLE_CLEANUP_ALLOWLIST_TAGS")); len(tags) > 0 {
+		roleCleanupAllowlistTags = tags
+	}
+
+	roleCleanupGracePeriod := 14 * 24 * time.Hour
+	if period, err := time.ParseDuration(os.Getenv("ROLE_CLEANUP_GRACE_PERIOD")); err == nil && period >= 0 {
+		roleCleanupGracePeriod = period
+	}
+
+	// Defaults to the refreshed price file in the state directory; built-in us-east-1 prices are used without one
+	priceListFiles := splitList(os.Getenv("PRICE_LIST_FILES"))
+	if len(priceListFiles) == 0 && stateDir != "" {
+		priceListFiles = []string{filepath.Join(stateDir, "aws-prices.json")}
+	}
+
+	ec2UtilizationDays := 14
+	if days, err := strconv.Atoi(os.Getenv("EC2_UTILIZATION_DAYS")); err == nil && days > 0 && days <= 90 {
+		ec2UtilizationDays = days
+	}
+
+	schedulerEnabled := true
+	if enabled, err := strconv.ParseBool(os.Getenv("SCHEDULER_ENABLED")); err == nil {
+		schedulerEnabled = enabled
+	}
+
 	return Config{
-		Port:         port,
-		AWSRegion:    region,
-		RoleName:     roleName,
-		AdminUsername: adminUsername,
-		AdminPassword: adminPassword,
+		Port:                port,
+		AWSRegion:           region,
+		RoleName:            roleName,
+		AdminUsername:       adminUsername,
+		AdminPassword:       adminPassword,
+		SecretStoreDir:      secretStoreDir,
+		SecretEncryptionKey: secretEncryptionKey,
+		SecretHandoffTTL:    secretHandoffTTL,
+		StateDir:            stateDir,
+
+		KeyRotationDeactivateDelay: keyRotationDeactivateDelay,
+		KeyRotationDeleteDelay:     keyRotationDeleteDelay,
+
+		UnusedRoleDays:            unusedRoleDays,
+		UnusedRoleMinAgeDays:      unusedRoleMinAgeDays,
+		RoleCleanupAllowlistTags:  roleCleanupAllowlistTags,
+		RoleCleanupAllowlistPaths: splitList(os.Getenv("ROLE_CLEANUP_ALLOWLIST_PATHS")),
+		RoleCleanupGracePeriod:    roleCleanupGracePeriod,
+
+		SecurityGroupPortRisks: splitList(os.Getenv("SG_PORT_RISKS")),
+		RegionScope:            splitList(os.Getenv("SCAN_REGIONS")),
+		PriceListFiles:         priceListFiles,
+		EC2UtilizationDays:     ec2UtilizationDays,
+		SchedulerEnabled:       schedulerEnabled,
+	}
+}
+
+// splitList splits a comma-separated environment value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
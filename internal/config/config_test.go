@@ -33,3 +33,95 @@ func TestLoadConfig(t *testing.T) {
 	os.Unsetenv("AWS_REGION")
 	os.Unsetenv("IAM_ORG_ROLE_NAME")
 }
+
+func TestLoadConfigRoleCleanup(t *testing.T) {
+	os.Unsetenv("UNUSED_ROLE_DAYS")
+	os.Unsetenv("ROLE_CLEANUP_ALLOWLIST_TAGS")
+	os.Unsetenv("ROLE_CLEANUP_ALLOWLIST_PATHS")
+
+	cfg := LoadConfig()
+	assert.Equal(t, 90, cfg.UnusedRoleDays)
+	assert.Equal(t, []string{"iam-manager:keep"}, cfg.RoleCleanupAllowlistTags)
+	assert.Empty(t, cfg.RoleCleanupAllowlistPaths)
+
+	os.Setenv("UNUSED_ROLE_DAYS", "30")
+	os.Setenv("ROLE_CLEANUP_ALLOWLIST_TAGS", "keep, team=platform,")
+	os.Setenv("ROLE_CLEANUP_ALLOWLIST_PATHS", "/breakglass/")
+
+	cfg = LoadConfig()
+	assert.Equal(t, 30, cfg.UnusedRoleDays)
+	assert.Equal(t, []string{"keep", "team=platform"}, cfg.RoleCleanupAllowlistTags)
+	assert.Equal(t, []string{"/breakglass/"}, cfg.RoleCleanupAllowlistPaths)
+
+	// Clean up
+	os.Unsetenv("UNUSED_ROLE_DAYS")
+	os.Unsetenv("ROLE_CLEANUP_ALLOWLIST_TAGS")
+	os.Unsetenv("ROLE_CLEANUP_ALLOWLIST_PATHS")
+}
+
+func TestLoadConfigSecurityGroupPortRisks(t *testing.T) {
+	os.Unsetenv("SG_PORT_RISKS")
+	assert.Empty(t, LoadConfig().SecurityGroupPortRisks)
+
+	os.Setenv("SG_PORT_RISKS", "8080=high:Jenkins, 9000-9100=medium")
+	assert.Equal(t, []string{"8080=high:Jenkins", "9000-9100=medium"}, LoadConfig().SecurityGroupPortRisks)
+
+	// Clean up
+	os.Unsetenv("SG_PORT_RISKS")
+}
+
+func TestLoadConfigRegionScope(t *testing.T) {
+	os.Unsetenv("SCAN_REGIONS")
+	assert.Empty(t, LoadConfig().RegionScope)
+
+	os.Setenv("SCAN_REGIONS", "us-east-1, eu-*")
+	assert.Equal(t, []string{"us-east-1", "eu-*"}, LoadConfig().RegionScope)
+
+	// Clean up
+	os.Unsetenv("SCAN_REGIONS")
+}
+
+func TestLoadConfigPriceListFiles(t *testing.T) {
+	os.Unsetenv("PRICE_LIST_FILES")
+	os.Unsetenv("STATE_DIR")
+	assert.Empty(t, LoadConfig().PriceListFiles)
+
+	os.Setenv("STATE_DIR", "/var/lib/iam-manager")
+	assert.Equal(t, []string{"/var/lib/iam-manager/aws-prices.json"}, LoadConfig().PriceListFiles)
+
+	os.Setenv("PRICE_LIST_FILES", "/data/AmazonEC2.csv, /data/AWSELB.json")
+	assert.Equal(t, []string{"/data/AmazonEC2.csv", "/data/AWSELB.json"}, LoadConfig().PriceListFiles)
+
+	// Clean up
+	os.Unsetenv("PRICE_LIST_FILES")
+	os.Unsetenv("STATE_DIR")
+}
+
+func TestLoadConfigEC2UtilizationDays(t *testing.T) {
+	os.Unsetenv("EC2_UTILIZATION_DAYS")
+	assert.Equal(t, 14, LoadConfig().EC2UtilizationDays)
+
+	os.Setenv("EC2_UTILIZATION_DAYS", "30")
+	assert.Equal(t, 30, LoadConfig().EC2UtilizationDays)
+
+	// Out of range values fall back to the default
+	os.Setenv("EC2_UTILIZATION_DAYS", "365")
+	assert.Equal(t, 14, LoadConfig().EC2UtilizationDays)
+
+	// Clean up
+	os.Unsetenv("EC2_UTILIZATION_DAYS")
+}
+
+func TestLoadConfigSchedulerEnabled(t *testing.T) {
+	os.Unsetenv("SCHEDULER_ENABLED")
+	assert.True(t, LoadConfig().SchedulerEnabled)
+
+	os.Setenv("SCHEDULER_ENABLED", "false")
+	assert.False(t, LoadConfig().SchedulerEnabled)
+
+	os.Setenv("SCHEDULER_ENABLED", "not-a-bool")
+	assert.True(t, LoadConfig().SchedulerEnabled)
+
+	// Clean up
+	os.Unsetenv("SCHEDULER_ENABLED")
+}
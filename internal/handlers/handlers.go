@@ -2,13 +2,16 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/rusik69/aws-iam-manager/internal/config"
 	"github.com/rusik69/aws-iam-manager/internal/middleware"
+	"github.com/rusik69/aws-iam-manager/internal/models"
 	"github.com/rusik69/aws-iam-manager/internal/services"
 
 	"github.com/gin-gonic/gin"
@@ -327,6 +330,339 @@ func (h *Handler) RotateUserPassword(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// ============================================================================
+// STAGED KEY ROTATION HANDLERS
+// ============================================================================
+
+// keyRotationErrorStatus maps key rotation errors to HTTP status codes
+func keyRotationErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrKeyRotationNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrKeyRotationNotReady),
+		strings.Contains(err.Error(), "already"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "cannot access account"):
+		return http.StatusForbidden
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *Handler) StartKeyRotation(c *gin.Context) {
+	accountID := c.Param("accountId")
+	username := c.Param("username")
+	keyID := c.Param("keyId")
+
+	rotation, err := h.awsService.StartKeyRotation(accountID, username, keyID)
+	if err != nil {
+		fmt.Printf("[ERROR] StartKeyRotation failed for key %s, user %s in account %s: %v\n", keyID, username, accountID, err)
+		c.JSON(keyRotationErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, rotation)
+}
+
+func (h *Handler) ListKeyRotations(c *gin.Context) {
+	c.JSON(http.StatusOK, h.awsService.ListKeyRotations())
+}
+
+func (h *Handler) GetKeyRotation(c *gin.Context) {
+	rotationID := c.Param("rotationId")
+	rotation, err := h.awsService.GetKeyRotation(rotationID)
+	if err != nil {
+		c.JSON(keyRotationErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, rotation)
+}
+
+func (h *Handler) AdvanceKeyRotation(c *gin.Context) {
+	rotationID := c.Param("rotationId")
+	force := c.Query("force") == "true"
+
+	rotation, err := h.awsService.AdvanceKeyRotation(rotationID, force)
+	if err != nil {
+		fmt.Printf("[ERROR] AdvanceKeyRotation failed for rotation %s: %v\n", rotationID, err)
+		c.JSON(keyRotationErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, rotation)
+}
+
+func (h *Handler) RollbackKeyRotation(c *gin.Context) {
+	rotationID := c.Param("rotationId")
+
+	rotation, err := h.awsService.RollbackKeyRotation(rotationID)
+	if err != nil {
+		fmt.Printf("[ERROR] RollbackKeyRotation failed for rotation %s: %v\n", rotationID, err)
+		c.JSON(keyRotationErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, rotation)
+}
+
+// RedeemSecret returns a handed-off secret exactly once
+func (h *Handler) RedeemSecret(c *gin.Context) {
+	token := c.Param("token")
+
+	// Never let intermediaries keep a copy of the response
+	c.Header("Cache-Control", "no-store")
+
+	secret, err := h.awsService.RetrieveSecret(token)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, services.ErrSecretNotFound) {
+			statusCode = http.StatusNotFound
+		} else if errors.Is(err, services.ErrSecretExpired) {
+			statusCode = http.StatusGone
+		} else {
+			fmt.Printf("[ERROR] RedeemSecret failed: %v\n", err)
+		}
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, secret)
+}
+
+// ============================================================================
+// JOB HANDLERS
+// ============================================================================
+
+func (h *Handler) ListJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, h.awsService.ListJobs())
+}
+
+func (h *Handler) GetJob(c *gin.Context) {
+	jobID := c.Param("jobId")
+	job, err := h.awsService.GetJob(jobID)
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, services.ErrJobNotFound) {
+			statusCode = http.StatusNotFound
+		}
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// ============================================================================
+// REGION DISCOVERY HANDLERS
+// ============================================================================
+
+func (h *Handler) GetAccountRegions(c *gin.Context) {
+	accountID := c.Param("accountId")
+	regions, err := h.awsService.GetAccountRegions(accountID)
+	if err != nil {
+		fmt.Printf("[ERROR] GetAccountRegions failed for account %s: %v\n", accountID, err)
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "cannot access account") {
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, regions)
+}
+
+func (h *Handler) ListScanCoverage(c *gin.Context) {
+	c.JSON(http.StatusOK, h.awsService.ListScanCoverage(c.Query("scanner"), c.Query("account_id")))
+}
+
+// ============================================================================
+// PRICING HANDLERS
+// ============================================================================
+
+func (h *Handler) GetPriceListStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.awsService.GetPriceListStatus())
+}
+
+func (h *Handler) ReloadPriceList(c *gin.Context) {
+	status, err := h.awsService.ReloadPriceList()
+	if err != nil {
+		fmt.Printf("[ERROR] ReloadPriceList failed: %v\n", err)
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "no price list files configured") {
+			statusCode = http.StatusBadRequest
+		}
+		c.JSON(statusCode, gin.H{
+			"error":  err.Error(),
+			"status": status,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// ============================================================================
+// PRIVILEGE ESCALATION HANDLERS
+// ============================================================================
+
+func (h *Handler) AnalyzePrivilegeEscalation(c *gin.Context) {
+	accountID := c.Param("accountId")
+	findings, err := h.awsService.AnalyzePrivilegeEscalation(accountID)
+	if err != nil {
+		fmt.Printf("[ERROR] AnalyzePrivilegeEscalation failed for account %s: %v\n", accountID, err)
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "cannot access account") {
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, findings)
+}
+
+func (h *Handler) AnalyzeOrgPrivilegeEscalation(c *gin.Context) {
+	findings, err := h.awsService.AnalyzeOrgPrivilegeEscalation()
+	if err != nil {
+		fmt.Printf("[ERROR] AnalyzeOrgPrivilegeEscalation failed: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, findings)
+}
+
+// ============================================================================
+// ACCESS ADVISOR HANDLERS
+// ============================================================================
+
+// parseRecentDays reads the "days" query parameter used to decide what counts as recently used
+func parseRecentDays(c *gin.Context) (int, bool) {
+	var days int
+	if _, err := fmt.Sscanf(c.DefaultQuery("days", "90"), "%d", &days); err != nil || days <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "days must be a positive integer",
+		})
+		return 0, false
+	}
+	return days, true
+}
+
+func (h *Handler) AnalyzeAccountAccessAdvisor(c *gin.Context) {
+	accountID := c.Param("accountId")
+	days, ok := parseRecentDays(c)
+	if !ok {
+		return
+	}
+
+	job := h.awsService.AnalyzeAccountAccessAdvisor(accountID, days)
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (h *Handler) AnalyzeOrgAccessAdvisor(c *gin.Context) {
+	days, ok := parseRecentDays(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.awsService.AnalyzeOrgAccessAdvisor(days)
+	if err != nil {
+		fmt.Printf("[ERROR] AnalyzeOrgAccessAdvisor failed: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ============================================================================
+// POLICY SIMULATOR HANDLERS
+// ============================================================================
+
+func simulationErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	case strings.Contains(err.Error(), "cannot access account"):
+		return http.StatusForbidden
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// simulatePrincipal runs a simulation for one principal in one account
+func (h *Handler) simulatePrincipal(c *gin.Context, principalType, principalName string) {
+	accountID := c.Param("accountId")
+
+	var req models.SimulationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	result, err := h.awsService.SimulatePrincipalPolicy(accountID, principalType, principalName, req)
+	if err != nil {
+		fmt.Printf("[ERROR] SimulatePrincipalPolicy failed for %s %s in account %s: %v\n", principalType, principalName, accountID, err)
+		c.JSON(simulationErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// simulatePrincipalAcrossAccounts runs a simulation in every account where the principal exists
+func (h *Handler) simulatePrincipalAcrossAccounts(c *gin.Context, principalType, principalName string) {
+	var req models.SimulationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	results, err := h.awsService.SimulatePrincipalPolicyAcrossAccounts(principalType, principalName, req)
+	if err != nil {
+		fmt.Printf("[ERROR] SimulatePrincipalPolicyAcrossAccounts failed for %s %s: %v\n", principalType, principalName, err)
+		c.JSON(simulationErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+func (h *Handler) SimulateUserPolicy(c *gin.Context) {
+	h.simulatePrincipal(c, "user", c.Param("username"))
+}
+
+func (h *Handler) SimulateRolePolicy(c *gin.Context) {
+	h.simulatePrincipal(c, "role", c.Param("roleName"))
+}
+
+func (h *Handler) SimulateUserPolicyAcrossAccounts(c *gin.Context) {
+	h.simulatePrincipalAcrossAccounts(c, "user", c.Param("username"))
+}
+
+func (h *Handler) SimulateRolePolicyAcrossAccounts(c *gin.Context) {
+	h.simulatePrincipalAcrossAccounts(c, "role", c.Param("roleName"))
+}
+
 func (h *Handler) ListPublicIPs(c *gin.Context) {
 	ips, err := h.awsService.ListPublicIPs()
 	if err != nil {
@@ -492,90 +828,586 @@ func (h *Handler) DeleteSecurityGroup(c *gin.Context) {
 	})
 }
 
-// ============================================================================
-// SNAPSHOT HANDLERS
-// ============================================================================
-
-func (h *Handler) ListSnapshots(c *gin.Context) {
-	snapshots, err := h.awsService.ListSnapshots()
-	if err != nil {
-		fmt.Printf("[ERROR] ListSnapshots failed: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   err.Error(),
-			"details": "Failed to list snapshots. Check AWS credentials and permissions.",
-		})
-		return
-	}
-	c.JSON(http.StatusOK, snapshots)
-}
-
-func (h *Handler) ListSnapshotsByAccount(c *gin.Context) {
+// GetSecurityGroupGraph returns an account's security group reference graph as JSON or Graphviz DOT
+func (h *Handler) GetSecurityGroupGraph(c *gin.Context) {
 	accountID := c.Param("accountId")
+	format := c.DefaultQuery("format", "json")
 
-	if accountID == "" {
+	if format != "json" && format != "dot" {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Account ID is required",
+			"error": "format must be json or dot",
 		})
 		return
 	}
 
-	snapshots, err := h.awsService.ListSnapshotsByAccount(accountID)
+	graph, err := h.awsService.GetSecurityGroupGraph(accountID, c.Query("region"), c.Query("vpc_id"))
 	if err != nil {
-		fmt.Printf("[ERROR] ListSnapshotsByAccount failed for account %s: %v\n", accountID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   err.Error(),
-			"details": fmt.Sprintf("Failed to list snapshots for account %s.", accountID),
+		fmt.Printf("[ERROR] GetSecurityGroupGraph failed for account %s: %v\n", accountID, err)
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "cannot access account") {
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
-	c.JSON(http.StatusOK, snapshots)
-}
-
-func (h *Handler) DeleteSnapshot(c *gin.Context) {
-	accountID := c.Param("accountId")
-	region := c.Param("region")
-	snapshotID := c.Param("snapshotId")
 
-	if accountID == "" || region == "" || snapshotID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Account ID, region, and snapshot ID are required",
-		})
+	if format == "dot" {
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(services.SecurityGroupGraphDOT(graph)))
 		return
 	}
+	c.JSON(http.StatusOK, graph)
+}
 
-	err := h.awsService.DeleteSnapshot(accountID, region, snapshotID)
-	if err != nil {
-		fmt.Printf("[ERROR] DeleteSnapshot failed for snapshot %s in account %s, region %s: %v\n", snapshotID, accountID, region, err)
+// ListSecurityGroupFindings serves both the org-wide and the per-account findings routes
+func (h *Handler) ListSecurityGroupFindings(c *gin.Context) {
+	accountID := c.Param("accountId")
 
+	findings, err := h.awsService.ListSecurityGroupFindings(accountID, c.Query("min_severity"), c.Query("sort"))
+	if err != nil {
+		fmt.Printf("[ERROR] ListSecurityGroupFindings failed: %v\n", err)
 		statusCode := http.StatusInternalServerError
-		if strings.Contains(err.Error(), "not found") {
+		if strings.Contains(err.Error(), "invalid") {
+			statusCode = http.StatusBadRequest
+		} else if strings.Contains(err.Error(), "not found") {
 			statusCode = http.StatusNotFound
 		} else if strings.Contains(err.Error(), "cannot access account") {
 			statusCode = http.StatusForbidden
 		}
-
 		c.JSON(statusCode, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
+	c.JSON(http.StatusOK, findings)
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("Snapshot %s deleted successfully", snapshotID),
-	})
+func (h *Handler) GetPortRiskCatalogue(c *gin.Context) {
+	c.JSON(http.StatusOK, h.awsService.GetPortRiskCatalogue())
 }
 
-func (h *Handler) DeleteOldSnapshots(c *gin.Context) {
+// securityGroupChangeErrorStatus maps rule remediation errors to HTTP status codes
+func securityGroupChangeErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrSecurityGroupChangeNotFound), strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	case strings.Contains(err.Error(), "already reverted"), strings.Contains(err.Error(), "already being reverted"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "cannot access account"):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *Handler) RevokeSecurityGroupRule(c *gin.Context) {
+	accountID := c.Param("accountId")
+	region := c.Param("region")
+	groupID := c.Param("groupId")
+
+	var input models.RevokeRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Rule.IpProtocol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "rule with ip_protocol is required",
+		})
+		return
+	}
+
+	change, err := h.awsService.RevokeSecurityGroupRule(accountID, region, groupID, input)
+	if err != nil {
+		fmt.Printf("[ERROR] RevokeSecurityGroupRule failed for group %s in account %s, region %s: %v\n", groupID, accountID, region, err)
+		c.JSON(securityGroupChangeErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, change)
+}
+
+func (h *Handler) NarrowSecurityGroupRule(c *gin.Context) {
+	accountID := c.Param("accountId")
+	region := c.Param("region")
+	groupID := c.Param("groupId")
+
+	var input models.NarrowRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil || input.Rule.IpProtocol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "rule with ip_protocol is required",
+		})
+		return
+	}
+
+	change, err := h.awsService.NarrowSecurityGroupRule(accountID, region, groupID, input)
+	if err != nil {
+		fmt.Printf("[ERROR] NarrowSecurityGroupRule failed for group %s in account %s, region %s: %v\n", groupID, accountID, region, err)
+		c.JSON(securityGroupChangeErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, change)
+}
+
+func (h *Handler) ListSecurityGroupChanges(c *gin.Context) {
+	c.JSON(http.StatusOK, h.awsService.ListSecurityGroupChanges())
+}
+
+func (h *Handler) RevertSecurityGroupChange(c *gin.Context) {
+	changeID := c.Param("changeId")
+
+	change, err := h.awsService.RevertSecurityGroupChange(changeID)
+	if err != nil {
+		fmt.Printf("[ERROR] RevertSecurityGroupChange failed for change %s: %v\n", changeID, err)
+		c.JSON(securityGroupChangeErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, change)
+}
+
+func (h *Handler) StartUnusedSecurityGroupCleanup(c *gin.Context) {
+	var input models.SecurityGroupCleanupInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	job, err := h.awsService.StartUnusedSecurityGroupCleanup(input)
+	if err != nil {
+		fmt.Printf("[ERROR] StartUnusedSecurityGroupCleanup failed: %v\n", err)
+		c.JSON(securityGroupChangeErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (h *Handler) StartSecurityGroupRemediation(c *gin.Context) {
+	var input models.SecurityGroupRemediationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	job, err := h.awsService.StartSecurityGroupRemediation(input)
+	if err != nil {
+		fmt.Printf("[ERROR] StartSecurityGroupRemediation failed: %v\n", err)
+		c.JSON(securityGroupChangeErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ============================================================================
+// SNAPSHOT HANDLERS
+// ============================================================================
+
+func (h *Handler) ListSnapshots(c *gin.Context) {
+	snapshots, err := h.awsService.ListSnapshots()
+	if err != nil {
+		fmt.Printf("[ERROR] ListSnapshots failed: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   err.Error(),
+			"details": "Failed to list snapshots. Check AWS credentials and permissions.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, snapshots)
+}
+
+func (h *Handler) ListSnapshotsByAccount(c *gin.Context) {
 	accountID := c.Param("accountId")
-	olderThanMonthsStr := c.DefaultQuery("older_than_months", "6")
 
 	if accountID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Account ID is required",
+			"error": "Account ID is required",
+		})
+		return
+	}
+
+	snapshots, err := h.awsService.ListSnapshotsByAccount(accountID)
+	if err != nil {
+		fmt.Printf("[ERROR] ListSnapshotsByAccount failed for account %s: %v\n", accountID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   err.Error(),
+			"details": fmt.Sprintf("Failed to list snapshots for account %s.", accountID),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, snapshots)
+}
+
+func (h *Handler) DeleteSnapshot(c *gin.Context) {
+	accountID := c.Param("accountId")
+	region := c.Param("region")
+	snapshotID := c.Param("snapshotId")
+
+	if accountID == "" || region == "" || snapshotID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Account ID, region, and snapshot ID are required",
+		})
+		return
+	}
+
+	err := h.awsService.DeleteSnapshot(accountID, region, snapshotID)
+	if err != nil {
+		fmt.Printf("[ERROR] DeleteSnapshot failed for snapshot %s in account %s, region %s: %v\n", snapshotID, accountID, region, err)
+
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			statusCode = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "cannot access account") {
+			statusCode = http.StatusForbidden
+		}
+
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Snapshot %s deleted successfully", snapshotID),
+	})
+}
+
+func (h *Handler) DeleteOldSnapshots(c *gin.Context) {
+	accountID := c.Param("accountId")
+	olderThanMonthsStr := c.DefaultQuery("older_than_months", "6")
+
+	if accountID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Account ID is required",
+		})
+		return
+	}
+
+	var olderThanMonths int
+	if _, err := fmt.Sscanf(olderThanMonthsStr, "%d", &olderThanMonths); err != nil || olderThanMonths <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "older_than_months must be a positive integer",
+		})
+		return
+	}
+
+	result, err := h.awsService.DeleteOldSnapshots(accountID, olderThanMonths)
+	if err != nil {
+		fmt.Printf("[ERROR] DeleteOldSnapshots failed for account %s: %v\n", accountID, err)
+
+		// If some snapshots were deleted, return partial success
+		if result != nil && len(result.Deleted) > 0 {
+			c.JSON(http.StatusPartialContent, gin.H{
+				"message":           fmt.Sprintf("Deleted %d snapshots, but encountered errors", len(result.Deleted)),
+				"deleted_snapshots": result.Deleted,
+				"skipped_snapshots": result.Skipped,
+				"error":             err.Error(),
+			})
+			return
+		}
+
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "cannot access account") {
+			statusCode = http.StatusForbidden
+		}
+
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           fmt.Sprintf("Successfully deleted %d snapshot(s) older than %d months, skipped %d protected", len(result.Deleted), olderThanMonths, len(result.Skipped)),
+		"deleted_snapshots": result.Deleted,
+		"skipped_snapshots": result.Skipped,
+		"count":             len(result.Deleted),
+	})
+}
+
+// GetSnapshotCostReport serves both the org-wide and the per-account snapshot cost routes
+func (h *Handler) GetSnapshotCostReport(c *gin.Context) {
+	accountID := c.Param("accountId")
+	estimateIncremental := c.Query("estimate_incremental") == "true"
+
+	report, err := h.awsService.GetSnapshotCostReport(accountID, estimateIncremental)
+	if err != nil {
+		fmt.Printf("[ERROR] GetSnapshotCostReport failed: %v\n", err)
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "cannot access account") {
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// snapshotTierErrorStatus maps archive and restore errors to HTTP status codes
+func snapshotTierErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "invalid"), strings.Contains(err.Error(), "IncorrectState"):
+		return http.StatusBadRequest
+	case strings.Contains(err.Error(), "NotFound"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "cannot access account"):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *Handler) ArchiveSnapshot(c *gin.Context) {
+	accountID := c.Param("accountId")
+	region := c.Param("region")
+	snapshotID := c.Param("snapshotId")
+
+	if err := h.awsService.ArchiveSnapshot(accountID, region, snapshotID); err != nil {
+		fmt.Printf("[ERROR] ArchiveSnapshot failed for snapshot %s in account %s, region %s: %v\n", snapshotID, accountID, region, err)
+		c.JSON(snapshotTierErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": fmt.Sprintf("Snapshot %s is moving to the archive tier", snapshotID),
+	})
+}
+
+func (h *Handler) RestoreArchivedSnapshot(c *gin.Context) {
+	accountID := c.Param("accountId")
+	region := c.Param("region")
+	snapshotID := c.Param("snapshotId")
+
+	var input models.SnapshotRestoreInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := h.awsService.RestoreArchivedSnapshot(accountID, region, snapshotID, input); err != nil {
+		fmt.Printf("[ERROR] RestoreArchivedSnapshot failed for snapshot %s in account %s, region %s: %v\n", snapshotID, accountID, region, err)
+		c.JSON(snapshotTierErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": fmt.Sprintf("Snapshot %s is being restored from the archive tier", snapshotID),
+	})
+}
+
+// ============================================================================
+// SNAPSHOT RETENTION POLICY HANDLERS
+// ============================================================================
+
+// retentionPolicyErrorStatus maps retention policy errors to HTTP status codes
+func retentionPolicyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrRetentionPolicyNotFound):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	case strings.Contains(err.Error(), "cannot access account"):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *Handler) ListRetentionPolicies(c *gin.Context) {
+	c.JSON(http.StatusOK, h.awsService.ListRetentionPolicies())
+}
+
+func (h *Handler) GetRetentionPolicy(c *gin.Context) {
+	policy, err := h.awsService.GetRetentionPolicy(c.Param("policyId"))
+	if err != nil {
+		c.JSON(retentionPolicyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *Handler) CreateRetentionPolicy(c *gin.Context) {
+	var policy models.SnapshotRetentionPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	created, err := h.awsService.CreateRetentionPolicy(policy)
+	if err != nil {
+		fmt.Printf("[ERROR] CreateRetentionPolicy failed: %v\n", err)
+		c.JSON(retentionPolicyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+func (h *Handler) UpdateRetentionPolicy(c *gin.Context) {
+	policyID := c.Param("policyId")
+
+	var policy models.SnapshotRetentionPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	updated, err := h.awsService.UpdateRetentionPolicy(policyID, policy)
+	if err != nil {
+		fmt.Printf("[ERROR] UpdateRetentionPolicy failed for policy %s: %v\n", policyID, err)
+		c.JSON(retentionPolicyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+func (h *Handler) DeleteRetentionPolicy(c *gin.Context) {
+	policyID := c.Param("policyId")
+
+	if err := h.awsService.DeleteRetentionPolicy(policyID); err != nil {
+		c.JSON(retentionPolicyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Retention policy %s deleted successfully", policyID),
+	})
+}
+
+// PreviewRetentionPolicy shows which snapshots a policy would keep and delete, optionally for ?account_ids=1,2
+func (h *Handler) PreviewRetentionPolicy(c *gin.Context) {
+	policyID := c.Param("policyId")
+
+	var accountIDs []string
+	if raw := c.Query("account_ids"); raw != "" {
+		accountIDs = strings.Split(raw, ",")
+	}
+
+	preview, err := h.awsService.PreviewRetentionPolicy(policyID, accountIDs)
+	if err != nil {
+		fmt.Printf("[ERROR] PreviewRetentionPolicy failed for policy %s: %v\n", policyID, err)
+		c.JSON(retentionPolicyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, preview)
+}
+
+func (h *Handler) RunRetentionPolicy(c *gin.Context) {
+	policyID := c.Param("policyId")
+
+	var input models.SnapshotRetentionRunInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	job, err := h.awsService.StartRetentionPolicyRun(policyID, input)
+	if err != nil {
+		fmt.Printf("[ERROR] RunRetentionPolicy failed for policy %s: %v\n", policyID, err)
+		c.JSON(retentionPolicyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// ============================================================================
+// DB SNAPSHOT HANDLERS
+// ============================================================================
+
+func (h *Handler) ListDBSnapshots(c *gin.Context) {
+	snapshots, err := h.awsService.ListDBSnapshots()
+	if err != nil {
+		fmt.Printf("[ERROR] ListDBSnapshots failed: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   err.Error(),
+			"details": "Failed to list DB snapshots. Check AWS credentials and permissions.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, snapshots)
+}
+
+func (h *Handler) ListDBSnapshotsByAccount(c *gin.Context) {
+	accountID := c.Param("accountId")
+
+	snapshots, err := h.awsService.ListDBSnapshotsByAccount(accountID)
+	if err != nil {
+		fmt.Printf("[ERROR] ListDBSnapshotsByAccount failed for account %s: %v\n", accountID, err)
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "cannot access account") {
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, snapshots)
+}
+
+// DeleteDBSnapshot deletes a DB instance snapshot, or a cluster snapshot with ?kind=cluster
+func (h *Handler) DeleteDBSnapshot(c *gin.Context) {
+	accountID := c.Param("accountId")
+	region := c.Param("region")
+	snapshotID := c.Param("snapshotId")
+	kind := c.DefaultQuery("kind", models.DBSnapshotKindInstance)
+
+	if err := h.awsService.DeleteDBSnapshot(accountID, region, snapshotID, kind); err != nil {
+		fmt.Printf("[ERROR] DeleteDBSnapshot failed for snapshot %s in account %s, region %s: %v\n", snapshotID, accountID, region, err)
+
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "invalid") {
+			statusCode = http.StatusBadRequest
+		} else if strings.Contains(err.Error(), "not found") {
+			statusCode = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "cannot access account") {
+			statusCode = http.StatusForbidden
+		}
+
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("DB snapshot %s deleted successfully", snapshotID),
+	})
+}
+
+func (h *Handler) DeleteOldDBSnapshots(c *gin.Context) {
+	accountID := c.Param("accountId")
+	olderThanMonthsStr := c.DefaultQuery("older_than_months", "6")
+
 	var olderThanMonths int
 	if _, err := fmt.Sscanf(olderThanMonthsStr, "%d", &olderThanMonths); err != nil || olderThanMonths <= 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -584,20 +1416,165 @@ func (h *Handler) DeleteOldSnapshots(c *gin.Context) {
 		return
 	}
 
-	deletedSnapshots, err := h.awsService.DeleteOldSnapshots(accountID, olderThanMonths)
+	result, err := h.awsService.DeleteOldDBSnapshots(accountID, olderThanMonths)
 	if err != nil {
-		fmt.Printf("[ERROR] DeleteOldSnapshots failed for account %s: %v\n", accountID, err)
-		
+		fmt.Printf("[ERROR] DeleteOldDBSnapshots failed for account %s: %v\n", accountID, err)
+
 		// If some snapshots were deleted, return partial success
-		if len(deletedSnapshots) > 0 {
+		if result != nil && len(result.Deleted) > 0 {
+			c.JSON(http.StatusPartialContent, gin.H{
+				"message":           fmt.Sprintf("Deleted %d DB snapshots, but encountered errors", len(result.Deleted)),
+				"deleted_snapshots": result.Deleted,
+				"skipped_snapshots": result.Skipped,
+				"error":             err.Error(),
+			})
+			return
+		}
+
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "cannot access account") {
+			statusCode = http.StatusForbidden
+		}
+
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           fmt.Sprintf("Successfully deleted %d DB snapshot(s) older than %d months, skipped %d protected", len(result.Deleted), olderThanMonths, len(result.Skipped)),
+		"deleted_snapshots": result.Deleted,
+		"skipped_snapshots": result.Skipped,
+		"count":             len(result.Deleted),
+	})
+}
+
+// ListDBSnapshotFindings serves both the org-wide and the per-account DB snapshot findings routes
+func (h *Handler) ListDBSnapshotFindings(c *gin.Context) {
+	accountID := c.Param("accountId")
+
+	findings, err := h.awsService.ListDBSnapshotFindings(accountID, c.Query("min_severity"))
+	if err != nil {
+		fmt.Printf("[ERROR] ListDBSnapshotFindings failed: %v\n", err)
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "invalid") {
+			statusCode = http.StatusBadRequest
+		} else if strings.Contains(err.Error(), "cannot access account") {
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, findings)
+}
+
+// ============================================================================
+// AMI HANDLERS
+// ============================================================================
+
+func (h *Handler) ListAMIs(c *gin.Context) {
+	images, err := h.awsService.ListAMIs()
+	if err != nil {
+		fmt.Printf("[ERROR] ListAMIs failed: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   err.Error(),
+			"details": "Failed to list AMIs. Check AWS credentials and permissions.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, images)
+}
+
+func (h *Handler) ListAMIsByAccount(c *gin.Context) {
+	accountID := c.Param("accountId")
+
+	images, err := h.awsService.ListAMIsByAccount(accountID)
+	if err != nil {
+		fmt.Printf("[ERROR] ListAMIsByAccount failed for account %s: %v\n", accountID, err)
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "cannot access account") {
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, images)
+}
+
+// DeregisterAMI deregisters an unused AMI and deletes its snapshots
+func (h *Handler) DeregisterAMI(c *gin.Context) {
+	accountID := c.Param("accountId")
+	region := c.Param("region")
+	imageID := c.Param("imageId")
+
+	result, err := h.awsService.DeregisterAMI(accountID, region, imageID)
+	if err != nil {
+		fmt.Printf("[ERROR] DeregisterAMI failed for image %s in account %s, region %s: %v\n", imageID, accountID, region, err)
+
+		// Deregistered but some snapshots could not be deleted
+		if result != nil && len(result.Deregistered) > 0 {
 			c.JSON(http.StatusPartialContent, gin.H{
-				"message":          fmt.Sprintf("Deleted %d snapshots, but encountered errors", len(deletedSnapshots)),
-				"deleted_snapshots": deletedSnapshots,
+				"message":           fmt.Sprintf("AMI %s deregistered, but some snapshots could not be deleted", imageID),
+				"deleted_snapshots": result.DeletedSnapshots,
 				"error":             err.Error(),
 			})
 			return
 		}
 
+		statusCode := http.StatusInternalServerError
+		if errors.Is(err, services.ErrAMIProtected) {
+			statusCode = http.StatusConflict
+		} else if strings.Contains(err.Error(), "not found") {
+			statusCode = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "cannot access account") {
+			statusCode = http.StatusForbidden
+		}
+
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           fmt.Sprintf("AMI %s deregistered and %d snapshot(s) deleted", imageID, len(result.DeletedSnapshots)),
+		"deleted_snapshots": result.DeletedSnapshots,
+	})
+}
+
+func (h *Handler) DeleteUnusedAMIs(c *gin.Context) {
+	accountID := c.Param("accountId")
+	unusedDaysStr := c.DefaultQuery("unused_days", "90")
+
+	var unusedDays int
+	if _, err := fmt.Sscanf(unusedDaysStr, "%d", &unusedDays); err != nil || unusedDays <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "unused_days must be a positive integer",
+		})
+		return
+	}
+
+	result, err := h.awsService.DeleteUnusedAMIs(accountID, unusedDays)
+	if err != nil {
+		fmt.Printf("[ERROR] DeleteUnusedAMIs failed for account %s: %v\n", accountID, err)
+
+		// If some images were deregistered, return partial success
+		if result != nil && len(result.Deregistered) > 0 {
+			c.JSON(http.StatusPartialContent, gin.H{
+				"message":             fmt.Sprintf("Deregistered %d AMIs, but encountered errors", len(result.Deregistered)),
+				"deregistered_images": result.Deregistered,
+				"deleted_snapshots":   result.DeletedSnapshots,
+				"skipped_images":      result.Skipped,
+				"error":               err.Error(),
+			})
+			return
+		}
+
 		statusCode := http.StatusInternalServerError
 		if strings.Contains(err.Error(), "cannot access account") {
 			statusCode = http.StatusForbidden
@@ -610,12 +1587,35 @@ func (h *Handler) DeleteOldSnapshots(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":          fmt.Sprintf("Successfully deleted %d snapshot(s) older than %d months", len(deletedSnapshots), olderThanMonths),
-		"deleted_snapshots": deletedSnapshots,
-		"count":            len(deletedSnapshots),
+		"message":             fmt.Sprintf("Successfully deregistered %d AMI(s) unused for %d days, skipped %d protected", len(result.Deregistered), unusedDays, len(result.Skipped)),
+		"deregistered_images": result.Deregistered,
+		"deleted_snapshots":   result.DeletedSnapshots,
+		"skipped_images":      result.Skipped,
+		"count":               len(result.Deregistered),
 	})
 }
 
+// ListAMIFindings serves both the org-wide and the per-account AMI findings routes
+func (h *Handler) ListAMIFindings(c *gin.Context) {
+	accountID := c.Param("accountId")
+
+	findings, err := h.awsService.ListAMIFindings(accountID, c.Query("min_severity"))
+	if err != nil {
+		fmt.Printf("[ERROR] ListAMIFindings failed: %v\n", err)
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "invalid") {
+			statusCode = http.StatusBadRequest
+		} else if strings.Contains(err.Error(), "cannot access account") {
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, findings)
+}
+
 // ============================================================================
 // EC2 INSTANCE HANDLERS
 // ============================================================================
@@ -638,7 +1638,23 @@ func (h *Handler) InvalidateEC2InstancesCache(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "EC2 instances cache invalidated successfully"})
 }
 
-func (h *Handler) StopEC2Instance(c *gin.Context) {
+// ec2InstanceErrorStatus maps EC2 instance action errors to HTTP status codes
+func ec2InstanceErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrEC2InstanceProtected), strings.Contains(err.Error(), "current state"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	case strings.Contains(err.Error(), "cannot access account"):
+		return http.StatusForbidden
+	}
+	return http.StatusInternalServerError
+}
+
+// ec2InstanceAction runs a start, stop, reboot, hibernate or terminate request
+func (h *Handler) ec2InstanceAction(c *gin.Context, name string, action func(accountID, region, instanceID string) error, pastTense string) {
 	accountID := c.Param("accountId")
 	region := c.Param("region")
 	instanceID := c.Param("instanceId")
@@ -650,56 +1666,152 @@ func (h *Handler) StopEC2Instance(c *gin.Context) {
 		return
 	}
 
-	err := h.awsService.StopEC2Instance(accountID, region, instanceID)
-	if err != nil {
-		fmt.Printf("[ERROR] StopEC2Instance failed for instance %s in account %s, region %s: %v\n", instanceID, accountID, region, err)
+	if err := action(accountID, region, instanceID); err != nil {
+		fmt.Printf("[ERROR] %s failed for instance %s in account %s, region %s: %v\n", name, instanceID, accountID, region, err)
+		c.JSON(ec2InstanceErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
 
-		statusCode := http.StatusInternalServerError
-		if strings.Contains(err.Error(), "cannot access account") {
-			statusCode = http.StatusForbidden
-		}
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Instance %s %s successfully", instanceID, pastTense),
+	})
+}
 
-		c.JSON(statusCode, gin.H{
+func (h *Handler) StartEC2Instance(c *gin.Context) {
+	h.ec2InstanceAction(c, "StartEC2Instance", h.awsService.StartEC2Instance, "started")
+}
+
+func (h *Handler) StopEC2Instance(c *gin.Context) {
+	h.ec2InstanceAction(c, "StopEC2Instance", h.awsService.StopEC2Instance, "stopped")
+}
+
+func (h *Handler) RebootEC2Instance(c *gin.Context) {
+	h.ec2InstanceAction(c, "RebootEC2Instance", h.awsService.RebootEC2Instance, "rebooted")
+}
+
+func (h *Handler) HibernateEC2Instance(c *gin.Context) {
+	h.ec2InstanceAction(c, "HibernateEC2Instance", h.awsService.HibernateEC2Instance, "hibernated")
+}
+
+func (h *Handler) TerminateEC2Instance(c *gin.Context) {
+	h.ec2InstanceAction(c, "TerminateEC2Instance", h.awsService.TerminateEC2Instance, "terminated")
+}
+
+func (h *Handler) GetEC2Instance(c *gin.Context) {
+	accountID := c.Param("accountId")
+	region := c.Param("region")
+	instanceID := c.Param("instanceId")
+
+	details, err := h.awsService.GetEC2Instance(accountID, region, instanceID)
+	if err != nil {
+		fmt.Printf("[ERROR] GetEC2Instance failed for instance %s in account %s, region %s: %v\n", instanceID, accountID, region, err)
+		c.JSON(ec2InstanceErrorStatus(err), gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
+	c.JSON(http.StatusOK, details)
+}
+
+func (h *Handler) SetEC2InstanceProtection(c *gin.Context) {
+	accountID := c.Param("accountId")
+	region := c.Param("region")
+	instanceID := c.Param("instanceId")
+
+	var input models.EC2ProtectionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
 
+	if err := h.awsService.SetEC2InstanceProtection(accountID, region, instanceID, input); err != nil {
+		fmt.Printf("[ERROR] SetEC2InstanceProtection failed for instance %s in account %s, region %s: %v\n", instanceID, accountID, region, err)
+		c.JSON(ec2InstanceErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("Instance %s stopped successfully", instanceID),
+		"message": fmt.Sprintf("Instance %s protection updated successfully", instanceID),
 	})
 }
 
-func (h *Handler) TerminateEC2Instance(c *gin.Context) {
+func (h *Handler) UpdateEC2InstanceTags(c *gin.Context) {
 	accountID := c.Param("accountId")
 	region := c.Param("region")
 	instanceID := c.Param("instanceId")
 
-	if accountID == "" || region == "" || instanceID == "" {
+	var input models.EC2TagInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Account ID, region, and instance ID are required",
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	if err := h.awsService.UpdateEC2InstanceTags(accountID, region, instanceID, input); err != nil {
+		fmt.Printf("[ERROR] UpdateEC2InstanceTags failed for instance %s in account %s, region %s: %v\n", instanceID, accountID, region, err)
+		c.JSON(ec2InstanceErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Instance %s tags updated successfully", instanceID),
+	})
+}
+
+func (h *Handler) StartEC2BulkAction(c *gin.Context) {
+	var input models.EC2BulkActionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	job, err := h.awsService.StartEC2BulkAction(input)
+	if err != nil {
+		fmt.Printf("[ERROR] StartEC2BulkAction failed: %v\n", err)
+		c.JSON(ec2InstanceErrorStatus(err), gin.H{
+			"error": err.Error(),
 		})
 		return
 	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+// AnalyzeEC2Utilization starts a job that classifies running instances as idle or underutilized.
+// Query parameters: days (default EC2_UTILIZATION_DAYS, max 90).
+func (h *Handler) AnalyzeEC2Utilization(c *gin.Context) {
+	days := 0
+	if value := c.Query("days"); value != "" {
+		if _, err := fmt.Sscanf(value, "%d", &days); err != nil || days <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "days must be a positive integer",
+			})
+			return
+		}
+	}
 
-	err := h.awsService.TerminateEC2Instance(accountID, region, instanceID)
+	job, err := h.awsService.AnalyzeEC2Utilization(days)
 	if err != nil {
-		fmt.Printf("[ERROR] TerminateEC2Instance failed for instance %s in account %s, region %s: %v\n", instanceID, accountID, region, err)
-
 		statusCode := http.StatusInternalServerError
-		if strings.Contains(err.Error(), "cannot access account") {
-			statusCode = http.StatusForbidden
+		if strings.Contains(err.Error(), "invalid") {
+			statusCode = http.StatusBadRequest
+		} else {
+			fmt.Printf("[ERROR] AnalyzeEC2Utilization failed: %v\n", err)
 		}
-
 		c.JSON(statusCode, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": fmt.Sprintf("Instance %s terminated successfully", instanceID),
-	})
+	c.JSON(http.StatusAccepted, job)
 }
 
 // ============================================================================
@@ -969,6 +2081,273 @@ func (h *Handler) DeleteRole(c *gin.Context) {
 	})
 }
 
+func (h *Handler) ListRoleTrustFindings(c *gin.Context) {
+	accountID := c.Param("accountId")
+	findings, err := h.awsService.ListRoleTrustFindings(accountID)
+	if err != nil {
+		fmt.Printf("[ERROR] ListRoleTrustFindings failed for account %s: %v\n", accountID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   err.Error(),
+			"details": fmt.Sprintf("Failed to analyze role trust policies for account %s. Check AWS credentials and permissions.", accountID),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, findings)
+}
+
+func (h *Handler) ListAllRoleTrustFindings(c *gin.Context) {
+	findings, err := h.awsService.ListAllRoleTrustFindings()
+	if err != nil {
+		fmt.Printf("[ERROR] ListAllRoleTrustFindings failed: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   err.Error(),
+			"details": "Failed to analyze role trust policies. Check AWS credentials and permissions.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, findings)
+}
+
+// parseUnusedRoleThresholds reads the optional "days" and "min_age_days" query parameters.
+// Missing parameters fall back to the configured thresholds.
+func parseUnusedRoleThresholds(c *gin.Context) (int, int, bool) {
+	unusedDays, minAgeDays := 0, -1
+	if value := c.Query("days"); value != "" {
+		if _, err := fmt.Sscanf(value, "%d", &unusedDays); err != nil || unusedDays <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "days must be a positive integer",
+			})
+			return 0, 0, false
+		}
+	}
+	if value := c.Query("min_age_days"); value != "" {
+		if _, err := fmt.Sscanf(value, "%d", &minAgeDays); err != nil || minAgeDays < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "min_age_days must be a non-negative integer",
+			})
+			return 0, 0, false
+		}
+	}
+	return unusedDays, minAgeDays, true
+}
+
+func (h *Handler) ListUnusedRoles(c *gin.Context) {
+	accountID := c.Param("accountId")
+	unusedDays, minAgeDays, ok := parseUnusedRoleThresholds(c)
+	if !ok {
+		return
+	}
+
+	roles, err := h.awsService.ListUnusedRoles(accountID, unusedDays, minAgeDays)
+	if err != nil {
+		fmt.Printf("[ERROR] ListUnusedRoles failed for account %s: %v\n", accountID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   err.Error(),
+			"details": fmt.Sprintf("Failed to list unused roles for account %s. Check AWS credentials and permissions.", accountID),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+func (h *Handler) ListAllUnusedRoles(c *gin.Context) {
+	unusedDays, minAgeDays, ok := parseUnusedRoleThresholds(c)
+	if !ok {
+		return
+	}
+
+	roles, err := h.awsService.ListAllUnusedRoles(unusedDays, minAgeDays)
+	if err != nil {
+		fmt.Printf("[ERROR] ListAllUnusedRoles failed: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   err.Error(),
+			"details": "Failed to list unused roles. Check AWS credentials and permissions.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// RoleCleanupRequest selects roles to advance through the staged cleanup
+type RoleCleanupRequest struct {
+	Roles []models.RoleCleanupTarget `json:"roles"`
+	Force bool                       `json:"force"`
+}
+
+func (h *Handler) StartRoleCleanup(c *gin.Context) {
+	var req RoleCleanupRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+	for _, target := range req.Roles {
+		if target.AccountID == "" || target.RoleName == "" {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "account_id and role_name are required for every role",
+			})
+			return
+		}
+	}
+
+	job, err := h.awsService.StartRoleCleanup(req.Roles, req.Force)
+	if err != nil {
+		fmt.Printf("[ERROR] StartRoleCleanup failed: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusAccepted, job)
+}
+
+func (h *Handler) RestoreRoleCleanup(c *gin.Context) {
+	accountID := c.Param("accountId")
+	roleName := c.Param("roleName")
+	err := h.awsService.RestoreRoleCleanup(accountID, roleName)
+	if err != nil {
+		fmt.Printf("[ERROR] RestoreRoleCleanup failed for role %s in account %s: %v\n", roleName, accountID, err)
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			statusCode = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "cannot access account") {
+			statusCode = http.StatusForbidden
+		}
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Cleanup of role %s cancelled", roleName),
+	})
+}
+
+// roleManagementErrorStatus maps role create/update errors to HTTP status codes
+func roleManagementErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	case strings.Contains(err.Error(), "already exists"):
+		return http.StatusConflict
+	case strings.Contains(err.Error(), "cannot access account"):
+		return http.StatusForbidden
+	case strings.Contains(err.Error(), "not found"), strings.Contains(err.Error(), "not attached"):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *Handler) CreateRole(c *gin.Context) {
+	accountID := c.Param("accountId")
+
+	var input models.CreateRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	role, failedPolicies, err := h.awsService.CreateRole(accountID, input)
+	if err != nil {
+		fmt.Printf("[ERROR] CreateRole failed for role %s in account %s: %v\n", input.RoleName, accountID, err)
+		c.JSON(roleManagementErrorStatus(err), gin.H{
+			"error":           err.Error(),
+			"failed_policies": failedPolicies,
+		})
+		return
+	}
+
+	response := gin.H{
+		"message": fmt.Sprintf("Role %s created successfully", input.RoleName),
+		"role":    role,
+	}
+	if len(failedPolicies) > 0 {
+		response["failed_policies"] = failedPolicies
+		response["message"] = fmt.Sprintf("Role %s created, but %d policy(ies) failed to attach", input.RoleName, len(failedPolicies))
+	}
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *Handler) UpdateRoleTrustPolicy(c *gin.Context) {
+	accountID := c.Param("accountId")
+	roleName := c.Param("roleName")
+
+	var input models.UpdateTrustPolicyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body",
+		})
+		return
+	}
+
+	role, err := h.awsService.UpdateRoleTrustPolicy(accountID, roleName, input)
+	if err != nil {
+		fmt.Printf("[ERROR] UpdateRoleTrustPolicy failed for role %s in account %s: %v\n", roleName, accountID, err)
+		c.JSON(roleManagementErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, role)
+}
+
+// RolePolicyRequest identifies a managed policy to attach to a role
+type RolePolicyRequest struct {
+	PolicyArn string `json:"policy_arn" binding:"required"`
+}
+
+func (h *Handler) AttachRolePolicy(c *gin.Context) {
+	accountID := c.Param("accountId")
+	roleName := c.Param("roleName")
+
+	var req RolePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "policy_arn is required",
+		})
+		return
+	}
+
+	if err := h.awsService.AttachRolePolicy(accountID, roleName, req.PolicyArn); err != nil {
+		fmt.Printf("[ERROR] AttachRolePolicy failed for role %s in account %s: %v\n", roleName, accountID, err)
+		c.JSON(roleManagementErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Policy %s attached to role %s", req.PolicyArn, roleName),
+	})
+}
+
+func (h *Handler) DetachRolePolicy(c *gin.Context) {
+	accountID := c.Param("accountId")
+	roleName := c.Param("roleName")
+
+	// Policy ARNs contain slashes, so they are passed as a query parameter
+	policyArn := c.Query("policy_arn")
+	if policyArn == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "policy_arn is required",
+		})
+		return
+	}
+
+	if err := h.awsService.DetachRolePolicy(accountID, roleName, policyArn); err != nil {
+		fmt.Printf("[ERROR] DetachRolePolicy failed for role %s in account %s: %v\n", roleName, accountID, err)
+		c.JSON(roleManagementErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Policy %s detached from role %s", policyArn, roleName),
+	})
+}
+
 func (h *Handler) InvalidateRolesCache(c *gin.Context) {
 	h.awsService.InvalidateRolesCache()
 	c.JSON(http.StatusOK, gin.H{"message": "Roles cache invalidated successfully"})
@@ -986,6 +2365,171 @@ func (h *Handler) InvalidateAccountRolesCache(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Roles cache invalidated for account %s", accountID)})
 }
 
+// ============================================================================
+// CUSTOMER-MANAGED POLICY HANDLERS
+// ============================================================================
+
+// policyErrorStatus maps policy management errors to HTTP status codes
+func policyErrorStatus(err error) int {
+	switch {
+	case strings.Contains(err.Error(), "cannot access account"):
+		return http.StatusForbidden
+	case strings.Contains(err.Error(), "not found"):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "still attached"):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *Handler) ListPolicies(c *gin.Context) {
+	accountID := c.Param("accountId")
+	policies, err := h.awsService.ListPolicies(accountID)
+	if err != nil {
+		fmt.Printf("[ERROR] ListPolicies failed for account %s: %v\n", accountID, err)
+		c.JSON(policyErrorStatus(err), gin.H{
+			"error":   err.Error(),
+			"details": fmt.Sprintf("Failed to list policies for account %s. Check AWS credentials and permissions.", accountID),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, policies)
+}
+
+func (h *Handler) ListAllPolicies(c *gin.Context) {
+	policies, err := h.awsService.ListAllPolicies()
+	if err != nil {
+		fmt.Printf("[ERROR] ListAllPolicies failed: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   err.Error(),
+			"details": "Failed to list all policies. Check AWS credentials and permissions.",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, policies)
+}
+
+func (h *Handler) GetPolicy(c *gin.Context) {
+	accountID := c.Param("accountId")
+	policyName := c.Param("policyName")
+	policy, err := h.awsService.GetPolicy(accountID, policyName)
+	if err != nil {
+		fmt.Printf("[ERROR] GetPolicy failed for policy %s in account %s: %v\n", policyName, accountID, err)
+		c.JSON(policyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, policy)
+}
+
+func (h *Handler) ListPolicyVersions(c *gin.Context) {
+	accountID := c.Param("accountId")
+	policyName := c.Param("policyName")
+	versions, err := h.awsService.ListPolicyVersions(accountID, policyName)
+	if err != nil {
+		fmt.Printf("[ERROR] ListPolicyVersions failed for policy %s in account %s: %v\n", policyName, accountID, err)
+		c.JSON(policyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, versions)
+}
+
+func (h *Handler) DiffPolicyVersions(c *gin.Context) {
+	accountID := c.Param("accountId")
+	policyName := c.Param("policyName")
+	fromVersion := c.Query("from")
+	toVersion := c.Query("to")
+	if fromVersion == "" || toVersion == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "from and to version IDs are required",
+		})
+		return
+	}
+
+	diff, err := h.awsService.DiffPolicyVersions(accountID, policyName, fromVersion, toVersion)
+	if err != nil {
+		fmt.Printf("[ERROR] DiffPolicyVersions failed for policy %s in account %s: %v\n", policyName, accountID, err)
+		c.JSON(policyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+func (h *Handler) DeleteNonDefaultPolicyVersions(c *gin.Context) {
+	accountID := c.Param("accountId")
+	policyName := c.Param("policyName")
+	deletedVersions, err := h.awsService.DeleteNonDefaultPolicyVersions(accountID, policyName)
+	if err != nil {
+		fmt.Printf("[ERROR] DeleteNonDefaultPolicyVersions failed for policy %s in account %s: %v\n", policyName, accountID, err)
+		c.JSON(policyErrorStatus(err), gin.H{
+			"error":            err.Error(),
+			"deleted_versions": deletedVersions,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":          fmt.Sprintf("Deleted %d non-default version(s) of policy %s", len(deletedVersions), policyName),
+		"deleted_versions": deletedVersions,
+	})
+}
+
+func (h *Handler) DeletePolicy(c *gin.Context) {
+	accountID := c.Param("accountId")
+	policyName := c.Param("policyName")
+	err := h.awsService.DeletePolicy(accountID, policyName)
+	if err != nil {
+		fmt.Printf("[ERROR] DeletePolicy failed for policy %s in account %s: %v\n", policyName, accountID, err)
+		c.JSON(policyErrorStatus(err), gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Policy %s deleted successfully", policyName),
+	})
+}
+
+func (h *Handler) DeleteUnattachedPolicies(c *gin.Context) {
+	accountID := c.Param("accountId")
+	deletedPolicies, failedPolicies, err := h.awsService.DeleteUnattachedPolicies(accountID)
+	if err != nil {
+		fmt.Printf("[ERROR] DeleteUnattachedPolicies failed for account %s: %v\n", accountID, err)
+		c.JSON(policyErrorStatus(err), gin.H{
+			"error":   err.Error(),
+			"details": fmt.Sprintf("Failed to delete unattached policies from account %s. Check AWS credentials and permissions.", accountID),
+		})
+		return
+	}
+
+	response := gin.H{
+		"message":          fmt.Sprintf("Deleted %d unattached policy(ies) successfully", len(deletedPolicies)),
+		"deleted_policies": deletedPolicies,
+	}
+
+	if len(failedPolicies) > 0 {
+		response["failed_policies"] = failedPolicies
+		response["message"] = fmt.Sprintf("Deleted %d unattached policy(ies) successfully. Failed to delete %d policy(ies)", len(deletedPolicies), len(failedPolicies))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) InvalidatePoliciesCache(c *gin.Context) {
+	h.awsService.InvalidatePoliciesCache()
+	c.JSON(http.StatusOK, gin.H{"message": "Policies cache invalidated successfully"})
+}
+
+func (h *Handler) InvalidateAccountPoliciesCache(c *gin.Context) {
+	accountID := c.Param("accountId")
+	h.awsService.InvalidateAccountPoliciesCache(accountID)
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("Policies cache invalidated for account %s", accountID)})
+}
+
 // ============================================================================
 // LOAD BALANCER HANDLERS (Account-Specific)
 // ============================================================================
@@ -0,0 +1,181 @@
+// Package handlers provides HTTP request handlers for start/stop schedules
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rusik69/aws-iam-manager/internal/models"
+	"github.com/rusik69/aws-iam-manager/internal/services"
+)
+
+type ScheduleHandler struct {
+	scheduleService services.ScheduleServiceInterface
+}
+
+func NewScheduleHandler(scheduleService services.ScheduleServiceInterface) *ScheduleHandler {
+	return &ScheduleHandler{
+		scheduleService: scheduleService,
+	}
+}
+
+// scheduleErrorStatus maps schedule errors to HTTP status codes
+func scheduleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrScheduleNotFound):
+		return http.StatusNotFound
+	case strings.Contains(err.Error(), "invalid"):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func (h *ScheduleHandler) respondError(c *gin.Context, operation string, err error) {
+	statusCode := scheduleErrorStatus(err)
+	if statusCode == http.StatusInternalServerError {
+		fmt.Printf("[ERROR] Failed to %s: %v\n", operation, err)
+	}
+	c.JSON(statusCode, gin.H{
+		"error": err.Error(),
+	})
+}
+
+// ListSchedules returns all start/stop schedules
+func (h *ScheduleHandler) ListSchedules(c *gin.Context) {
+	c.JSON(http.StatusOK, h.scheduleService.ListSchedules())
+}
+
+// GetSchedule returns one schedule
+func (h *ScheduleHandler) GetSchedule(c *gin.Context) {
+	schedule, err := h.scheduleService.GetSchedule(c.Param("scheduleId"))
+	if err != nil {
+		h.respondError(c, "get schedule", err)
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// CreateSchedule creates a schedule
+func (h *ScheduleHandler) CreateSchedule(c *gin.Context) {
+	var schedule models.Schedule
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	created, err := h.scheduleService.CreateSchedule(schedule)
+	if err != nil {
+		h.respondError(c, "create schedule", err)
+		return
+	}
+	c.JSON(http.StatusCreated, created)
+}
+
+// UpdateSchedule replaces a schedule's definition
+func (h *ScheduleHandler) UpdateSchedule(c *gin.Context) {
+	var schedule models.Schedule
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	updated, err := h.scheduleService.UpdateSchedule(c.Param("scheduleId"), schedule)
+	if err != nil {
+		h.respondError(c, "update schedule", err)
+		return
+	}
+	c.JSON(http.StatusOK, updated)
+}
+
+// DeleteSchedule deletes a schedule
+func (h *ScheduleHandler) DeleteSchedule(c *gin.Context) {
+	if err := h.scheduleService.DeleteSchedule(c.Param("scheduleId")); err != nil {
+		h.respondError(c, "delete schedule", err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Schedule deleted successfully"})
+}
+
+// SnoozeSchedule suspends a schedule's actions for a duration starting now
+func (h *ScheduleHandler) SnoozeSchedule(c *gin.Context) {
+	var input models.ScheduleSnoozeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request body: " + err.Error(),
+		})
+		return
+	}
+
+	schedule, err := h.scheduleService.SnoozeSchedule(c.Param("scheduleId"), input)
+	if err != nil {
+		h.respondError(c, "snooze schedule", err)
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// ClearScheduleOverrides removes all override windows of a schedule
+func (h *ScheduleHandler) ClearScheduleOverrides(c *gin.Context) {
+	schedule, err := h.scheduleService.ClearScheduleOverrides(c.Param("scheduleId"))
+	if err != nil {
+		h.respondError(c, "clear schedule overrides", err)
+		return
+	}
+	c.JSON(http.StatusOK, schedule)
+}
+
+// PreviewSchedule returns the next runs of a schedule. Query parameters: count (default 10, max 50).
+func (h *ScheduleHandler) PreviewSchedule(c *gin.Context) {
+	count := 10
+	if value := c.Query("count"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > 50 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "count must be between 1 and 50",
+			})
+			return
+		}
+		count = parsed
+	}
+
+	runs, err := h.scheduleService.PreviewSchedule(c.Param("scheduleId"), count)
+	if err != nil {
+		h.respondError(c, "preview schedule", err)
+		return
+	}
+	c.JSON(http.StatusOK, runs)
+}
+
+// ListScheduleAudit returns recent scheduled actions. Query parameters: schedule_id, limit (default 100).
+func (h *ScheduleHandler) ListScheduleAudit(c *gin.Context) {
+	limit := 100
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "limit must be a non-negative integer",
+			})
+			return
+		}
+		limit = parsed
+	}
+
+	scheduleID := c.Query("schedule_id")
+	if id := c.Param("scheduleId"); id != "" {
+		scheduleID = id
+	}
+	c.JSON(http.StatusOK, h.scheduleService.ListScheduleAudit(scheduleID, limit))
+}
+
+// GetSchedulerStatus reports whether this instance is the scheduler leader
+func (h *ScheduleHandler) GetSchedulerStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, h.scheduleService.GetSchedulerStatus())
+}
@@ -0,0 +1,68 @@
+// Package handlers provides HTTP request handlers for the principal search index
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rusik69/aws-iam-manager/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchHandler struct {
+	searchService services.SearchServiceInterface
+}
+
+func NewSearchHandler(searchService services.SearchServiceInterface) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+	}
+}
+
+// Search finds IAM users and roles, SSO users and groups and Azure service principals.
+// Query parameters: q (required), type (comma-separated), limit (default 50), refresh=true.
+func (h *SearchHandler) Search(c *gin.Context) {
+	query := c.Query("q")
+
+	var types []string
+	if value := c.Query("type"); value != "" {
+		for _, t := range strings.Split(value, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types = append(types, t)
+			}
+		}
+	}
+
+	limit := 50
+	if value := c.Query("limit"); value != "" {
+		if _, err := fmt.Sscanf(value, "%d", &limit); err != nil || limit < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "limit must be a non-negative integer",
+			})
+			return
+		}
+	}
+
+	response, err := h.searchService.Search(query, types, limit, c.Query("refresh") == "true")
+	if err != nil {
+		statusCode := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "invalid") {
+			statusCode = http.StatusBadRequest
+		} else {
+			fmt.Printf("[ERROR] Search failed for query %q: %v\n", query, err)
+		}
+		c.JSON(statusCode, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// InvalidateSearchIndex forces the search index to be rebuilt on the next query
+func (h *SearchHandler) InvalidateSearchIndex(c *gin.Context) {
+	h.searchService.InvalidateSearchIndex()
+	c.JSON(http.StatusOK, gin.H{"message": "Search index invalidated successfully"})
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Service usage classifications reported by IAM Access Advisor analysis
+const (
+	ServiceUsageUsed            = "used"
+	ServiceUsageNotRecentlyUsed = "not_recently_used"
+	ServiceUsageNeverUsed       = "never_used"
+)
+
+// AccessAdvisorReport summarizes which granted services an IAM principal actually uses
+type AccessAdvisorReport struct {
+	PrincipalType           string          `json:"principal_type"` // "user" or "role"
+	PrincipalName           string          `json:"principal_name"`
+	Arn                     string          `json:"arn"`
+	AccountID               string          `json:"account_id"`
+	AccountName             string          `json:"account_name"`
+	GeneratedAt             time.Time       `json:"generated_at"`
+	Services                []ServiceAccess `json:"services"`
+	UsedServices            []string        `json:"used_services,omitempty"`
+	NotRecentlyUsedServices []string        `json:"not_recently_used_services,omitempty"`
+	NeverUsedServices       []string        `json:"never_used_services,omitempty"`
+	SuggestedPolicy         string          `json:"suggested_policy,omitempty"`   // JSON policy document covering only used actions
+	UntrackedServices       []string        `json:"untracked_services,omitempty"` // Used services without action-level data, left out of the suggested policy
+}
+
+// ServiceAccess is the last-accessed information for one service granted to a principal
+type ServiceAccess struct {
+	ServiceNamespace        string         `json:"service_namespace"`
+	ServiceName             string         `json:"service_name"`
+	LastAuthenticated       *time.Time     `json:"last_authenticated,omitempty"`
+	LastAuthenticatedEntity string         `json:"last_authenticated_entity,omitempty"`
+	LastAuthenticatedRegion string         `json:"last_authenticated_region,omitempty"`
+	Usage                   string         `json:"usage"`
+	TrackedActions          []ActionAccess `json:"tracked_actions,omitempty"`
+}
+
+// ActionAccess is the last-accessed information for a single tracked action
+type ActionAccess struct {
+	ActionName         string     `json:"action_name"`
+	LastAccessed       *time.Time `json:"last_accessed,omitempty"`
+	LastAccessedRegion string     `json:"last_accessed_region,omitempty"`
+}
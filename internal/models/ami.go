@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// AMISnapshot is an EBS snapshot backing one of an AMI's block devices
+type AMISnapshot struct {
+	SnapshotID string `json:"snapshot_id"`
+	DeviceName string `json:"device_name"`
+	VolumeSize int64  `json:"volume_size"` // in GiB
+}
+
+// AMI represents an Amazon Machine Image owned by an account, with where it is used
+type AMI struct {
+	ImageID          string        `json:"image_id"`
+	Name             string        `json:"name"`
+	Description      string        `json:"description,omitempty"`
+	OwnerID          string        `json:"owner_id"`
+	State            string        `json:"state"`
+	Architecture     string        `json:"architecture"`
+	Platform         string        `json:"platform"` // e.g. "Linux/UNIX" or "Windows"
+	RootDeviceType   string        `json:"root_device_type"`
+	CreationDate     time.Time     `json:"creation_date"`
+	LastLaunchedTime *time.Time    `json:"last_launched_time,omitempty"` // Unset if never launched or not tracked yet
+	DeprecationTime  *time.Time    `json:"deprecation_time,omitempty"`
+	Snapshots        []AMISnapshot `json:"snapshots,omitempty"`
+	SnapshotSizeGB   int64         `json:"snapshot_size_gb"`
+	AccountID        string        `json:"account_id"`
+	AccountName      string        `json:"account_name"`
+	Region           string        `json:"region"`
+	Tags             []Tag         `json:"tags,omitempty"`
+
+	Public     bool     `json:"public"`                // Launchable by any AWS account
+	SharedWith []string `json:"shared_with,omitempty"` // Account IDs, organization and OU ARNs allowed to launch the image
+
+	InstanceCount        int      `json:"instance_count"`                  // Non-terminated instances in the account and region
+	LaunchTemplateCount  int      `json:"launch_template_count"`           // Launch templates with any version that uses the image
+	LaunchTemplates      []string `json:"launch_templates,omitempty"`      // "lt-id:version"
+	LaunchConfigurations []string `json:"launch_configurations,omitempty"` // Names of Auto Scaling launch configurations that use the image
+	InUse                bool     `json:"in_use"`                          // Used by an instance, launch template or launch configuration
+	ProtectedReason      string   `json:"protected_reason,omitempty"`      // Why unused image cleanup skips this image
+}
+
+// AMIFinding flags an AMI that is public or shared with accounts outside the organization
+type AMIFinding struct {
+	AccountID        string   `json:"account_id"`
+	AccountName      string   `json:"account_name"`
+	Region           string   `json:"region"`
+	ImageID          string   `json:"image_id"`
+	Name             string   `json:"name"`
+	Public           bool     `json:"public"`
+	ExternalAccounts []string `json:"external_accounts,omitempty"` // Shared-with accounts, organization and OU ARNs outside the organization
+	Severity         string   `json:"severity"`
+	Description      string   `json:"description"`
+}
+
+// SkippedAMI is an image left alone by unused image cleanup
+type SkippedAMI struct {
+	ImageID string `json:"image_id"`
+	Region  string `json:"region"`
+	Reason  string `json:"reason"`
+}
+
+// AMICleanupResult reports what a deregistration did
+type AMICleanupResult struct {
+	Deregistered     []string     `json:"deregistered_images"`
+	DeletedSnapshots []string     `json:"deleted_snapshots"`
+	Skipped          []SkippedAMI `json:"skipped_images"`
+}
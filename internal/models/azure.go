@@ -20,16 +20,17 @@ type AzureEnterpriseApplication struct {
 
 // AzureVM represents an Azure Virtual Machine
 type AzureVM struct {
-	ID                string    `json:"id"`
-	Name              string    `json:"name"`
-	ResourceGroup     string    `json:"resource_group"`
-	Location          string    `json:"location"`
-	VMSize            string    `json:"vm_size"`
-	Status            string    `json:"status"`
-	ProvisioningState string    `json:"provisioning_state"`
-	OsType            string    `json:"os_type"`
-	CreatedTime       time.Time `json:"created_time"`
-	SubscriptionID    string    `json:"subscription_id"`
+	ID                string            `json:"id"`
+	Name              string            `json:"name"`
+	ResourceGroup     string            `json:"resource_group"`
+	Location          string            `json:"location"`
+	VMSize            string            `json:"vm_size"`
+	Status            string            `json:"status"`
+	ProvisioningState string            `json:"provisioning_state"`
+	OsType            string            `json:"os_type"`
+	CreatedTime       time.Time         `json:"created_time"`
+	SubscriptionID    string            `json:"subscription_id"`
+	Tags              map[string]string `json:"tags,omitempty"`
 }
 
 // AzureStorageAccount represents an Azure Storage Account
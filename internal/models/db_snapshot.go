@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// DB snapshot kinds
+const (
+	DBSnapshotKindInstance = "instance" // RDS DB instance snapshot
+	DBSnapshotKindCluster  = "cluster"  // Aurora DB cluster snapshot
+)
+
+// DBSnapshot represents an RDS DB instance snapshot or an Aurora DB cluster snapshot
+type DBSnapshot struct {
+	SnapshotID       string    `json:"snapshot_id"`
+	SnapshotArn      string    `json:"snapshot_arn"`
+	Kind             string    `json:"kind"`          // "instance" or "cluster"
+	SnapshotType     string    `json:"snapshot_type"` // "manual", "automated" or "awsbackup"
+	SourceIdentifier string    `json:"source_identifier"`
+	SourceExists     bool      `json:"source_exists"` // The DB instance or cluster the snapshot was taken from still exists
+	Engine           string    `json:"engine"`
+	EngineVersion    string    `json:"engine_version"`
+	AllocatedStorage int64     `json:"allocated_storage"` // in GiB
+	Status           string    `json:"status"`
+	Encrypted        bool      `json:"encrypted"`
+	KmsKeyID         string    `json:"kms_key_id,omitempty"`
+	CreateTime       time.Time `json:"create_time"`
+	AccountID        string    `json:"account_id"`
+	AccountName      string    `json:"account_name"`
+	Region           string    `json:"region"`
+	Tags             []Tag     `json:"tags,omitempty"`
+
+	Public          bool     `json:"public"`                     // Restorable by any AWS account
+	SharedWith      []string `json:"shared_with,omitempty"`      // Account IDs allowed to restore the snapshot
+	ProtectedReason string   `json:"protected_reason,omitempty"` // Why bulk deletion skips this snapshot
+}
+
+// DBSnapshotFinding flags a DB snapshot that is public or shared with accounts outside the organization
+type DBSnapshotFinding struct {
+	AccountID        string   `json:"account_id"`
+	AccountName      string   `json:"account_name"`
+	Region           string   `json:"region"`
+	SnapshotID       string   `json:"snapshot_id"`
+	SnapshotArn      string   `json:"snapshot_arn"`
+	Kind             string   `json:"kind"`
+	Engine           string   `json:"engine"`
+	Encrypted        bool     `json:"encrypted"`
+	Public           bool     `json:"public"`
+	ExternalAccounts []string `json:"external_accounts,omitempty"` // Shared-with accounts that are not in the organization
+	Severity         string   `json:"severity"`
+	Description      string   `json:"description"`
+}
@@ -0,0 +1,48 @@
+package models
+
+// EC2 instance actions, single or in bulk
+const (
+	EC2ActionStart     = "start"
+	EC2ActionStop      = "stop"
+	EC2ActionReboot    = "reboot"
+	EC2ActionHibernate = "hibernate"
+	EC2ActionTerminate = "terminate"
+	EC2ActionProtect   = "protect" // Change termination and stop protection
+	EC2ActionTag       = "tag"     // Add and remove tags
+)
+
+// EC2InstanceRef identifies an instance in an account and region
+type EC2InstanceRef struct {
+	AccountID  string `json:"account_id"`
+	Region     string `json:"region"`
+	InstanceID string `json:"instance_id"`
+}
+
+// EC2InstanceDetails is an instance with the attributes DescribeInstances does not return
+type EC2InstanceDetails struct {
+	EC2Instance
+	TerminationProtection bool   `json:"termination_protection"`
+	StopProtection        bool   `json:"stop_protection"`
+	ShutdownBehavior      string `json:"shutdown_behavior"` // What an OS shutdown does: "stop" or "terminate"
+}
+
+// EC2ProtectionInput changes an instance's protection flags; nil flags are left unchanged
+type EC2ProtectionInput struct {
+	TerminationProtection *bool `json:"termination_protection,omitempty"`
+	StopProtection        *bool `json:"stop_protection,omitempty"`
+}
+
+// EC2TagInput adds or overwrites and removes instance tags
+type EC2TagInput struct {
+	Add    []Tag    `json:"add,omitempty"`
+	Remove []string `json:"remove,omitempty"` // Tag keys
+}
+
+// EC2BulkActionInput applies one action to several instances as a background job
+type EC2BulkActionInput struct {
+	Action     string              `json:"action"`
+	Instances  []EC2InstanceRef    `json:"instances"`
+	Protection *EC2ProtectionInput `json:"protection,omitempty"` // Required for EC2ActionProtect
+	Tags       *EC2TagInput        `json:"tags,omitempty"`       // Required for EC2ActionTag
+	DryRun     bool                `json:"dry_run"`
+}
@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// EC2 utilization classifications
+const (
+	EC2UtilizationIdle             = "idle"
+	EC2UtilizationUnderutilized    = "underutilized"
+	EC2UtilizationOptimized        = "optimized"
+	EC2UtilizationInsufficientData = "insufficient_data"
+)
+
+// EC2UtilizationMetrics summarizes an instance's CloudWatch metrics over the analysis window.
+// Peaks are the highest hourly average.
+type EC2UtilizationMetrics struct {
+	Hours              int      `json:"hours"` // Hours with CPU datapoints
+	CPUAverage         float64  `json:"cpu_average"`
+	CPUPeak            float64  `json:"cpu_peak"`
+	NetworkInMBPerDay  float64  `json:"network_in_mb_per_day"`
+	NetworkOutMBPerDay float64  `json:"network_out_mb_per_day"`
+	MemoryAverage      *float64 `json:"memory_average,omitempty"` // Only when the CloudWatch agent reports memory
+	MemoryPeak         *float64 `json:"memory_peak,omitempty"`
+}
+
+// EC2UtilizationFinding classifies one running instance and suggests a smaller instance type
+type EC2UtilizationFinding struct {
+	InstanceID              string                `json:"instance_id"`
+	Name                    string                `json:"name"`
+	AccountID               string                `json:"account_id"`
+	AccountName             string                `json:"account_name"`
+	Region                  string                `json:"region"`
+	InstanceType            string                `json:"instance_type"`
+	Platform                string                `json:"platform,omitempty"`
+	LaunchTime              time.Time             `json:"launch_time"`
+	Classification          string                `json:"classification"`
+	Reasons                 []string              `json:"reasons,omitempty"`
+	Metrics                 EC2UtilizationMetrics `json:"metrics"`
+	MonthlyCost             float64               `json:"monthly_cost"`
+	CostSource              string                `json:"cost_source"`
+	SuggestedInstanceType   string                `json:"suggested_instance_type,omitempty"`
+	SuggestedMonthlyCost    float64               `json:"suggested_monthly_cost,omitempty"`
+	EstimatedMonthlySavings float64               `json:"estimated_monthly_savings"` // Full cost for idle instances
+	Error                   string                `json:"error,omitempty"`           // Set when metrics could not be read
+}
+
+// EC2UtilizationReport is the result of an EC2 utilization analysis
+type EC2UtilizationReport struct {
+	GeneratedAt             time.Time               `json:"generated_at"`
+	WindowDays              int                     `json:"window_days"`
+	Idle                    int                     `json:"idle"`
+	Underutilized           int                     `json:"underutilized"`
+	Optimized               int                     `json:"optimized"`
+	InsufficientData        int                     `json:"insufficient_data"`
+	EstimatedMonthlySavings float64                 `json:"estimated_monthly_savings"`
+	Instances               []EC2UtilizationFinding `json:"instances"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Job states
+const (
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
+
+// Job represents a long-running background operation such as an analysis or a bulk cleanup
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Description string          `json:"description"`
+	Status      string          `json:"status"`
+	CreatedAt   time.Time       `json:"created_at"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+	Total       int             `json:"total"`
+	Processed   int             `json:"processed"`
+	Results     []JobItemResult `json:"results,omitempty"`
+	Output      any             `json:"output,omitempty"`
+	Error       string          `json:"error,omitempty"`
+}
+
+// JobItemResult is the outcome of a job for a single resource
+type JobItemResult struct {
+	AccountID  string `json:"account_id,omitempty"`
+	Region     string `json:"region,omitempty"`
+	ResourceID string `json:"resource_id"`
+	Status     string `json:"status"` // "succeeded", "failed", "skipped"
+	Message    string `json:"message,omitempty"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Sources of a resource's estimated monthly cost
+const (
+	CostSourcePriceList = "price_list" // Region-specific price from the loaded price list
+	CostSourceBuiltin   = "builtin"    // Built-in us-east-1 Linux on-demand estimate
+)
+
+// PriceListStatus describes the price list used for cost estimates
+type PriceListStatus struct {
+	Loaded             bool      `json:"loaded"`
+	Files              []string  `json:"files"`
+	GeneratedAt        time.Time `json:"generated_at,omitempty"` // When the prices were downloaded
+	LoadedAt           time.Time `json:"loaded_at,omitempty"`
+	EC2Prices          int       `json:"ec2_prices"`
+	EBSPrices          int       `json:"ebs_prices"`
+	NATGatewayPrices   int       `json:"nat_gateway_prices"`
+	LoadBalancerPrices int       `json:"load_balancer_prices"`
+	Error              string    `json:"error,omitempty"`
+}
@@ -0,0 +1,27 @@
+package models
+
+// PrivilegeEscalationFinding reports an IAM principal that can grant itself more privileges
+type PrivilegeEscalationFinding struct {
+	AccountID          string              `json:"account_id"`
+	AccountName        string              `json:"account_name"`
+	PrincipalType      string              `json:"principal_type"` // "user" or "role"
+	PrincipalName      string              `json:"principal_name"`
+	PrincipalArn       string              `json:"principal_arn"`
+	Technique          string              `json:"technique"`
+	Description        string              `json:"description"`
+	Severity           string              `json:"severity"` // "critical", "high" or "medium"
+	RequiredActions    []string            `json:"required_actions"`
+	Conditional        bool                `json:"conditional"` // True if any granting statement has conditions
+	GrantingStatements []GrantingStatement `json:"granting_statements"`
+}
+
+// GrantingStatement identifies the policy statement that grants an action
+type GrantingStatement struct {
+	Action     string `json:"action"`
+	PolicyName string `json:"policy_name"`
+	PolicyArn  string `json:"policy_arn,omitempty"` // Empty for inline policies
+	PolicyType string `json:"policy_type"`          // "inline" or "managed"
+	Source     string `json:"source,omitempty"`     // Group name for policies inherited from a group
+	Sid        string `json:"sid,omitempty"`
+	Statement  string `json:"statement"` // JSON string
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// AccountRegion is a region of an account and whether resource scans cover it
+type AccountRegion struct {
+	Region      string `json:"region"`
+	OptInStatus string `json:"opt_in_status"` // "opt-in-not-required", "opted-in" or "not-opted-in"
+	Scanned     bool   `json:"scanned"`
+	SkipReason  string `json:"skip_reason,omitempty"`
+}
+
+// RegionSkip is a region a scan left out and why
+type RegionSkip struct {
+	Region string `json:"region"`
+	Reason string `json:"reason"`
+}
+
+// ScanCoverage records which regions the latest scan of a resource type covered in an account
+type ScanCoverage struct {
+	Scanner        string            `json:"scanner"` // e.g. "snapshots", "security_groups"
+	AccountID      string            `json:"account_id"`
+	ScannedRegions []string          `json:"scanned_regions"`
+	FailedRegions  map[string]string `json:"failed_regions,omitempty"` // Region to error
+	SkippedRegions []RegionSkip      `json:"skipped_regions,omitempty"`
+	StartedAt      time.Time         `json:"started_at"`
+	CompletedAt    time.Time         `json:"completed_at"`
+}
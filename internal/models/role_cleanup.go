@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Staged role cleanup stages, recorded as tags on the role itself
+const (
+	RoleCleanupStageTagged   = "tagged"
+	RoleCleanupStageDisabled = "disabled"
+	RoleCleanupStageDeleted  = "deleted"
+)
+
+// UnusedRole is a role that has not been used recently and is a cleanup candidate
+type UnusedRole struct {
+	AccountID        string     `json:"account_id"`
+	AccountName      string     `json:"account_name"`
+	RoleName         string     `json:"role_name"`
+	Arn              string     `json:"arn"`
+	Path             string     `json:"path"`
+	CreateDate       time.Time  `json:"create_date"`
+	LastUsedDate     *time.Time `json:"last_used_date,omitempty"`
+	LastUsedRegion   string     `json:"last_used_region,omitempty"`
+	DaysUnused       int        `json:"days_unused"`
+	CleanupStage     string     `json:"cleanup_stage,omitempty"` // Empty, "tagged" or "disabled"
+	CleanupStageDate *time.Time `json:"cleanup_stage_date,omitempty"`
+	NextStageAfter   *time.Time `json:"next_stage_after,omitempty"` // When the grace period for the current stage ends
+}
+
+// RoleCleanupTarget identifies a role to advance through the staged cleanup
+type RoleCleanupTarget struct {
+	AccountID string `json:"account_id"`
+	RoleName  string `json:"role_name"`
+}
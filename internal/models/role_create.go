@@ -0,0 +1,30 @@
+package models
+
+// RoleTrustInput describes who may assume a role; it is turned into a trust policy document
+type RoleTrustInput struct {
+	AccountIDs      []string `json:"account_ids,omitempty"`
+	ExternalID      string   `json:"external_id,omitempty"` // Required via sts:ExternalId for the accounts above
+	Services        []string `json:"services,omitempty"`    // e.g. "ec2.amazonaws.com"
+	OIDCProviderArn string   `json:"oidc_provider_arn,omitempty"`
+	OIDCSubjects    []string `json:"oidc_subjects,omitempty"` // Allowed token "sub" values; wildcards permitted
+	OIDCAudiences   []string `json:"oidc_audiences,omitempty"`
+}
+
+// CreateRoleInput holds the settings for a new IAM role
+type CreateRoleInput struct {
+	RoleName            string         `json:"role_name"`
+	Path                string         `json:"path,omitempty"`
+	Description         string         `json:"description,omitempty"`
+	MaxSessionDuration  int64          `json:"max_session_duration,omitempty"` // Seconds, 3600-43200
+	PermissionsBoundary string         `json:"permissions_boundary,omitempty"` // Managed policy ARN
+	Trust               RoleTrustInput `json:"trust"`
+	TrustPolicyDocument string         `json:"trust_policy_document,omitempty"` // Raw document; overrides Trust
+	Tags                []Tag          `json:"tags,omitempty"`
+	PolicyArns          []string       `json:"policy_arns,omitempty"`
+}
+
+// UpdateTrustPolicyInput replaces a role's trust policy, from either builder inputs or a raw document
+type UpdateTrustPolicyInput struct {
+	Trust               RoleTrustInput `json:"trust"`
+	TrustPolicyDocument string         `json:"trust_policy_document,omitempty"`
+}
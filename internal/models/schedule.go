@@ -0,0 +1,107 @@
+package models
+
+import "time"
+
+// Scheduled actions
+const (
+	ScheduleActionStart = "start"
+	ScheduleActionStop  = "stop"
+)
+
+// Providers of scheduled machines
+const (
+	ScheduleProviderAWS   = "aws"
+	ScheduleProviderAzure = "azure"
+)
+
+// ScheduleRule runs an action whenever its cron expression matches in the schedule's time zone
+type ScheduleRule struct {
+	Cron   string `json:"cron"`   // "minute hour day-of-month month day-of-week", e.g. "0 8 * * MON-FRI"
+	Action string `json:"action"` // "start" or "stop"
+}
+
+// ScheduleTarget is an explicitly selected EC2 instance or Azure VM
+type ScheduleTarget struct {
+	Provider       string `json:"provider"` // "aws" or "azure"
+	AccountID      string `json:"account_id,omitempty"`
+	Region         string `json:"region,omitempty"`
+	InstanceID     string `json:"instance_id,omitempty"`
+	SubscriptionID string `json:"subscription_id,omitempty"`
+	ResourceGroup  string `json:"resource_group,omitempty"`
+	VMName         string `json:"vm_name,omitempty"`
+}
+
+// ScheduleSelector selects machines by tag. All tags must match.
+type ScheduleSelector struct {
+	Tags            map[string]string `json:"tags"`                       // Tag key to value; "*" matches any value
+	Providers       []string          `json:"providers,omitempty"`        // Default: both
+	AccountIDs      []string          `json:"account_ids,omitempty"`      // Limit EC2 instances to these accounts
+	SubscriptionIDs []string          `json:"subscription_ids,omitempty"` // Limit Azure VMs to these subscriptions
+}
+
+// ScheduleOverride suspends scheduled actions during a window, e.g. to keep machines running late
+type ScheduleOverride struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Actions []string  `json:"actions,omitempty"` // Actions to skip; empty skips all
+	Reason  string    `json:"reason,omitempty"`
+}
+
+// Schedule starts and stops machines on cron rules. Runs missed while the service was down
+// are caught up within the catch-up window; only the latest missed action is applied.
+type Schedule struct {
+	ID             string             `json:"id"`
+	Name           string             `json:"name"`
+	Description    string             `json:"description,omitempty"`
+	TimeZone       string             `json:"time_zone"` // IANA name, e.g. "Europe/Berlin"
+	Rules          []ScheduleRule     `json:"rules"`
+	Targets        []ScheduleTarget   `json:"targets,omitempty"`
+	Selector       *ScheduleSelector  `json:"selector,omitempty"`
+	Enabled        bool               `json:"enabled"`
+	CatchUpMinutes int                `json:"catch_up_minutes,omitempty"` // Default 720; negative disables catch-up
+	Overrides      []ScheduleOverride `json:"overrides,omitempty"`
+	LastEvaluated  time.Time          `json:"last_evaluated,omitempty"`
+	LastRunAt      time.Time          `json:"last_run_at,omitempty"` // Time of the latest rule that fired
+	LastAction     string             `json:"last_action,omitempty"`
+	CreatedAt      time.Time          `json:"created_at"`
+	UpdatedAt      time.Time          `json:"updated_at"`
+}
+
+// ScheduleSnoozeInput adds an override window starting now
+type ScheduleSnoozeInput struct {
+	Duration string   `json:"duration"` // Go duration, e.g. "3h"
+	Actions  []string `json:"actions,omitempty"`
+	Reason   string   `json:"reason,omitempty"`
+}
+
+// ScheduleRun is a time a schedule rule fires
+type ScheduleRun struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+}
+
+// ScheduleAuditEntry records one scheduled action on one machine
+type ScheduleAuditEntry struct {
+	Time         time.Time `json:"time"`
+	ScheduleID   string    `json:"schedule_id"`
+	ScheduleName string    `json:"schedule_name"`
+	ScheduledFor time.Time `json:"scheduled_for"`
+	CatchUp      bool      `json:"catch_up"` // Applied after the service missed the run
+	Action       string    `json:"action"`
+	Provider     string    `json:"provider,omitempty"`
+	Resource     string    `json:"resource,omitempty"` // Instance ID or "resource-group/vm-name"
+	Account      string    `json:"account,omitempty"`  // Account or subscription ID
+	Status       string    `json:"status"`             // "succeeded", "failed" or "skipped"
+	Message      string    `json:"message,omitempty"`
+	Leader       string    `json:"leader"` // Scheduler instance that ran the action
+}
+
+// SchedulerStatus describes the in-process scheduler
+type SchedulerStatus struct {
+	Enabled      bool      `json:"enabled"`
+	InstanceID   string    `json:"instance_id"`
+	IsLeader     bool      `json:"is_leader"`
+	Leader       string    `json:"leader,omitempty"`
+	LeaseExpires time.Time `json:"lease_expires,omitempty"`
+	LastTick     time.Time `json:"last_tick,omitempty"`
+}
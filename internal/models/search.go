@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Principal types returned by the search index
+const (
+	SearchTypeIAMUser               = "iam_user"
+	SearchTypeIAMRole               = "iam_role"
+	SearchTypeSSOUser               = "sso_user"
+	SearchTypeSSOGroup              = "sso_group"
+	SearchTypeAzureServicePrincipal = "azure_service_principal"
+)
+
+// SearchResult is a principal matching a search query
+type SearchResult struct {
+	Type          string   `json:"type"`
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	DisplayName   string   `json:"display_name,omitempty"`
+	Arn           string   `json:"arn,omitempty"`
+	Emails        []string `json:"emails,omitempty"`
+	AppID         string   `json:"app_id,omitempty"`
+	AccountID     string   `json:"account_id,omitempty"`
+	AccountName   string   `json:"account_name,omitempty"`
+	Tags          []string `json:"tags,omitempty"`           // "key=value" for AWS tags, plain values for Azure tags
+	MatchedFields []string `json:"matched_fields,omitempty"` // e.g. "name", "arn", "email", "app_id", "tag"
+	Score         int      `json:"score"`                    // Higher is better: exact > prefix > substring
+}
+
+// SearchResponse is the result of a search over the principal index
+type SearchResponse struct {
+	Query        string            `json:"query"`
+	Total        int               `json:"total"` // Matches before the limit was applied
+	Results      []SearchResult    `json:"results"`
+	IndexedAt    time.Time         `json:"indexed_at"`
+	SourceErrors map[string]string `json:"source_errors,omitempty"` // Sources that could not be indexed, by type
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// SecretReference points at a secret held for one-time retrieval.
+// It is returned by API calls that create credentials instead of the secret itself.
+type SecretReference struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// RetrievedSecret is the decrypted payload returned when a secret reference is redeemed
+type RetrievedSecret struct {
+	Kind      string            `json:"kind"` // "access_key" or "password"
+	AccountID string            `json:"account_id"`
+	Username  string            `json:"username"`
+	Values    map[string]string `json:"values"`
+	CreatedAt time.Time         `json:"created_at"`
+}
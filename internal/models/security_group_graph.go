@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Security group graph node types
+const (
+	GraphNodeSecurityGroup         = "security_group"
+	GraphNodeExternalSecurityGroup = "external_security_group" // Referenced group outside the scanned scope, e.g. in a peered account
+	GraphNodeNetworkInterface      = "network_interface"
+	GraphNodeInstance              = "instance"
+)
+
+// Security group graph edge types
+const (
+	GraphEdgeRuleReference = "rule_reference" // From the group owning the rule to the group it references
+	GraphEdgeAttachment    = "attachment"     // From an ENI to its group, or from an instance to its ENI
+)
+
+// SecurityGroupGraphNode is a security group, network interface or instance
+type SecurityGroupGraphNode struct {
+	ID        string `json:"id"`
+	Type      string `json:"type"`
+	Label     string `json:"label"`
+	AccountID string `json:"account_id,omitempty"`
+	Region    string `json:"region,omitempty"`
+	VpcID     string `json:"vpc_id,omitempty"`
+	Owner     string `json:"owner,omitempty"` // Owning service of a network interface, e.g. "load_balancer"
+}
+
+// SecurityGroupGraphEdge connects two nodes
+type SecurityGroupGraphEdge struct {
+	From         string   `json:"from"`
+	To           string   `json:"to"`
+	Type         string   `json:"type"`
+	Direction    string   `json:"direction,omitempty"` // "ingress" or "egress" for rule references
+	Rules        []string `json:"rules,omitempty"`     // e.g. "tcp 443"
+	CrossAccount bool     `json:"cross_account,omitempty"`
+}
+
+// SecurityGroupGraph shows how security groups reference each other and what they are attached to
+type SecurityGroupGraph struct {
+	AccountID     string                   `json:"account_id"`
+	Region        string                   `json:"region,omitempty"` // Empty when all regions are included
+	VpcID         string                   `json:"vpc_id,omitempty"`
+	Nodes         []SecurityGroupGraphNode `json:"nodes"`
+	Edges         []SecurityGroupGraphEdge `json:"edges"`
+	FailedRegions map[string]string        `json:"failed_regions,omitempty"` // Region to error; the graph is incomplete when set
+	GeneratedAt   time.Time                `json:"generated_at"`
+}
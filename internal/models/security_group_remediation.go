@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Security group rule directions
+const (
+	SecurityGroupIngress = "ingress"
+	SecurityGroupEgress  = "egress"
+)
+
+// Security group remediation actions
+const (
+	SecurityGroupChangeRevoke = "revoke" // Remove a rule
+	SecurityGroupChangeNarrow = "narrow" // Replace a rule's CIDR with narrower CIDRs
+)
+
+// Security group change revert states
+const (
+	SecurityGroupChangeReverted          = "reverted"
+	SecurityGroupChangePartiallyReverted = "partially_reverted" // Some rules were restored or removed before the revert failed
+)
+
+// RevokeRuleInput identifies a single rule to remove from a security group
+type RevokeRuleInput struct {
+	Direction string            `json:"direction"` // "ingress" (default) or "egress"
+	Rule      SecurityGroupRule `json:"rule"`
+}
+
+// NarrowRuleInput replaces the CIDR of a rule (typically 0.0.0.0/0 or ::/0) with a list of CIDRs
+type NarrowRuleInput struct {
+	Direction        string            `json:"direction"` // "ingress" (default) or "egress"
+	Rule             SecurityGroupRule `json:"rule"`
+	ReplacementCidrs []string          `json:"replacement_cidrs"`
+}
+
+// SecurityGroupChange records a rule change and the rules before it, so it can be reverted
+type SecurityGroupChange struct {
+	ID                   string              `json:"id"`
+	AccountID            string              `json:"account_id"`
+	Region               string              `json:"region"`
+	GroupID              string              `json:"group_id"`
+	Action               string              `json:"action"`
+	Direction            string              `json:"direction"`
+	RemovedRules         []SecurityGroupRule `json:"removed_rules,omitempty"`
+	AddedRules           []SecurityGroupRule `json:"added_rules,omitempty"`
+	PreviousIngressRules []SecurityGroupRule `json:"previous_ingress_rules"`
+	PreviousEgressRules  []SecurityGroupRule `json:"previous_egress_rules"`
+	CreatedAt            time.Time           `json:"created_at"`
+	RevertedAt           *time.Time          `json:"reverted_at,omitempty"`
+	RevertStatus         string              `json:"revert_status,omitempty"`
+	RevertError          string              `json:"revert_error,omitempty"` // Why the last revert attempt failed
+}
+
+// SecurityGroupRemediationInput selects open rules across many groups and the action to apply to each
+type SecurityGroupRemediationInput struct {
+	Action           string   `json:"action"`                      // "revoke" or "narrow"
+	Source           string   `json:"source,omitempty"`            // Open CIDR to remediate; defaults to 0.0.0.0/0
+	Protocol         string   `json:"protocol,omitempty"`          // Only rules with this protocol, e.g. "tcp"
+	Port             *int64   `json:"port,omitempty"`              // Only rules whose port range includes this port
+	ReplacementCidrs []string `json:"replacement_cidrs,omitempty"` // Required for "narrow"
+	AccountIDs       []string `json:"account_ids,omitempty"`       // Limit to these accounts
+	GroupIDs         []string `json:"group_ids,omitempty"`         // Limit to these groups
+	DryRun           bool     `json:"dry_run"`
+}
+
+// SecurityGroupCleanupInput selects unused security groups to delete
+type SecurityGroupCleanupInput struct {
+	AccountIDs []string `json:"account_ids,omitempty"` // Limit to these accounts
+	GroupIDs   []string `json:"group_ids,omitempty"`   // Limit to these groups
+	DryRun     bool     `json:"dry_run"`
+}
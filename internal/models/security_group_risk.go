@@ -0,0 +1,31 @@
+package models
+
+// SecurityGroupFinding is a scored ingress rule that exposes a group to a wide address range
+type SecurityGroupFinding struct {
+	AccountID    string `json:"account_id"`
+	AccountName  string `json:"account_name"`
+	Region       string `json:"region"`
+	GroupID      string `json:"group_id"`
+	GroupName    string `json:"group_name"`
+	VpcID        string `json:"vpc_id,omitempty"`
+	Protocol     string `json:"protocol"`
+	PortRange    string `json:"port_range"`
+	Source       string `json:"source"`
+	Service      string `json:"service,omitempty"` // Sensitive service on the exposed ports, e.g. "SSH"
+	PortSeverity string `json:"port_severity"`     // Catalogue severity of the exposed ports
+	PrefixLength int    `json:"prefix_length"`
+	Private      bool   `json:"private"`       // Source is a private (RFC 1918 / ULA) range
+	PublicFacing bool   `json:"public_facing"` // Group is attached to an interface with a public IP
+	Attached     bool   `json:"attached"`
+	Score        int    `json:"score"` // 0-100
+	Severity     string `json:"severity"`
+	Description  string `json:"description"`
+}
+
+// PortRisk is a port catalogue entry used to score exposed ports
+type PortRisk struct {
+	FromPort int64  `json:"from_port"`
+	ToPort   int64  `json:"to_port"`
+	Service  string `json:"service,omitempty"`
+	Severity string `json:"severity"` // "critical", "high", "medium" or "low"
+}
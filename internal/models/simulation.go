@@ -0,0 +1,54 @@
+package models
+
+// Policy simulation decisions
+const (
+	SimulationAllowed      = "allowed"
+	SimulationExplicitDeny = "explicitDeny"
+	SimulationImplicitDeny = "implicitDeny"
+)
+
+// SimulationRequest asks whether a principal can perform actions on resources
+type SimulationRequest struct {
+	Actions        []string                 `json:"actions"`
+	ResourceArns   []string                 `json:"resource_arns,omitempty"` // "${AccountId}" is replaced per account in batch mode
+	ContextEntries []SimulationContextEntry `json:"context_entries,omitempty"`
+}
+
+// SimulationContextEntry supplies a condition context key value, e.g. aws:SourceIp
+type SimulationContextEntry struct {
+	Key    string   `json:"key"`
+	Type   string   `json:"type"` // string, stringList, numeric, boolean, date, ip, ...
+	Values []string `json:"values"`
+}
+
+// SimulationResult is the outcome of simulating a request for one principal
+type SimulationResult struct {
+	AccountID     string             `json:"account_id"`
+	AccountName   string             `json:"account_name"`
+	PrincipalType string             `json:"principal_type"` // "user" or "role"
+	PrincipalName string             `json:"principal_name"`
+	PrincipalArn  string             `json:"principal_arn,omitempty"`
+	AllAllowed    bool               `json:"all_allowed"`
+	Results       []ActionSimulation `json:"results,omitempty"`
+	Error         string             `json:"error,omitempty"` // Set in batch mode when an account could not be evaluated
+}
+
+// ActionSimulation is the decision for one action on one resource
+type ActionSimulation struct {
+	Action               string             `json:"action"`
+	Resource             string             `json:"resource"`
+	Decision             string             `json:"decision"`
+	Allowed              bool               `json:"allowed"`
+	MatchedStatements    []MatchedStatement `json:"matched_statements,omitempty"`
+	MissingContextValues []string           `json:"missing_context_values,omitempty"`
+	DeniedByOrganization bool               `json:"denied_by_organization,omitempty"` // An SCP denied the request
+	DeniedByBoundary     bool               `json:"denied_by_boundary,omitempty"`     // The permissions boundary denied the request
+}
+
+// MatchedStatement identifies a policy statement that contributed to a decision
+type MatchedStatement struct {
+	SourcePolicyID   string `json:"source_policy_id"`
+	SourcePolicyType string `json:"source_policy_type"`
+	StartLine        int64  `json:"start_line,omitempty"`
+	EndLine          int64  `json:"end_line,omitempty"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Snapshot storage tiers
+const (
+	SnapshotTierStandard = "standard"
+	SnapshotTierArchive  = "archive"
+)
+
+// How a snapshot's billable size was estimated
+const (
+	SnapshotSizeVolume        = "volume_size"    // Source volume size; an upper bound, since only written blocks are billed
+	SnapshotSizeChangedBlocks = "changed_blocks" // Blocks written since the previous snapshot of the volume (all for the first), via the EBS direct APIs
+)
+
+// SnapshotCostTotal is the snapshot storage of one account in one region
+type SnapshotCostTotal struct {
+	AccountID     string  `json:"account_id"`
+	AccountName   string  `json:"account_name"`
+	Region        string  `json:"region"`
+	SnapshotCount int     `json:"snapshot_count"`
+	StandardCount int     `json:"standard_count"`
+	ArchiveCount  int     `json:"archive_count"`
+	StandardGiB   float64 `json:"standard_gib"`
+	ArchiveGiB    float64 `json:"archive_gib"`
+	MonthlyCost   float64 `json:"monthly_cost"`
+}
+
+// SnapshotCostReport totals estimated snapshot storage cost per account and region
+type SnapshotCostReport struct {
+	Totals              []SnapshotCostTotal `json:"totals"`
+	TotalMonthlyCost    float64             `json:"total_monthly_cost"`
+	TotalSnapshots      int                 `json:"total_snapshots"`
+	IncrementalEstimate bool                `json:"incremental_estimate"` // Whether standard-tier sizes were measured with the EBS direct APIs
+	GeneratedAt         time.Time           `json:"generated_at"`
+}
+
+// SnapshotRestoreInput restores an archived snapshot either permanently or for a number of days
+type SnapshotRestoreInput struct {
+	TemporaryRestoreDays int64 `json:"temporary_restore_days,omitempty"`
+	Permanent            bool  `json:"permanent"`
+}
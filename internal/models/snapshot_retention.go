@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Snapshot retention decisions
+const (
+	RetentionKeep   = "keep"
+	RetentionDelete = "delete"
+)
+
+// SnapshotRetentionMatch selects the snapshots a rule applies to. All set criteria must match.
+type SnapshotRetentionMatch struct {
+	Tags               map[string]string `json:"tags,omitempty"`                // Tag key to value; "*" matches any value
+	VolumeIDs          []string          `json:"volume_ids,omitempty"`          // Source volumes
+	DescriptionPattern string            `json:"description_pattern,omitempty"` // Regular expression matched against the description
+	AccountIDs         []string          `json:"account_ids,omitempty"`
+	OUIDs              []string          `json:"ou_ids,omitempty"` // Organizational units containing the account, at any depth
+}
+
+// SnapshotRetentionRule decides which matching snapshots are kept. Counts apply per source volume.
+type SnapshotRetentionRule struct {
+	Name            string                 `json:"name"`
+	Match           SnapshotRetentionMatch `json:"match"`
+	KeepLast        int                    `json:"keep_last,omitempty"`         // Newest N snapshots
+	KeepDaily       int                    `json:"keep_daily,omitempty"`        // Newest snapshot of each of the last N days with snapshots
+	KeepWeekly      int                    `json:"keep_weekly,omitempty"`       // Newest snapshot of each of the last N ISO weeks with snapshots
+	KeepMonthly     int                    `json:"keep_monthly,omitempty"`      // Newest snapshot of each of the last N months with snapshots
+	MaxAgeDays      int                    `json:"max_age_days,omitempty"`      // Snapshots older than this are deleted even if a keep count retains them
+	NeverDeleteTags []string               `json:"never_delete_tags,omitempty"` // "key" or "key=value"
+}
+
+// SnapshotRetentionPolicy is an ordered list of rules; each snapshot is governed by the first rule it matches
+type SnapshotRetentionPolicy struct {
+	ID          string                  `json:"id"`
+	Name        string                  `json:"name"`
+	Description string                  `json:"description,omitempty"`
+	Rules       []SnapshotRetentionRule `json:"rules"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+// SnapshotRetentionDecision is what a policy does with one snapshot and why
+type SnapshotRetentionDecision struct {
+	SnapshotID string    `json:"snapshot_id"`
+	AccountID  string    `json:"account_id"`
+	Region     string    `json:"region"`
+	VolumeID   string    `json:"volume_id"`
+	StartTime  time.Time `json:"start_time"`
+	Rule       string    `json:"rule,omitempty"` // Name of the governing rule
+	Action     string    `json:"action"`         // "keep" or "delete"
+	Reasons    []string  `json:"reasons"`
+}
+
+// SnapshotRetentionPreview lists the decisions a policy would make right now
+type SnapshotRetentionPreview struct {
+	PolicyID    string                      `json:"policy_id"`
+	GeneratedAt time.Time                   `json:"generated_at"`
+	KeepCount   int                         `json:"keep_count"`
+	DeleteCount int                         `json:"delete_count"`
+	Decisions   []SnapshotRetentionDecision `json:"decisions"`
+}
+
+// SnapshotRetentionRunInput limits a policy run
+type SnapshotRetentionRunInput struct {
+	AccountIDs []string `json:"account_ids,omitempty"` // Limit to these accounts
+	DryRun     bool     `json:"dry_run"`
+}
@@ -19,6 +19,7 @@ type User struct {
 	PasswordSet     bool        `json:"password_set"`
 	PasswordLastUsed *time.Time `json:"password_last_used,omitempty"`
 	AccessKeys      []AccessKey `json:"access_keys"`
+	Tags            []Tag       `json:"tags,omitempty"`
 }
 
 // UserWithAccount represents an AWS IAM user with account information
@@ -38,6 +39,40 @@ type AccessKey struct {
 	LastUsedRegion  string     `json:"last_used_region,omitempty"`
 }
 
+// Key rotation states
+const (
+	KeyRotationNewKeyCreated     = "new_key_created"
+	KeyRotationOldKeyDeactivated = "old_key_deactivated"
+	KeyRotationCompleted         = "completed"
+	KeyRotationRolledBack        = "rolled_back"
+)
+
+// KeyRotation tracks a staged access key rotation for a single old key
+type KeyRotation struct {
+	ID              string             `json:"id"`
+	AccountID       string             `json:"account_id"`
+	Username        string             `json:"username"`
+	OldKeyID        string             `json:"old_key_id"`
+	NewKeyID        string             `json:"new_key_id"`
+	State           string             `json:"state"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+	DeactivateAfter time.Time          `json:"deactivate_after"`       // Earliest time the old key may be deactivated
+	DeleteAfter     *time.Time         `json:"delete_after,omitempty"` // Earliest time the old key may be deleted
+	NewKeyLastUsed  *time.Time         `json:"new_key_last_used,omitempty"`
+	LastError       string             `json:"last_error,omitempty"`
+	History         []KeyRotationEvent `json:"history"`
+	SecretReference *SecretReference   `json:"secret_reference,omitempty"` // Only set on the response that created the new key
+}
+
+// KeyRotationEvent records a state transition of a key rotation
+type KeyRotationEvent struct {
+	Time    time.Time `json:"time"`
+	From    string    `json:"from,omitempty"`
+	To      string    `json:"to"`
+	Message string    `json:"message,omitempty"`
+}
+
 // PublicIP represents a public IP address used by AWS resources
 type PublicIP struct {
 	IPAddress    string `json:"ip_address"`
@@ -52,20 +87,23 @@ type PublicIP struct {
 
 // SecurityGroup represents an AWS security group
 type SecurityGroup struct {
-	GroupID       string              `json:"group_id"`
-	GroupName     string              `json:"group_name"`
-	Description   string              `json:"description"`
-	AccountID     string              `json:"account_id"`
-	AccountName   string              `json:"account_name"`
-	Region        string              `json:"region"`
-	VpcID         string              `json:"vpc_id,omitempty"`
-	IsDefault     bool                `json:"is_default"`
-	IngressRules  []SecurityGroupRule `json:"ingress_rules"`
-	EgressRules   []SecurityGroupRule `json:"egress_rules"`
-	HasOpenPorts  bool                `json:"has_open_ports"`
-	OpenPortsInfo []OpenPortInfo      `json:"open_ports_info,omitempty"`
-	IsUnused      bool                `json:"is_unused"`
-	UsageInfo     SecurityGroupUsage  `json:"usage_info"`
+	GroupID       string                 `json:"group_id"`
+	GroupName     string                 `json:"group_name"`
+	Description   string                 `json:"description"`
+	AccountID     string                 `json:"account_id"`
+	AccountName   string                 `json:"account_name"`
+	Region        string                 `json:"region"`
+	VpcID         string                 `json:"vpc_id,omitempty"`
+	IsDefault     bool                   `json:"is_default"`
+	IngressRules  []SecurityGroupRule    `json:"ingress_rules"`
+	EgressRules   []SecurityGroupRule    `json:"egress_rules"`
+	HasOpenPorts  bool                   `json:"has_open_ports"`
+	OpenPortsInfo []OpenPortInfo         `json:"open_ports_info,omitempty"`
+	IsUnused      bool                   `json:"is_unused"`
+	UsageInfo     SecurityGroupUsage     `json:"usage_info"`
+	Severity      string                 `json:"severity,omitempty"` // Highest finding severity
+	RiskScore     int                    `json:"risk_score"`         // Highest finding score, 0-100
+	Findings      []SecurityGroupFinding `json:"findings,omitempty"`
 }
 
 // SecurityGroupUsage represents usage information for a security group
@@ -74,18 +112,29 @@ type SecurityGroupUsage struct {
 	AttachedToNetworkInterfaces []string `json:"attached_to_network_interfaces,omitempty"`
 	AttachedToLoadBalancers     []string `json:"attached_to_load_balancers,omitempty"`
 	ReferencedBySecurityGroups  []string `json:"referenced_by_security_groups,omitempty"`
+	PublicNetworkInterfaces     []string `json:"public_network_interfaces,omitempty"` // Attached ENIs with a public IP
+	AttachedToRDS               []string `json:"attached_to_rds,omitempty"`           // RDS network interface IDs
+	AttachedToLambdaFunctions   []string `json:"attached_to_lambda_functions,omitempty"`
+	AttachedToVPCEndpoints      []string `json:"attached_to_vpc_endpoints,omitempty"`
+	AttachedToEFSMountTargets   []string `json:"attached_to_efs_mount_targets,omitempty"`
+	AttachedToECSTasks          []string `json:"attached_to_ecs_tasks,omitempty"`          // ECS task attachment ARNs
+	AttachedToOtherServices     []string `json:"attached_to_other_services,omitempty"`     // "requester: eni-id" for other managed services
+	ReferencedByLaunchTemplates []string `json:"referenced_by_launch_templates,omitempty"` // "lt-id:version"
 	TotalAttachments            int      `json:"total_attachments"`
+	IncompleteReason            string   `json:"incomplete_reason,omitempty"` // Set when part of the usage could not be determined
 }
 
 // SecurityGroupRule represents a security group rule
 type SecurityGroupRule struct {
-	IpProtocol string `json:"ip_protocol"`
-	FromPort   int64  `json:"from_port,omitempty"`
-	ToPort     int64  `json:"to_port,omitempty"`
-	CidrIPv4   string `json:"cidr_ipv4,omitempty"`
-	CidrIPv6   string `json:"cidr_ipv6,omitempty"`
-	GroupID    string `json:"group_id,omitempty"`
-	GroupOwner string `json:"group_owner,omitempty"`
+	IpProtocol   string `json:"ip_protocol"`
+	FromPort     int64  `json:"from_port,omitempty"`
+	ToPort       int64  `json:"to_port,omitempty"`
+	CidrIPv4     string `json:"cidr_ipv4,omitempty"`
+	CidrIPv6     string `json:"cidr_ipv6,omitempty"`
+	GroupID      string `json:"group_id,omitempty"`
+	GroupOwner   string `json:"group_owner,omitempty"`
+	PrefixListID string `json:"prefix_list_id,omitempty"`
+	Description  string `json:"description,omitempty"`
 }
 
 // OpenPortInfo represents information about ports open to the internet
@@ -111,6 +160,37 @@ type Snapshot struct {
 	AccountName string    `json:"account_name"`
 	Region      string    `json:"region"`
 	Tags        []Tag     `json:"tags,omitempty"`
+
+	VolumeExists    bool     `json:"volume_exists"`
+	AMIIDs          []string `json:"ami_ids,omitempty"`          // Registered AMIs backed by this snapshot
+	ManagedBy       string   `json:"managed_by,omitempty"`       // "aws_backup" or "dlm"
+	Orphaned        bool     `json:"orphaned"`                   // Source volume is gone and no AMI uses the snapshot
+	ProtectedReason string   `json:"protected_reason,omitempty"` // Why bulk deletion skips this snapshot
+
+	StorageTier       string     `json:"storage_tier"`                  // "standard" or "archive"
+	RestoreExpiryTime *time.Time `json:"restore_expiry_time,omitempty"` // When a temporarily restored snapshot returns to the archive
+	EstimatedSizeGiB  float64    `json:"estimated_size_gib"`            // Estimated billable size
+	SizeEstimate      string     `json:"size_estimate"`                 // How EstimatedSizeGiB was derived
+	MonthlyCost       float64    `json:"monthly_cost"`                  // Estimated monthly storage cost in USD
+}
+
+// Snapshot lifecycle managers
+const (
+	SnapshotManagedByBackup = "aws_backup"
+	SnapshotManagedByDLM    = "dlm"
+)
+
+// SkippedSnapshot is a snapshot left alone by a bulk deletion
+type SkippedSnapshot struct {
+	SnapshotID string `json:"snapshot_id"`
+	Region     string `json:"region"`
+	Reason     string `json:"reason"`
+}
+
+// SnapshotCleanupResult reports what a bulk snapshot deletion did
+type SnapshotCleanupResult struct {
+	Deleted []string          `json:"deleted_snapshots"`
+	Skipped []SkippedSnapshot `json:"skipped_snapshots"`
 }
 
 // Tag represents an AWS resource tag
@@ -128,9 +208,22 @@ type EC2Instance struct {
 	Region       string    `json:"region"`
 	InstanceType string    `json:"instance_type"` // Flavor
 	LaunchTime   time.Time `json:"launch_time"`
-	State        string    `json:"state"` // running, stopped, etc.
-	MonthlyCost  float64   `json:"monthly_cost"` // Estimated monthly cost in USD (for running instances)
+	State        string    `json:"state"`              // running, stopped, etc.
+	Platform     string    `json:"platform,omitempty"` // PlatformDetails, e.g. "Linux/UNIX", "Windows"
+	Tenancy      string    `json:"tenancy,omitempty"`  // default, dedicated or host
+	MonthlyCost  float64   `json:"monthly_cost"`       // Estimated monthly cost in USD (for running instances)
+	CostSource   string    `json:"cost_source"`        // CostSourcePriceList or CostSourceBuiltin
 	Tags         []Tag     `json:"tags,omitempty"`
+
+	PrivateIP             string `json:"private_ip,omitempty"`
+	PublicIP              string `json:"public_ip,omitempty"`
+	VpcID                 string `json:"vpc_id,omitempty"`
+	SubnetID              string `json:"subnet_id,omitempty"`
+	IAMInstanceProfile    string `json:"iam_instance_profile,omitempty"` // Instance profile ARN
+	KeyName               string `json:"key_name,omitempty"`
+	ImageID               string `json:"image_id,omitempty"`
+	UsageOperation        string `json:"usage_operation,omitempty"` // Billing code, e.g. "RunInstances:0800" for Windows BYOL
+	HibernationConfigured bool   `json:"hibernation_configured"`
 }
 
 // EBSVolume represents an AWS EBS volume
@@ -150,6 +243,7 @@ type EBSVolume struct {
 	Throughput       int64              `json:"throughput,omitempty"` // MB/s (for gp3)
 	SnapshotID       string             `json:"snapshot_id,omitempty"`
 	MonthlyCost      float64            `json:"monthly_cost"` // Estimated monthly cost in USD
+	CostSource       string             `json:"cost_source"`  // CostSourcePriceList or CostSourceBuiltin
 	Attachments      []VolumeAttachment `json:"attachments,omitempty"`
 	Tags             []Tag              `json:"tags,omitempty"`
 }
@@ -179,23 +273,60 @@ type S3Bucket struct {
 }
 
 // IAMRole represents an AWS IAM role
+
 type IAMRole struct {
-	RoleName                 string           `json:"role_name"`
-	RoleID                   string           `json:"role_id"`
-	Arn                      string           `json:"arn"`
-	AccountID                string           `json:"account_id"`
-	AccountName              string           `json:"account_name"`
-	CreateDate               time.Time        `json:"create_date"`
-	Path                     string           `json:"path"`
-	Description              string           `json:"description,omitempty"`
-	MaxSessionDuration       *int64           `json:"max_session_duration,omitempty"`
-	AssumeRolePolicyDocument string           `json:"assume_role_policy_document"` // JSON string
-	AttachedManagedPolicies  []AttachedPolicy `json:"attached_managed_policies,omitempty"`
-	InlinePolicies           []InlinePolicy   `json:"inline_policies,omitempty"`
-	InstanceProfiles         []string         `json:"instance_profiles,omitempty"`
-	Tags                     []Tag            `json:"tags,omitempty"`
-	LastUsedDate             *time.Time       `json:"last_used_date,omitempty"`
-	LastUsedRegion           string           `json:"last_used_region,omitempty"`
+	RoleName                 string             `json:"role_name"`
+	RoleID                   string             `json:"role_id"`
+	Arn                      string             `json:"arn"`
+	AccountID                string             `json:"account_id"`
+	AccountName              string             `json:"account_name"`
+	CreateDate               time.Time          `json:"create_date"`
+	Path                     string             `json:"path"`
+	Description              string             `json:"description,omitempty"`
+	MaxSessionDuration       *int64             `json:"max_session_duration,omitempty"`
+	AssumeRolePolicyDocument string             `json:"assume_role_policy_document"` // JSON string
+	AttachedManagedPolicies  []AttachedPolicy   `json:"attached_managed_policies,omitempty"`
+	InlinePolicies           []InlinePolicy     `json:"inline_policies,omitempty"`
+	InstanceProfiles         []string           `json:"instance_profiles,omitempty"`
+	Tags                     []Tag              `json:"tags,omitempty"`
+	LastUsedDate             *time.Time         `json:"last_used_date,omitempty"`
+	LastUsedRegion           string             `json:"last_used_region,omitempty"`
+	TrustedPrincipals        []TrustedPrincipal `json:"trusted_principals,omitempty"`
+	TrustFindings            []TrustFinding     `json:"trust_findings,omitempty"`
+}
+
+// Trusted principal classifications for role trust policies
+const (
+	TrustPrincipalSameAccount         = "same_account"
+	TrustPrincipalOrgAccount          = "org_account"
+	TrustPrincipalExternalAccount     = "external_account"
+	TrustPrincipalUnclassifiedAccount = "unclassified_account" // Organization membership could not be determined
+	TrustPrincipalAWSService          = "aws_service"
+	TrustPrincipalFederated           = "federated"
+	TrustPrincipalWildcard            = "wildcard"
+)
+
+// TrustedPrincipal is a principal allowed to assume a role by its trust policy
+type TrustedPrincipal struct {
+	Principal     string   `json:"principal"`
+	Type          string   `json:"type"`
+	AccountID     string   `json:"account_id,omitempty"` // For account principals
+	Actions       []string `json:"actions"`
+	ConditionKeys []string `json:"condition_keys,omitempty"`
+	HasExternalID bool     `json:"has_external_id"`
+}
+
+// TrustFinding is a risky trust relationship on a role
+type TrustFinding struct {
+	AccountID     string `json:"account_id"`
+	AccountName   string `json:"account_name"`
+	RoleName      string `json:"role_name"`
+	RoleArn       string `json:"role_arn"`
+	Principal     string `json:"principal"`
+	PrincipalType string `json:"principal_type"`
+	Issue         string `json:"issue"`    // "wildcard_principal", "missing_external_id" or "oidc_without_sub_condition"
+	Severity      string `json:"severity"` // "critical", "high" or "medium"
+	Description   string `json:"description"`
 }
 
 // RoleWithAccount represents an IAM role with account information
@@ -216,6 +347,48 @@ type InlinePolicy struct {
 	PolicyDocument string `json:"policy_document"` // JSON string
 }
 
+// IAMPolicy represents a customer-managed IAM policy
+type IAMPolicy struct {
+	PolicyName                    string    `json:"policy_name"`
+	PolicyID                      string    `json:"policy_id"`
+	Arn                           string    `json:"arn"`
+	Path                          string    `json:"path"`
+	Description                   string    `json:"description,omitempty"`
+	AccountID                     string    `json:"account_id"`
+	AccountName                   string    `json:"account_name"`
+	CreateDate                    time.Time `json:"create_date"`
+	UpdateDate                    time.Time `json:"update_date"`
+	DefaultVersionID              string    `json:"default_version_id"`
+	DefaultVersionDocument        string    `json:"default_version_document"` // JSON string
+	VersionCount                  int       `json:"version_count"`
+	AttachmentCount               int64     `json:"attachment_count"`
+	PermissionsBoundaryUsageCount int64     `json:"permissions_boundary_usage_count"`
+	Unattached                    bool      `json:"unattached"` // Not attached and not used as a permissions boundary
+}
+
+// PolicyVersion represents a single version of a managed policy
+type PolicyVersion struct {
+	VersionID        string    `json:"version_id"`
+	IsDefaultVersion bool      `json:"is_default_version"`
+	CreateDate       time.Time `json:"create_date"`
+	Document         string    `json:"document,omitempty"` // JSON string
+}
+
+// PolicyVersionDiff is a line-based diff between two versions of a managed policy
+type PolicyVersionDiff struct {
+	PolicyArn   string           `json:"policy_arn"`
+	FromVersion string           `json:"from_version"`
+	ToVersion   string           `json:"to_version"`
+	Identical   bool             `json:"identical"`
+	Lines       []PolicyDiffLine `json:"lines"`
+}
+
+// PolicyDiffLine is one line of a policy diff
+type PolicyDiffLine struct {
+	Op   string `json:"op"` // "equal", "added" or "removed"
+	Text string `json:"text"`
+}
+
 // LoadBalancer represents an AWS Load Balancer (ALB, NLB, or Classic ELB)
 type LoadBalancer struct {
 	LoadBalancerArn    string     `json:"load_balancer_arn,omitempty"` // For ALB/NLB
@@ -232,7 +405,9 @@ type LoadBalancer struct {
 	TargetCount        int        `json:"target_count"` // Number of healthy/unhealthy targets
 	HealthyTargetCount int        `json:"healthy_target_count"`
 	ListenerCount      int        `json:"listener_count"`
-	IsUnused           bool       `json:"is_unused"` // True if no targets or all targets unhealthy
+	IsUnused           bool       `json:"is_unused"`    // True if no targets or all targets unhealthy
+	MonthlyCost        float64    `json:"monthly_cost"` // Estimated hourly charge per month in USD, excluding capacity units
+	CostSource         string     `json:"cost_source"`
 	Tags               []Tag      `json:"tags,omitempty"`
 }
 
@@ -269,5 +444,7 @@ type NATGateway struct {
 	PublicIP         string     `json:"public_ip,omitempty"`
 	PrivateIP        string     `json:"private_ip,omitempty"`
 	CreateTime       *time.Time `json:"create_time,omitempty"`
+	MonthlyCost      float64    `json:"monthly_cost"` // Estimated hourly charge per month in USD, excluding data processed
+	CostSource       string     `json:"cost_source"`
 	Tags             []Tag      `json:"tags,omitempty"`
 }
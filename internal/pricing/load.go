@@ -0,0 +1,498 @@
+package pricing
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ============================================================================
+// PRICE FILE LOADING
+// ============================================================================
+//
+// Bulk offer files are tens to hundreds of megabytes per region, so they are streamed: products
+// are filtered as they are read and only the on-demand terms of kept products are decoded.
+// Attribute names are normalised (lowercase, letters and digits only) so the JSON attributes
+// ("instanceType", "regionCode") and the CSV columns ("Instance Type", "Region Code") match.
+
+// Load reads and merges price files. Files ending in ".csv" are bulk CSV offer files; JSON files
+// are either bulk offer files or trimmed price files.
+func Load(paths ...string) (*PriceList, error) {
+	prices := NewPriceList()
+	for _, path := range paths {
+		if err := prices.loadFile(path); err != nil {
+			return nil, err
+		}
+		prices.Sources = append(prices.Sources, path)
+	}
+	return prices, nil
+}
+
+func (p *PriceList) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open price file: %w", err)
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		err = p.readBulkCSV(f)
+	} else {
+		err = p.readJSON(f)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read price file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Save writes the prices to a trimmed price file, replacing it atomically
+func (p *PriceList) Save(path string) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create price file directory: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(p.File(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode price file: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write price file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace price file: %w", err)
+	}
+	return nil
+}
+
+// bulkProduct is a product entry of a bulk JSON offer file
+type bulkProduct struct {
+	ProductFamily string            `json:"productFamily"`
+	Attributes    map[string]string `json:"attributes"`
+}
+
+// bulkTerm is an offer term of a bulk JSON offer file
+type bulkTerm struct {
+	PriceDimensions map[string]struct {
+		Unit         string            `json:"unit"`
+		BeginRange   string            `json:"beginRange"`
+		PricePerUnit map[string]string `json:"pricePerUnit"`
+	} `json:"priceDimensions"`
+}
+
+// readJSON reads a bulk JSON offer file or a trimmed price file
+func (p *PriceList) readJSON(r io.Reader) error {
+	dec := json.NewDecoder(bufio.NewReaderSize(r, 1<<20))
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+
+	products := make(map[string]map[string]string)
+	var trimmed PriceFile
+	for dec.More() {
+		key, err := readKey(dec)
+		if err != nil {
+			return err
+		}
+
+		switch key {
+		case "products":
+			if err := readBulkProducts(dec, products); err != nil {
+				return err
+			}
+		case "terms":
+			if err := p.readBulkTerms(dec, products); err != nil {
+				return err
+			}
+		case "publicationDate":
+			var published string
+			if err := dec.Decode(&published); err != nil {
+				return err
+			}
+			if t, err := time.Parse(time.RFC3339, published); err == nil && t.After(p.GeneratedAt) {
+				p.GeneratedAt = t
+			}
+		case "generated_at":
+			err = dec.Decode(&trimmed.GeneratedAt)
+		case "ec2":
+			err = dec.Decode(&trimmed.EC2)
+		case "ebs":
+			err = dec.Decode(&trimmed.EBS)
+		case "nat_gateways":
+			err = dec.Decode(&trimmed.NATGateways)
+		case "load_balancers":
+			err = dec.Decode(&trimmed.LoadBalancers)
+		default:
+			err = skipValue(dec)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	p.addFile(trimmed)
+	return nil
+}
+
+// readBulkProducts keeps the attributes of products that have a price this package indexes
+func readBulkProducts(dec *json.Decoder, products map[string]map[string]string) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		sku, err := readKey(dec)
+		if err != nil {
+			return err
+		}
+		var product bulkProduct
+		if err := dec.Decode(&product); err != nil {
+			return err
+		}
+
+		attrs := make(map[string]string, len(product.Attributes)+1)
+		for name, value := range product.Attributes {
+			attrs[attributeKey(name)] = value
+		}
+		attrs["productfamily"] = product.ProductFamily
+		if classifyProduct(attrs) != "" {
+			products[sku] = attrs
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// readBulkTerms records the on-demand price of every kept product
+func (p *PriceList) readBulkTerms(dec *json.Decoder, products map[string]map[string]string) error {
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		termType, err := readKey(dec)
+		if err != nil {
+			return err
+		}
+		if termType != "OnDemand" {
+			if err := skipValue(dec); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := expectDelim(dec, '{'); err != nil {
+			return err
+		}
+		for dec.More() {
+			sku, err := readKey(dec)
+			if err != nil {
+				return err
+			}
+			attrs, kept := products[sku]
+			if !kept {
+				if err := skipValue(dec); err != nil {
+					return err
+				}
+				continue
+			}
+
+			var offers map[string]bulkTerm
+			if err := dec.Decode(&offers); err != nil {
+				return err
+			}
+			for _, offer := range offers {
+				for _, dimension := range offer.PriceDimensions {
+					if dimension.BeginRange != "" && dimension.BeginRange != "0" {
+						continue
+					}
+					if price, err := strconv.ParseFloat(dimension.PricePerUnit["USD"], 64); err == nil {
+						p.addBulkPrice(attrs, dimension.Unit, price)
+					}
+				}
+			}
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// readBulkCSV reads a bulk CSV offer file. The header row follows a few lines of metadata.
+func (p *PriceList) readBulkCSV(r io.Reader) error {
+	reader := csv.NewReader(bufio.NewReaderSize(r, 1<<20))
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	var columns []string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if columns == nil {
+			if len(record) > 1 && record[0] == "SKU" {
+				columns = make([]string, len(record))
+				for i, name := range record {
+					columns[i] = attributeKey(name)
+				}
+			} else if len(record) == 2 && record[0] == "Publication Date" {
+				if t, err := time.Parse(time.RFC3339, record[1]); err == nil && t.After(p.GeneratedAt) {
+					p.GeneratedAt = t
+				}
+			}
+			continue
+		}
+
+		attrs := make(map[string]string, len(columns))
+		for i, value := range record {
+			if i < len(columns) && value != "" {
+				attrs[columns[i]] = value
+			}
+		}
+		if attrs["termtype"] != "OnDemand" || attrs["currency"] != "USD" || classifyProduct(attrs) == "" {
+			continue
+		}
+		if begin := attrs["startingrange"]; begin != "" && begin != "0" {
+			continue
+		}
+		if price, err := strconv.ParseFloat(attrs["priceperunit"], 64); err == nil {
+			p.addBulkPrice(attrs, attrs["unit"], price)
+		}
+	}
+
+	if columns == nil {
+		return fmt.Errorf("no header row found")
+	}
+	return nil
+}
+
+// Product kinds this package indexes
+const (
+	productEC2             = "ec2"
+	productEBSStorage      = "ebs_storage"
+	productEBSIOPS         = "ebs_iops"
+	productEBSThroughput   = "ebs_throughput"
+	productNATHours        = "nat_hours"
+	productNATBytes        = "nat_bytes"
+	productLBHours         = "lb_hours"
+	productLBCapacityUnits = "lb_capacity_units"
+)
+
+// classifyProduct returns the kind of price a product carries, or "" for products that are not indexed
+func classifyProduct(attrs map[string]string) string {
+	family := attrs["productfamily"]
+	usageType := attrs["usagetype"]
+
+	switch {
+	case strings.HasPrefix(family, "Compute Instance"):
+		// Skip capacity reservations, host fees and spot entries
+		if status := attrs["capacitystatus"]; status != "" && status != "Used" {
+			return ""
+		}
+		if option := attrs["marketoption"]; option != "" && option != "OnDemand" {
+			return ""
+		}
+		return productEC2
+	case strings.Contains(usageType, "EBS:VolumeUsage"):
+		return productEBSStorage
+	case strings.Contains(usageType, "EBS:VolumeP-IOPS"):
+		return productEBSIOPS
+	case strings.Contains(usageType, "EBS:VolumeP-Throughput"):
+		return productEBSThroughput
+	case strings.Contains(usageType, "NatGateway-Hours"):
+		return productNATHours
+	case strings.Contains(usageType, "NatGateway-Bytes"):
+		return productNATBytes
+	case strings.HasPrefix(family, "Load Balancer") && strings.Contains(usageType, "LoadBalancerUsage"):
+		return productLBHours
+	case strings.HasPrefix(family, "Load Balancer") && strings.Contains(usageType, "LCUUsage"):
+		return productLBCapacityUnits
+	}
+	return ""
+}
+
+// addBulkPrice indexes the on-demand price of a bulk offer product
+func (p *PriceList) addBulkPrice(attrs map[string]string, unit string, price float64) {
+	region := attrs["regioncode"]
+	if region == "" {
+		return
+	}
+
+	switch classifyProduct(attrs) {
+	case productEC2:
+		if attrs["instancetype"] == "" || !strings.EqualFold(unit, "Hrs") {
+			return
+		}
+		p.ec2[EC2Key{
+			Region:          region,
+			InstanceType:    attrs["instancetype"],
+			OperatingSystem: attrs["operatingsystem"],
+			PreInstalledSW:  attrs["preinstalledsw"],
+			Tenancy:         attrs["tenancy"],
+			LicenseModel:    attrs["licensemodel"],
+		}] = price
+
+	case productEBSStorage:
+		p.ebsEntry(region, ebsVolumeType(attrs)).GBMonth = price
+
+	case productEBSIOPS:
+		entry := p.ebsEntry(region, ebsVolumeType(attrs))
+		switch usageType := attrs["usagetype"]; {
+		case strings.HasSuffix(usageType, ".tier2"):
+			entry.IOPSTier2Month = price
+		case strings.HasSuffix(usageType, ".tier3"):
+			entry.IOPSTier3Month = price
+		default:
+			entry.IOPSMonth = price
+		}
+
+	case productEBSThroughput:
+		// gp3 throughput is listed per GiBps-month
+		if strings.HasPrefix(strings.ToLower(unit), "gibps") {
+			price /= 1024
+		}
+		p.ebsEntry(region, ebsVolumeType(attrs)).ThroughputMonth = price
+
+	case productNATHours:
+		p.natGatewayEntry(region).Hourly = price
+
+	case productNATBytes:
+		p.natGatewayEntry(region).PerGB = price
+
+	case productLBHours:
+		p.loadBalancerEntry(region, loadBalancerType(attrs["productfamily"])).Hourly = price
+
+	case productLBCapacityUnits:
+		p.loadBalancerEntry(region, loadBalancerType(attrs["productfamily"])).CapacityUnitHourly = price
+	}
+}
+
+func (p *PriceList) ebsEntry(region, volumeType string) *EBSPrice {
+	key := region + "|" + volumeType
+	if p.ebs[key] == nil {
+		p.ebs[key] = &EBSPrice{Region: region, VolumeType: volumeType}
+	}
+	return p.ebs[key]
+}
+
+func (p *PriceList) natGatewayEntry(region string) *NATGatewayPrice {
+	if p.natGateways[region] == nil {
+		p.natGateways[region] = &NATGatewayPrice{Region: region}
+	}
+	return p.natGateways[region]
+}
+
+func (p *PriceList) loadBalancerEntry(region, lbType string) *LoadBalancerPrice {
+	key := region + "|" + lbType
+	if p.loadBalancers[key] == nil {
+		p.loadBalancers[key] = &LoadBalancerPrice{Region: region, Type: lbType}
+	}
+	return p.loadBalancers[key]
+}
+
+// ebsVolumeType returns the API name of a volume type; io1 IOPS are listed as "piops" usage
+func ebsVolumeType(attrs map[string]string) string {
+	if volumeType := attrs["volumeapiname"]; volumeType != "" {
+		return volumeType
+	}
+	usageType := attrs["usagetype"]
+	switch {
+	case strings.Contains(usageType, ".piops"):
+		return "io1"
+	case strings.HasSuffix(usageType, "EBS:VolumeUsage"):
+		return "standard"
+	}
+	// "USE2-EBS:VolumeP-IOPS.io2.tier2" is io2
+	if idx := strings.Index(usageType, "EBS:"); idx != -1 {
+		if dot := strings.Index(usageType[idx:], "."); dot != -1 {
+			return strings.Split(usageType[idx+dot+1:], ".")[0]
+		}
+	}
+	return ""
+}
+
+// loadBalancerType maps an ELB product family to a load balancer type
+func loadBalancerType(family string) string {
+	switch family {
+	case "Load Balancer-Application":
+		return "application"
+	case "Load Balancer-Network":
+		return "network"
+	case "Load Balancer-Gateway":
+		return "gateway"
+	}
+	return "classic"
+}
+
+// attributeKey normalises an attribute or column name to lowercase letters and digits
+func attributeKey(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// readKey reads an object key
+func readKey(dec *json.Decoder) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := tok.(string)
+	if !ok {
+		return "", fmt.Errorf("expected object key, got %v", tok)
+	}
+	return key, nil
+}
+
+// expectDelim reads a delimiter token
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return nil
+}
+
+// skipValue skips the next value without keeping it in memory
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if delim, ok := tok.(json.Delim); ok {
+			switch delim {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// Package pricing indexes AWS on-demand prices loaded from the AWS Price List bulk offer files
+// (JSON or CSV) or from the trimmed price file written by the refresh command, so cost estimates
+// can use the price of the resource's region, operating system, tenancy and license.
+package pricing
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// HoursPerMonth is the number of hours cost estimates bill per month
+const HoursPerMonth = 24 * 30
+
+// EC2 license models as named in the price list
+const (
+	LicenseIncluded = "No License required"
+	LicenseBYOL     = "Bring your own license"
+)
+
+// EC2Key identifies an on-demand EC2 instance price
+type EC2Key struct {
+	Region          string `json:"region"`
+	InstanceType    string `json:"instance_type"`
+	OperatingSystem string `json:"operating_system"` // "Linux", "Windows", "RHEL", "SUSE", ...
+	PreInstalledSW  string `json:"pre_installed_sw"` // "NA", "SQL Std", "SQL Web" or "SQL Ent"
+	Tenancy         string `json:"tenancy"`          // "Shared", "Dedicated" or "Host"
+	LicenseModel    string `json:"license_model"`    // LicenseIncluded or LicenseBYOL
+}
+
+// EC2Price is the hourly on-demand price of an instance type
+type EC2Price struct {
+	EC2Key
+	Hourly float64 `json:"hourly"`
+}
+
+// EBSPrice holds the monthly prices of an EBS volume type in a region
+type EBSPrice struct {
+	Region          string  `json:"region"`
+	VolumeType      string  `json:"volume_type"`
+	GBMonth         float64 `json:"gb_month"`
+	IOPSMonth       float64 `json:"iops_month,omitempty"`       // Provisioned IOPS; first tier for io2
+	IOPSTier2Month  float64 `json:"iops_tier2_month,omitempty"` // io2 IOPS from 32,001 to 64,000
+	IOPSTier3Month  float64 `json:"iops_tier3_month,omitempty"` // io2 IOPS above 64,000
+	ThroughputMonth float64 `json:"throughput_month,omitempty"` // Per provisioned MiB/s
+}
+
+// NATGatewayPrice holds the prices of a NAT gateway in a region
+type NATGatewayPrice struct {
+	Region string  `json:"region"`
+	Hourly float64 `json:"hourly"`
+	PerGB  float64 `json:"per_gb"` // Data processed
+}
+
+// LoadBalancerPrice holds the prices of a load balancer type in a region
+type LoadBalancerPrice struct {
+	Region             string  `json:"region"`
+	Type               string  `json:"type"` // "application", "network", "gateway" or "classic"
+	Hourly             float64 `json:"hourly"`
+	CapacityUnitHourly float64 `json:"capacity_unit_hourly,omitempty"` // LCU, NLCU or GLCU
+}
+
+// PriceFile is the trimmed price list written by the refresh command
+type PriceFile struct {
+	GeneratedAt   time.Time           `json:"generated_at"`
+	Currency      string              `json:"currency"`
+	EC2           []EC2Price          `json:"ec2"`
+	EBS           []EBSPrice          `json:"ebs"`
+	NATGateways   []NATGatewayPrice   `json:"nat_gateways"`
+	LoadBalancers []LoadBalancerPrice `json:"load_balancers"`
+}
+
+// PriceList is an index of prices by region and resource attributes
+type PriceList struct {
+	GeneratedAt time.Time
+	Sources     []string
+
+	ec2           map[EC2Key]float64
+	ebs           map[string]*EBSPrice
+	natGateways   map[string]*NATGatewayPrice
+	loadBalancers map[string]*LoadBalancerPrice
+}
+
+// NewPriceList returns an empty price list
+func NewPriceList() *PriceList {
+	return &PriceList{
+		ec2:           make(map[EC2Key]float64),
+		ebs:           make(map[string]*EBSPrice),
+		natGateways:   make(map[string]*NATGatewayPrice),
+		loadBalancers: make(map[string]*LoadBalancerPrice),
+	}
+}
+
+// EC2Hourly returns the hourly price of an instance
+func (p *PriceList) EC2Hourly(key EC2Key) (float64, bool) {
+	price, found := p.ec2[key]
+	return price, found
+}
+
+// EBS returns the prices of a volume type in a region
+func (p *PriceList) EBS(region, volumeType string) (EBSPrice, bool) {
+	price, found := p.ebs[region+"|"+volumeType]
+	if !found || price.GBMonth == 0 {
+		return EBSPrice{}, false
+	}
+	return *price, true
+}
+
+// NATGateway returns the NAT gateway prices of a region
+func (p *PriceList) NATGateway(region string) (NATGatewayPrice, bool) {
+	price, found := p.natGateways[region]
+	if !found || price.Hourly == 0 {
+		return NATGatewayPrice{}, false
+	}
+	return *price, true
+}
+
+// LoadBalancer returns the prices of a load balancer type in a region
+func (p *PriceList) LoadBalancer(region, lbType string) (LoadBalancerPrice, bool) {
+	price, found := p.loadBalancers[region+"|"+lbType]
+	if !found || price.Hourly == 0 {
+		return LoadBalancerPrice{}, false
+	}
+	return *price, true
+}
+
+// Counts returns the number of EC2, EBS, NAT gateway and load balancer prices
+func (p *PriceList) Counts() (ec2, ebs, natGateways, loadBalancers int) {
+	return len(p.ec2), len(p.ebs), len(p.natGateways), len(p.loadBalancers)
+}
+
+// File returns the prices as a trimmed price file, sorted for stable output
+func (p *PriceList) File() PriceFile {
+	file := PriceFile{
+		GeneratedAt:   p.GeneratedAt,
+		Currency:      "USD",
+		EC2:           make([]EC2Price, 0, len(p.ec2)),
+		EBS:           make([]EBSPrice, 0, len(p.ebs)),
+		NATGateways:   make([]NATGatewayPrice, 0, len(p.natGateways)),
+		LoadBalancers: make([]LoadBalancerPrice, 0, len(p.loadBalancers)),
+	}
+	for key, hourly := range p.ec2 {
+		file.EC2 = append(file.EC2, EC2Price{EC2Key: key, Hourly: hourly})
+	}
+	for _, price := range p.ebs {
+		file.EBS = append(file.EBS, *price)
+	}
+	for _, price := range p.natGateways {
+		file.NATGateways = append(file.NATGateways, *price)
+	}
+	for _, price := range p.loadBalancers {
+		file.LoadBalancers = append(file.LoadBalancers, *price)
+	}
+
+	sort.Slice(file.EC2, func(i, j int) bool {
+		a, b := file.EC2[i], file.EC2[j]
+		return strings.Join([]string{a.Region, a.InstanceType, a.OperatingSystem, a.PreInstalledSW, a.Tenancy, a.LicenseModel}, "|") <
+			strings.Join([]string{b.Region, b.InstanceType, b.OperatingSystem, b.PreInstalledSW, b.Tenancy, b.LicenseModel}, "|")
+	})
+	sort.Slice(file.EBS, func(i, j int) bool {
+		return file.EBS[i].Region+"|"+file.EBS[i].VolumeType < file.EBS[j].Region+"|"+file.EBS[j].VolumeType
+	})
+	sort.Slice(file.NATGateways, func(i, j int) bool {
+		return file.NATGateways[i].Region < file.NATGateways[j].Region
+	})
+	sort.Slice(file.LoadBalancers, func(i, j int) bool {
+		return file.LoadBalancers[i].Region+"|"+file.LoadBalancers[i].Type < file.LoadBalancers[j].Region+"|"+file.LoadBalancers[j].Type
+	})
+	return file
+}
+
+// addFile merges the prices of a trimmed price file
+func (p *PriceList) addFile(file PriceFile) {
+	if file.GeneratedAt.After(p.GeneratedAt) {
+		p.GeneratedAt = file.GeneratedAt
+	}
+	for _, price := range file.EC2 {
+		p.ec2[price.EC2Key] = price.Hourly
+	}
+	for _, price := range file.EBS {
+		p.ebs[price.Region+"|"+price.VolumeType] = &price
+	}
+	for _, price := range file.NATGateways {
+		p.natGateways[price.Region] = &price
+	}
+	for _, price := range file.LoadBalancers {
+		p.loadBalancers[price.Region+"|"+price.Type] = &price
+	}
+}
+
+// MonthlyCost returns the monthly cost of a volume. gp3 includes 3,000 IOPS and 125 MiB/s;
+// io1 and io2 charge for every provisioned IOPS.
+func (e EBSPrice) MonthlyCost(sizeGB, iops, throughputMBs int64) float64 {
+	cost := float64(sizeGB) * e.GBMonth
+
+	switch e.VolumeType {
+	case "gp3":
+		if iops > 3000 {
+			cost += float64(iops-3000) * e.IOPSMonth
+		}
+		if throughputMBs > 125 {
+			cost += float64(throughputMBs-125) * e.ThroughputMonth
+		}
+	case "io2":
+		tier2, tier3 := e.IOPSTier2Month, e.IOPSTier3Month
+		if tier2 == 0 {
+			tier2 = e.IOPSMonth
+		}
+		if tier3 == 0 {
+			tier3 = tier2
+		}
+		cost += float64(min(iops, 32000)) * e.IOPSMonth
+		if iops > 32000 {
+			cost += float64(min(iops, 64000)-32000) * tier2
+		}
+		if iops > 64000 {
+			cost += float64(iops-64000) * tier3
+		}
+	case "io1":
+		cost += float64(iops) * e.IOPSMonth
+	}
+	return cost
+}
+
+// EC2KeyFor builds the price key of an instance from its DescribeInstances attributes:
+// PlatformDetails (e.g. "Windows with SQL Server Standard"), placement tenancy ("default",
+// "dedicated" or "host") and UsageOperation ("RunInstances:0800" is Windows BYOL).
+func EC2KeyFor(region, instanceType, platformDetails, tenancy, usageOperation string) EC2Key {
+	key := EC2Key{
+		Region:          region,
+		InstanceType:    strings.ToLower(instanceType),
+		OperatingSystem: "Linux",
+		PreInstalledSW:  "NA",
+		Tenancy:         "Shared",
+		LicenseModel:    LicenseIncluded,
+	}
+
+	switch tenancy {
+	case "dedicated":
+		key.Tenancy = "Dedicated"
+	case "host":
+		key.Tenancy = "Host"
+	}
+	if usageOperation == "RunInstances:0800" {
+		key.LicenseModel = LicenseBYOL
+	}
+
+	platform := platformDetails
+	if idx := strings.Index(platform, " with SQL Server "); idx != -1 {
+		switch strings.TrimSpace(platform[idx+len(" with SQL Server "):]) {
+		case "Standard":
+			key.PreInstalledSW = "SQL Std"
+		case "Web":
+			key.PreInstalledSW = "SQL Web"
+		case "Enterprise":
+			key.PreInstalledSW = "SQL Ent"
+		}
+		platform = platform[:idx]
+	}
+
+	switch platform {
+	case "Windows", "Windows BYOL":
+		key.OperatingSystem = "Windows"
+	case "Red Hat Enterprise Linux":
+		key.OperatingSystem = "RHEL"
+	case "Red Hat Enterprise Linux with HA":
+		key.OperatingSystem = "Red Hat Enterprise Linux with HA"
+	case "SUSE Linux":
+		key.OperatingSystem = "SUSE"
+	case "Ubuntu Pro":
+		key.OperatingSystem = "Ubuntu Pro"
+	}
+	return key
+}
@@ -0,0 +1,178 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bulkEC2Offer is a trimmed-down regional AmazonEC2 offer file
+const bulkEC2Offer = `{
+  "formatVersion": "v1.0",
+  "offerCode": "AmazonEC2",
+  "publicationDate": "2024-05-01T00:00:00Z",
+  "products": {
+    "LINUX": {"sku": "LINUX", "productFamily": "Compute Instance", "attributes": {
+      "regionCode": "eu-west-1", "instanceType": "m5.large", "operatingSystem": "Linux", "preInstalledSw": "NA",
+      "tenancy": "Shared", "licenseModel": "No License required", "capacitystatus": "Used", "usagetype": "EU-BoxUsage:m5.large"}},
+    "WINDOWS": {"sku": "WINDOWS", "productFamily": "Compute Instance", "attributes": {
+      "regionCode": "eu-west-1", "instanceType": "m5.large", "operatingSystem": "Windows", "preInstalledSw": "NA",
+      "tenancy": "Shared", "licenseModel": "No License required", "capacitystatus": "Used", "usagetype": "EU-BoxUsage:m5.large"}},
+    "RESERVED": {"sku": "RESERVED", "productFamily": "Compute Instance", "attributes": {
+      "regionCode": "eu-west-1", "instanceType": "m5.large", "operatingSystem": "Linux", "preInstalledSw": "NA",
+      "tenancy": "Shared", "licenseModel": "No License required", "capacitystatus": "UnusedCapacityReservation"}},
+    "GP3": {"sku": "GP3", "productFamily": "Storage", "attributes": {"regionCode": "eu-west-1", "volumeApiName": "gp3", "usagetype": "EU-EBS:VolumeUsage.gp3"}},
+    "GP3IOPS": {"sku": "GP3IOPS", "productFamily": "System Operation", "attributes": {"regionCode": "eu-west-1", "volumeApiName": "gp3", "usagetype": "EU-EBS:VolumeP-IOPS.gp3"}},
+    "GP3TP": {"sku": "GP3TP", "productFamily": "Provisioned Throughput", "attributes": {"regionCode": "eu-west-1", "volumeApiName": "gp3", "usagetype": "EU-EBS:VolumeP-Throughput.gp3"}},
+    "NAT": {"sku": "NAT", "productFamily": "NAT Gateway", "attributes": {"regionCode": "eu-west-1", "usagetype": "EU-NatGateway-Hours"}},
+    "SNAP": {"sku": "SNAP", "productFamily": "Storage Snapshot", "attributes": {"regionCode": "eu-west-1", "usagetype": "EU-EBS:SnapshotUsage"}}
+  },
+  "terms": {
+    "OnDemand": {
+      "LINUX": {"LINUX.JRTCKXETXF": {"priceDimensions": {"LINUX.JRTCKXETXF.6YS6EN2CT7": {"unit": "Hrs", "beginRange": "0", "pricePerUnit": {"USD": "0.1070000000"}}}}},
+      "WINDOWS": {"WINDOWS.JRTCKXETXF": {"priceDimensions": {"WINDOWS.JRTCKXETXF.6YS6EN2CT7": {"unit": "Hrs", "beginRange": "0", "pricePerUnit": {"USD": "0.1990000000"}}}}},
+      "RESERVED": {"RESERVED.JRTCKXETXF": {"priceDimensions": {"RESERVED.JRTCKXETXF.6YS6EN2CT7": {"unit": "Hrs", "pricePerUnit": {"USD": "0.1070000000"}}}}},
+      "GP3": {"GP3.JRTCKXETXF": {"priceDimensions": {"GP3.JRTCKXETXF.6YS6EN2CT7": {"unit": "GB-Mo", "pricePerUnit": {"USD": "0.0880000000"}}}}},
+      "GP3IOPS": {"GP3IOPS.JRTCKXETXF": {"priceDimensions": {"GP3IOPS.JRTCKXETXF.6YS6EN2CT7": {"unit": "IOPS-Mo", "pricePerUnit": {"USD": "0.0055000000"}}}}},
+      "GP3TP": {"GP3TP.JRTCKXETXF": {"priceDimensions": {"GP3TP.JRTCKXETXF.6YS6EN2CT7": {"unit": "GiBps-mo", "pricePerUnit": {"USD": "45.0560000000"}}}}},
+      "NAT": {"NAT.JRTCKXETXF": {"priceDimensions": {"NAT.JRTCKXETXF.6YS6EN2CT7": {"unit": "Hrs", "pricePerUnit": {"USD": "0.0480000000"}}}}},
+      "SNAP": {"SNAP.JRTCKXETXF": {"priceDimensions": {"SNAP.JRTCKXETXF.6YS6EN2CT7": {"unit": "GB-Mo", "pricePerUnit": {"USD": "0.0500000000"}}}}}
+    },
+    "Reserved": {
+      "LINUX": {"LINUX.4NA7Y494T4": {"priceDimensions": {"LINUX.4NA7Y494T4.6YS6EN2CT7": {"unit": "Hrs", "pricePerUnit": {"USD": "0.0670000000"}}}}}
+    }
+  }
+}`
+
+// bulkELBOffer is a trimmed-down regional AWSELB offer file in CSV form
+const bulkELBOffer = `"FormatVersion","v1.0"
+"Disclaimer","This pricing list is for informational purposes only."
+"Publication Date","2024-05-02T00:00:00Z"
+"Version","20240502000000"
+"OfferCode","AWSELB"
+"SKU","OfferTermCode","RateCode","TermType","PriceDescription","EffectiveDate","StartingRange","EndingRange","Unit","PricePerUnit","Currency","Product Family","serviceCode","Location","Region Code","usageType"
+"ALB","JRTCKXETXF","ALB.1","OnDemand","$0.0252 per ALB-hour","2024-05-01","","","Hrs","0.0252000000","USD","Load Balancer-Application","AWSELB","EU (Ireland)","eu-west-1","EU-LoadBalancerUsage"
+"ALBLCU","JRTCKXETXF","ALBLCU.1","OnDemand","$0.008 per LCU-hour","2024-05-01","","","LCU-Hrs","0.0080000000","USD","Load Balancer-Application","AWSELB","EU (Ireland)","eu-west-1","EU-LCUUsage"
+"CLB","JRTCKXETXF","CLB.1","OnDemand","$0.028 per LoadBalancer-hour","2024-05-01","","","Hrs","0.0280000000","USD","Load Balancer","AWSELB","EU (Ireland)","eu-west-1","EU-LoadBalancerUsage"
+"CLB","4NA7Y494T4","CLB.2","Reserved","","2024-05-01","","","Hrs","0.0100000000","USD","Load Balancer","AWSELB","EU (Ireland)","eu-west-1","EU-LoadBalancerUsage"
+`
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoadBulkOffers(t *testing.T) {
+	prices, err := Load(writeFile(t, "ec2.json", bulkEC2Offer), writeFile(t, "elb.csv", bulkELBOffer))
+	require.NoError(t, err)
+
+	linux := EC2KeyFor("eu-west-1", "m5.large", "Linux/UNIX", "default", "RunInstances")
+	hourly, found := prices.EC2Hourly(linux)
+	assert.True(t, found)
+	assert.Equal(t, 0.107, hourly)
+
+	windows := EC2KeyFor("eu-west-1", "m5.large", "Windows", "default", "RunInstances:0002")
+	hourly, found = prices.EC2Hourly(windows)
+	assert.True(t, found)
+	assert.Equal(t, 0.199, hourly)
+
+	_, found = prices.EC2Hourly(EC2KeyFor("us-east-1", "m5.large", "Linux/UNIX", "default", "RunInstances"))
+	assert.False(t, found)
+
+	gp3, found := prices.EBS("eu-west-1", "gp3")
+	assert.True(t, found)
+	assert.Equal(t, 0.088, gp3.GBMonth)
+	assert.Equal(t, 0.0055, gp3.IOPSMonth)
+	assert.InDelta(t, 0.044, gp3.ThroughputMonth, 1e-9)
+
+	nat, found := prices.NATGateway("eu-west-1")
+	assert.True(t, found)
+	assert.Equal(t, 0.048, nat.Hourly)
+
+	alb, found := prices.LoadBalancer("eu-west-1", "application")
+	assert.True(t, found)
+	assert.Equal(t, 0.0252, alb.Hourly)
+	assert.Equal(t, 0.008, alb.CapacityUnitHourly)
+
+	clb, found := prices.LoadBalancer("eu-west-1", "classic")
+	assert.True(t, found)
+	assert.Equal(t, 0.028, clb.Hourly, "reserved terms are ignored")
+
+	ec2Count, ebsCount, natCount, lbCount := prices.Counts()
+	assert.Equal(t, []int{2, 1, 1, 2}, []int{ec2Count, ebsCount, natCount, lbCount})
+}
+
+func TestSaveAndLoadTrimmedFile(t *testing.T) {
+	prices, err := Load(writeFile(t, "ec2.json", bulkEC2Offer))
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "prices", "aws-prices.json")
+	require.NoError(t, prices.Save(path))
+
+	reloaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, prices.File(), reloaded.File())
+	assert.Equal(t, "2024-05-01T00:00:00Z", reloaded.GeneratedAt.Format("2006-01-02T15:04:05Z07:00"))
+}
+
+func TestEC2KeyFor(t *testing.T) {
+	key := EC2KeyFor("us-east-1", "R5.XLarge", "Windows with SQL Server Enterprise", "dedicated", "RunInstances:0102")
+	assert.Equal(t, EC2Key{Region: "us-east-1", InstanceType: "r5.xlarge", OperatingSystem: "Windows", PreInstalledSW: "SQL Ent", Tenancy: "Dedicated", LicenseModel: LicenseIncluded}, key)
+
+	key = EC2KeyFor("us-east-1", "m5.large", "Windows BYOL", "host", "RunInstances:0800")
+	assert.Equal(t, "Windows", key.OperatingSystem)
+	assert.Equal(t, "Host", key.Tenancy)
+	assert.Equal(t, LicenseBYOL, key.LicenseModel)
+
+	assert.Equal(t, "RHEL", EC2KeyFor("us-east-1", "m5.large", "Red Hat Enterprise Linux", "default", "").OperatingSystem)
+	assert.Equal(t, "SUSE", EC2KeyFor("us-east-1", "m5.large", "SUSE Linux", "default", "").OperatingSystem)
+	assert.Equal(t, "Linux", EC2KeyFor("us-east-1", "m5.large", "", "", "").OperatingSystem)
+}
+
+func TestEBSPriceMonthlyCost(t *testing.T) {
+	gp3 := EBSPrice{VolumeType: "gp3", GBMonth: 0.08, IOPSMonth: 0.005, ThroughputMonth: 0.04}
+	assert.InDelta(t, 8.0, gp3.MonthlyCost(100, 3000, 125), 1e-9)
+	assert.InDelta(t, 8.0+5+10, gp3.MonthlyCost(100, 4000, 375), 1e-9)
+
+	io2 := EBSPrice{VolumeType: "io2", GBMonth: 0.125, IOPSMonth: 0.065, IOPSTier2Month: 0.0455, IOPSTier3Month: 0.0319}
+	assert.InDelta(t, 12.5+32000*0.065+8000*0.0455, io2.MonthlyCost(100, 40000, 0), 1e-6)
+}
+
+func TestRefresh(t *testing.T) {
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.Path)
+		if r.URL.Path == "/offers/v1.0/aws/AmazonEC2/current/eu-west-1/index.json" {
+			fmt.Fprint(w, bulkEC2Offer)
+			return
+		}
+		fmt.Fprint(w, `{"formatVersion": "v1.0", "products": {}, "terms": {"OnDemand": {}}}`)
+	}))
+	defer server.Close()
+
+	defer func(url string) { OfferBaseURL = url }(OfferBaseURL)
+	OfferBaseURL = server.URL
+
+	prices, err := Refresh(context.Background(), server.Client(), []string{"eu-west-1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"/offers/v1.0/aws/AmazonEC2/current/eu-west-1/index.json",
+		"/offers/v1.0/aws/AWSELB/current/eu-west-1/index.json",
+	}, requested)
+
+	_, found := prices.EC2Hourly(EC2KeyFor("eu-west-1", "m5.large", "Linux/UNIX", "default", "RunInstances"))
+	assert.True(t, found)
+	assert.False(t, prices.GeneratedAt.IsZero())
+
+	_, err = Refresh(context.Background(), server.Client(), nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package pricing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ============================================================================
+// PRICE LIST REFRESH
+// ============================================================================
+
+// OfferBaseURL is the AWS Price List bulk API endpoint
+var OfferBaseURL = "https://pricing.us-east-1.amazonaws.com"
+
+// Offers whose prices are indexed: EC2 covers instances, EBS and NAT gateways, AWSELB load balancers
+var Offers = []string{"AmazonEC2", "AWSELB"}
+
+// Refresh downloads the current regional bulk offer files for the given regions and returns their
+// indexed prices. The offer files are streamed, so memory use stays bounded by the kept prices.
+func Refresh(ctx context.Context, client *http.Client, regions []string) (*PriceList, error) {
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("at least one region is required")
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Minute}
+	}
+
+	prices := NewPriceList()
+	for _, offer := range Offers {
+		for _, region := range regions {
+			url := fmt.Sprintf("%s/offers/v1.0/aws/%s/current/%s/index.json", OfferBaseURL, offer, region)
+			if err := prices.download(ctx, client, url); err != nil {
+				return nil, fmt.Errorf("failed to load %s prices for %s: %w", offer, region, err)
+			}
+			prices.Sources = append(prices.Sources, url)
+		}
+	}
+
+	prices.GeneratedAt = time.Now().UTC()
+	return prices, nil
+}
+
+func (p *PriceList) download(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return p.readJSON(resp.Body)
+}
@@ -25,37 +25,47 @@ type Server struct {
 	azureRMHandler  *handlers.AzureRMHandler
 	ssoHandler      *handlers.SSOHandler
 	ssoInitError    error
+	searchHandler   *handlers.SearchHandler
+	scheduleHandler *handlers.ScheduleHandler
 }
 
 func NewServer(cfg config.Config) *Server {
 	awsService := services.NewAWSService(cfg)
 	handler := handlers.NewHandler(awsService, cfg)
 
+	// Advance staged key rotations whose delays have elapsed
+	awsService.StartKeyRotationWorker(15 * time.Minute)
+
 	// Initialize Azure handler (optional - will log error if credentials not configured)
 	var azureHandler *handlers.AzureHandler
+	var azureSearchSource services.AzureServiceInterface
 	azureService, err := services.NewAzureService()
 	if err != nil {
 		log.Printf("[WARNING] Azure service not initialized: %v", err)
 		log.Printf("[INFO] Azure endpoints will not be available. Set AZURE_TENANT_ID, AZURE_CLIENT_ID, and AZURE_CLIENT_SECRET to enable Azure features.")
 	} else {
 		azureHandler = handlers.NewAzureHandler(azureService)
+		azureSearchSource = azureService
 		log.Printf("[INFO] Azure service initialized successfully")
 	}
 
 	// Initialize Azure Resource Manager handler (optional)
 	var azureRMHandler *handlers.AzureRMHandler
+	var azureScheduleTarget services.AzureRMServiceInterface
 	azureRMService, err := services.NewAzureRMService()
 	if err != nil {
 		log.Printf("[WARNING] Azure Resource Manager service not initialized: %v", err)
 		log.Printf("[INFO] Azure Resource Manager endpoints will not be available. Set AZURE_TENANT_ID, AZURE_CLIENT_ID, and AZURE_CLIENT_SECRET to enable Azure RM features.")
 	} else {
 		azureRMHandler = handlers.NewAzureRMHandler(azureRMService)
+		azureScheduleTarget = azureRMService
 		log.Printf("[INFO] Azure Resource Manager service initialized successfully (works across all subscriptions)")
 	}
 
 	// Initialize SSO handler (optional - will log error if Identity Center not configured)
 	var ssoHandler *handlers.SSOHandler
 	var ssoInitError error
+	var ssoSearchSource services.SSOServiceInterface
 	ssoService, err := services.NewSSOService(awsService)
 	if err != nil {
 		ssoInitError = err
@@ -63,16 +73,30 @@ func NewServer(cfg config.Config) *Server {
 		log.Printf("[INFO] SSO endpoints will return error messages. Ensure IAM Identity Center is enabled and you have the required permissions.")
 	} else {
 		ssoHandler = handlers.NewSSOHandler(ssoService)
+		ssoSearchSource = ssoService
 		log.Printf("[INFO] SSO service initialized successfully")
 	}
 
+	// The search index is built from whichever principal sources are configured
+	searchHandler := handlers.NewSearchHandler(services.NewSearchService(awsService, ssoSearchSource, azureSearchSource))
+
+	// Start/stop schedules cover Azure VMs only when Azure Resource Manager is configured
+	scheduleService := services.NewScheduleService(awsService, azureScheduleTarget, cfg)
+	if cfg.SchedulerEnabled {
+		scheduleService.Start(time.Minute)
+	} else {
+		log.Printf("[INFO] Scheduler disabled; schedules can be managed but are not run by this instance")
+	}
+
 	return &Server{
-		config:         cfg,
-		handler:        handler,
-		azureHandler:   azureHandler,
-		azureRMHandler: azureRMHandler,
-		ssoHandler:     ssoHandler,
-		ssoInitError:   ssoInitError,
+		config:          cfg,
+		handler:         handler,
+		azureHandler:    azureHandler,
+		azureRMHandler:  azureRMHandler,
+		ssoHandler:      ssoHandler,
+		ssoInitError:    ssoInitError,
+		searchHandler:   searchHandler,
+		scheduleHandler: handlers.NewScheduleHandler(scheduleService),
 	}
 }
 
@@ -167,25 +191,122 @@ func (s *Server) SetupRoutes() *gin.Engine {
 		apiProtected.DELETE("/accounts/:accountId/users/:username/keys/:keyId", s.handler.DeleteAccessKey)
 		apiProtected.PUT("/accounts/:accountId/users/:username/keys/:keyId/rotate", s.handler.RotateAccessKey)
 
+		// Staged key rotation routes
+		apiProtected.POST("/accounts/:accountId/users/:username/keys/:keyId/rotations", s.handler.StartKeyRotation)
+		apiProtected.GET("/key-rotations", s.handler.ListKeyRotations)
+		apiProtected.GET("/key-rotations/:rotationId", s.handler.GetKeyRotation)
+		apiProtected.POST("/key-rotations/:rotationId/advance", s.handler.AdvanceKeyRotation)
+		apiProtected.POST("/key-rotations/:rotationId/rollback", s.handler.RollbackKeyRotation)
+
+		// One-time secret retrieval for created keys and passwords
+		apiProtected.POST("/secrets/:token/redeem", s.handler.RedeemSecret)
+
+		// Background job routes
+		apiProtected.GET("/jobs", s.handler.ListJobs)
+		apiProtected.GET("/jobs/:jobId", s.handler.GetJob)
+
+		// Region discovery routes
+		apiProtected.GET("/accounts/:accountId/regions", s.handler.GetAccountRegions)
+		apiProtected.GET("/scan-coverage", s.handler.ListScanCoverage)
+
+		// Price list routes
+		apiProtected.GET("/pricing/status", s.handler.GetPriceListStatus)
+		apiProtected.POST("/pricing/reload", s.handler.ReloadPriceList)
+
+		// Privilege escalation analysis routes
+		apiProtected.GET("/privilege-escalation", s.handler.AnalyzeOrgPrivilegeEscalation)
+		apiProtected.GET("/accounts/:accountId/privilege-escalation", s.handler.AnalyzePrivilegeEscalation)
+
+		// Access Advisor analysis routes
+		apiProtected.POST("/accounts/:accountId/access-advisor", s.handler.AnalyzeAccountAccessAdvisor)
+		apiProtected.POST("/access-advisor", s.handler.AnalyzeOrgAccessAdvisor)
+
+		// Principal search routes
+		apiProtected.GET("/search", s.searchHandler.Search)
+
+		// Start/stop schedule routes
+		apiProtected.GET("/schedules", s.scheduleHandler.ListSchedules)
+		apiProtected.POST("/schedules", s.scheduleHandler.CreateSchedule)
+		apiProtected.GET("/schedules/status", s.scheduleHandler.GetSchedulerStatus)
+		apiProtected.GET("/schedules/audit", s.scheduleHandler.ListScheduleAudit)
+		apiProtected.GET("/schedules/:scheduleId", s.scheduleHandler.GetSchedule)
+		apiProtected.PUT("/schedules/:scheduleId", s.scheduleHandler.UpdateSchedule)
+		apiProtected.DELETE("/schedules/:scheduleId", s.scheduleHandler.DeleteSchedule)
+		apiProtected.GET("/schedules/:scheduleId/preview", s.scheduleHandler.PreviewSchedule)
+		apiProtected.GET("/schedules/:scheduleId/audit", s.scheduleHandler.ListScheduleAudit)
+		apiProtected.POST("/schedules/:scheduleId/snooze", s.scheduleHandler.SnoozeSchedule)
+		apiProtected.DELETE("/schedules/:scheduleId/overrides", s.scheduleHandler.ClearScheduleOverrides)
+
+		// Policy simulator routes
+		apiProtected.POST("/accounts/:accountId/users/:username/simulate", s.handler.SimulateUserPolicy)
+		apiProtected.POST("/accounts/:accountId/roles/:roleName/simulate", s.handler.SimulateRolePolicy)
+		apiProtected.POST("/users/:username/simulate", s.handler.SimulateUserPolicyAcrossAccounts)
+		apiProtected.POST("/roles/:roleName/simulate", s.handler.SimulateRolePolicyAcrossAccounts)
+
 		// IP management routes
 		apiProtected.GET("/public-ips", s.handler.ListPublicIPs)
 
 		// Security groups routes
 		apiProtected.GET("/security-groups", s.handler.ListSecurityGroups)
 		apiProtected.GET("/accounts/:accountId/security-groups", s.handler.ListSecurityGroupsByAccount)
+		apiProtected.GET("/security-groups/findings", s.handler.ListSecurityGroupFindings)
+		apiProtected.GET("/accounts/:accountId/security-groups/findings", s.handler.ListSecurityGroupFindings)
+		apiProtected.GET("/accounts/:accountId/security-groups/graph", s.handler.GetSecurityGroupGraph)
+		apiProtected.GET("/security-groups/port-risks", s.handler.GetPortRiskCatalogue)
 		apiProtected.GET("/accounts/:accountId/regions/:region/security-groups/:groupId", s.handler.GetSecurityGroup)
 		apiProtected.DELETE("/accounts/:accountId/regions/:region/security-groups/:groupId", s.handler.DeleteSecurityGroup)
+		apiProtected.POST("/accounts/:accountId/regions/:region/security-groups/:groupId/rules/revoke", s.handler.RevokeSecurityGroupRule)
+		apiProtected.POST("/accounts/:accountId/regions/:region/security-groups/:groupId/rules/narrow", s.handler.NarrowSecurityGroupRule)
+		apiProtected.GET("/security-groups/changes", s.handler.ListSecurityGroupChanges)
+		apiProtected.POST("/security-groups/changes/:changeId/revert", s.handler.RevertSecurityGroupChange)
+		apiProtected.POST("/security-groups/remediate", s.handler.StartSecurityGroupRemediation)
+		apiProtected.POST("/security-groups/unused/delete", s.handler.StartUnusedSecurityGroupCleanup)
 
 		// Snapshots routes
 		apiProtected.GET("/snapshots", s.handler.ListSnapshots)
 		apiProtected.GET("/accounts/:accountId/snapshots", s.handler.ListSnapshotsByAccount)
 		apiProtected.DELETE("/accounts/:accountId/regions/:region/snapshots/:snapshotId", s.handler.DeleteSnapshot)
 		apiProtected.DELETE("/accounts/:accountId/snapshots/old", s.handler.DeleteOldSnapshots)
+		apiProtected.GET("/snapshots/costs", s.handler.GetSnapshotCostReport)
+		apiProtected.GET("/accounts/:accountId/snapshots/costs", s.handler.GetSnapshotCostReport)
+		apiProtected.POST("/accounts/:accountId/regions/:region/snapshots/:snapshotId/archive", s.handler.ArchiveSnapshot)
+		apiProtected.POST("/accounts/:accountId/regions/:region/snapshots/:snapshotId/restore", s.handler.RestoreArchivedSnapshot)
+		apiProtected.GET("/snapshots/retention-policies", s.handler.ListRetentionPolicies)
+		apiProtected.POST("/snapshots/retention-policies", s.handler.CreateRetentionPolicy)
+		apiProtected.GET("/snapshots/retention-policies/:policyId", s.handler.GetRetentionPolicy)
+		apiProtected.PUT("/snapshots/retention-policies/:policyId", s.handler.UpdateRetentionPolicy)
+		apiProtected.DELETE("/snapshots/retention-policies/:policyId", s.handler.DeleteRetentionPolicy)
+		apiProtected.GET("/snapshots/retention-policies/:policyId/preview", s.handler.PreviewRetentionPolicy)
+		apiProtected.POST("/snapshots/retention-policies/:policyId/run", s.handler.RunRetentionPolicy)
+
+		// RDS and Aurora snapshot routes
+		apiProtected.GET("/db-snapshots", s.handler.ListDBSnapshots)
+		apiProtected.GET("/db-snapshots/findings", s.handler.ListDBSnapshotFindings)
+		apiProtected.GET("/accounts/:accountId/db-snapshots", s.handler.ListDBSnapshotsByAccount)
+		apiProtected.GET("/accounts/:accountId/db-snapshots/findings", s.handler.ListDBSnapshotFindings)
+		apiProtected.DELETE("/accounts/:accountId/db-snapshots/old", s.handler.DeleteOldDBSnapshots)
+		apiProtected.DELETE("/accounts/:accountId/regions/:region/db-snapshots/:snapshotId", s.handler.DeleteDBSnapshot)
+
+		// AMI routes
+		apiProtected.GET("/amis", s.handler.ListAMIs)
+		apiProtected.GET("/amis/findings", s.handler.ListAMIFindings)
+		apiProtected.GET("/accounts/:accountId/amis", s.handler.ListAMIsByAccount)
+		apiProtected.GET("/accounts/:accountId/amis/findings", s.handler.ListAMIFindings)
+		apiProtected.DELETE("/accounts/:accountId/amis/unused", s.handler.DeleteUnusedAMIs)
+		apiProtected.DELETE("/accounts/:accountId/regions/:region/amis/:imageId", s.handler.DeregisterAMI)
 
 		// EC2 instances routes
 		apiProtected.GET("/ec2-instances", s.handler.ListEC2Instances)
+		apiProtected.POST("/ec2-instances/bulk", s.handler.StartEC2BulkAction)
+		apiProtected.POST("/ec2-instances/utilization", s.handler.AnalyzeEC2Utilization)
+		apiProtected.GET("/accounts/:accountId/regions/:region/instances/:instanceId", s.handler.GetEC2Instance)
+		apiProtected.POST("/accounts/:accountId/regions/:region/instances/:instanceId/start", s.handler.StartEC2Instance)
 		apiProtected.POST("/accounts/:accountId/regions/:region/instances/:instanceId/stop", s.handler.StopEC2Instance)
+		apiProtected.POST("/accounts/:accountId/regions/:region/instances/:instanceId/reboot", s.handler.RebootEC2Instance)
+		apiProtected.POST("/accounts/:accountId/regions/:region/instances/:instanceId/hibernate", s.handler.HibernateEC2Instance)
 		apiProtected.POST("/accounts/:accountId/regions/:region/instances/:instanceId/terminate", s.handler.TerminateEC2Instance)
+		apiProtected.PUT("/accounts/:accountId/regions/:region/instances/:instanceId/protection", s.handler.SetEC2InstanceProtection)
+		apiProtected.POST("/accounts/:accountId/regions/:region/instances/:instanceId/tags", s.handler.UpdateEC2InstanceTags)
 
 		// EBS volumes routes
 		apiProtected.GET("/ebs-volumes", s.handler.ListEBSVolumes)
@@ -200,9 +321,29 @@ func (s *Server) SetupRoutes() *gin.Engine {
 
 		// IAM roles routes
 		apiProtected.GET("/roles", s.handler.ListAllRoles)
+		apiProtected.GET("/roles/trust-findings", s.handler.ListAllRoleTrustFindings)
 		apiProtected.GET("/accounts/:accountId/roles", s.handler.ListRoles)
+		apiProtected.GET("/accounts/:accountId/roles/trust-findings", s.handler.ListRoleTrustFindings)
+		apiProtected.GET("/roles/unused", s.handler.ListAllUnusedRoles)
+		apiProtected.GET("/accounts/:accountId/roles/unused", s.handler.ListUnusedRoles)
+		apiProtected.POST("/roles/unused/cleanup", s.handler.StartRoleCleanup)
+		apiProtected.POST("/accounts/:accountId/roles/:roleName/cleanup/restore", s.handler.RestoreRoleCleanup)
 		apiProtected.GET("/accounts/:accountId/roles/:roleName", s.handler.GetRole)
 		apiProtected.DELETE("/accounts/:accountId/roles/:roleName", s.handler.DeleteRole)
+		apiProtected.POST("/accounts/:accountId/roles", s.handler.CreateRole)
+		apiProtected.PUT("/accounts/:accountId/roles/:roleName/trust-policy", s.handler.UpdateRoleTrustPolicy)
+		apiProtected.POST("/accounts/:accountId/roles/:roleName/policies", s.handler.AttachRolePolicy)
+		apiProtected.DELETE("/accounts/:accountId/roles/:roleName/policies", s.handler.DetachRolePolicy)
+
+		// Customer-managed policy routes
+		apiProtected.GET("/policies", s.handler.ListAllPolicies)
+		apiProtected.GET("/accounts/:accountId/policies", s.handler.ListPolicies)
+		apiProtected.POST("/accounts/:accountId/policies/unattached/delete", s.handler.DeleteUnattachedPolicies)
+		apiProtected.GET("/accounts/:accountId/policies/:policyName", s.handler.GetPolicy)
+		apiProtected.DELETE("/accounts/:accountId/policies/:policyName", s.handler.DeletePolicy)
+		apiProtected.GET("/accounts/:accountId/policies/:policyName/versions", s.handler.ListPolicyVersions)
+		apiProtected.DELETE("/accounts/:accountId/policies/:policyName/versions", s.handler.DeleteNonDefaultPolicyVersions)
+		apiProtected.GET("/accounts/:accountId/policies/:policyName/diff", s.handler.DiffPolicyVersions)
 
 		// Load balancer routes
 		apiProtected.GET("/load-balancers", s.handler.ListAllLoadBalancers)
@@ -373,10 +514,13 @@ func (s *Server) SetupRoutes() *gin.Engine {
 		apiProtected.POST("/cache/s3-buckets/invalidate", s.handler.InvalidateS3BucketsCache)
 		apiProtected.POST("/cache/roles/invalidate", s.handler.InvalidateRolesCache)
 		apiProtected.POST("/cache/accounts/:accountId/roles/invalidate", s.handler.InvalidateAccountRolesCache)
+		apiProtected.POST("/cache/policies/invalidate", s.handler.InvalidatePoliciesCache)
+		apiProtected.POST("/cache/accounts/:accountId/policies/invalidate", s.handler.InvalidateAccountPoliciesCache)
 		apiProtected.POST("/cache/load-balancers/invalidate", s.handler.InvalidateAllLoadBalancersCache)
 		apiProtected.POST("/cache/accounts/:accountId/load-balancers/invalidate", s.handler.InvalidateLoadBalancersCache)
 		apiProtected.POST("/cache/vpcs/invalidate", s.handler.InvalidateVPCsCache)
 		apiProtected.POST("/cache/nat-gateways/invalidate", s.handler.InvalidateNATGatewaysCache)
+		apiProtected.POST("/cache/search/invalidate", s.searchHandler.InvalidateSearchIndex)
 	}
 
 	// Serve frontend
@@ -0,0 +1,351 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+// ============================================================================
+// IAM ACCESS ADVISOR ANALYSIS
+// ============================================================================
+
+const (
+	// accessAdvisorPollInterval is how often a pending last-accessed report is polled
+	accessAdvisorPollInterval = 2 * time.Second
+	// accessAdvisorTimeout bounds how long a single principal's report may take to generate
+	accessAdvisorTimeout = 5 * time.Minute
+	// accessAdvisorAccountWorkers bounds how many accounts an org-wide analysis processes at once
+	accessAdvisorAccountWorkers = 5
+)
+
+// accessAdvisorPrincipal is a user or role to analyze
+type accessAdvisorPrincipal struct {
+	principalType string
+	name          string
+	arn           string
+}
+
+// AnalyzeAccountAccessAdvisor starts a background job that reports unused permissions
+// for every user and role in an account. Services not used in recentDays are flagged.
+func (s *AWSService) AnalyzeAccountAccessAdvisor(accountID string, recentDays int) models.Job {
+	account := models.Account{ID: accountID, Name: s.lookupAccountName(accountID)}
+
+	return s.jobs.start("access_advisor", fmt.Sprintf("Access Advisor analysis for account %s", accountID), func(jc *jobContext) (any, error) {
+		return s.collectAccessAdvisorReports(account, recentDays, jc)
+	})
+}
+
+// AnalyzeOrgAccessAdvisor starts a background job that runs the Access Advisor analysis
+// across all accessible accounts in the organization
+func (s *AWSService) AnalyzeOrgAccessAdvisor(recentDays int) (models.Job, error) {
+	accounts, err := s.listAccessibleAccounts()
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	job := s.jobs.start("access_advisor", "Organization-wide Access Advisor analysis", func(jc *jobContext) (any, error) {
+		type accountResult struct {
+			reports   []models.AccessAdvisorReport
+			err       error
+			accountID string
+		}
+
+		resultChan := make(chan accountResult, len(accounts))
+		sem := make(chan struct{}, accessAdvisorAccountWorkers)
+		var wg sync.WaitGroup
+
+		for _, account := range accounts {
+			wg.Add(1)
+			go func(acc models.Account) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				reports, err := s.collectAccessAdvisorReports(acc, recentDays, jc)
+				resultChan <- accountResult{reports: reports, err: err, accountID: acc.ID}
+			}(account)
+		}
+
+		go func() {
+			wg.Wait()
+			close(resultChan)
+		}()
+
+		var allReports []models.AccessAdvisorReport
+		for result := range resultChan {
+			if result.err != nil {
+				fmt.Printf("[WARNING] Access Advisor analysis failed for account %s: %v\n", result.accountID, result.err)
+				jc.AddResult(models.JobItemResult{
+					AccountID:  result.accountID,
+					ResourceID: result.accountID,
+					Status:     "failed",
+					Message:    result.err.Error(),
+				})
+				continue
+			}
+			allReports = append(allReports, result.reports...)
+		}
+
+		return allReports, nil
+	})
+
+	return job, nil
+}
+
+// collectAccessAdvisorReports generates and collects last-accessed reports for all principals in an account
+func (s *AWSService) collectAccessAdvisorReports(account models.Account, recentDays int, jc *jobContext) ([]models.AccessAdvisorReport, error) {
+	sess, err := s.getSessionForAccount(account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", account.ID, err)
+	}
+	iamClient := iam.New(sess)
+
+	principals, err := s.listAccessAdvisorPrincipals(iamClient)
+	if err != nil {
+		return nil, err
+	}
+
+	jc.AddTotal(len(principals))
+
+	// Start all report generations first so AWS can work on them in parallel
+	jobIDs := make(map[string]string, len(principals))
+	for _, principal := range principals {
+		result, err := iamClient.GenerateServiceLastAccessedDetails(&iam.GenerateServiceLastAccessedDetailsInput{
+			Arn:         aws.String(principal.arn),
+			Granularity: aws.String(iam.AccessAdvisorUsageGranularityTypeActionLevel),
+		})
+		if err != nil {
+			jc.AddResult(models.JobItemResult{
+				AccountID:  account.ID,
+				ResourceID: principal.arn,
+				Status:     "failed",
+				Message:    fmt.Sprintf("failed to generate last accessed details: %v", err),
+			})
+			continue
+		}
+		jobIDs[principal.arn] = aws.StringValue(result.JobId)
+	}
+
+	threshold := time.Now().AddDate(0, 0, -recentDays)
+	var reports []models.AccessAdvisorReport
+
+	for _, principal := range principals {
+		jobID, started := jobIDs[principal.arn]
+		if !started {
+			continue
+		}
+
+		serviceDetails, generatedAt, err := s.waitForServiceLastAccessed(iamClient, jobID)
+		if err != nil {
+			jc.AddResult(models.JobItemResult{
+				AccountID:  account.ID,
+				ResourceID: principal.arn,
+				Status:     "failed",
+				Message:    err.Error(),
+			})
+			continue
+		}
+
+		report := buildAccessAdvisorReport(principal, account, serviceDetails, threshold)
+		report.GeneratedAt = generatedAt
+		reports = append(reports, report)
+
+		jc.AddResult(models.JobItemResult{
+			AccountID:  account.ID,
+			ResourceID: principal.arn,
+			Status:     "succeeded",
+			Message:    fmt.Sprintf("%d never used, %d not recently used", len(report.NeverUsedServices), len(report.NotRecentlyUsedServices)),
+		})
+	}
+
+	return reports, nil
+}
+
+// listAccessAdvisorPrincipals lists all users and all non-service-linked roles in an account
+func (s *AWSService) listAccessAdvisorPrincipals(iamClient *iam.IAM) ([]accessAdvisorPrincipal, error) {
+	var principals []accessAdvisorPrincipal
+
+	err := iamClient.ListUsersPages(&iam.ListUsersInput{}, func(page *iam.ListUsersOutput, lastPage bool) bool {
+		for _, user := range page.Users {
+			principals = append(principals, accessAdvisorPrincipal{
+				principalType: "user",
+				name:          aws.StringValue(user.UserName),
+				arn:           aws.StringValue(user.Arn),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+
+	err = iamClient.ListRolesPages(&iam.ListRolesInput{}, func(page *iam.ListRolesOutput, lastPage bool) bool {
+		for _, role := range page.Roles {
+			// Service-linked roles are managed by AWS and cannot be right-sized
+			if strings.HasPrefix(aws.StringValue(role.Path), "/aws-service-role/") {
+				continue
+			}
+			principals = append(principals, accessAdvisorPrincipal{
+				principalType: "role",
+				name:          aws.StringValue(role.RoleName),
+				arn:           aws.StringValue(role.Arn),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+
+	return principals, nil
+}
+
+// waitForServiceLastAccessed polls a last-accessed report until it completes and returns all pages
+func (s *AWSService) waitForServiceLastAccessed(iamClient *iam.IAM, jobID string) ([]*iam.ServiceLastAccessed, time.Time, error) {
+	deadline := time.Now().Add(accessAdvisorTimeout)
+	var services []*iam.ServiceLastAccessed
+	var marker *string
+
+	for {
+		result, err := iamClient.GetServiceLastAccessedDetails(&iam.GetServiceLastAccessedDetailsInput{
+			JobId:  aws.String(jobID),
+			Marker: marker,
+		})
+		if err != nil {
+			return nil, time.Time{}, fmt.Errorf("failed to get last accessed details: %w", err)
+		}
+
+		switch aws.StringValue(result.JobStatus) {
+		case iam.JobStatusTypeInProgress:
+			if time.Now().After(deadline) {
+				return nil, time.Time{}, fmt.Errorf("timed out waiting for last accessed report %s", jobID)
+			}
+			time.Sleep(accessAdvisorPollInterval)
+			continue
+		case iam.JobStatusTypeFailed:
+			message := "unknown error"
+			if result.Error != nil {
+				message = aws.StringValue(result.Error.Message)
+			}
+			return nil, time.Time{}, fmt.Errorf("last accessed report %s failed: %s", jobID, message)
+		}
+
+		services = append(services, result.ServicesLastAccessed...)
+		if !aws.BoolValue(result.IsTruncated) {
+			return services, aws.TimeValue(result.JobCompletionDate), nil
+		}
+		marker = result.Marker
+	}
+}
+
+// buildAccessAdvisorReport classifies each granted service as used, not recently used or never used
+func buildAccessAdvisorReport(principal accessAdvisorPrincipal, account models.Account, details []*iam.ServiceLastAccessed, threshold time.Time) models.AccessAdvisorReport {
+	report := models.AccessAdvisorReport{
+		PrincipalType: principal.principalType,
+		PrincipalName: principal.name,
+		Arn:           principal.arn,
+		AccountID:     account.ID,
+		AccountName:   account.Name,
+	}
+
+	for _, detail := range details {
+		access := models.ServiceAccess{
+			ServiceNamespace:        aws.StringValue(detail.ServiceNamespace),
+			ServiceName:             aws.StringValue(detail.ServiceName),
+			LastAuthenticated:       detail.LastAuthenticated,
+			LastAuthenticatedEntity: aws.StringValue(detail.LastAuthenticatedEntity),
+			LastAuthenticatedRegion: aws.StringValue(detail.LastAuthenticatedRegion),
+		}
+
+		for _, action := range detail.TrackedActionsLastAccessed {
+			access.TrackedActions = append(access.TrackedActions, models.ActionAccess{
+				ActionName:         aws.StringValue(action.ActionName),
+				LastAccessed:       action.LastAccessedTime,
+				LastAccessedRegion: aws.StringValue(action.LastAccessedRegion),
+			})
+		}
+
+		switch {
+		case access.LastAuthenticated == nil:
+			access.Usage = models.ServiceUsageNeverUsed
+			report.NeverUsedServices = append(report.NeverUsedServices, access.ServiceNamespace)
+		case access.LastAuthenticated.Before(threshold):
+			access.Usage = models.ServiceUsageNotRecentlyUsed
+			report.NotRecentlyUsedServices = append(report.NotRecentlyUsedServices, access.ServiceNamespace)
+		default:
+			access.Usage = models.ServiceUsageUsed
+			report.UsedServices = append(report.UsedServices, access.ServiceNamespace)
+		}
+
+		report.Services = append(report.Services, access)
+	}
+
+	report.SuggestedPolicy, report.UntrackedServices = suggestRightSizedPolicy(report.Services, threshold)
+
+	return report
+}
+
+// suggestRightSizedPolicy builds a policy that only allows the tracked actions used since the threshold.
+// Access Advisor only reports services the principal is granted, so the result never exceeds current
+// grants. Used services without action-level tracking are returned separately instead of being widened
+// to service:*, and must be added by hand; so must data-plane actions, which are never tracked.
+func suggestRightSizedPolicy(services []models.ServiceAccess, threshold time.Time) (string, []string) {
+	actionSet := make(map[string]bool)
+	var untracked []string
+	for _, svc := range services {
+		if svc.Usage != models.ServiceUsageUsed {
+			continue
+		}
+		if len(svc.TrackedActions) == 0 {
+			untracked = append(untracked, svc.ServiceNamespace)
+			continue
+		}
+		for _, action := range svc.TrackedActions {
+			if action.LastAccessed == nil || action.LastAccessed.Before(threshold) {
+				continue
+			}
+			name := action.ActionName
+			if !strings.Contains(name, ":") {
+				name = svc.ServiceNamespace + ":" + name
+			}
+			actionSet[name] = true
+		}
+	}
+
+	if len(actionSet) == 0 {
+		return "", untracked
+	}
+
+	actions := make([]string, 0, len(actionSet))
+	for action := range actionSet {
+		actions = append(actions, action)
+	}
+	sort.Strings(actions)
+
+	policy := map[string]any{
+		"Version": "2012-10-17",
+		"Statement": []map[string]any{
+			{
+				"Sid":      "RightSizedFromAccessAdvisor",
+				"Effect":   "Allow",
+				"Action":   actions,
+				"Resource": "*",
+			},
+		},
+	}
+
+	document, err := json.MarshalIndent(policy, "", "  ")
+	if err != nil {
+		return "", untracked
+	}
+	return string(document), untracked
+}
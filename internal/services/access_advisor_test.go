@@ -0,0 +1,56 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildAccessAdvisorReport(t *testing.T) {
+	now := time.Now()
+	threshold := now.AddDate(0, 0, -90)
+
+	details := []*iam.ServiceLastAccessed{
+		{ServiceNamespace: aws.String("s3"), ServiceName: aws.String("Amazon S3"), LastAuthenticated: aws.Time(now.AddDate(0, 0, -1)),
+			TrackedActionsLastAccessed: []*iam.TrackedActionLastAccessed{
+				{ActionName: aws.String("ListAllMyBuckets"), LastAccessedTime: aws.Time(now.AddDate(0, 0, -1))},
+				{ActionName: aws.String("CreateBucket"), LastAccessedTime: aws.Time(now.AddDate(0, 0, -150))},
+				{ActionName: aws.String("DeleteBucket")},
+			}},
+		{ServiceNamespace: aws.String("sqs"), ServiceName: aws.String("Amazon SQS"), LastAuthenticated: aws.Time(now.AddDate(0, 0, -2))},
+		{ServiceNamespace: aws.String("ec2"), ServiceName: aws.String("Amazon EC2"), LastAuthenticated: aws.Time(now.AddDate(0, 0, -200))},
+		{ServiceNamespace: aws.String("dynamodb"), ServiceName: aws.String("Amazon DynamoDB")},
+	}
+
+	principal := accessAdvisorPrincipal{principalType: "role", name: "app", arn: "arn:aws:iam::123456789012:role/app"}
+	account := models.Account{ID: "123456789012", Name: "Test"}
+
+	report := buildAccessAdvisorReport(principal, account, details, threshold)
+
+	assert.Equal(t, []string{"s3", "sqs"}, report.UsedServices)
+	assert.Equal(t, []string{"ec2"}, report.NotRecentlyUsedServices)
+	assert.Equal(t, []string{"dynamodb"}, report.NeverUsedServices)
+	assert.Len(t, report.Services, 4)
+	assert.Contains(t, report.SuggestedPolicy, `"s3:ListAllMyBuckets"`)
+	assert.NotContains(t, report.SuggestedPolicy, "s3:*")
+	assert.NotContains(t, report.SuggestedPolicy, "CreateBucket")
+	assert.NotContains(t, report.SuggestedPolicy, "DeleteBucket")
+	assert.NotContains(t, report.SuggestedPolicy, "sqs")
+	assert.NotContains(t, report.SuggestedPolicy, "ec2")
+	assert.NotContains(t, report.SuggestedPolicy, "dynamodb")
+	assert.Equal(t, []string{"sqs"}, report.UntrackedServices)
+}
+
+func TestSuggestRightSizedPolicyNoUsage(t *testing.T) {
+	services := []models.ServiceAccess{
+		{ServiceNamespace: "ec2", Usage: models.ServiceUsageNeverUsed},
+	}
+	policy, untracked := suggestRightSizedPolicy(services, time.Now().AddDate(0, 0, -90))
+	assert.Empty(t, policy)
+	assert.Empty(t, untracked)
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/rusik69/aws-iam-manager/internal/models"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/organizations"
 )
 
@@ -64,6 +65,25 @@ func (s *AWSService) ListAccounts() ([]models.Account, error) {
 	return accounts, nil
 }
 
+// organizationID returns the ID ("o-...") of the organization the master account belongs to
+func (s *AWSService) organizationID() (string, error) {
+	const cacheKey = "organization-id"
+	if cached, found := s.cache.Get(cacheKey); found {
+		if orgID, ok := cached.(string); ok {
+			return orgID, nil
+		}
+	}
+
+	result, err := organizations.New(s.masterSession).DescribeOrganization(&organizations.DescribeOrganizationInput{})
+	if err != nil {
+		return "", fmt.Errorf("failed to describe organization: %w", err)
+	}
+	orgID := aws.StringValue(result.Organization.Id)
+
+	s.cache.Set(cacheKey, orgID, s.cacheTTL)
+	return orgID, nil
+}
+
 // testAccountAccess checks if we can assume role in the given account
 func (s *AWSService) testAccountAccess(accountID string) bool {
 	_, err := s.getSessionForAccount(accountID)
@@ -87,3 +107,30 @@ func (s *AWSService) InvalidateAccountCache(accountID string) {
 	s.cache.Delete(fmt.Sprintf("vpcs-%s", accountID))
 	s.cache.Delete(fmt.Sprintf("nat-gateways-%s", accountID))
 }
+
+// lookupAccountName returns the organization name of an account, falling back to its ID
+func (s *AWSService) lookupAccountName(accountID string) string {
+	accounts, _ := s.ListAccounts()
+	for _, acc := range accounts {
+		if acc.ID == accountID {
+			return acc.Name
+		}
+	}
+	return accountID
+}
+
+// listAccessibleAccounts returns the organization accounts the service can assume a role in
+func (s *AWSService) listAccessibleAccounts() ([]models.Account, error) {
+	accounts, err := s.ListAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %v", err)
+	}
+
+	var accessibleAccounts []models.Account
+	for _, account := range accounts {
+		if account.Accessible {
+			accessibleAccounts = append(accessibleAccounts, account)
+		}
+	}
+	return accessibleAccounts, nil
+}
@@ -0,0 +1,623 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// ============================================================================
+// AMI MANAGEMENT
+// ============================================================================
+//
+// AMIs owned by each account are listed with their backing snapshots, sharing, last launch and
+// usage by instances, launch template versions and launch configurations in the same account and region. Instances in other
+// accounts cannot be seen, so shared and public images are never cleaned up. Deregistering an
+// image also deletes its snapshots, which otherwise stay behind and keep costing money.
+
+// ErrAMIProtected is returned when an image is in use, shared or managed and must not be deregistered
+var ErrAMIProtected = errors.New("AMI is protected")
+
+// amiLaunchConfigurationPageSize is the maximum page size DescribeLaunchConfigurations accepts
+const amiLaunchConfigurationPageSize = 100
+
+// ListAMIs returns the AMIs owned by all accessible accounts
+func (s *AWSService) ListAMIs() ([]models.AMI, error) {
+	const cacheKey = "amis"
+
+	if cached, found := s.cache.Get(cacheKey); found {
+		if images, ok := cached.([]models.AMI); ok {
+			return images, nil
+		}
+	}
+
+	accounts, err := s.listAccessibleAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	var allImages []models.AMI
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, account := range accounts {
+		wg.Add(1)
+		go func(acc models.Account) {
+			defer wg.Done()
+
+			images, err := s.ListAMIsByAccount(acc.ID)
+			if err != nil {
+				fmt.Printf("[WARNING] Failed to get AMIs for account %s: %v\n", acc.ID, err)
+				return
+			}
+
+			mu.Lock()
+			allImages = append(allImages, images...)
+			mu.Unlock()
+		}(account)
+	}
+
+	wg.Wait()
+
+	s.cache.Set(cacheKey, allImages, s.cacheTTL)
+	return allImages, nil
+}
+
+// ListAMIsByAccount returns the AMIs an account owns in every scanned region
+func (s *AWSService) ListAMIsByAccount(accountID string) ([]models.AMI, error) {
+	cacheKey := fmt.Sprintf("amis:%s", accountID)
+	if cached, found := s.cache.Get(cacheKey); found {
+		if images, ok := cached.([]models.AMI); ok {
+			return images, nil
+		}
+	}
+
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+
+	scan, err := s.startRegionScan(scannerAMIs, accountID, sess)
+	if err != nil {
+		return nil, err
+	}
+
+	accountName := s.lookupAccountName(accountID)
+
+	allImages := []models.AMI{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, region := range scan.Regions() {
+		wg.Add(1)
+		go func(r string) {
+			defer wg.Done()
+
+			regionSess := sess.Copy(&aws.Config{Region: aws.String(r)})
+			images, err := describeRegionAMIs(ec2.New(regionSess), autoscaling.New(regionSess))
+			if err != nil {
+				fmt.Printf("[WARNING] Failed to list AMIs in %s for account %s: %v\n", r, accountID, err)
+				scan.Fail(r, err)
+				return
+			}
+
+			for i := range images {
+				images[i].AccountID = accountID
+				images[i].AccountName = accountName
+				images[i].Region = r
+			}
+
+			mu.Lock()
+			allImages = append(allImages, images...)
+			mu.Unlock()
+		}(region)
+	}
+
+	wg.Wait()
+	scan.Finish()
+
+	s.cache.Set(cacheKey, allImages, s.cacheTTL)
+	return allImages, nil
+}
+
+// describeRegionAMIs lists the images the account owns in a region with their sharing, last launch
+// and the instances, launch templates and launch configurations that use them
+func describeRegionAMIs(ec2Client ec2iface.EC2API, asClient autoscalingiface.AutoScalingAPI) ([]models.AMI, error) {
+	var images []*ec2.Image
+	err := ec2Client.DescribeImagesPages(&ec2.DescribeImagesInput{
+		Owners:     aws.StringSlice([]string{"self"}),
+		MaxResults: aws.Int64(sgScanPageSize),
+	}, func(page *ec2.DescribeImagesOutput, lastPage bool) bool {
+		images = append(images, page.Images...)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe images: %v", err)
+	}
+	if len(images) == 0 {
+		return []models.AMI{}, nil
+	}
+
+	instanceCounts := make(map[string]int)
+	err = ec2Client.DescribeInstancesPages(&ec2.DescribeInstancesInput{
+		MaxResults: aws.Int64(sgScanPageSize),
+	}, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				if instance.State != nil && aws.StringValue(instance.State.Name) == ec2.InstanceStateNameTerminated {
+					continue
+				}
+				instanceCounts[aws.StringValue(instance.ImageId)]++
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instances: %v", err)
+	}
+
+	// Without launch template usage an image cannot be known to be unused
+	templateRefs := make(map[string][]string)
+	versions, templateErr := describeLaunchTemplateVersions(ec2Client)
+	if templateErr != nil {
+		fmt.Printf("[WARNING] Launch template usage of AMIs unknown: %v\n", templateErr)
+	} else {
+		templateRefs = amiLaunchTemplateRefs(versions)
+	}
+
+	// Auto Scaling groups still launching from legacy launch configurations use their images too
+	configRefs, configErr := amiLaunchConfigurationRefs(asClient)
+	if configErr != nil {
+		fmt.Printf("[WARNING] Launch configuration usage of AMIs unknown: %v\n", configErr)
+	}
+
+	result := make([]models.AMI, 0, len(images))
+	for _, image := range images {
+		ami := convertAMI(image)
+		ami.InstanceCount = instanceCounts[ami.ImageID]
+		ami.LaunchTemplates = templateRefs[ami.ImageID]
+		ami.LaunchTemplateCount = countLaunchTemplates(ami.LaunchTemplates)
+		ami.LaunchConfigurations = configRefs[ami.ImageID]
+		ami.InUse = ami.InstanceCount > 0 || ami.LaunchTemplateCount > 0 || len(ami.LaunchConfigurations) > 0
+
+		if lastLaunched, err := amiLastLaunchedTime(ec2Client, ami.ImageID); err != nil {
+			fmt.Printf("[WARNING] Failed to read last launched time of AMI %s: %v\n", ami.ImageID, err)
+		} else {
+			ami.LastLaunchedTime = lastLaunched
+		}
+
+		permissions, err := ec2Client.DescribeImageAttribute(&ec2.DescribeImageAttributeInput{
+			ImageId:   aws.String(ami.ImageID),
+			Attribute: aws.String(ec2.ImageAttributeNameLaunchPermission),
+		})
+		switch {
+		case err != nil:
+			// Sharing is unknown rather than absent; keep the image out of cleanup
+			fmt.Printf("[WARNING] Failed to read launch permissions of AMI %s: %v\n", ami.ImageID, err)
+			ami.ProtectedReason = "sharing could not be determined"
+		case templateErr != nil:
+			applyAMISharing(&ami, permissions.LaunchPermissions)
+			ami.ProtectedReason = "launch template usage could not be determined"
+		case configErr != nil:
+			applyAMISharing(&ami, permissions.LaunchPermissions)
+			ami.ProtectedReason = "launch configuration usage could not be determined"
+		default:
+			applyAMISharing(&ami, permissions.LaunchPermissions)
+			ami.ProtectedReason = amiProtectionReason(ami)
+		}
+
+		result = append(result, ami)
+	}
+	return result, nil
+}
+
+// convertAMI converts an image to the model without account, region, sharing or usage
+func convertAMI(image *ec2.Image) models.AMI {
+	ami := models.AMI{
+		ImageID:        aws.StringValue(image.ImageId),
+		Name:           aws.StringValue(image.Name),
+		Description:    aws.StringValue(image.Description),
+		OwnerID:        aws.StringValue(image.OwnerId),
+		State:          aws.StringValue(image.State),
+		Architecture:   aws.StringValue(image.Architecture),
+		Platform:       aws.StringValue(image.PlatformDetails),
+		RootDeviceType: aws.StringValue(image.RootDeviceType),
+		Public:         aws.BoolValue(image.Public),
+	}
+	if created, err := time.Parse(time.RFC3339, aws.StringValue(image.CreationDate)); err == nil {
+		ami.CreationDate = created
+	}
+	if deprecation, err := time.Parse(time.RFC3339, aws.StringValue(image.DeprecationTime)); err == nil {
+		ami.DeprecationTime = &deprecation
+	}
+	for _, tag := range image.Tags {
+		ami.Tags = append(ami.Tags, models.Tag{Key: aws.StringValue(tag.Key), Value: aws.StringValue(tag.Value)})
+	}
+	for _, mapping := range image.BlockDeviceMappings {
+		if mapping.Ebs == nil || mapping.Ebs.SnapshotId == nil {
+			continue
+		}
+		ami.Snapshots = append(ami.Snapshots, models.AMISnapshot{
+			SnapshotID: aws.StringValue(mapping.Ebs.SnapshotId),
+			DeviceName: aws.StringValue(mapping.DeviceName),
+			VolumeSize: aws.Int64Value(mapping.Ebs.VolumeSize),
+		})
+		ami.SnapshotSizeGB += aws.Int64Value(mapping.Ebs.VolumeSize)
+	}
+	return ami
+}
+
+// amiLaunchConfigurationRefs maps each AMI ID to the names of the launch configurations that launch it
+func amiLaunchConfigurationRefs(asClient autoscalingiface.AutoScalingAPI) (map[string][]string, error) {
+	refs := make(map[string][]string)
+	err := asClient.DescribeLaunchConfigurationsPages(&autoscaling.DescribeLaunchConfigurationsInput{
+		MaxRecords: aws.Int64(amiLaunchConfigurationPageSize),
+	}, func(page *autoscaling.DescribeLaunchConfigurationsOutput, lastPage bool) bool {
+		for _, config := range page.LaunchConfigurations {
+			imageID := aws.StringValue(config.ImageId)
+			refs[imageID] = appendUnique(refs[imageID], aws.StringValue(config.LaunchConfigurationName))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe launch configurations: %v", err)
+	}
+	return refs, nil
+}
+
+// amiLaunchTemplateRefs maps each AMI ID to the launch template versions that launch it
+func amiLaunchTemplateRefs(versions []*ec2.LaunchTemplateVersion) map[string][]string {
+	refs := make(map[string][]string)
+	for _, version := range versions {
+		if version.LaunchTemplateData == nil {
+			continue
+		}
+		imageID := aws.StringValue(version.LaunchTemplateData.ImageId)
+		if !strings.HasPrefix(imageID, "ami-") {
+			continue // Unset or resolved from an SSM parameter at launch
+		}
+		ref := fmt.Sprintf("%s:%d", aws.StringValue(version.LaunchTemplateId), aws.Int64Value(version.VersionNumber))
+		refs[imageID] = appendUnique(refs[imageID], ref)
+	}
+	return refs
+}
+
+// countLaunchTemplates counts distinct templates in "lt-id:version" references
+func countLaunchTemplates(refs []string) int {
+	templates := make(map[string]bool)
+	for _, ref := range refs {
+		templateID, _, _ := strings.Cut(ref, ":")
+		templates[templateID] = true
+	}
+	return len(templates)
+}
+
+// amiLastLaunchedTime returns when an instance was last launched from an image, or nil if never
+func amiLastLaunchedTime(ec2Client ec2iface.EC2API, imageID string) (*time.Time, error) {
+	result, err := ec2Client.DescribeImageAttribute(&ec2.DescribeImageAttributeInput{
+		ImageId:   aws.String(imageID),
+		Attribute: aws.String(ec2.ImageAttributeNameLastLaunchedTime),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.LastLaunchedTime == nil || aws.StringValue(result.LastLaunchedTime.Value) == "" {
+		return nil, nil
+	}
+	launched, err := time.Parse(time.RFC3339, aws.StringValue(result.LastLaunchedTime.Value))
+	if err != nil {
+		return nil, fmt.Errorf("invalid last launched time %q", aws.StringValue(result.LastLaunchedTime.Value))
+	}
+	return &launched, nil
+}
+
+// applyAMISharing records who may launch an image; the "all" group makes it public
+func applyAMISharing(ami *models.AMI, permissions []*ec2.LaunchPermission) {
+	for _, permission := range permissions {
+		switch {
+		case aws.StringValue(permission.Group) == ec2.PermissionGroupAll:
+			ami.Public = true
+		case permission.UserId != nil:
+			ami.SharedWith = appendUnique(ami.SharedWith, aws.StringValue(permission.UserId))
+		case permission.OrganizationArn != nil:
+			ami.SharedWith = appendUnique(ami.SharedWith, aws.StringValue(permission.OrganizationArn))
+		case permission.OrganizationalUnitArn != nil:
+			ami.SharedWith = appendUnique(ami.SharedWith, aws.StringValue(permission.OrganizationalUnitArn))
+		}
+	}
+}
+
+// amiProtectionReason explains why cleanup must leave an image alone, or returns "" when it may be deregistered
+func amiProtectionReason(ami models.AMI) string {
+	switch {
+	case ami.State != ec2.ImageStateAvailable && ami.State != ec2.ImageStateFailed:
+		return fmt.Sprintf("image is %s", ami.State)
+	case ami.InUse:
+		return fmt.Sprintf("used by %d instance(s), %d launch template(s) and %d launch configuration(s)", ami.InstanceCount, ami.LaunchTemplateCount, len(ami.LaunchConfigurations))
+	case ami.Public || len(ami.SharedWith) > 0:
+		return "shared with other accounts"
+	}
+	switch snapshotManager(ami.Tags, "") {
+	case models.SnapshotManagedByBackup:
+		return "managed by AWS Backup"
+	case models.SnapshotManagedByDLM:
+		return "managed by Data Lifecycle Manager"
+	}
+	return ""
+}
+
+// amiLastUsed is when an image was last launched, or created if it never was
+func amiLastUsed(ami models.AMI) time.Time {
+	if ami.LastLaunchedTime != nil && ami.LastLaunchedTime.After(ami.CreationDate) {
+		return *ami.LastLaunchedTime
+	}
+	return ami.CreationDate
+}
+
+// DeregisterAMI deregisters an unused image and deletes its snapshots. Usage and sharing are
+// checked again first; protected images return ErrAMIProtected.
+func (s *AWSService) DeregisterAMI(accountID, region, imageID string) (*models.AMICleanupResult, error) {
+	sess, err := s.getSessionForAccountAndRegion(accountID, region)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+	ec2Client := ec2.New(sess)
+
+	images, err := describeRegionAMIs(ec2Client, autoscaling.New(sess))
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range images {
+		if image.ImageID != imageID {
+			continue
+		}
+		if image.ProtectedReason != "" {
+			return nil, fmt.Errorf("%w: %s", ErrAMIProtected, image.ProtectedReason)
+		}
+		result := &models.AMICleanupResult{Deregistered: []string{}, DeletedSnapshots: []string{}, Skipped: []models.SkippedAMI{}}
+		err := s.deregisterAMI(ec2Client, accountID, image, result)
+		return result, err
+	}
+	return nil, fmt.Errorf("AMI %s not found", imageID)
+}
+
+// deregisterAMI deregisters an image, deletes its snapshots and records both in the result
+func (s *AWSService) deregisterAMI(ec2Client ec2iface.EC2API, accountID string, image models.AMI, result *models.AMICleanupResult) error {
+	_, err := ec2Client.DeregisterImage(&ec2.DeregisterImageInput{ImageId: aws.String(image.ImageID)})
+	if err != nil {
+		if strings.Contains(err.Error(), "InvalidAMIID") {
+			return fmt.Errorf("AMI %s not found", image.ImageID)
+		}
+		return fmt.Errorf("failed to deregister AMI %s: %v", image.ImageID, err)
+	}
+	result.Deregistered = append(result.Deregistered, image.ImageID)
+	s.updateAMICache(accountID, image.ImageID)
+
+	var failed []string
+	for _, snapshot := range image.Snapshots {
+		_, err := ec2Client.DeleteSnapshot(&ec2.DeleteSnapshotInput{SnapshotId: aws.String(snapshot.SnapshotID)})
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", snapshot.SnapshotID, err))
+			continue
+		}
+		result.DeletedSnapshots = append(result.DeletedSnapshots, snapshot.SnapshotID)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("deregistered AMI %s but failed to delete snapshots %s", image.ImageID, strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// updateAMICache removes a deregistered image from the cache. Cached snapshots list the AMIs they
+// back, so they are dropped too.
+func (s *AWSService) updateAMICache(accountID, imageID string) {
+	for _, cacheKey := range []string{fmt.Sprintf("amis:%s", accountID), "amis"} {
+		if cached, found := s.cache.Get(cacheKey); found {
+			if images, ok := cached.([]models.AMI); ok {
+				updated := []models.AMI{}
+				for _, image := range images {
+					if image.ImageID != imageID || image.AccountID != accountID {
+						updated = append(updated, image)
+					}
+				}
+				s.cache.Set(cacheKey, updated, s.cacheTTL)
+			}
+		}
+	}
+	s.cache.DeletePattern("snapshots")
+}
+
+// DeleteUnusedAMIs deregisters the account's images that no instance, launch template or launch configuration uses and
+// that were not launched or created in the last unusedDays, and deletes their snapshots. Shared,
+// public and managed images are skipped and reported with the reason.
+func (s *AWSService) DeleteUnusedAMIs(accountID string, unusedDays int) (*models.AMICleanupResult, error) {
+	// Usage must be current before anything is deregistered
+	s.cache.Delete(fmt.Sprintf("amis:%s", accountID))
+	images, err := s.ListAMIsByAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list AMIs: %w", err)
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -unusedDays)
+
+	result := &models.AMICleanupResult{Deregistered: []string{}, DeletedSnapshots: []string{}, Skipped: []models.SkippedAMI{}}
+	candidates := make(map[string][]models.AMI)
+	for _, image := range images {
+		if !amiLastUsed(image).Before(cutoff) {
+			continue
+		}
+		if image.ProtectedReason != "" {
+			result.Skipped = append(result.Skipped, models.SkippedAMI{
+				ImageID: image.ImageID,
+				Region:  image.Region,
+				Reason:  image.ProtectedReason,
+			})
+			continue
+		}
+		candidates[image.Region] = append(candidates[image.Region], image)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var errs []error
+
+	for region, regionImages := range candidates {
+		wg.Add(1)
+		go func(region string, regionImages []models.AMI) {
+			defer wg.Done()
+
+			ec2Client, err := s.regionEC2Client(accountID, region)
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+				return
+			}
+
+			for _, image := range regionImages {
+				regionResult := &models.AMICleanupResult{}
+				err := s.deregisterAMI(ec2Client, accountID, image, regionResult)
+
+				mu.Lock()
+				result.Deregistered = append(result.Deregistered, regionResult.Deregistered...)
+				result.DeletedSnapshots = append(result.DeletedSnapshots, regionResult.DeletedSnapshots...)
+				if err != nil {
+					errs = append(errs, err)
+				}
+				mu.Unlock()
+			}
+		}(region, regionImages)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		errMsg := fmt.Sprintf("deregistered %d AMIs, but encountered %d errors", len(result.Deregistered), len(errs))
+		for _, e := range errs {
+			errMsg += "; " + e.Error()
+		}
+		return result, fmt.Errorf("%s", errMsg)
+	}
+
+	return result, nil
+}
+
+// ListAMIFindings reports public AMIs and AMIs shared with accounts outside the organization,
+// for one account or all accessible accounts
+func (s *AWSService) ListAMIFindings(accountID, minSeverity string) ([]models.AMIFinding, error) {
+	if minSeverity != "" && severityRanks[minSeverity] == 0 {
+		return nil, fmt.Errorf("invalid severity %q", minSeverity)
+	}
+
+	var images []models.AMI
+	var err error
+	if accountID != "" {
+		images, err = s.ListAMIsByAccount(accountID)
+	} else {
+		images, err = s.ListAMIs()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.ListAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %v", err)
+	}
+	orgAccounts := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		orgAccounts[account.ID] = true
+	}
+	// Without the organization ID every organization and OU an image is shared with is reported
+	orgID, err := s.organizationID()
+	if err != nil {
+		fmt.Printf("[WARNING] Treating AMIs shared with organizations or OUs as external: %v\n", err)
+	}
+
+	findings := []models.AMIFinding{}
+	for _, image := range images {
+		finding, ok := amiFinding(image, orgAccounts, orgID)
+		if ok && severityRanks[finding.Severity] >= severityRanks[minSeverity] {
+			findings = append(findings, finding)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if severityRanks[a.Severity] != severityRanks[b.Severity] {
+			return severityRanks[a.Severity] > severityRanks[b.Severity]
+		}
+		if a.AccountID != b.AccountID {
+			return a.AccountID < b.AccountID
+		}
+		return a.ImageID < b.ImageID
+	})
+	return findings, nil
+}
+
+// amiFinding flags a public image as critical and one shared outside the organization as high.
+// Organization accounts and the ARNs of the organization (orgID) and its OUs are not findings.
+func amiFinding(image models.AMI, orgAccounts map[string]bool, orgID string) (models.AMIFinding, bool) {
+	var external []string
+	for _, sharedWith := range image.SharedWith {
+		internal := orgAccounts[sharedWith]
+		if strings.HasPrefix(sharedWith, "arn:") {
+			internal = orgID != "" && organizationIDFromARN(sharedWith) == orgID
+		}
+		if !internal {
+			external = append(external, sharedWith)
+		}
+	}
+	if !image.Public && len(external) == 0 {
+		return models.AMIFinding{}, false
+	}
+
+	finding := models.AMIFinding{
+		AccountID:        image.AccountID,
+		AccountName:      image.AccountName,
+		Region:           image.Region,
+		ImageID:          image.ImageID,
+		Name:             image.Name,
+		Public:           image.Public,
+		ExternalAccounts: external,
+	}
+
+	var exposure []string
+	if image.Public {
+		finding.Severity = "critical"
+		exposure = append(exposure, "can be launched by any AWS account")
+	} else {
+		finding.Severity = "high"
+	}
+	if len(external) > 0 {
+		exposure = append(exposure, fmt.Sprintf("is shared with %d account(s), organization(s) or OU(s) outside the organization (%s)", len(external), strings.Join(external, ", ")))
+	}
+	finding.Description = fmt.Sprintf("AMI %s (%s) %s", image.ImageID, image.Name, strings.Join(exposure, " and "))
+	return finding, true
+}
+
+// organizationIDFromARN returns the organization ID of an organization ARN
+// ("arn:aws:organizations::111111111111:organization/o-abc") or OU ARN
+// ("arn:aws:organizations::111111111111:ou/o-abc/ou-abc-123"), or "" for other ARNs
+func organizationIDFromARN(arn string) string {
+	parts := strings.SplitN(arn, ":", 6)
+	if len(parts) != 6 || parts[2] != "organizations" {
+		return ""
+	}
+	resource := strings.Split(parts[5], "/")
+	if len(resource) < 2 || (resource[0] != "organization" && resource[0] != "ou") {
+		return ""
+	}
+	return resource[1]
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/autoscaling"
+	"github.com/aws/aws-sdk-go/service/autoscaling/autoscalingiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAMIEC2 serves images, instances, launch templates and image attributes from memory
+type fakeAMIEC2 struct {
+	ec2iface.EC2API
+	images       []*ec2.Image
+	instances    []*ec2.Instance
+	versions     []*ec2.LaunchTemplateVersion
+	templateErr  error
+	permissions  map[string][]*ec2.LaunchPermission
+	lastLaunched map[string]string
+
+	deregistered     []string
+	deletedSnapshots []string
+}
+
+func (f *fakeAMIEC2) DescribeImagesPages(input *ec2.DescribeImagesInput, fn func(*ec2.DescribeImagesOutput, bool) bool) error {
+	fn(&ec2.DescribeImagesOutput{Images: f.images}, true)
+	return nil
+}
+
+func (f *fakeAMIEC2) DescribeInstancesPages(input *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool) error {
+	fn(&ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{{Instances: f.instances}}}, true)
+	return nil
+}
+
+func (f *fakeAMIEC2) DescribeLaunchTemplatesPages(input *ec2.DescribeLaunchTemplatesInput, fn func(*ec2.DescribeLaunchTemplatesOutput, bool) bool) error {
+	if f.templateErr != nil {
+		return f.templateErr
+	}
+	fn(&ec2.DescribeLaunchTemplatesOutput{LaunchTemplates: fakeLaunchTemplates(f.versions)}, true)
+	return nil
+}
+
+func (f *fakeAMIEC2) DescribeLaunchTemplateVersionsPages(input *ec2.DescribeLaunchTemplateVersionsInput, fn func(*ec2.DescribeLaunchTemplateVersionsOutput, bool) bool) error {
+	fn(&ec2.DescribeLaunchTemplateVersionsOutput{LaunchTemplateVersions: fakeTemplateVersions(f.versions, aws.StringValue(input.LaunchTemplateId))}, true)
+	return nil
+}
+
+// fakeAMIAutoScaling serves launch configurations from memory
+type fakeAMIAutoScaling struct {
+	autoscalingiface.AutoScalingAPI
+	configs []*autoscaling.LaunchConfiguration
+	err     error
+}
+
+func (f *fakeAMIAutoScaling) DescribeLaunchConfigurationsPages(input *autoscaling.DescribeLaunchConfigurationsInput, fn func(*autoscaling.DescribeLaunchConfigurationsOutput, bool) bool) error {
+	if f.err != nil {
+		return f.err
+	}
+	fn(&autoscaling.DescribeLaunchConfigurationsOutput{LaunchConfigurations: f.configs}, true)
+	return nil
+}
+
+func newFakeAMIAutoScaling() *fakeAMIAutoScaling {
+	return &fakeAMIAutoScaling{configs: []*autoscaling.LaunchConfiguration{
+		{LaunchConfigurationName: aws.String("legacy-web"), ImageId: aws.String("ami-config")},
+	}}
+}
+
+func (f *fakeAMIEC2) DescribeImageAttribute(input *ec2.DescribeImageAttributeInput) (*ec2.DescribeImageAttributeOutput, error) {
+	imageID := aws.StringValue(input.ImageId)
+	if aws.StringValue(input.Attribute) == ec2.ImageAttributeNameLastLaunchedTime {
+		output := &ec2.DescribeImageAttributeOutput{ImageId: input.ImageId}
+		if launched, ok := f.lastLaunched[imageID]; ok {
+			output.LastLaunchedTime = &ec2.AttributeValue{Value: aws.String(launched)}
+		}
+		return output, nil
+	}
+	return &ec2.DescribeImageAttributeOutput{ImageId: input.ImageId, LaunchPermissions: f.permissions[imageID]}, nil
+}
+
+func (f *fakeAMIEC2) DeregisterImage(input *ec2.DeregisterImageInput) (*ec2.DeregisterImageOutput, error) {
+	f.deregistered = append(f.deregistered, aws.StringValue(input.ImageId))
+	return &ec2.DeregisterImageOutput{}, nil
+}
+
+func (f *fakeAMIEC2) DeleteSnapshot(input *ec2.DeleteSnapshotInput) (*ec2.DeleteSnapshotOutput, error) {
+	if aws.StringValue(input.SnapshotId) == "snap-locked" {
+		return nil, errors.New("InvalidSnapshot.InUse: snapshot is in use")
+	}
+	f.deletedSnapshots = append(f.deletedSnapshots, aws.StringValue(input.SnapshotId))
+	return &ec2.DeleteSnapshotOutput{}, nil
+}
+
+func fakeImage(imageID string, snapshotIDs ...string) *ec2.Image {
+	image := &ec2.Image{
+		ImageId:      aws.String(imageID),
+		Name:         aws.String(imageID + "-name"),
+		OwnerId:      aws.String("111111111111"),
+		State:        aws.String(ec2.ImageStateAvailable),
+		CreationDate: aws.String("2025-01-15T10:00:00.000Z"),
+	}
+	for _, snapshotID := range snapshotIDs {
+		image.BlockDeviceMappings = append(image.BlockDeviceMappings, &ec2.BlockDeviceMapping{
+			DeviceName: aws.String("/dev/xvda"),
+			Ebs:        &ec2.EbsBlockDevice{SnapshotId: aws.String(snapshotID), VolumeSize: aws.Int64(8)},
+		})
+	}
+	return image
+}
+
+func newFakeAMIRegion() *fakeAMIEC2 {
+	backup := fakeImage("ami-backup", "snap-5")
+	backup.Tags = []*ec2.Tag{{Key: aws.String("aws:backup:source-resource"), Value: aws.String("arn:aws:ec2:us-east-1:111111111111:instance/i-9")}}
+
+	return &fakeAMIEC2{
+		images: []*ec2.Image{
+			fakeImage("ami-running", "snap-1"),
+			fakeImage("ami-template", "snap-2"),
+			fakeImage("ami-config"),
+			fakeImage("ami-unused", "snap-3", "snap-4"),
+			fakeImage("ami-public"),
+			fakeImage("ami-shared"),
+			backup,
+		},
+		instances: []*ec2.Instance{
+			{InstanceId: aws.String("i-1"), ImageId: aws.String("ami-running"), State: &ec2.InstanceState{Name: aws.String("running")}},
+			{InstanceId: aws.String("i-2"), ImageId: aws.String("ami-running"), State: &ec2.InstanceState{Name: aws.String("stopped")}},
+			{InstanceId: aws.String("i-3"), ImageId: aws.String("ami-unused"), State: &ec2.InstanceState{Name: aws.String("terminated")}},
+		},
+		versions: []*ec2.LaunchTemplateVersion{
+			{LaunchTemplateId: aws.String("lt-1"), VersionNumber: aws.Int64(3), LaunchTemplateData: &ec2.ResponseLaunchTemplateData{ImageId: aws.String("ami-template")}},
+			{LaunchTemplateId: aws.String("lt-1"), VersionNumber: aws.Int64(4), LaunchTemplateData: &ec2.ResponseLaunchTemplateData{ImageId: aws.String("ami-template")}},
+			{LaunchTemplateId: aws.String("lt-2"), VersionNumber: aws.Int64(1), LaunchTemplateData: &ec2.ResponseLaunchTemplateData{ImageId: aws.String("resolve:ssm:/golden/ami")}},
+		},
+		permissions: map[string][]*ec2.LaunchPermission{
+			"ami-public": {{Group: aws.String("all")}},
+			"ami-shared": {
+				{UserId: aws.String("222222222222")},
+				{UserId: aws.String("999999999999")},
+				{OrganizationArn: aws.String("arn:aws:organizations::111111111111:organization/o-abc")},
+			},
+		},
+		lastLaunched: map[string]string{"ami-running": "2026-10-01T08:00:00Z"},
+	}
+}
+
+func amisByID(images []models.AMI) map[string]models.AMI {
+	byID := make(map[string]models.AMI, len(images))
+	for _, image := range images {
+		byID[image.ImageID] = image
+	}
+	return byID
+}
+
+func TestDescribeRegionAMIs(t *testing.T) {
+	images, err := describeRegionAMIs(newFakeAMIRegion(), newFakeAMIAutoScaling())
+	require.NoError(t, err)
+	require.Len(t, images, 7)
+	byID := amisByID(images)
+
+	running := byID["ami-running"]
+	assert.Equal(t, 2, running.InstanceCount)
+	assert.True(t, running.InUse)
+	assert.Equal(t, "used by 2 instance(s), 0 launch template(s) and 0 launch configuration(s)", running.ProtectedReason)
+	require.NotNil(t, running.LastLaunchedTime)
+	assert.Equal(t, time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC), *running.LastLaunchedTime)
+	assert.Equal(t, time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC), running.CreationDate)
+
+	template := byID["ami-template"]
+	assert.Equal(t, 1, template.LaunchTemplateCount)
+	assert.Equal(t, []string{"lt-1:3", "lt-1:4"}, template.LaunchTemplates)
+	assert.True(t, template.InUse)
+
+	config := byID["ami-config"]
+	assert.Equal(t, []string{"legacy-web"}, config.LaunchConfigurations)
+	assert.True(t, config.InUse)
+	assert.Equal(t, "used by 0 instance(s), 0 launch template(s) and 1 launch configuration(s)", config.ProtectedReason)
+
+	// Terminated instances do not count
+	unused := byID["ami-unused"]
+	assert.Zero(t, unused.InstanceCount)
+	assert.False(t, unused.InUse)
+	assert.Empty(t, unused.ProtectedReason)
+	assert.Nil(t, unused.LastLaunchedTime)
+	assert.Equal(t, int64(16), unused.SnapshotSizeGB)
+	require.Len(t, unused.Snapshots, 2)
+	assert.Equal(t, "snap-4", unused.Snapshots[1].SnapshotID)
+
+	assert.True(t, byID["ami-public"].Public)
+	assert.Equal(t, "shared with other accounts", byID["ami-public"].ProtectedReason)
+	assert.Len(t, byID["ami-shared"].SharedWith, 3)
+	assert.Equal(t, "shared with other accounts", byID["ami-shared"].ProtectedReason)
+	assert.Equal(t, "managed by AWS Backup", byID["ami-backup"].ProtectedReason)
+}
+
+func TestDescribeRegionAMIsProtectsWhenLaunchTemplatesUnknown(t *testing.T) {
+	client := newFakeAMIRegion()
+	client.templateErr = errors.New("UnauthorizedOperation")
+
+	images, err := describeRegionAMIs(client, newFakeAMIAutoScaling())
+	require.NoError(t, err)
+
+	unused := amisByID(images)["ami-unused"]
+	assert.Equal(t, "launch template usage could not be determined", unused.ProtectedReason)
+}
+
+func TestDescribeRegionAMIsProtectsWhenLaunchConfigurationsUnknown(t *testing.T) {
+	asClient := newFakeAMIAutoScaling()
+	asClient.err = errors.New("AccessDenied")
+
+	images, err := describeRegionAMIs(newFakeAMIRegion(), asClient)
+	require.NoError(t, err)
+
+	unused := amisByID(images)["ami-unused"]
+	assert.Equal(t, "launch configuration usage could not be determined", unused.ProtectedReason)
+}
+
+func TestDescribeRegionAMIsChecksEveryLaunchTemplateVersion(t *testing.T) {
+	client := newFakeAMIRegion()
+	// An Auto Scaling group pins version 1 while the default and latest versions moved on
+	client.versions = append(client.versions, &ec2.LaunchTemplateVersion{
+		LaunchTemplateId: aws.String("lt-3"), VersionNumber: aws.Int64(1), LaunchTemplateData: &ec2.ResponseLaunchTemplateData{ImageId: aws.String("ami-unused")},
+	}, &ec2.LaunchTemplateVersion{
+		LaunchTemplateId: aws.String("lt-3"), VersionNumber: aws.Int64(2), LaunchTemplateData: &ec2.ResponseLaunchTemplateData{ImageId: aws.String("ami-template")},
+	})
+
+	images, err := describeRegionAMIs(client, newFakeAMIAutoScaling())
+	require.NoError(t, err)
+
+	unused := amisByID(images)["ami-unused"]
+	assert.Equal(t, []string{"lt-3:1"}, unused.LaunchTemplates)
+	assert.True(t, unused.InUse)
+	assert.NotEmpty(t, unused.ProtectedReason)
+}
+
+func TestAMILastUsed(t *testing.T) {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	launched := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, created, amiLastUsed(models.AMI{CreationDate: created}))
+	assert.Equal(t, launched, amiLastUsed(models.AMI{CreationDate: created, LastLaunchedTime: &launched}))
+}
+
+func TestAMIFinding(t *testing.T) {
+	orgAccounts := map[string]bool{"111111111111": true, "222222222222": true}
+
+	public := models.AMI{ImageID: "ami-public", Name: "base", Public: true}
+	finding, ok := amiFinding(public, orgAccounts, "o-abc")
+	require.True(t, ok)
+	assert.Equal(t, "critical", finding.Severity)
+	assert.Contains(t, finding.Description, "any AWS account")
+
+	shared := models.AMI{ImageID: "ami-shared", SharedWith: []string{
+		"222222222222", "999999999999",
+		"arn:aws:organizations::111111111111:organization/o-abc",
+		"arn:aws:organizations::111111111111:ou/o-abc/ou-abc-1",
+		"arn:aws:organizations::999999999999:organization/o-other",
+		"arn:aws:organizations::999999999999:ou/o-other/ou-other-1",
+	}}
+	finding, ok = amiFinding(shared, orgAccounts, "o-abc")
+	require.True(t, ok)
+	assert.Equal(t, "high", finding.Severity)
+	assert.Equal(t, []string{
+		"999999999999",
+		"arn:aws:organizations::999999999999:organization/o-other",
+		"arn:aws:organizations::999999999999:ou/o-other/ou-other-1",
+	}, finding.ExternalAccounts)
+
+	internal := models.AMI{ImageID: "ami-internal", SharedWith: []string{"222222222222", "arn:aws:organizations::111111111111:ou/o-abc/ou-abc-1"}}
+	_, ok = amiFinding(internal, orgAccounts, "o-abc")
+	assert.False(t, ok)
+
+	// An unknown organization ID cannot vouch for any organization or OU
+	finding, ok = amiFinding(internal, orgAccounts, "")
+	require.True(t, ok)
+	assert.Equal(t, []string{"arn:aws:organizations::111111111111:ou/o-abc/ou-abc-1"}, finding.ExternalAccounts)
+}
+
+func TestOrganizationIDFromARN(t *testing.T) {
+	assert.Equal(t, "o-abc", organizationIDFromARN("arn:aws:organizations::111111111111:organization/o-abc"))
+	assert.Equal(t, "o-abc", organizationIDFromARN("arn:aws:organizations::111111111111:ou/o-abc/ou-abc-1"))
+	assert.Empty(t, organizationIDFromARN("arn:aws:iam::111111111111:role/o-abc"))
+	assert.Empty(t, organizationIDFromARN("111111111111"))
+}
+
+func TestDeregisterAMIDeletesSnapshotsAndUpdatesCache(t *testing.T) {
+	s := &AWSService{cache: NewCache(), cacheTTL: time.Minute}
+	s.cache.Set("amis:111111111111", []models.AMI{{ImageID: "ami-unused", AccountID: "111111111111"}, {ImageID: "ami-running", AccountID: "111111111111"}}, time.Minute)
+	s.cache.Set("snapshots:111111111111", []models.Snapshot{}, time.Minute)
+
+	client := newFakeAMIRegion()
+	image := convertAMI(fakeImage("ami-unused", "snap-3", "snap-locked"))
+	result := &models.AMICleanupResult{}
+
+	err := s.deregisterAMI(client, "111111111111", image, result)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "snap-locked")
+
+	assert.Equal(t, []string{"ami-unused"}, client.deregistered)
+	assert.Equal(t, []string{"ami-unused"}, result.Deregistered)
+	assert.Equal(t, []string{"snap-3"}, result.DeletedSnapshots)
+
+	cached, found := s.cache.Get("amis:111111111111")
+	require.True(t, found)
+	assert.Equal(t, []models.AMI{{ImageID: "ami-running", AccountID: "111111111111"}}, cached)
+	_, found = s.cache.Get("snapshots:111111111111")
+	assert.False(t, found)
+}
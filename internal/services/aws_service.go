@@ -17,10 +17,18 @@ import (
 
 // AWSService provides AWS service integration
 type AWSService struct {
-	masterSession *session.Session
-	config        config.Config
-	cache         *Cache
-	cacheTTL      time.Duration
+	masterSession     *session.Session
+	config            config.Config
+	cache             *Cache
+	cacheTTL          time.Duration
+	secrets           *SecretHandoff
+	keyRotations      *keyRotationTracker
+	jobs              *jobManager
+	sgChanges         *securityGroupChangeTracker
+	portRisks         []models.PortRisk
+	retentionPolicies *retentionPolicyStore
+	coverage          *scanCoverageTracker
+	prices            *priceCatalog
 }
 
 // NewAWSService creates a new AWS service instance
@@ -51,11 +59,24 @@ func NewAWSService(cfg config.Config) *AWSService {
 		panic(fmt.Sprintf("Failed to create AWS session: %v", err))
 	}
 
+	secrets, err := newSecretHandoffFromConfig(cfg)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to initialize secret handoff: %v", err))
+	}
+
 	return &AWSService{
-		masterSession: sess,
-		config:        cfg,
-		cache:         NewCache(),
-		cacheTTL:      5 * time.Minute, // Default 5 minute TTL
+		masterSession:     sess,
+		config:            cfg,
+		cache:             NewCache(),
+		cacheTTL:          5 * time.Minute, // Default 5 minute TTL
+		secrets:           secrets,
+		keyRotations:      newKeyRotationTracker(cfg.StateDir),
+		jobs:              newJobManager(cfg.StateDir),
+		sgChanges:         newSecurityGroupChangeTracker(cfg.StateDir),
+		portRisks:         buildPortRiskCatalogue(cfg.SecurityGroupPortRisks),
+		retentionPolicies: newRetentionPolicyStore(cfg.StateDir),
+		coverage:          newScanCoverageTracker(),
+		prices:            newPriceCatalog(cfg.PriceListFiles),
 	}
 }
 
@@ -151,6 +172,7 @@ func (s *AWSService) InvalidateAccountSecurityGroupsCache(accountID string) {
 // InvalidateSecurityGroupCache invalidates cache for a specific security group
 func (s *AWSService) InvalidateSecurityGroupCache(accountID, region, groupID string) {
 	s.cache.Delete(fmt.Sprintf("security-group:%s:%s:%s", accountID, region, groupID))
+	s.cache.DeletePattern(fmt.Sprintf("security-group:%s:graph:", accountID))
 	// Also invalidate broader caches that contain this security group
 	s.cache.Delete(fmt.Sprintf("security-groups:%s", accountID))
 	s.cache.Delete("security-groups")
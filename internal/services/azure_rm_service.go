@@ -646,6 +646,14 @@ func (s *AzureRMService) convertVMToModel(vm *armcompute.VirtualMachine, resourc
 	if vm.Location != nil {
 		azureVM.Location = *vm.Location
 	}
+	if len(vm.Tags) > 0 {
+		azureVM.Tags = make(map[string]string, len(vm.Tags))
+		for key, value := range vm.Tags {
+			if value != nil {
+				azureVM.Tags[key] = *value
+			}
+		}
+	}
 	if vm.Properties != nil {
 		if vm.Properties.HardwareProfile != nil && vm.Properties.HardwareProfile.VMSize != nil {
 			azureVM.VMSize = string(*vm.Properties.HardwareProfile.VMSize)
@@ -0,0 +1,510 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+)
+
+// ============================================================================
+// RDS AND AURORA SNAPSHOT MANAGEMENT
+// ============================================================================
+//
+// DB instance snapshots and Aurora cluster snapshots are listed together as models.DBSnapshot.
+// Only manual snapshots can be shared, so the "restore" attribute, which holds the account IDs
+// a snapshot is shared with or "all" for public snapshots, is read for manual snapshots only.
+
+// ListDBSnapshots returns the RDS and Aurora snapshots of all accessible accounts
+func (s *AWSService) ListDBSnapshots() ([]models.DBSnapshot, error) {
+	const cacheKey = "db-snapshots"
+
+	if cached, found := s.cache.Get(cacheKey); found {
+		if snapshots, ok := cached.([]models.DBSnapshot); ok {
+			return snapshots, nil
+		}
+	}
+
+	accounts, err := s.listAccessibleAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	var allSnapshots []models.DBSnapshot
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, account := range accounts {
+		wg.Add(1)
+		go func(acc models.Account) {
+			defer wg.Done()
+
+			snapshots, err := s.ListDBSnapshotsByAccount(acc.ID)
+			if err != nil {
+				fmt.Printf("[WARNING] Failed to get DB snapshots for account %s: %v\n", acc.ID, err)
+				return
+			}
+
+			mu.Lock()
+			allSnapshots = append(allSnapshots, snapshots...)
+			mu.Unlock()
+		}(account)
+	}
+
+	wg.Wait()
+
+	s.cache.Set(cacheKey, allSnapshots, s.cacheTTL)
+	return allSnapshots, nil
+}
+
+// ListDBSnapshotsByAccount returns the RDS DB instance and Aurora cluster snapshots of an account
+func (s *AWSService) ListDBSnapshotsByAccount(accountID string) ([]models.DBSnapshot, error) {
+	cacheKey := fmt.Sprintf("db-snapshots:%s", accountID)
+	if cached, found := s.cache.Get(cacheKey); found {
+		if snapshots, ok := cached.([]models.DBSnapshot); ok {
+			return snapshots, nil
+		}
+	}
+
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+
+	scan, err := s.startRegionScan(scannerDBSnapshots, accountID, sess)
+	if err != nil {
+		return nil, err
+	}
+
+	accountName := s.lookupAccountName(accountID)
+
+	allSnapshots := []models.DBSnapshot{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, region := range scan.Regions() {
+		wg.Add(1)
+		go func(r string) {
+			defer wg.Done()
+
+			rdsClient := rds.New(sess.Copy(&aws.Config{Region: aws.String(r)}))
+			snapshots, err := describeRegionDBSnapshots(rdsClient)
+			if err != nil {
+				fmt.Printf("[WARNING] Failed to list DB snapshots in %s for account %s: %v\n", r, accountID, err)
+				scan.Fail(r, err)
+				return
+			}
+
+			for i := range snapshots {
+				snapshots[i].AccountID = accountID
+				snapshots[i].AccountName = accountName
+				snapshots[i].Region = r
+			}
+
+			mu.Lock()
+			allSnapshots = append(allSnapshots, snapshots...)
+			mu.Unlock()
+		}(region)
+	}
+
+	wg.Wait()
+	scan.Finish()
+
+	s.cache.Set(cacheKey, allSnapshots, s.cacheTTL)
+	return allSnapshots, nil
+}
+
+// describeRegionDBSnapshots lists the DB instance and cluster snapshots in a region with their sharing
+// and whether the instance or cluster they were taken from still exists
+func describeRegionDBSnapshots(rdsClient rdsiface.RDSAPI) ([]models.DBSnapshot, error) {
+	instances := make(map[string]bool)
+	err := rdsClient.DescribeDBInstancesPages(&rds.DescribeDBInstancesInput{}, func(page *rds.DescribeDBInstancesOutput, lastPage bool) bool {
+		for _, instance := range page.DBInstances {
+			instances[aws.StringValue(instance.DBInstanceIdentifier)] = true
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe DB instances: %v", err)
+	}
+
+	clusters := make(map[string]bool)
+	err = rdsClient.DescribeDBClustersPages(&rds.DescribeDBClustersInput{}, func(page *rds.DescribeDBClustersOutput, lastPage bool) bool {
+		for _, cluster := range page.DBClusters {
+			clusters[aws.StringValue(cluster.DBClusterIdentifier)] = true
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe DB clusters: %v", err)
+	}
+
+	var snapshots []models.DBSnapshot
+	err = rdsClient.DescribeDBSnapshotsPages(&rds.DescribeDBSnapshotsInput{}, func(page *rds.DescribeDBSnapshotsOutput, lastPage bool) bool {
+		for _, snap := range page.DBSnapshots {
+			snapshot := models.DBSnapshot{
+				SnapshotID:       aws.StringValue(snap.DBSnapshotIdentifier),
+				SnapshotArn:      aws.StringValue(snap.DBSnapshotArn),
+				Kind:             models.DBSnapshotKindInstance,
+				SnapshotType:     aws.StringValue(snap.SnapshotType),
+				SourceIdentifier: aws.StringValue(snap.DBInstanceIdentifier),
+				Engine:           aws.StringValue(snap.Engine),
+				EngineVersion:    aws.StringValue(snap.EngineVersion),
+				AllocatedStorage: aws.Int64Value(snap.AllocatedStorage),
+				Status:           aws.StringValue(snap.Status),
+				Encrypted:        aws.BoolValue(snap.Encrypted),
+				KmsKeyID:         aws.StringValue(snap.KmsKeyId),
+				Tags:             convertRDSTags(snap.TagList),
+			}
+			if snap.SnapshotCreateTime != nil {
+				snapshot.CreateTime = *snap.SnapshotCreateTime
+			}
+			snapshot.SourceExists = instances[snapshot.SourceIdentifier]
+			snapshots = append(snapshots, snapshot)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe DB snapshots: %v", err)
+	}
+
+	err = rdsClient.DescribeDBClusterSnapshotsPages(&rds.DescribeDBClusterSnapshotsInput{}, func(page *rds.DescribeDBClusterSnapshotsOutput, lastPage bool) bool {
+		for _, snap := range page.DBClusterSnapshots {
+			snapshot := models.DBSnapshot{
+				SnapshotID:       aws.StringValue(snap.DBClusterSnapshotIdentifier),
+				SnapshotArn:      aws.StringValue(snap.DBClusterSnapshotArn),
+				Kind:             models.DBSnapshotKindCluster,
+				SnapshotType:     aws.StringValue(snap.SnapshotType),
+				SourceIdentifier: aws.StringValue(snap.DBClusterIdentifier),
+				Engine:           aws.StringValue(snap.Engine),
+				EngineVersion:    aws.StringValue(snap.EngineVersion),
+				AllocatedStorage: aws.Int64Value(snap.AllocatedStorage),
+				Status:           aws.StringValue(snap.Status),
+				Encrypted:        aws.BoolValue(snap.StorageEncrypted),
+				KmsKeyID:         aws.StringValue(snap.KmsKeyId),
+				Tags:             convertRDSTags(snap.TagList),
+			}
+			if snap.SnapshotCreateTime != nil {
+				snapshot.CreateTime = *snap.SnapshotCreateTime
+			}
+			snapshot.SourceExists = clusters[snapshot.SourceIdentifier]
+			snapshots = append(snapshots, snapshot)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe DB cluster snapshots: %v", err)
+	}
+
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		if snapshot.SnapshotType == "manual" {
+			restoreAccounts, err := dbSnapshotRestoreAccounts(rdsClient, snapshot)
+			if err != nil {
+				// Sharing is unknown rather than absent; keep the snapshot out of bulk deletion
+				fmt.Printf("[WARNING] Failed to read sharing of DB snapshot %s: %v\n", snapshot.SnapshotID, err)
+				snapshot.ProtectedReason = "sharing could not be determined"
+				continue
+			}
+			applyDBSnapshotSharing(snapshot, restoreAccounts)
+		}
+		snapshot.ProtectedReason = dbSnapshotProtectionReason(*snapshot)
+	}
+
+	return snapshots, nil
+}
+
+// dbSnapshotRestoreAccounts returns the values of a manual snapshot's "restore" attribute
+func dbSnapshotRestoreAccounts(rdsClient rdsiface.RDSAPI, snapshot *models.DBSnapshot) ([]string, error) {
+	if snapshot.Kind == models.DBSnapshotKindCluster {
+		result, err := rdsClient.DescribeDBClusterSnapshotAttributes(&rds.DescribeDBClusterSnapshotAttributesInput{
+			DBClusterSnapshotIdentifier: aws.String(snapshot.SnapshotID),
+		})
+		if err != nil {
+			return nil, err
+		}
+		if result.DBClusterSnapshotAttributesResult == nil {
+			return nil, nil
+		}
+		for _, attr := range result.DBClusterSnapshotAttributesResult.DBClusterSnapshotAttributes {
+			if aws.StringValue(attr.AttributeName) == "restore" {
+				return aws.StringValueSlice(attr.AttributeValues), nil
+			}
+		}
+		return nil, nil
+	}
+
+	result, err := rdsClient.DescribeDBSnapshotAttributes(&rds.DescribeDBSnapshotAttributesInput{
+		DBSnapshotIdentifier: aws.String(snapshot.SnapshotID),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.DBSnapshotAttributesResult == nil {
+		return nil, nil
+	}
+	for _, attr := range result.DBSnapshotAttributesResult.DBSnapshotAttributes {
+		if aws.StringValue(attr.AttributeName) == "restore" {
+			return aws.StringValueSlice(attr.AttributeValues), nil
+		}
+	}
+	return nil, nil
+}
+
+// applyDBSnapshotSharing records the accounts a snapshot is shared with; "all" makes it public
+func applyDBSnapshotSharing(snapshot *models.DBSnapshot, restoreAccounts []string) {
+	for _, value := range restoreAccounts {
+		if value == "all" {
+			snapshot.Public = true
+			continue
+		}
+		snapshot.SharedWith = appendUnique(snapshot.SharedWith, value)
+	}
+}
+
+// dbSnapshotProtectionReason explains why bulk deletion must leave a DB snapshot alone, or returns ""
+func dbSnapshotProtectionReason(snapshot models.DBSnapshot) string {
+	switch {
+	case snapshot.SnapshotType == "automated":
+		return "automated snapshot, removed by the backup retention period"
+	case snapshot.SnapshotType == "awsbackup":
+		return "managed by AWS Backup"
+	case snapshot.SnapshotType != "manual":
+		return fmt.Sprintf("%s snapshot", snapshot.SnapshotType)
+	case snapshot.Public || len(snapshot.SharedWith) > 0:
+		return "shared with other accounts"
+	}
+	return ""
+}
+
+// convertRDSTags converts RDS tags to model tags
+func convertRDSTags(tags []*rds.Tag) []models.Tag {
+	var result []models.Tag
+	for _, tag := range tags {
+		result = append(result, models.Tag{
+			Key:   aws.StringValue(tag.Key),
+			Value: aws.StringValue(tag.Value),
+		})
+	}
+	return result
+}
+
+// DeleteDBSnapshot deletes an RDS DB instance snapshot or, for kind "cluster", an Aurora cluster snapshot
+func (s *AWSService) DeleteDBSnapshot(accountID, region, snapshotID, kind string) error {
+	if kind != models.DBSnapshotKindInstance && kind != models.DBSnapshotKindCluster {
+		return fmt.Errorf("invalid snapshot kind %q: must be instance or cluster", kind)
+	}
+
+	sess, err := s.getSessionForAccountAndRegion(accountID, region)
+	if err != nil {
+		return fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+	rdsClient := rds.New(sess)
+
+	if kind == models.DBSnapshotKindCluster {
+		_, err = rdsClient.DeleteDBClusterSnapshot(&rds.DeleteDBClusterSnapshotInput{
+			DBClusterSnapshotIdentifier: aws.String(snapshotID),
+		})
+	} else {
+		_, err = rdsClient.DeleteDBSnapshot(&rds.DeleteDBSnapshotInput{
+			DBSnapshotIdentifier: aws.String(snapshotID),
+		})
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "NotFound") {
+			return fmt.Errorf("DB snapshot %s not found", snapshotID)
+		}
+		return fmt.Errorf("failed to delete DB snapshot %s: %v", snapshotID, err)
+	}
+
+	s.updateDBSnapshotCache(accountID, snapshotID)
+	return nil
+}
+
+// updateDBSnapshotCache removes a deleted DB snapshot from the cache
+func (s *AWSService) updateDBSnapshotCache(accountID, snapshotID string) {
+	for _, cacheKey := range []string{fmt.Sprintf("db-snapshots:%s", accountID), "db-snapshots"} {
+		if cached, found := s.cache.Get(cacheKey); found {
+			if snapshots, ok := cached.([]models.DBSnapshot); ok {
+				updated := []models.DBSnapshot{}
+				for _, snap := range snapshots {
+					if snap.SnapshotID != snapshotID || snap.AccountID != accountID {
+						updated = append(updated, snap)
+					}
+				}
+				s.cache.Set(cacheKey, updated, s.cacheTTL)
+			}
+		}
+	}
+}
+
+// DeleteOldDBSnapshots deletes the manual DB snapshots of an account older than the given number of months.
+// Automated, AWS Backup and shared snapshots are skipped and reported with the reason.
+func (s *AWSService) DeleteOldDBSnapshots(accountID string, olderThanMonths int) (*models.SnapshotCleanupResult, error) {
+	snapshots, err := s.ListDBSnapshotsByAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list DB snapshots: %w", err)
+	}
+
+	cutoffDate := time.Now().AddDate(0, -olderThanMonths, 0)
+
+	result := &models.SnapshotCleanupResult{Deleted: []string{}, Skipped: []models.SkippedSnapshot{}}
+	var oldSnapshots []models.DBSnapshot
+	for _, snap := range snapshots {
+		if !snap.CreateTime.Before(cutoffDate) || snap.Status != "available" {
+			continue
+		}
+		if snap.ProtectedReason != "" {
+			result.Skipped = append(result.Skipped, models.SkippedSnapshot{
+				SnapshotID: snap.SnapshotID,
+				Region:     snap.Region,
+				Reason:     snap.ProtectedReason,
+			})
+			continue
+		}
+		oldSnapshots = append(oldSnapshots, snap)
+	}
+
+	if len(oldSnapshots) == 0 {
+		return result, nil
+	}
+
+	type deleteResult struct {
+		snapshotID string
+		err        error
+	}
+
+	resultChan := make(chan deleteResult, len(oldSnapshots))
+	var wg sync.WaitGroup
+
+	for _, snap := range oldSnapshots {
+		wg.Add(1)
+		go func(snapshot models.DBSnapshot) {
+			defer wg.Done()
+			err := s.DeleteDBSnapshot(accountID, snapshot.Region, snapshot.SnapshotID, snapshot.Kind)
+			resultChan <- deleteResult{
+				snapshotID: snapshot.SnapshotID,
+				err:        err,
+			}
+		}(snap)
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	var errors []error
+	for deleted := range resultChan {
+		if deleted.err != nil {
+			errors = append(errors, deleted.err)
+		} else {
+			result.Deleted = append(result.Deleted, deleted.snapshotID)
+		}
+	}
+
+	if len(errors) > 0 {
+		errMsg := fmt.Sprintf("deleted %d DB snapshots, but encountered %d errors", len(result.Deleted), len(errors))
+		for _, e := range errors {
+			errMsg += "; " + e.Error()
+		}
+		return result, fmt.Errorf("%s", errMsg)
+	}
+
+	return result, nil
+}
+
+// ListDBSnapshotFindings reports public DB snapshots and snapshots shared with accounts outside the
+// organization, for one account or all accessible accounts
+func (s *AWSService) ListDBSnapshotFindings(accountID, minSeverity string) ([]models.DBSnapshotFinding, error) {
+	if minSeverity != "" && severityRanks[minSeverity] == 0 {
+		return nil, fmt.Errorf("invalid severity %q", minSeverity)
+	}
+
+	var snapshots []models.DBSnapshot
+	var err error
+	if accountID != "" {
+		snapshots, err = s.ListDBSnapshotsByAccount(accountID)
+	} else {
+		snapshots, err = s.ListDBSnapshots()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.ListAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %v", err)
+	}
+	orgAccounts := make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		orgAccounts[account.ID] = true
+	}
+
+	findings := []models.DBSnapshotFinding{}
+	for _, snapshot := range snapshots {
+		finding, ok := dbSnapshotFinding(snapshot, orgAccounts)
+		if ok && severityRanks[finding.Severity] >= severityRanks[minSeverity] {
+			findings = append(findings, finding)
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if severityRanks[a.Severity] != severityRanks[b.Severity] {
+			return severityRanks[a.Severity] > severityRanks[b.Severity]
+		}
+		if a.AccountID != b.AccountID {
+			return a.AccountID < b.AccountID
+		}
+		return a.SnapshotID < b.SnapshotID
+	})
+	return findings, nil
+}
+
+// dbSnapshotFinding flags a public snapshot as critical and one shared outside the organization as high.
+// Snapshots shared only with organization accounts are not findings.
+func dbSnapshotFinding(snapshot models.DBSnapshot, orgAccounts map[string]bool) (models.DBSnapshotFinding, bool) {
+	var external []string
+	for _, sharedWith := range snapshot.SharedWith {
+		if !orgAccounts[sharedWith] {
+			external = append(external, sharedWith)
+		}
+	}
+	if !snapshot.Public && len(external) == 0 {
+		return models.DBSnapshotFinding{}, false
+	}
+
+	finding := models.DBSnapshotFinding{
+		AccountID:        snapshot.AccountID,
+		AccountName:      snapshot.AccountName,
+		Region:           snapshot.Region,
+		SnapshotID:       snapshot.SnapshotID,
+		SnapshotArn:      snapshot.SnapshotArn,
+		Kind:             snapshot.Kind,
+		Engine:           snapshot.Engine,
+		Encrypted:        snapshot.Encrypted,
+		Public:           snapshot.Public,
+		ExternalAccounts: external,
+	}
+
+	var exposure []string
+	if snapshot.Public {
+		finding.Severity = "critical"
+		exposure = append(exposure, "can be restored by any AWS account")
+	} else {
+		finding.Severity = "high"
+	}
+	if len(external) > 0 {
+		exposure = append(exposure, fmt.Sprintf("is shared with %d account(s) outside the organization (%s)", len(external), strings.Join(external, ", ")))
+	}
+	finding.Description = fmt.Sprintf("%s snapshot %s %s", snapshot.Engine, snapshot.SnapshotID, strings.Join(exposure, " and "))
+	return finding, true
+}
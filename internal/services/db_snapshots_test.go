@@ -0,0 +1,132 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRDSClient serves fixed instances, clusters and snapshots with their "restore" attributes
+type fakeRDSClient struct {
+	rdsiface.RDSAPI
+	instances        []*rds.DBInstance
+	clusters         []*rds.DBCluster
+	snapshots        []*rds.DBSnapshot
+	clusterSnapshots []*rds.DBClusterSnapshot
+	restore          map[string][]string
+}
+
+func (f *fakeRDSClient) DescribeDBInstancesPages(input *rds.DescribeDBInstancesInput, fn func(*rds.DescribeDBInstancesOutput, bool) bool) error {
+	fn(&rds.DescribeDBInstancesOutput{DBInstances: f.instances}, true)
+	return nil
+}
+
+func (f *fakeRDSClient) DescribeDBClustersPages(input *rds.DescribeDBClustersInput, fn func(*rds.DescribeDBClustersOutput, bool) bool) error {
+	fn(&rds.DescribeDBClustersOutput{DBClusters: f.clusters}, true)
+	return nil
+}
+
+func (f *fakeRDSClient) DescribeDBSnapshotsPages(input *rds.DescribeDBSnapshotsInput, fn func(*rds.DescribeDBSnapshotsOutput, bool) bool) error {
+	fn(&rds.DescribeDBSnapshotsOutput{DBSnapshots: f.snapshots}, true)
+	return nil
+}
+
+func (f *fakeRDSClient) DescribeDBClusterSnapshotsPages(input *rds.DescribeDBClusterSnapshotsInput, fn func(*rds.DescribeDBClusterSnapshotsOutput, bool) bool) error {
+	fn(&rds.DescribeDBClusterSnapshotsOutput{DBClusterSnapshots: f.clusterSnapshots}, true)
+	return nil
+}
+
+func (f *fakeRDSClient) DescribeDBSnapshotAttributes(input *rds.DescribeDBSnapshotAttributesInput) (*rds.DescribeDBSnapshotAttributesOutput, error) {
+	id := aws.StringValue(input.DBSnapshotIdentifier)
+	return &rds.DescribeDBSnapshotAttributesOutput{DBSnapshotAttributesResult: &rds.DBSnapshotAttributesResult{
+		DBSnapshotIdentifier: input.DBSnapshotIdentifier,
+		DBSnapshotAttributes: []*rds.DBSnapshotAttribute{
+			{AttributeName: aws.String("restore"), AttributeValues: aws.StringSlice(f.restore[id])},
+		},
+	}}, nil
+}
+
+func (f *fakeRDSClient) DescribeDBClusterSnapshotAttributes(input *rds.DescribeDBClusterSnapshotAttributesInput) (*rds.DescribeDBClusterSnapshotAttributesOutput, error) {
+	id := aws.StringValue(input.DBClusterSnapshotIdentifier)
+	return &rds.DescribeDBClusterSnapshotAttributesOutput{DBClusterSnapshotAttributesResult: &rds.DBClusterSnapshotAttributesResult{
+		DBClusterSnapshotIdentifier: input.DBClusterSnapshotIdentifier,
+		DBClusterSnapshotAttributes: []*rds.DBClusterSnapshotAttribute{
+			{AttributeName: aws.String("restore"), AttributeValues: aws.StringSlice(f.restore[id])},
+		},
+	}}, nil
+}
+
+func TestDescribeRegionDBSnapshots(t *testing.T) {
+	client := &fakeRDSClient{
+		instances: []*rds.DBInstance{{DBInstanceIdentifier: aws.String("orders-db")}},
+		clusters:  []*rds.DBCluster{{DBClusterIdentifier: aws.String("billing")}},
+		snapshots: []*rds.DBSnapshot{
+			{DBSnapshotIdentifier: aws.String("rds:orders-db-2024-01-01"), DBInstanceIdentifier: aws.String("orders-db"), SnapshotType: aws.String("automated"), Engine: aws.String("postgres")},
+			{DBSnapshotIdentifier: aws.String("legacy-final"), DBInstanceIdentifier: aws.String("legacy-db"), SnapshotType: aws.String("manual"), Engine: aws.String("mysql")},
+			{DBSnapshotIdentifier: aws.String("orders-export"), DBInstanceIdentifier: aws.String("orders-db"), SnapshotType: aws.String("manual"), Engine: aws.String("postgres")},
+		},
+		clusterSnapshots: []*rds.DBClusterSnapshot{
+			{DBClusterSnapshotIdentifier: aws.String("billing-pre-upgrade"), DBClusterIdentifier: aws.String("billing"), SnapshotType: aws.String("manual"), Engine: aws.String("aurora-postgresql"), StorageEncrypted: aws.Bool(true)},
+			{DBClusterSnapshotIdentifier: aws.String("awsbackup:job-1"), DBClusterIdentifier: aws.String("billing"), SnapshotType: aws.String("awsbackup"), Engine: aws.String("aurora-postgresql")},
+		},
+		restore: map[string][]string{
+			"orders-export":       {"all"},
+			"billing-pre-upgrade": {"222222222222", "999999999999"},
+		},
+	}
+
+	snapshots, err := describeRegionDBSnapshots(client)
+	assert.NoError(t, err)
+
+	byID := make(map[string]models.DBSnapshot)
+	for _, snapshot := range snapshots {
+		byID[snapshot.SnapshotID] = snapshot
+	}
+	assert.Len(t, byID, 5)
+
+	automated := byID["rds:orders-db-2024-01-01"]
+	assert.Equal(t, models.DBSnapshotKindInstance, automated.Kind)
+	assert.True(t, automated.SourceExists)
+	assert.Equal(t, "automated snapshot, removed by the backup retention period", automated.ProtectedReason)
+
+	legacy := byID["legacy-final"]
+	assert.False(t, legacy.SourceExists)
+	assert.False(t, legacy.Public)
+	assert.Empty(t, legacy.SharedWith)
+	assert.Empty(t, legacy.ProtectedReason)
+
+	export := byID["orders-export"]
+	assert.True(t, export.Public)
+	assert.Equal(t, "shared with other accounts", export.ProtectedReason)
+
+	cluster := byID["billing-pre-upgrade"]
+	assert.Equal(t, models.DBSnapshotKindCluster, cluster.Kind)
+	assert.True(t, cluster.SourceExists)
+	assert.True(t, cluster.Encrypted)
+	assert.Equal(t, []string{"222222222222", "999999999999"}, cluster.SharedWith)
+
+	assert.Equal(t, "managed by AWS Backup", byID["awsbackup:job-1"].ProtectedReason)
+}
+
+func TestDBSnapshotFinding(t *testing.T) {
+	orgAccounts := map[string]bool{"111111111111": true, "222222222222": true}
+
+	_, ok := dbSnapshotFinding(models.DBSnapshot{SnapshotID: "internal", SharedWith: []string{"222222222222"}}, orgAccounts)
+	assert.False(t, ok, "sharing inside the organization is not a finding")
+
+	finding, ok := dbSnapshotFinding(models.DBSnapshot{SnapshotID: "partner", Engine: "mysql", SharedWith: []string{"222222222222", "999999999999"}}, orgAccounts)
+	assert.True(t, ok)
+	assert.Equal(t, "high", finding.Severity)
+	assert.Equal(t, []string{"999999999999"}, finding.ExternalAccounts)
+	assert.Equal(t, "mysql snapshot partner is shared with 1 account(s) outside the organization (999999999999)", finding.Description)
+
+	finding, ok = dbSnapshotFinding(models.DBSnapshot{SnapshotID: "export", Engine: "postgres", Public: true}, orgAccounts)
+	assert.True(t, ok)
+	assert.Equal(t, "critical", finding.Severity)
+	assert.Equal(t, "postgres snapshot export can be restored by any AWS account", finding.Description)
+}
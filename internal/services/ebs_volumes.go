@@ -181,14 +181,13 @@ func (s *AWSService) ListEBSVolumesByAccount(accountID string) ([]models.EBSVolu
 		}
 	}
 
-	// Get all regions
-	ec2Client := ec2.New(sess)
-	regionsResult, err := ec2Client.DescribeRegions(&ec2.DescribeRegionsInput{})
+	scan, err := s.startRegionScan(scannerEBSVolumes, accountID, sess)
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe regions: %v", err)
+		return nil, err
 	}
-
-	if len(regionsResult.Regions) == 0 {
+	regions := scan.Regions()
+	if len(regions) == 0 {
+		scan.Finish()
 		return []models.EBSVolume{}, nil
 	}
 
@@ -197,7 +196,7 @@ func (s *AWSService) ListEBSVolumesByAccount(accountID string) ([]models.EBSVolu
 	var wg sync.WaitGroup
 
 	// Process each region in parallel
-	for _, region := range regionsResult.Regions {
+	for _, region := range regions {
 		wg.Add(1)
 		go func(r string) {
 			defer wg.Done()
@@ -211,6 +210,7 @@ func (s *AWSService) ListEBSVolumesByAccount(accountID string) ([]models.EBSVolu
 			result, err := ec2Client.DescribeVolumes(input)
 			if err != nil {
 				fmt.Printf("[WARNING] Failed to list volumes in %s for account %s: %v\n", r, accountID, err)
+				scan.Fail(r, err)
 				return
 			}
 
@@ -261,7 +261,7 @@ func (s *AWSService) ListEBSVolumesByAccount(accountID string) ([]models.EBSVolu
 				}
 
 				// Calculate monthly cost
-				monthlyCost := calculateEBSVolumeMonthlyCost(volumeType, size, iops, throughput)
+				monthlyCost, costSource := s.ebsVolumeMonthlyCost(r, volumeType, size, iops, throughput)
 
 				volume := models.EBSVolume{
 					VolumeID:         aws.StringValue(vol.VolumeId),
@@ -278,6 +278,7 @@ func (s *AWSService) ListEBSVolumesByAccount(accountID string) ([]models.EBSVolu
 					IOPS:             iops,
 					Throughput:       throughput,
 					MonthlyCost:      monthlyCost,
+					CostSource:       costSource,
 					Attachments:      attachments,
 					Tags:             tags,
 				}
@@ -292,10 +293,11 @@ func (s *AWSService) ListEBSVolumesByAccount(accountID string) ([]models.EBSVolu
 			mu.Lock()
 			allVolumes = append(allVolumes, regionVolumes...)
 			mu.Unlock()
-		}(*region.RegionName)
+		}(region)
 	}
 
 	wg.Wait()
+	scan.Finish()
 
 	// Cache the result
 	s.cache.Set(cacheKey, allVolumes, s.cacheTTL)
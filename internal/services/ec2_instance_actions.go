@@ -0,0 +1,409 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// ============================================================================
+// EC2 INSTANCE ACTIONS
+// ============================================================================
+
+// ErrEC2InstanceProtected is returned when termination or stop protection blocks an action
+var ErrEC2InstanceProtected = errors.New("instance is protected")
+
+// ec2InstanceActionPastTense describes a completed action
+var ec2InstanceActionPastTense = map[string]string{
+	models.EC2ActionStart:     "started",
+	models.EC2ActionStop:      "stopped",
+	models.EC2ActionReboot:    "rebooted",
+	models.EC2ActionHibernate: "hibernated",
+	models.EC2ActionTerminate: "terminated",
+	models.EC2ActionProtect:   "protection updated",
+	models.EC2ActionTag:       "tags updated",
+}
+
+// regionEC2Client returns an EC2 client for an account and region
+func (s *AWSService) regionEC2Client(accountID, region string) (ec2iface.EC2API, error) {
+	sess, err := s.getSessionForAccountAndRegion(accountID, region)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+	return ec2.New(sess), nil
+}
+
+// ec2InstanceActionError turns EC2 API errors into errors the handlers can map to status codes
+func ec2InstanceActionError(action, instanceID string, err error) error {
+	message := err.Error()
+	switch {
+	case strings.Contains(message, "OperationNotPermitted"):
+		protection := "stop"
+		if action == models.EC2ActionTerminate {
+			protection = "termination"
+		}
+		return fmt.Errorf("%w: cannot %s instance %s while %s protection is enabled", ErrEC2InstanceProtected, action, instanceID, protection)
+	case action == models.EC2ActionHibernate && (strings.Contains(message, "UnsupportedHibernationConfiguration") ||
+		strings.Contains(message, "UnsupportedOperation")):
+		return fmt.Errorf("invalid action: hibernation is not configured for instance %s", instanceID)
+	case strings.Contains(message, "InvalidInstanceID"):
+		return fmt.Errorf("instance %s not found", instanceID)
+	case strings.Contains(message, "IncorrectInstanceState"):
+		return fmt.Errorf("cannot %s instance %s in its current state: %v", action, instanceID, err)
+	}
+	return fmt.Errorf("failed to %s instance %s: %v", action, instanceID, err)
+}
+
+// applyEC2InstanceStateAction starts, stops, reboots, hibernates or terminates an instance and returns its new state.
+// Rebooting leaves the state unchanged and returns an empty state.
+func applyEC2InstanceStateAction(client ec2iface.EC2API, action, instanceID string) (string, error) {
+	ids := []*string{aws.String(instanceID)}
+
+	var err error
+	var newState string
+	switch action {
+	case models.EC2ActionStart:
+		_, err = client.StartInstances(&ec2.StartInstancesInput{InstanceIds: ids})
+		newState = "pending"
+	case models.EC2ActionStop:
+		_, err = client.StopInstances(&ec2.StopInstancesInput{InstanceIds: ids})
+		newState = "stopping"
+	case models.EC2ActionHibernate:
+		_, err = client.StopInstances(&ec2.StopInstancesInput{InstanceIds: ids, Hibernate: aws.Bool(true)})
+		newState = "stopping"
+	case models.EC2ActionReboot:
+		_, err = client.RebootInstances(&ec2.RebootInstancesInput{InstanceIds: ids})
+	case models.EC2ActionTerminate:
+		_, err = client.TerminateInstances(&ec2.TerminateInstancesInput{InstanceIds: ids})
+		newState = "terminating"
+	default:
+		return "", fmt.Errorf("invalid action %q", action)
+	}
+	if err != nil {
+		return "", ec2InstanceActionError(action, instanceID, err)
+	}
+	return newState, nil
+}
+
+// changeEC2InstanceState applies a state action to an instance and updates the cached instance
+func (s *AWSService) changeEC2InstanceState(accountID, region, instanceID, action string) error {
+	client, err := s.regionEC2Client(accountID, region)
+	if err != nil {
+		return err
+	}
+
+	newState, err := applyEC2InstanceStateAction(client, action, instanceID)
+	if err != nil {
+		return err
+	}
+	if newState != "" {
+		s.updateEC2InstanceStateInCache(instanceID, newState)
+	}
+	return nil
+}
+
+// StartEC2Instance starts a stopped instance
+func (s *AWSService) StartEC2Instance(accountID, region, instanceID string) error {
+	return s.changeEC2InstanceState(accountID, region, instanceID, models.EC2ActionStart)
+}
+
+// RebootEC2Instance reboots a running instance
+func (s *AWSService) RebootEC2Instance(accountID, region, instanceID string) error {
+	return s.changeEC2InstanceState(accountID, region, instanceID, models.EC2ActionReboot)
+}
+
+// HibernateEC2Instance hibernates an instance launched with hibernation enabled
+func (s *AWSService) HibernateEC2Instance(accountID, region, instanceID string) error {
+	return s.changeEC2InstanceState(accountID, region, instanceID, models.EC2ActionHibernate)
+}
+
+// validateEC2ProtectionInput checks that a protection change sets at least one flag
+func validateEC2ProtectionInput(input *models.EC2ProtectionInput) error {
+	if input == nil || (input.TerminationProtection == nil && input.StopProtection == nil) {
+		return fmt.Errorf("invalid protection change: set termination_protection or stop_protection")
+	}
+	return nil
+}
+
+// setEC2InstanceProtection changes the termination and stop protection of an instance
+func setEC2InstanceProtection(client ec2iface.EC2API, instanceID string, input models.EC2ProtectionInput) error {
+	if err := validateEC2ProtectionInput(&input); err != nil {
+		return err
+	}
+
+	if input.TerminationProtection != nil {
+		_, err := client.ModifyInstanceAttribute(&ec2.ModifyInstanceAttributeInput{
+			InstanceId:            aws.String(instanceID),
+			DisableApiTermination: &ec2.AttributeBooleanValue{Value: input.TerminationProtection},
+		})
+		if err != nil {
+			return ec2InstanceActionError(models.EC2ActionProtect, instanceID, err)
+		}
+	}
+	if input.StopProtection != nil {
+		_, err := client.ModifyInstanceAttribute(&ec2.ModifyInstanceAttributeInput{
+			InstanceId:     aws.String(instanceID),
+			DisableApiStop: &ec2.AttributeBooleanValue{Value: input.StopProtection},
+		})
+		if err != nil {
+			return ec2InstanceActionError(models.EC2ActionProtect, instanceID, err)
+		}
+	}
+	return nil
+}
+
+// SetEC2InstanceProtection enables or disables termination and stop protection of an instance
+func (s *AWSService) SetEC2InstanceProtection(accountID, region, instanceID string, input models.EC2ProtectionInput) error {
+	client, err := s.regionEC2Client(accountID, region)
+	if err != nil {
+		return err
+	}
+	return setEC2InstanceProtection(client, instanceID, input)
+}
+
+// validateEC2TagInput checks that a tag change has valid keys. Keys starting with "aws:" are reserved.
+func validateEC2TagInput(input *models.EC2TagInput) error {
+	if input == nil || (len(input.Add) == 0 && len(input.Remove) == 0) {
+		return fmt.Errorf("invalid tag change: set tags to add or remove")
+	}
+
+	keys := make([]string, 0, len(input.Add)+len(input.Remove))
+	for _, tag := range input.Add {
+		keys = append(keys, tag.Key)
+	}
+	keys = append(keys, input.Remove...)
+	for _, key := range keys {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("invalid tag change: tag keys cannot be empty")
+		}
+		if strings.HasPrefix(strings.ToLower(key), "aws:") {
+			return fmt.Errorf("invalid tag change: tag key %q is reserved", key)
+		}
+	}
+	return nil
+}
+
+// updateEC2InstanceTags adds and removes instance tags
+func updateEC2InstanceTags(client ec2iface.EC2API, instanceID string, input models.EC2TagInput) error {
+	if err := validateEC2TagInput(&input); err != nil {
+		return err
+	}
+
+	if len(input.Add) > 0 {
+		tags := make([]*ec2.Tag, 0, len(input.Add))
+		for _, tag := range input.Add {
+			tags = append(tags, &ec2.Tag{Key: aws.String(tag.Key), Value: aws.String(tag.Value)})
+		}
+		_, err := client.CreateTags(&ec2.CreateTagsInput{
+			Resources: []*string{aws.String(instanceID)},
+			Tags:      tags,
+		})
+		if err != nil {
+			return ec2InstanceActionError(models.EC2ActionTag, instanceID, err)
+		}
+	}
+
+	if len(input.Remove) > 0 {
+		tags := make([]*ec2.Tag, 0, len(input.Remove))
+		for _, key := range input.Remove {
+			tags = append(tags, &ec2.Tag{Key: aws.String(key)})
+		}
+		_, err := client.DeleteTags(&ec2.DeleteTagsInput{
+			Resources: []*string{aws.String(instanceID)},
+			Tags:      tags,
+		})
+		if err != nil {
+			return ec2InstanceActionError(models.EC2ActionTag, instanceID, err)
+		}
+	}
+	return nil
+}
+
+// applyEC2TagInput returns tags with a tag change applied
+func applyEC2TagInput(tags []models.Tag, input models.EC2TagInput) []models.Tag {
+	var updated []models.Tag
+	for _, tag := range tags {
+		if containsString(input.Remove, tag.Key) {
+			continue
+		}
+		replaced := false
+		for _, add := range input.Add {
+			if add.Key == tag.Key {
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			updated = append(updated, tag)
+		}
+	}
+	return append(updated, input.Add...)
+}
+
+// UpdateEC2InstanceTags adds or overwrites and removes tags of an instance
+func (s *AWSService) UpdateEC2InstanceTags(accountID, region, instanceID string, input models.EC2TagInput) error {
+	client, err := s.regionEC2Client(accountID, region)
+	if err != nil {
+		return err
+	}
+	if err := updateEC2InstanceTags(client, instanceID, input); err != nil {
+		return err
+	}
+
+	s.updateEC2InstanceInCache(instanceID, func(instance *models.EC2Instance) {
+		instance.Tags = applyEC2TagInput(instance.Tags, input)
+		instance.Name = ""
+		for _, tag := range instance.Tags {
+			if tag.Key == "Name" {
+				instance.Name = tag.Value
+			}
+		}
+	})
+	return nil
+}
+
+// describeEC2InstanceDetails returns an instance with its protection flags and shutdown behaviour
+func (s *AWSService) describeEC2InstanceDetails(client ec2iface.EC2API, account models.Account, region, instanceID string) (*models.EC2InstanceDetails, error) {
+	result, err := client.DescribeInstances(&ec2.DescribeInstancesInput{
+		InstanceIds: []*string{aws.String(instanceID)},
+	})
+	if err != nil {
+		return nil, ec2InstanceActionError("describe", instanceID, err)
+	}
+
+	var instance *ec2.Instance
+	for _, reservation := range result.Reservations {
+		for _, candidate := range reservation.Instances {
+			if aws.StringValue(candidate.InstanceId) == instanceID {
+				instance = candidate
+			}
+		}
+	}
+	if instance == nil {
+		return nil, fmt.Errorf("instance %s not found", instanceID)
+	}
+
+	details := &models.EC2InstanceDetails{EC2Instance: s.convertEC2Instance(account, region, instance)}
+
+	for _, attribute := range []string{
+		ec2.InstanceAttributeNameDisableApiTermination,
+		ec2.InstanceAttributeNameDisableApiStop,
+		ec2.InstanceAttributeNameInstanceInitiatedShutdownBehavior,
+	} {
+		output, err := client.DescribeInstanceAttribute(&ec2.DescribeInstanceAttributeInput{
+			InstanceId: aws.String(instanceID),
+			Attribute:  aws.String(attribute),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe %s of instance %s: %v", attribute, instanceID, err)
+		}
+
+		switch attribute {
+		case ec2.InstanceAttributeNameDisableApiTermination:
+			if output.DisableApiTermination != nil {
+				details.TerminationProtection = aws.BoolValue(output.DisableApiTermination.Value)
+			}
+		case ec2.InstanceAttributeNameDisableApiStop:
+			if output.DisableApiStop != nil {
+				details.StopProtection = aws.BoolValue(output.DisableApiStop.Value)
+			}
+		case ec2.InstanceAttributeNameInstanceInitiatedShutdownBehavior:
+			if output.InstanceInitiatedShutdownBehavior != nil {
+				details.ShutdownBehavior = aws.StringValue(output.InstanceInitiatedShutdownBehavior.Value)
+			}
+		}
+	}
+
+	return details, nil
+}
+
+// GetEC2Instance returns an instance with its network, IAM, image and protection details
+func (s *AWSService) GetEC2Instance(accountID, region, instanceID string) (*models.EC2InstanceDetails, error) {
+	client, err := s.regionEC2Client(accountID, region)
+	if err != nil {
+		return nil, err
+	}
+	account := models.Account{ID: accountID, Name: s.lookupAccountName(accountID)}
+	return s.describeEC2InstanceDetails(client, account, region, instanceID)
+}
+
+// applyEC2InstanceAction applies an action from a bulk request to one instance
+func (s *AWSService) applyEC2InstanceAction(ref models.EC2InstanceRef, input models.EC2BulkActionInput) error {
+	switch input.Action {
+	case models.EC2ActionProtect:
+		return s.SetEC2InstanceProtection(ref.AccountID, ref.Region, ref.InstanceID, *input.Protection)
+	case models.EC2ActionTag:
+		return s.UpdateEC2InstanceTags(ref.AccountID, ref.Region, ref.InstanceID, *input.Tags)
+	}
+	return s.changeEC2InstanceState(ref.AccountID, ref.Region, ref.InstanceID, input.Action)
+}
+
+// StartEC2BulkAction applies one action to several instances as a background job.
+// Instances whose protection blocks the action are skipped.
+func (s *AWSService) StartEC2BulkAction(input models.EC2BulkActionInput) (models.Job, error) {
+	if _, found := ec2InstanceActionPastTense[input.Action]; !found {
+		return models.Job{}, fmt.Errorf("invalid action %q", input.Action)
+	}
+	if len(input.Instances) == 0 {
+		return models.Job{}, fmt.Errorf("invalid request: no instances given")
+	}
+	for _, ref := range input.Instances {
+		if ref.AccountID == "" || ref.Region == "" || ref.InstanceID == "" {
+			return models.Job{}, fmt.Errorf("invalid request: instances need account_id, region and instance_id")
+		}
+	}
+	switch input.Action {
+	case models.EC2ActionProtect:
+		if err := validateEC2ProtectionInput(input.Protection); err != nil {
+			return models.Job{}, err
+		}
+	case models.EC2ActionTag:
+		if err := validateEC2TagInput(input.Tags); err != nil {
+			return models.Job{}, err
+		}
+	}
+
+	description := fmt.Sprintf("EC2 %s of %d instance(s)", input.Action, len(input.Instances))
+	if input.DryRun {
+		description = "Dry run: " + description
+	}
+
+	job := s.jobs.start("ec2_bulk_action", description, func(jc *jobContext) (any, error) {
+		jc.AddTotal(len(input.Instances))
+
+		for _, ref := range input.Instances {
+			result := models.JobItemResult{
+				AccountID:  ref.AccountID,
+				Region:     ref.Region,
+				ResourceID: ref.InstanceID,
+				Status:     "succeeded",
+				Message:    fmt.Sprintf("Instance %s %s", ref.InstanceID, ec2InstanceActionPastTense[input.Action]),
+			}
+
+			if input.DryRun {
+				result.Status = "skipped"
+				result.Message = fmt.Sprintf("Would %s %s", input.Action, ref.InstanceID)
+				jc.AddResult(result)
+				continue
+			}
+
+			if err := s.applyEC2InstanceAction(ref, input); err != nil {
+				result.Status = "failed"
+				if errors.Is(err, ErrEC2InstanceProtected) {
+					result.Status = "skipped"
+				}
+				result.Message = err.Error()
+			}
+			jc.AddResult(result)
+		}
+		return nil, nil
+	})
+
+	return job, nil
+}
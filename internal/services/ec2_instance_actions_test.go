@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInstanceEC2 records instance actions and fails them with a configured error
+type fakeInstanceEC2 struct {
+	ec2iface.EC2API
+	instance   *ec2.Instance
+	attributes map[string]*ec2.DescribeInstanceAttributeOutput
+	err        error
+
+	stopInput *ec2.StopInstancesInput
+	modified  []*ec2.ModifyInstanceAttributeInput
+	created   []*ec2.Tag
+	deleted   []*ec2.Tag
+}
+
+func (f *fakeInstanceEC2) StartInstances(input *ec2.StartInstancesInput) (*ec2.StartInstancesOutput, error) {
+	return &ec2.StartInstancesOutput{}, f.err
+}
+
+func (f *fakeInstanceEC2) StopInstances(input *ec2.StopInstancesInput) (*ec2.StopInstancesOutput, error) {
+	f.stopInput = input
+	return &ec2.StopInstancesOutput{}, f.err
+}
+
+func (f *fakeInstanceEC2) RebootInstances(input *ec2.RebootInstancesInput) (*ec2.RebootInstancesOutput, error) {
+	return &ec2.RebootInstancesOutput{}, f.err
+}
+
+func (f *fakeInstanceEC2) TerminateInstances(input *ec2.TerminateInstancesInput) (*ec2.TerminateInstancesOutput, error) {
+	return &ec2.TerminateInstancesOutput{}, f.err
+}
+
+func (f *fakeInstanceEC2) ModifyInstanceAttribute(input *ec2.ModifyInstanceAttributeInput) (*ec2.ModifyInstanceAttributeOutput, error) {
+	f.modified = append(f.modified, input)
+	return &ec2.ModifyInstanceAttributeOutput{}, f.err
+}
+
+func (f *fakeInstanceEC2) CreateTags(input *ec2.CreateTagsInput) (*ec2.CreateTagsOutput, error) {
+	f.created = append(f.created, input.Tags...)
+	return &ec2.CreateTagsOutput{}, f.err
+}
+
+func (f *fakeInstanceEC2) DeleteTags(input *ec2.DeleteTagsInput) (*ec2.DeleteTagsOutput, error) {
+	f.deleted = append(f.deleted, input.Tags...)
+	return &ec2.DeleteTagsOutput{}, f.err
+}
+
+func (f *fakeInstanceEC2) DescribeInstances(input *ec2.DescribeInstancesInput) (*ec2.DescribeInstancesOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if f.instance == nil {
+		return &ec2.DescribeInstancesOutput{}, nil
+	}
+	return &ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{{Instances: []*ec2.Instance{f.instance}}}}, nil
+}
+
+func (f *fakeInstanceEC2) DescribeInstanceAttribute(input *ec2.DescribeInstanceAttributeInput) (*ec2.DescribeInstanceAttributeOutput, error) {
+	if output, found := f.attributes[aws.StringValue(input.Attribute)]; found {
+		return output, nil
+	}
+	return &ec2.DescribeInstanceAttributeOutput{}, nil
+}
+
+func TestApplyEC2InstanceStateAction(t *testing.T) {
+	client := &fakeInstanceEC2{}
+
+	state, err := applyEC2InstanceStateAction(client, models.EC2ActionHibernate, "i-1")
+	require.NoError(t, err)
+	assert.Equal(t, "stopping", state)
+	assert.True(t, aws.BoolValue(client.stopInput.Hibernate))
+
+	state, err = applyEC2InstanceStateAction(client, models.EC2ActionReboot, "i-1")
+	require.NoError(t, err)
+	assert.Empty(t, state)
+
+	_, err = applyEC2InstanceStateAction(client, "resize", "i-1")
+	assert.ErrorContains(t, err, "invalid action")
+}
+
+func TestEC2InstanceActionErrors(t *testing.T) {
+	client := &fakeInstanceEC2{err: awserr.New("OperationNotPermitted", "The instance 'i-1' may not be terminated.", nil)}
+	_, err := applyEC2InstanceStateAction(client, models.EC2ActionTerminate, "i-1")
+	assert.True(t, errors.Is(err, ErrEC2InstanceProtected))
+	assert.ErrorContains(t, err, "termination protection is enabled")
+
+	_, err = applyEC2InstanceStateAction(client, models.EC2ActionStop, "i-1")
+	assert.ErrorContains(t, err, "stop protection is enabled")
+
+	client.err = awserr.New("UnsupportedOperation", "hibernation is not enabled", nil)
+	_, err = applyEC2InstanceStateAction(client, models.EC2ActionHibernate, "i-1")
+	assert.ErrorContains(t, err, "invalid action: hibernation is not configured")
+
+	client.err = awserr.New("InvalidInstanceID.NotFound", "The instance ID 'i-1' does not exist", nil)
+	_, err = applyEC2InstanceStateAction(client, models.EC2ActionStart, "i-1")
+	assert.EqualError(t, err, "instance i-1 not found")
+}
+
+func TestSetEC2InstanceProtection(t *testing.T) {
+	client := &fakeInstanceEC2{}
+
+	err := setEC2InstanceProtection(client, "i-1", models.EC2ProtectionInput{})
+	assert.ErrorContains(t, err, "invalid protection change")
+	assert.Empty(t, client.modified)
+
+	require.NoError(t, setEC2InstanceProtection(client, "i-1", models.EC2ProtectionInput{
+		TerminationProtection: aws.Bool(true),
+		StopProtection:        aws.Bool(false),
+	}))
+	require.Len(t, client.modified, 2)
+	assert.True(t, aws.BoolValue(client.modified[0].DisableApiTermination.Value))
+	assert.Nil(t, client.modified[0].DisableApiStop)
+	assert.False(t, aws.BoolValue(client.modified[1].DisableApiStop.Value))
+}
+
+func TestUpdateEC2InstanceTags(t *testing.T) {
+	client := &fakeInstanceEC2{}
+
+	err := updateEC2InstanceTags(client, "i-1", models.EC2TagInput{Add: []models.Tag{{Key: "aws:cloudformation:stack-name", Value: "x"}}})
+	assert.ErrorContains(t, err, "reserved")
+
+	err = updateEC2InstanceTags(client, "i-1", models.EC2TagInput{Remove: []string{" "}})
+	assert.ErrorContains(t, err, "cannot be empty")
+
+	require.NoError(t, updateEC2InstanceTags(client, "i-1", models.EC2TagInput{
+		Add:    []models.Tag{{Key: "team", Value: "data"}},
+		Remove: []string{"temp"},
+	}))
+	require.Len(t, client.created, 1)
+	assert.Equal(t, "data", aws.StringValue(client.created[0].Value))
+	require.Len(t, client.deleted, 1)
+	assert.Equal(t, "temp", aws.StringValue(client.deleted[0].Key))
+}
+
+func TestApplyEC2TagInput(t *testing.T) {
+	tags := []models.Tag{{Key: "Name", Value: "web"}, {Key: "team", Value: "ops"}, {Key: "temp", Value: "1"}}
+	updated := applyEC2TagInput(tags, models.EC2TagInput{
+		Add:    []models.Tag{{Key: "team", Value: "data"}},
+		Remove: []string{"temp"},
+	})
+	assert.Equal(t, []models.Tag{{Key: "Name", Value: "web"}, {Key: "team", Value: "data"}}, updated)
+}
+
+func TestDescribeEC2InstanceDetails(t *testing.T) {
+	client := &fakeInstanceEC2{
+		instance: &ec2.Instance{
+			InstanceId:         aws.String("i-1"),
+			InstanceType:       aws.String("t3.micro"),
+			State:              &ec2.InstanceState{Name: aws.String("running")},
+			PlatformDetails:    aws.String("Windows"),
+			PrivateIpAddress:   aws.String("10.0.1.5"),
+			PublicIpAddress:    aws.String("54.1.2.3"),
+			VpcId:              aws.String("vpc-1"),
+			SubnetId:           aws.String("subnet-1"),
+			KeyName:            aws.String("ops"),
+			ImageId:            aws.String("ami-1"),
+			IamInstanceProfile: &ec2.IamInstanceProfile{Arn: aws.String("arn:aws:iam::123456789012:instance-profile/web")},
+			HibernationOptions: &ec2.HibernationOptions{Configured: aws.Bool(true)},
+			Tags:               []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("web")}},
+		},
+		attributes: map[string]*ec2.DescribeInstanceAttributeOutput{
+			ec2.InstanceAttributeNameDisableApiTermination: {DisableApiTermination: &ec2.AttributeBooleanValue{Value: aws.Bool(true)}},
+			ec2.InstanceAttributeNameInstanceInitiatedShutdownBehavior: {
+				InstanceInitiatedShutdownBehavior: &ec2.AttributeValue{Value: aws.String("stop")},
+			},
+		},
+	}
+	s := &AWSService{cache: NewCache()}
+
+	details, err := s.describeEC2InstanceDetails(client, models.Account{ID: "123456789012", Name: "prod"}, "us-east-1", "i-1")
+	require.NoError(t, err)
+	assert.Equal(t, "web", details.Name)
+	assert.Equal(t, "Windows", details.Platform)
+	assert.Equal(t, "54.1.2.3", details.PublicIP)
+	assert.Equal(t, "subnet-1", details.SubnetID)
+	assert.Equal(t, "arn:aws:iam::123456789012:instance-profile/web", details.IAMInstanceProfile)
+	assert.Equal(t, "ami-1", details.ImageID)
+	assert.True(t, details.HibernationConfigured)
+	assert.True(t, details.TerminationProtection)
+	assert.False(t, details.StopProtection)
+	assert.Equal(t, "stop", details.ShutdownBehavior)
+
+	client.instance = nil
+	_, err = s.describeEC2InstanceDetails(client, models.Account{ID: "123456789012"}, "us-east-1", "i-1")
+	assert.EqualError(t, err, "instance i-1 not found")
+}
+
+func TestStartEC2BulkActionValidation(t *testing.T) {
+	s := &AWSService{cache: NewCache()}
+	ref := models.EC2InstanceRef{AccountID: "123456789012", Region: "us-east-1", InstanceID: "i-1"}
+
+	_, err := s.StartEC2BulkAction(models.EC2BulkActionInput{Action: "resize", Instances: []models.EC2InstanceRef{ref}})
+	assert.ErrorContains(t, err, "invalid action")
+
+	_, err = s.StartEC2BulkAction(models.EC2BulkActionInput{Action: models.EC2ActionStop})
+	assert.ErrorContains(t, err, "no instances")
+
+	_, err = s.StartEC2BulkAction(models.EC2BulkActionInput{Action: models.EC2ActionStop, Instances: []models.EC2InstanceRef{{InstanceID: "i-1"}}})
+	assert.ErrorContains(t, err, "need account_id")
+
+	_, err = s.StartEC2BulkAction(models.EC2BulkActionInput{Action: models.EC2ActionProtect, Instances: []models.EC2InstanceRef{ref}})
+	assert.ErrorContains(t, err, "invalid protection change")
+
+	_, err = s.StartEC2BulkAction(models.EC2BulkActionInput{Action: models.EC2ActionTag, Instances: []models.EC2InstanceRef{ref}})
+	assert.ErrorContains(t, err, "invalid tag change")
+}
@@ -17,6 +17,147 @@ import (
 // EC2 INSTANCE MANAGEMENT
 // ============================================================================
 
+// builtinEC2Hourly holds EC2 On-Demand Linux prices per hour (US East N. Virginia baseline).
+// Prices are approximate and may vary by region.
+var builtinEC2Hourly = map[string]float64{
+	// t3 family (burstable performance)
+	"t3.nano":    0.0052,
+	"t3.micro":   0.0104,
+	"t3.small":   0.0208,
+	"t3.medium":  0.0416,
+	"t3.large":   0.0832,
+	"t3.xlarge":  0.1664,
+	"t3.2xlarge": 0.3328,
+	// t3a family
+	"t3a.nano":    0.0047,
+	"t3a.micro":   0.0094,
+	"t3a.small":   0.0188,
+	"t3a.medium":  0.0376,
+	"t3a.large":   0.0752,
+	"t3a.xlarge":  0.1504,
+	"t3a.2xlarge": 0.3008,
+	// t4g family (ARM-based)
+	"t4g.nano":    0.0034,
+	"t4g.micro":   0.0068,
+	"t4g.small":   0.0136,
+	"t4g.medium":  0.0272,
+	"t4g.large":   0.0544,
+	"t4g.xlarge":  0.1088,
+	"t4g.2xlarge": 0.2176,
+	// m5 family (general purpose)
+	"m5.large":    0.096,
+	"m5.xlarge":   0.192,
+	"m5.2xlarge":  0.384,
+	"m5.4xlarge":  0.768,
+	"m5.8xlarge":  1.536,
+	"m5.12xlarge": 2.304,
+	"m5.16xlarge": 3.072,
+	"m5.24xlarge": 4.608,
+	// m5a family
+	"m5a.large":    0.086,
+	"m5a.xlarge":   0.172,
+	"m5a.2xlarge":  0.344,
+	"m5a.4xlarge":  0.688,
+	"m5a.8xlarge":  1.376,
+	"m5a.12xlarge": 2.064,
+	"m5a.16xlarge": 2.752,
+	"m5a.24xlarge": 4.128,
+	// m6i family
+	"m6i.large":    0.096,
+	"m6i.xlarge":   0.192,
+	"m6i.2xlarge":  0.384,
+	"m6i.4xlarge":  0.768,
+	"m6i.8xlarge":  1.536,
+	"m6i.12xlarge": 2.304,
+	"m6i.16xlarge": 3.072,
+	"m6i.24xlarge": 4.608,
+	"m6i.32xlarge": 6.144,
+	// c5 family (compute optimized)
+	"c5.large":    0.085,
+	"c5.xlarge":   0.17,
+	"c5.2xlarge":  0.34,
+	"c5.4xlarge":  0.68,
+	"c5.9xlarge":  1.53,
+	"c5.12xlarge": 2.04,
+	"c5.18xlarge": 3.06,
+	"c5.24xlarge": 4.08,
+	// c5a family
+	"c5a.large":    0.077,
+	"c5a.xlarge":   0.154,
+	"c5a.2xlarge":  0.308,
+	"c5a.4xlarge":  0.616,
+	"c5a.8xlarge":  1.232,
+	"c5a.12xlarge": 1.848,
+	"c5a.16xlarge": 2.464,
+	"c5a.24xlarge": 3.696,
+	// c6i family
+	"c6i.large":    0.085,
+	"c6i.xlarge":   0.17,
+	"c6i.2xlarge":  0.34,
+	"c6i.4xlarge":  0.68,
+	"c6i.8xlarge":  1.36,
+	"c6i.12xlarge": 2.04,
+	"c6i.16xlarge": 2.72,
+	"c6i.24xlarge": 4.08,
+	"c6i.32xlarge": 5.44,
+	// r5 family (memory optimized)
+	"r5.large":    0.126,
+	"r5.xlarge":   0.252,
+	"r5.2xlarge":  0.504,
+	"r5.4xlarge":  1.008,
+	"r5.8xlarge":  2.016,
+	"r5.12xlarge": 3.024,
+	"r5.16xlarge": 4.032,
+	"r5.24xlarge": 6.048,
+	// r5a family
+	"r5a.large":    0.113,
+	"r5a.xlarge":   0.226,
+	"r5a.2xlarge":  0.452,
+	"r5a.4xlarge":  0.904,
+	"r5a.8xlarge":  1.808,
+	"r5a.12xlarge": 2.712,
+	"r5a.16xlarge": 3.616,
+	"r5a.24xlarge": 5.424,
+	// r6i family
+	"r6i.large":    0.126,
+	"r6i.xlarge":   0.252,
+	"r6i.2xlarge":  0.504,
+	"r6i.4xlarge":  1.008,
+	"r6i.8xlarge":  2.016,
+	"r6i.12xlarge": 3.024,
+	"r6i.16xlarge": 4.032,
+	"r6i.24xlarge": 6.048,
+	"r6i.32xlarge": 8.064,
+	// i3 family (storage optimized)
+	"i3.large":    0.156,
+	"i3.xlarge":   0.312,
+	"i3.2xlarge":  0.624,
+	"i3.4xlarge":  1.248,
+	"i3.8xlarge":  2.496,
+	"i3.16xlarge": 4.992,
+	// i3en family
+	"i3en.large":    0.216,
+	"i3en.xlarge":   0.432,
+	"i3en.2xlarge":  0.864,
+	"i3en.3xlarge":  1.296,
+	"i3en.6xlarge":  2.592,
+	"i3en.12xlarge": 5.184,
+	"i3en.24xlarge": 10.368,
+	// g4dn family (GPU)
+	"g4dn.xlarge":   0.526,
+	"g4dn.2xlarge":  0.752,
+	"g4dn.4xlarge":  1.204,
+	"g4dn.8xlarge":  2.176,
+	"g4dn.12xlarge": 3.108,
+	"g4dn.16xlarge": 4.352,
+	// p3 family (GPU)
+	"p3.2xlarge":  3.06,
+	"p3.8xlarge":  12.24,
+	"p3.16xlarge": 24.48,
+	// p4d family (GPU)
+	"p4d.24xlarge": 32.77,
+}
+
 // calculateEC2InstanceMonthlyCost calculates the monthly cost for an EC2 instance
 // Based on AWS EC2 On-Demand Linux pricing (US East N. Virginia region as baseline)
 // Pricing varies by region, but this provides a reasonable estimate
@@ -27,152 +168,11 @@ func calculateEC2InstanceMonthlyCost(instanceType, state string) float64 {
 		return 0.0
 	}
 
-	// EC2 On-Demand Linux pricing per hour (US East N. Virginia baseline)
-	// Prices are approximate and may vary by region
-	hourlyPricing := map[string]float64{
-		// t3 family (burstable performance)
-		"t3.nano":     0.0052,
-		"t3.micro":    0.0104,
-		"t3.small":    0.0208,
-		"t3.medium":   0.0416,
-		"t3.large":    0.0832,
-		"t3.xlarge":   0.1664,
-		"t3.2xlarge":  0.3328,
-		// t3a family
-		"t3a.nano":    0.0047,
-		"t3a.micro":   0.0094,
-		"t3a.small":   0.0188,
-		"t3a.medium":  0.0376,
-		"t3a.large":   0.0752,
-		"t3a.xlarge":  0.1504,
-		"t3a.2xlarge": 0.3008,
-		// t4g family (ARM-based)
-		"t4g.nano":    0.0034,
-		"t4g.micro":   0.0068,
-		"t4g.small":   0.0136,
-		"t4g.medium":  0.0272,
-		"t4g.large":   0.0544,
-		"t4g.xlarge":  0.1088,
-		"t4g.2xlarge": 0.2176,
-		// m5 family (general purpose)
-		"m5.large":    0.096,
-		"m5.xlarge":   0.192,
-		"m5.2xlarge":  0.384,
-		"m5.4xlarge":  0.768,
-		"m5.8xlarge":  1.536,
-		"m5.12xlarge": 2.304,
-		"m5.16xlarge": 3.072,
-		"m5.24xlarge": 4.608,
-		// m5a family
-		"m5a.large":    0.086,
-		"m5a.xlarge":   0.172,
-		"m5a.2xlarge":  0.344,
-		"m5a.4xlarge":  0.688,
-		"m5a.8xlarge":  1.376,
-		"m5a.12xlarge": 2.064,
-		"m5a.16xlarge": 2.752,
-		"m5a.24xlarge": 4.128,
-		// m6i family
-		"m6i.large":    0.096,
-		"m6i.xlarge":   0.192,
-		"m6i.2xlarge":  0.384,
-		"m6i.4xlarge":  0.768,
-		"m6i.8xlarge":  1.536,
-		"m6i.12xlarge": 2.304,
-		"m6i.16xlarge": 3.072,
-		"m6i.24xlarge": 4.608,
-		"m6i.32xlarge": 6.144,
-		// c5 family (compute optimized)
-		"c5.large":    0.085,
-		"c5.xlarge":   0.17,
-		"c5.2xlarge":  0.34,
-		"c5.4xlarge":  0.68,
-		"c5.9xlarge":  1.53,
-		"c5.12xlarge": 2.04,
-		"c5.18xlarge": 3.06,
-		"c5.24xlarge": 4.08,
-		// c5a family
-		"c5a.large":    0.077,
-		"c5a.xlarge":   0.154,
-		"c5a.2xlarge":  0.308,
-		"c5a.4xlarge":  0.616,
-		"c5a.8xlarge":  1.232,
-		"c5a.12xlarge": 1.848,
-		"c5a.16xlarge": 2.464,
-		"c5a.24xlarge": 3.696,
-		// c6i family
-		"c6i.large":    0.085,
-		"c6i.xlarge":   0.17,
-		"c6i.2xlarge":  0.34,
-		"c6i.4xlarge":  0.68,
-		"c6i.8xlarge":  1.36,
-		"c6i.12xlarge": 2.04,
-		"c6i.16xlarge": 2.72,
-		"c6i.24xlarge": 4.08,
-		"c6i.32xlarge": 5.44,
-		// r5 family (memory optimized)
-		"r5.large":    0.126,
-		"r5.xlarge":   0.252,
-		"r5.2xlarge":  0.504,
-		"r5.4xlarge":  1.008,
-		"r5.8xlarge":  2.016,
-		"r5.12xlarge": 3.024,
-		"r5.16xlarge": 4.032,
-		"r5.24xlarge": 6.048,
-		// r5a family
-		"r5a.large":    0.113,
-		"r5a.xlarge":   0.226,
-		"r5a.2xlarge":  0.452,
-		"r5a.4xlarge":  0.904,
-		"r5a.8xlarge":  1.808,
-		"r5a.12xlarge": 2.712,
-		"r5a.16xlarge": 3.616,
-		"r5a.24xlarge": 5.424,
-		// r6i family
-		"r6i.large":    0.126,
-		"r6i.xlarge":   0.252,
-		"r6i.2xlarge":  0.504,
-		"r6i.4xlarge":  1.008,
-		"r6i.8xlarge":  2.016,
-		"r6i.12xlarge": 3.024,
-		"r6i.16xlarge": 4.032,
-		"r6i.24xlarge": 6.048,
-		"r6i.32xlarge": 8.064,
-		// i3 family (storage optimized)
-		"i3.large":    0.156,
-		"i3.xlarge":   0.312,
-		"i3.2xlarge":  0.624,
-		"i3.4xlarge":  1.248,
-		"i3.8xlarge":  2.496,
-		"i3.16xlarge": 4.992,
-		// i3en family
-		"i3en.large":    0.216,
-		"i3en.xlarge":   0.432,
-		"i3en.2xlarge":  0.864,
-		"i3en.3xlarge":  1.296,
-		"i3en.6xlarge":  2.592,
-		"i3en.12xlarge": 5.184,
-		"i3en.24xlarge": 10.368,
-		// g4dn family (GPU)
-		"g4dn.xlarge":   0.526,
-		"g4dn.2xlarge":  0.752,
-		"g4dn.4xlarge":  1.204,
-		"g4dn.8xlarge":  2.176,
-		"g4dn.12xlarge": 3.108,
-		"g4dn.16xlarge": 4.352,
-		// p3 family (GPU)
-		"p3.2xlarge":  3.06,
-		"p3.8xlarge":  12.24,
-		"p3.16xlarge": 24.48,
-		// p4d family (GPU)
-		"p4d.24xlarge": 32.77,
-	}
-
 	// Normalize instance type (lowercase)
 	instanceTypeLower := strings.ToLower(instanceType)
 
 	// Look up pricing
-	hourlyRate, found := hourlyPricing[instanceTypeLower]
+	hourlyRate, found := builtinEC2Hourly[instanceTypeLower]
 	if !found {
 		// For unknown instance types, try to estimate based on pattern
 		// This is a rough heuristic - actual pricing may vary
@@ -278,14 +278,13 @@ func (s *AWSService) getEC2InstancesForAccount(account models.Account) ([]models
 		return nil, fmt.Errorf("cannot access account %s: %w", account.ID, err)
 	}
 
-	// Get all regions
-	ec2Client := ec2.New(sess)
-	regionsResult, err := ec2Client.DescribeRegions(&ec2.DescribeRegionsInput{})
+	scan, err := s.startRegionScan(scannerEC2Instances, account.ID, sess)
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe regions: %v", err)
+		return nil, err
 	}
-
-	if len(regionsResult.Regions) == 0 {
+	regions := scan.Regions()
+	if len(regions) == 0 {
+		scan.Finish()
 		return []models.EC2Instance{}, nil
 	}
 
@@ -295,11 +294,11 @@ func (s *AWSService) getEC2InstancesForAccount(account models.Account) ([]models
 		regionName string
 	}
 
-	resultChan := make(chan regionResult, len(regionsResult.Regions))
+	resultChan := make(chan regionResult, len(regions))
 	var wg sync.WaitGroup
 
 	// Process each region in parallel
-	for _, region := range regionsResult.Regions {
+	for _, region := range regions {
 		wg.Add(1)
 		go func(regionName string) {
 			defer wg.Done()
@@ -309,6 +308,7 @@ func (s *AWSService) getEC2InstancesForAccount(account models.Account) ([]models
 			regionInstances, err := s.getEC2InstancesForRegion(regionSess, account, regionName)
 			if err != nil {
 				fmt.Printf("[WARNING] Failed to get instances in region %s for account %s: %v\n", regionName, account.ID, err)
+				scan.Fail(regionName, err)
 				regionInstances = []models.EC2Instance{}
 			}
 
@@ -316,12 +316,13 @@ func (s *AWSService) getEC2InstancesForAccount(account models.Account) ([]models
 				instances:  regionInstances,
 				regionName: regionName,
 			}
-		}(*region.RegionName)
+		}(region)
 	}
 
 	// Wait for all goroutines to complete and close channel
 	go func() {
 		wg.Wait()
+		scan.Finish()
 		close(resultChan)
 	}()
 
@@ -355,47 +356,7 @@ func (s *AWSService) getEC2InstancesForRegion(sess *session.Session, account mod
 		// Process instances from this page
 		for _, reservation := range result.Reservations {
 			for _, instance := range reservation.Instances {
-				// Extract instance name from tags
-				instanceName := ""
-				for _, tag := range instance.Tags {
-					if *tag.Key == "Name" {
-						instanceName = *tag.Value
-						break
-					}
-				}
-
-				// Convert tags
-				var tags []models.Tag
-				for _, tag := range instance.Tags {
-					tags = append(tags, models.Tag{
-						Key:   aws.StringValue(tag.Key),
-						Value: aws.StringValue(tag.Value),
-					})
-				}
-
-				instanceType := aws.StringValue(instance.InstanceType)
-				instanceState := aws.StringValue(instance.State.Name)
-				
-				// Calculate monthly cost
-				monthlyCost := calculateEC2InstanceMonthlyCost(instanceType, instanceState)
-
-				ec2Instance := models.EC2Instance{
-					InstanceID:   aws.StringValue(instance.InstanceId),
-					Name:         instanceName,
-					AccountID:    account.ID,
-					AccountName:  account.Name,
-					Region:       region,
-					InstanceType: instanceType,
-					State:        instanceState,
-					MonthlyCost:  monthlyCost,
-					Tags:         tags,
-				}
-
-				if instance.LaunchTime != nil {
-					ec2Instance.LaunchTime = *instance.LaunchTime
-				}
-
-				instances = append(instances, ec2Instance)
+				instances = append(instances, s.convertEC2Instance(account, region, instance))
 			}
 		}
 
@@ -409,6 +370,71 @@ func (s *AWSService) getEC2InstancesForRegion(sess *session.Session, account mod
 	return instances, nil
 }
 
+// convertEC2Instance converts a DescribeInstances result to the API model
+func (s *AWSService) convertEC2Instance(account models.Account, region string, instance *ec2.Instance) models.EC2Instance {
+	// Extract instance name from tags
+	instanceName := ""
+	for _, tag := range instance.Tags {
+		if aws.StringValue(tag.Key) == "Name" {
+			instanceName = aws.StringValue(tag.Value)
+			break
+		}
+	}
+
+	// Convert tags
+	var tags []models.Tag
+	for _, tag := range instance.Tags {
+		tags = append(tags, models.Tag{
+			Key:   aws.StringValue(tag.Key),
+			Value: aws.StringValue(tag.Value),
+		})
+	}
+
+	instanceState := ""
+	if instance.State != nil {
+		instanceState = aws.StringValue(instance.State.Name)
+	}
+
+	// Calculate monthly cost
+	monthlyCost, costSource := s.ec2InstanceMonthlyCost(region, instance)
+
+	ec2Instance := models.EC2Instance{
+		InstanceID:   aws.StringValue(instance.InstanceId),
+		Name:         instanceName,
+		AccountID:    account.ID,
+		AccountName:  account.Name,
+		Region:       region,
+		InstanceType: aws.StringValue(instance.InstanceType),
+		State:        instanceState,
+		Platform:     aws.StringValue(instance.PlatformDetails),
+		PrivateIP:    aws.StringValue(instance.PrivateIpAddress),
+		PublicIP:     aws.StringValue(instance.PublicIpAddress),
+		VpcID:        aws.StringValue(instance.VpcId),
+		SubnetID:     aws.StringValue(instance.SubnetId),
+		KeyName:      aws.StringValue(instance.KeyName),
+		ImageID:      aws.StringValue(instance.ImageId),
+		MonthlyCost:  monthlyCost,
+		CostSource:   costSource,
+		Tags:         tags,
+	}
+
+	if instance.LaunchTime != nil {
+		ec2Instance.LaunchTime = *instance.LaunchTime
+	}
+	if instance.Placement != nil {
+		ec2Instance.Tenancy = aws.StringValue(instance.Placement.Tenancy)
+	}
+	ec2Instance.UsageOperation = aws.StringValue(instance.UsageOperation)
+	if instance.IamInstanceProfile != nil {
+		ec2Instance.IAMInstanceProfile = aws.StringValue(instance.IamInstanceProfile.Arn)
+	}
+	if instance.HibernationOptions != nil {
+		ec2Instance.HibernationConfigured = aws.BoolValue(instance.HibernationOptions.Configured)
+	}
+
+	return ec2Instance
+}
+
 // InvalidateEC2InstancesCache invalidates the EC2 instances cache
 func (s *AWSService) InvalidateEC2InstancesCache() {
 	s.cache.Delete("ec2-instances")
@@ -416,14 +442,21 @@ func (s *AWSService) InvalidateEC2InstancesCache() {
 
 // updateEC2InstanceStateInCache updates a specific instance's state in the cache
 func (s *AWSService) updateEC2InstanceStateInCache(instanceID, newState string) {
+	s.updateEC2InstanceInCache(instanceID, func(instance *models.EC2Instance) {
+		instance.State = newState
+	})
+}
+
+// updateEC2InstanceInCache applies an update to a specific cached instance
+func (s *AWSService) updateEC2InstanceInCache(instanceID string, update func(*models.EC2Instance)) {
 	const cacheKey = "ec2-instances"
 
 	if cached, found := s.cache.Get(cacheKey); found {
 		if instances, ok := cached.([]models.EC2Instance); ok {
-			// Update the specific instance's state
+			// Update the specific instance
 			for i := range instances {
 				if instances[i].InstanceID == instanceID {
-					instances[i].State = newState
+					update(&instances[i])
 					break
 				}
 			}
@@ -435,52 +468,13 @@ func (s *AWSService) updateEC2InstanceStateInCache(instanceID, newState string)
 
 // StopEC2Instance stops an EC2 instance
 func (s *AWSService) StopEC2Instance(accountID, region, instanceID string) error {
-	sess, err := s.getSessionForAccount(accountID)
-	if err != nil {
-		return fmt.Errorf("cannot access account %s: %w", accountID, err)
-	}
-
-	// Create session for the specific region
-	regionSess := sess.Copy(&aws.Config{Region: aws.String(region)})
-	ec2Client := ec2.New(regionSess)
-
-	// Stop the instance
-	_, err = ec2Client.StopInstances(&ec2.StopInstancesInput{
-		InstanceIds: []*string{aws.String(instanceID)},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to stop instance %s: %v", instanceID, err)
-	}
-
-	// Update the instance state in cache instead of invalidating
-	s.updateEC2InstanceStateInCache(instanceID, "stopping")
-
-	return nil
+	return s.changeEC2InstanceState(accountID, region, instanceID, models.EC2ActionStop)
 }
 
-// TerminateEC2Instance terminates an EC2 instance
+// TerminateEC2Instance terminates an EC2 instance. Instances with termination protection
+// return ErrEC2InstanceProtected.
 func (s *AWSService) TerminateEC2Instance(accountID, region, instanceID string) error {
-	sess, err := s.getSessionForAccount(accountID)
-	if err != nil {
-		return fmt.Errorf("cannot access account %s: %w", accountID, err)
-	}
-
-	// Create session for the specific region
-	regionSess := sess.Copy(&aws.Config{Region: aws.String(region)})
-	ec2Client := ec2.New(regionSess)
-
-	// Terminate the instance
-	_, err = ec2Client.TerminateInstances(&ec2.TerminateInstancesInput{
-		InstanceIds: []*string{aws.String(instanceID)},
-	})
-	if err != nil {
-		return fmt.Errorf("failed to terminate instance %s: %v", instanceID, err)
-	}
-
-	// Update the instance state in cache instead of invalidating
-	s.updateEC2InstanceStateInCache(instanceID, "terminating")
-
-	return nil
+	return s.changeEC2InstanceState(accountID, region, instanceID, models.EC2ActionTerminate)
 }
 
 // ============================================================================
@@ -0,0 +1,368 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+	"github.com/rusik69/aws-iam-manager/internal/pricing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+)
+
+// ============================================================================
+// EC2 UTILIZATION ANALYSIS
+// ============================================================================
+//
+// Running instances are classified from hourly CloudWatch CPU and network metrics and, when the
+// CloudWatch agent publishes it, memory. Idle instances barely use CPU or network and could be
+// stopped; underutilized instances would fit a smaller size of the same family. Savings come from
+// the same prices as the instance cost estimates.
+
+const (
+	idleCPUAverage        = 2.0  // Percent
+	idleCPUPeak           = 5.0  // Percent, highest hourly average
+	idleNetworkMBPerDay   = 5.0  // Network in plus out
+	underutilizedPeak     = 40.0 // Percent of CPU and memory
+	rightsizeTargetPeak   = 60.0 // Projected peak the suggested type may reach
+	minUtilizationHours   = 24
+	ec2UtilizationWorkers = 10
+	maxEC2UtilizationDays = 90
+)
+
+// ec2SizeUnits is the relative capacity of each instance size, using the normalization factors
+// AWS applies to reserved instances: each size up doubles capacity.
+var ec2SizeUnits = map[string]float64{
+	"nano": 0.25, "micro": 0.5, "small": 1, "medium": 2, "large": 4, "xlarge": 8,
+	"2xlarge": 16, "3xlarge": 24, "4xlarge": 32, "6xlarge": 48, "8xlarge": 64, "9xlarge": 72,
+	"10xlarge": 80, "12xlarge": 96, "16xlarge": 128, "18xlarge": 144, "24xlarge": 192,
+	"32xlarge": 256, "48xlarge": 384,
+}
+
+// ec2SizeOrder lists the sizes from smallest to largest
+var ec2SizeOrder = []string{
+	"nano", "micro", "small", "medium", "large", "xlarge", "2xlarge", "3xlarge", "4xlarge",
+	"6xlarge", "8xlarge", "9xlarge", "10xlarge", "12xlarge", "16xlarge", "18xlarge",
+	"24xlarge", "32xlarge", "48xlarge",
+}
+
+// AnalyzeEC2Utilization starts a background job that classifies every running instance over the
+// last windowDays; zero uses EC2_UTILIZATION_DAYS
+func (s *AWSService) AnalyzeEC2Utilization(windowDays int) (models.Job, error) {
+	if windowDays == 0 {
+		windowDays = s.config.EC2UtilizationDays
+	}
+	if windowDays <= 0 || windowDays > maxEC2UtilizationDays {
+		return models.Job{}, fmt.Errorf("invalid window: days must be between 1 and %d", maxEC2UtilizationDays)
+	}
+
+	instances, err := s.ListEC2Instances()
+	if err != nil {
+		return models.Job{}, err
+	}
+	var running []models.EC2Instance
+	for _, instance := range instances {
+		if instance.State == "running" {
+			running = append(running, instance)
+		}
+	}
+
+	description := fmt.Sprintf("EC2 utilization analysis over %d days", windowDays)
+	job := s.jobs.start("ec2_utilization", description, func(jc *jobContext) (any, error) {
+		jc.AddTotal(len(running))
+		end := time.Now().Truncate(time.Hour)
+		start := end.AddDate(0, 0, -windowDays)
+
+		findings := make([]models.EC2UtilizationFinding, len(running))
+		sem := make(chan struct{}, ec2UtilizationWorkers)
+		var wg sync.WaitGroup
+		for i, instance := range running {
+			wg.Add(1)
+			go func(i int, instance models.EC2Instance) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+
+				finding := s.analyzeEC2InstanceUtilization(instance, start, end)
+				findings[i] = finding
+
+				result := models.JobItemResult{
+					AccountID:  instance.AccountID,
+					Region:     instance.Region,
+					ResourceID: instance.InstanceID,
+					Status:     "succeeded",
+					Message:    finding.Classification,
+				}
+				if finding.Error != "" {
+					result.Status, result.Message = "failed", finding.Error
+				}
+				jc.AddResult(result)
+			}(i, instance)
+		}
+		wg.Wait()
+
+		return buildEC2UtilizationReport(findings, windowDays), nil
+	})
+	return job, nil
+}
+
+// analyzeEC2InstanceUtilization reads an instance's metrics and classifies it
+func (s *AWSService) analyzeEC2InstanceUtilization(instance models.EC2Instance, start, end time.Time) models.EC2UtilizationFinding {
+	finding := models.EC2UtilizationFinding{
+		InstanceID:   instance.InstanceID,
+		Name:         instance.Name,
+		AccountID:    instance.AccountID,
+		AccountName:  instance.AccountName,
+		Region:       instance.Region,
+		InstanceType: instance.InstanceType,
+		Platform:     instance.Platform,
+		LaunchTime:   instance.LaunchTime,
+		MonthlyCost:  instance.MonthlyCost,
+		CostSource:   instance.CostSource,
+	}
+
+	sess, err := s.getSessionForAccountAndRegion(instance.AccountID, instance.Region)
+	if err != nil {
+		finding.Classification = models.EC2UtilizationInsufficientData
+		finding.Error = fmt.Sprintf("cannot access account %s: %v", instance.AccountID, err)
+		return finding
+	}
+
+	metrics, err := collectEC2UtilizationMetrics(cloudwatch.New(sess), instance.InstanceID, start, end)
+	if err != nil {
+		fmt.Printf("[WARNING] Failed to read CloudWatch metrics for instance %s in account %s: %v\n", instance.InstanceID, instance.AccountID, err)
+		finding.Classification = models.EC2UtilizationInsufficientData
+		finding.Error = err.Error()
+		return finding
+	}
+	finding.Metrics = metrics
+
+	classifyEC2Utilization(&finding, func(instanceType string) (float64, string, bool) {
+		return s.ec2InstanceTypeMonthlyCost(instance.Region, instanceType, instance.Platform, instance.Tenancy, instance.UsageOperation)
+	})
+	return finding
+}
+
+// collectEC2UtilizationMetrics reads hourly CPU, network and agent memory metrics of an instance
+func collectEC2UtilizationMetrics(client cloudwatchiface.CloudWatchAPI, instanceID string, start, end time.Time) (models.EC2UtilizationMetrics, error) {
+	dimensions := []*cloudwatch.Dimension{{Name: aws.String("InstanceId"), Value: aws.String(instanceID)}}
+	metricQuery := func(id, name, stat string) *cloudwatch.MetricDataQuery {
+		return &cloudwatch.MetricDataQuery{
+			Id: aws.String(id),
+			MetricStat: &cloudwatch.MetricStat{
+				Metric: &cloudwatch.Metric{
+					Namespace:  aws.String("AWS/EC2"),
+					MetricName: aws.String(name),
+					Dimensions: dimensions,
+				},
+				Period: aws.Int64(3600),
+				Stat:   aws.String(stat),
+			},
+		}
+	}
+
+	// The agent's memory metric carries whatever dimensions it was configured with, so it is found by search
+	memorySearch := fmt.Sprintf(`SEARCH('Namespace="CWAgent" (MetricName="mem_used_percent" OR MetricName="Memory %% Committed Bytes In Use") InstanceId="%s"', 'Average', 3600)`, instanceID)
+
+	input := &cloudwatch.GetMetricDataInput{
+		StartTime: aws.Time(start),
+		EndTime:   aws.Time(end),
+		MetricDataQueries: []*cloudwatch.MetricDataQuery{
+			metricQuery("cpu", "CPUUtilization", "Average"),
+			metricQuery("netin", "NetworkIn", "Sum"),
+			metricQuery("netout", "NetworkOut", "Sum"),
+			{Id: aws.String("memory"), Expression: aws.String(memorySearch)},
+		},
+	}
+
+	values := make(map[string][]float64)
+	err := client.GetMetricDataPages(input, func(page *cloudwatch.GetMetricDataOutput, lastPage bool) bool {
+		for _, result := range page.MetricDataResults {
+			id := aws.StringValue(result.Id)
+			values[id] = append(values[id], aws.Float64ValueSlice(result.Values)...)
+		}
+		return true
+	})
+	if err != nil {
+		return models.EC2UtilizationMetrics{}, fmt.Errorf("failed to get metric data: %w", err)
+	}
+
+	return summarizeEC2UtilizationMetrics(values), nil
+}
+
+// summarizeEC2UtilizationMetrics turns hourly values by query ID into averages, peaks and daily network volume
+func summarizeEC2UtilizationMetrics(values map[string][]float64) models.EC2UtilizationMetrics {
+	cpu := values["cpu"]
+	metrics := models.EC2UtilizationMetrics{Hours: len(cpu)}
+	if len(cpu) == 0 {
+		return metrics
+	}
+
+	metrics.CPUAverage, metrics.CPUPeak = averageAndPeak(cpu)
+
+	days := float64(len(cpu)) / 24
+	metrics.NetworkInMBPerDay = roundCost(sumValues(values["netin"]) / 1e6 / days)
+	metrics.NetworkOutMBPerDay = roundCost(sumValues(values["netout"]) / 1e6 / days)
+
+	if memory := values["memory"]; len(memory) > 0 {
+		average, peak := averageAndPeak(memory)
+		metrics.MemoryAverage = &average
+		metrics.MemoryPeak = &peak
+	}
+	return metrics
+}
+
+func averageAndPeak(values []float64) (float64, float64) {
+	peak := 0.0
+	for _, value := range values {
+		peak = math.Max(peak, value)
+	}
+	return roundCost(sumValues(values) / float64(len(values))), roundCost(peak)
+}
+
+func sumValues(values []float64) float64 {
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total
+}
+
+// classifyEC2Utilization sets the classification, reasons and suggested type of a finding whose
+// metrics are filled in. monthlyCost returns the cost of an instance type, its source and whether it is known.
+func classifyEC2Utilization(finding *models.EC2UtilizationFinding, monthlyCost func(instanceType string) (float64, string, bool)) {
+	metrics := finding.Metrics
+	current, source, priced := monthlyCost(finding.InstanceType)
+	if priced {
+		finding.MonthlyCost, finding.CostSource = current, source
+	}
+
+	if metrics.Hours < minUtilizationHours {
+		finding.Classification = models.EC2UtilizationInsufficientData
+		finding.Reasons = append(finding.Reasons, fmt.Sprintf("Only %d hours of CPU metrics; at least %d are needed", metrics.Hours, minUtilizationHours))
+		return
+	}
+
+	network := metrics.NetworkInMBPerDay + metrics.NetworkOutMBPerDay
+	if metrics.CPUAverage <= idleCPUAverage && metrics.CPUPeak <= idleCPUPeak && network <= idleNetworkMBPerDay {
+		finding.Classification = models.EC2UtilizationIdle
+		finding.Reasons = append(finding.Reasons,
+			fmt.Sprintf("Average CPU %.1f%% and peak %.1f%%", metrics.CPUAverage, metrics.CPUPeak),
+			fmt.Sprintf("Network %.1f MB per day", network))
+		finding.EstimatedMonthlySavings = finding.MonthlyCost
+		return
+	}
+
+	memoryLow := metrics.MemoryPeak == nil || *metrics.MemoryPeak <= underutilizedPeak
+	if metrics.CPUPeak > underutilizedPeak || !memoryLow {
+		finding.Classification = models.EC2UtilizationOptimized
+		return
+	}
+
+	finding.Classification = models.EC2UtilizationUnderutilized
+	finding.Reasons = append(finding.Reasons, fmt.Sprintf("Peak CPU %.1f%%", metrics.CPUPeak))
+	if metrics.MemoryPeak != nil {
+		finding.Reasons = append(finding.Reasons, fmt.Sprintf("Peak memory %.1f%%", *metrics.MemoryPeak))
+	} else {
+		finding.Reasons = append(finding.Reasons, "Memory not reported by the CloudWatch agent; the suggestion is based on CPU only")
+	}
+
+	// Savings are only compared between prices from the same source
+	suggested, ok := suggestSmallerEC2InstanceType(finding.InstanceType, metrics, func(instanceType string) bool {
+		_, candidateSource, found := monthlyCost(instanceType)
+		return found && candidateSource == source
+	})
+	if !priced || !ok {
+		finding.Reasons = append(finding.Reasons, "No smaller size of the family with a known price")
+		return
+	}
+
+	suggestedCost, _, _ := monthlyCost(suggested)
+	finding.SuggestedInstanceType = suggested
+	finding.SuggestedMonthlyCost = suggestedCost
+	finding.EstimatedMonthlySavings = roundCost(math.Max(finding.MonthlyCost-suggestedCost, 0))
+}
+
+// suggestSmallerEC2InstanceType returns the smallest size of the instance's family whose projected
+// CPU and memory peaks stay under the target. Only sizes the price list knows are considered.
+func suggestSmallerEC2InstanceType(instanceType string, metrics models.EC2UtilizationMetrics, priced func(string) bool) (string, bool) {
+	family, size, found := strings.Cut(strings.ToLower(instanceType), ".")
+	currentUnits, known := ec2SizeUnits[size]
+	if !found || !known {
+		return "", false
+	}
+
+	peak := metrics.CPUPeak
+	if metrics.MemoryPeak != nil {
+		peak = math.Max(peak, *metrics.MemoryPeak)
+	}
+
+	for _, candidate := range ec2SizeOrder {
+		units := ec2SizeUnits[candidate]
+		if units >= currentUnits {
+			break
+		}
+		if peak*currentUnits/units > rightsizeTargetPeak {
+			continue
+		}
+		if candidateType := family + "." + candidate; priced(candidateType) {
+			return candidateType, true
+		}
+	}
+	return "", false
+}
+
+// ec2InstanceTypeMonthlyCost returns the monthly cost of a running instance type, its source and
+// whether a price is known. The instance's usage operation keeps BYOL instances on BYOL prices.
+// Without a price list entry the built-in table is used.
+func (s *AWSService) ec2InstanceTypeMonthlyCost(region, instanceType, platform, tenancy, usageOperation string) (float64, string, bool) {
+	if prices := s.prices.list(); prices != nil {
+		key := pricing.EC2KeyFor(region, instanceType, platform, tenancy, usageOperation)
+		if hourly, found := prices.EC2Hourly(key); found {
+			return roundCost(hourly * pricing.HoursPerMonth), models.CostSourcePriceList, true
+		}
+	}
+	if hourly, found := builtinEC2Hourly[strings.ToLower(instanceType)]; found {
+		return roundCost(hourly * pricing.HoursPerMonth), models.CostSourceBuiltin, true
+	}
+	return 0, models.CostSourceBuiltin, false
+}
+
+// buildEC2UtilizationReport counts classifications and orders findings by savings
+func buildEC2UtilizationReport(findings []models.EC2UtilizationFinding, windowDays int) models.EC2UtilizationReport {
+	report := models.EC2UtilizationReport{
+		GeneratedAt: time.Now(),
+		WindowDays:  windowDays,
+		Instances:   findings,
+	}
+	if report.Instances == nil {
+		report.Instances = []models.EC2UtilizationFinding{}
+	}
+
+	savings := 0.0
+	for _, finding := range findings {
+		switch finding.Classification {
+		case models.EC2UtilizationIdle:
+			report.Idle++
+		case models.EC2UtilizationUnderutilized:
+			report.Underutilized++
+		case models.EC2UtilizationOptimized:
+			report.Optimized++
+		default:
+			report.InsufficientData++
+		}
+		savings += finding.EstimatedMonthlySavings
+	}
+	report.EstimatedMonthlySavings = roundCost(savings)
+
+	sort.SliceStable(report.Instances, func(i, j int) bool {
+		return report.Instances[i].EstimatedMonthlySavings > report.Instances[j].EstimatedMonthlySavings
+	})
+	return report
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCloudWatch returns canned metric data in two pages
+type fakeCloudWatch struct {
+	cloudwatchiface.CloudWatchAPI
+	pages []*cloudwatch.GetMetricDataOutput
+	input *cloudwatch.GetMetricDataInput
+}
+
+func (f *fakeCloudWatch) GetMetricDataPages(input *cloudwatch.GetMetricDataInput, fn func(*cloudwatch.GetMetricDataOutput, bool) bool) error {
+	f.input = input
+	for i, page := range f.pages {
+		if !fn(page, i == len(f.pages)-1) {
+			break
+		}
+	}
+	return nil
+}
+
+func repeatValue(value float64, count int) []float64 {
+	values := make([]float64, count)
+	for i := range values {
+		values[i] = value
+	}
+	return values
+}
+
+// builtinMonthlyCost prices instance types from the built-in table
+func builtinMonthlyCost(instanceType string) (float64, string, bool) {
+	hourly, found := builtinEC2Hourly[instanceType]
+	return roundCost(hourly * 720), models.CostSourceBuiltin, found
+}
+
+func TestCollectEC2UtilizationMetrics(t *testing.T) {
+	client := &fakeCloudWatch{pages: []*cloudwatch.GetMetricDataOutput{
+		{MetricDataResults: []*cloudwatch.MetricDataResult{
+			{Id: aws.String("cpu"), Values: aws.Float64Slice(repeatValue(10, 24))},
+			{Id: aws.String("netin"), Values: aws.Float64Slice(repeatValue(1e6, 24))},
+		}},
+		{MetricDataResults: []*cloudwatch.MetricDataResult{
+			{Id: aws.String("cpu"), Values: aws.Float64Slice(append(repeatValue(20, 23), 50))},
+			{Id: aws.String("netout"), Values: aws.Float64Slice(repeatValue(5e5, 48))},
+			{Id: aws.String("memory"), Label: aws.String("mem_used_percent"), Values: aws.Float64Slice([]float64{30, 70})},
+		}},
+	}}
+
+	end := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	metrics, err := collectEC2UtilizationMetrics(client, "i-1", end.AddDate(0, 0, -14), end)
+	require.NoError(t, err)
+
+	require.Len(t, client.input.MetricDataQueries, 4)
+	assert.Contains(t, aws.StringValue(client.input.MetricDataQueries[3].Expression), `InstanceId="i-1"`)
+
+	assert.Equal(t, 48, metrics.Hours)
+	assert.Equal(t, 15.63, metrics.CPUAverage)
+	assert.Equal(t, 50.0, metrics.CPUPeak)
+	assert.Equal(t, 12.0, metrics.NetworkInMBPerDay)
+	assert.Equal(t, 12.0, metrics.NetworkOutMBPerDay)
+	require.NotNil(t, metrics.MemoryPeak)
+	assert.Equal(t, 50.0, *metrics.MemoryAverage)
+	assert.Equal(t, 70.0, *metrics.MemoryPeak)
+}
+
+func TestClassifyEC2UtilizationIdle(t *testing.T) {
+	finding := models.EC2UtilizationFinding{
+		InstanceType: "m5.xlarge",
+		Metrics:      models.EC2UtilizationMetrics{Hours: 336, CPUAverage: 0.8, CPUPeak: 3, NetworkInMBPerDay: 1, NetworkOutMBPerDay: 2},
+	}
+	classifyEC2Utilization(&finding, builtinMonthlyCost)
+
+	assert.Equal(t, models.EC2UtilizationIdle, finding.Classification)
+	assert.Equal(t, 138.24, finding.MonthlyCost)
+	assert.Equal(t, 138.24, finding.EstimatedMonthlySavings)
+	assert.Empty(t, finding.SuggestedInstanceType)
+}
+
+func TestClassifyEC2UtilizationUnderutilized(t *testing.T) {
+	memoryPeak := 20.0
+	finding := models.EC2UtilizationFinding{
+		InstanceType: "m5.4xlarge",
+		Metrics:      models.EC2UtilizationMetrics{Hours: 336, CPUAverage: 8, CPUPeak: 12, NetworkInMBPerDay: 500, MemoryPeak: &memoryPeak},
+	}
+	classifyEC2Utilization(&finding, builtinMonthlyCost)
+
+	// A 20% peak on 32 units projects to 40% on m5.2xlarge and 80% on m5.xlarge
+	assert.Equal(t, models.EC2UtilizationUnderutilized, finding.Classification)
+	assert.Equal(t, "m5.2xlarge", finding.SuggestedInstanceType)
+	assert.Equal(t, 276.48, finding.SuggestedMonthlyCost)
+	assert.Equal(t, 276.48, finding.EstimatedMonthlySavings)
+}
+
+func TestClassifyEC2UtilizationWithoutSmallerPricedSize(t *testing.T) {
+	// m5 has no size below large in the price table
+	finding := models.EC2UtilizationFinding{
+		InstanceType: "m5.large",
+		Metrics:      models.EC2UtilizationMetrics{Hours: 336, CPUAverage: 10, CPUPeak: 15, NetworkInMBPerDay: 500},
+	}
+	classifyEC2Utilization(&finding, builtinMonthlyCost)
+
+	assert.Equal(t, models.EC2UtilizationUnderutilized, finding.Classification)
+	assert.Empty(t, finding.SuggestedInstanceType)
+	assert.Zero(t, finding.EstimatedMonthlySavings)
+	assert.Contains(t, finding.Reasons, "No smaller size of the family with a known price")
+}
+
+func TestClassifyEC2UtilizationOptimizedAndInsufficientData(t *testing.T) {
+	memoryPeak := 85.0
+	busyMemory := models.EC2UtilizationFinding{
+		InstanceType: "r5.2xlarge",
+		Metrics:      models.EC2UtilizationMetrics{Hours: 336, CPUAverage: 5, CPUPeak: 10, NetworkInMBPerDay: 500, MemoryPeak: &memoryPeak},
+	}
+	classifyEC2Utilization(&busyMemory, builtinMonthlyCost)
+	assert.Equal(t, models.EC2UtilizationOptimized, busyMemory.Classification)
+
+	busyCPU := models.EC2UtilizationFinding{
+		InstanceType: "c5.large",
+		Metrics:      models.EC2UtilizationMetrics{Hours: 336, CPUAverage: 30, CPUPeak: 90},
+	}
+	classifyEC2Utilization(&busyCPU, builtinMonthlyCost)
+	assert.Equal(t, models.EC2UtilizationOptimized, busyCPU.Classification)
+
+	recent := models.EC2UtilizationFinding{
+		InstanceType: "c5.large",
+		Metrics:      models.EC2UtilizationMetrics{Hours: 6},
+	}
+	classifyEC2Utilization(&recent, builtinMonthlyCost)
+	assert.Equal(t, models.EC2UtilizationInsufficientData, recent.Classification)
+}
+
+func TestSuggestSmallerEC2InstanceType(t *testing.T) {
+	metrics := models.EC2UtilizationMetrics{CPUPeak: 5}
+	priced := func(instanceType string) bool { return instanceType == "t3.small" || instanceType == "t3.medium" }
+
+	suggested, ok := suggestSmallerEC2InstanceType("t3.xlarge", metrics, priced)
+	require.True(t, ok)
+	assert.Equal(t, "t3.small", suggested)
+
+	_, ok = suggestSmallerEC2InstanceType("t3.xlarge", metrics, func(string) bool { return false })
+	assert.False(t, ok)
+
+	_, ok = suggestSmallerEC2InstanceType("m5.metal", metrics, priced)
+	assert.False(t, ok)
+}
+
+func TestBuildEC2UtilizationReport(t *testing.T) {
+	report := buildEC2UtilizationReport([]models.EC2UtilizationFinding{
+		{InstanceID: "i-1", Classification: models.EC2UtilizationOptimized},
+		{InstanceID: "i-2", Classification: models.EC2UtilizationUnderutilized, EstimatedMonthlySavings: 30},
+		{InstanceID: "i-3", Classification: models.EC2UtilizationIdle, EstimatedMonthlySavings: 70.5},
+		{InstanceID: "i-4", Classification: models.EC2UtilizationInsufficientData},
+	}, 14)
+
+	assert.Equal(t, 1, report.Idle)
+	assert.Equal(t, 1, report.Underutilized)
+	assert.Equal(t, 1, report.Optimized)
+	assert.Equal(t, 1, report.InsufficientData)
+	assert.Equal(t, 100.5, report.EstimatedMonthlySavings)
+	assert.Equal(t, "i-3", report.Instances[0].InstanceID)
+	assert.Equal(t, "i-2", report.Instances[1].InstanceID)
+}
+
+func TestEC2InstanceTypeMonthlyCostKeepsBYOLPricing(t *testing.T) {
+	s := newTestPricedService(t)
+
+	included, source, found := s.ec2InstanceTypeMonthlyCost("eu-west-1", "m5.large", "Windows", "default", "RunInstances:0002")
+	require.True(t, found)
+	assert.Equal(t, models.CostSourcePriceList, source)
+	assert.Equal(t, 143.28, included)
+
+	byol, _, found := s.ec2InstanceTypeMonthlyCost("eu-west-1", "m5.large", "Windows", "default", "RunInstances:0800")
+	require.True(t, found)
+	assert.Equal(t, 77.04, byol)
+}
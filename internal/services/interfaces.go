@@ -18,20 +18,84 @@ type AWSServiceInterface interface {
 	DeleteUserPassword(accountID, username string) error
 	RotateUserPassword(accountID, username string) (map[string]any, error)
 	DeleteInactiveUsers(accountID string) ([]string, []string, error)
+	RetrieveSecret(token string) (*models.RetrievedSecret, error)
+	// Staged access key rotation
+	StartKeyRotation(accountID, username, oldKeyID string) (*models.KeyRotation, error)
+	ListKeyRotations() []models.KeyRotation
+	GetKeyRotation(rotationID string) (*models.KeyRotation, error)
+	AdvanceKeyRotation(rotationID string, force bool) (*models.KeyRotation, error)
+	RollbackKeyRotation(rotationID string) (*models.KeyRotation, error)
+	// Background jobs
+	ListJobs() []models.Job
+	GetJob(jobID string) (*models.Job, error)
+	// Region discovery and scan coverage
+	GetAccountRegions(accountID string) ([]models.AccountRegion, error)
+	ListScanCoverage(scanner, accountID string) []models.ScanCoverage
+	// Price list used for cost estimates
+	GetPriceListStatus() models.PriceListStatus
+	ReloadPriceList() (models.PriceListStatus, error)
+	// Privilege escalation analysis
+	AnalyzePrivilegeEscalation(accountID string) ([]models.PrivilegeEscalationFinding, error)
+	AnalyzeOrgPrivilegeEscalation() ([]models.PrivilegeEscalationFinding, error)
+	// Access Advisor analysis
+	AnalyzeAccountAccessAdvisor(accountID string, recentDays int) models.Job
+	AnalyzeOrgAccessAdvisor(recentDays int) (models.Job, error)
+	// IAM policy simulation
+	SimulatePrincipalPolicy(accountID, principalType, principalName string, req models.SimulationRequest) (*models.SimulationResult, error)
+	SimulatePrincipalPolicyAcrossAccounts(principalType, principalName string, req models.SimulationRequest) ([]models.SimulationResult, error)
 	ListPublicIPs() ([]models.PublicIP, error)
 	ListSecurityGroups() ([]models.SecurityGroup, error)
 	ListSecurityGroupsByAccount(accountID string) ([]models.SecurityGroup, error)
 	GetSecurityGroup(accountID, region, groupID string) (*models.SecurityGroup, error)
 	DeleteSecurityGroup(accountID, region, groupID string) error
+	RevokeSecurityGroupRule(accountID, region, groupID string, input models.RevokeRuleInput) (*models.SecurityGroupChange, error)
+	NarrowSecurityGroupRule(accountID, region, groupID string, input models.NarrowRuleInput) (*models.SecurityGroupChange, error)
+	ListSecurityGroupChanges() []models.SecurityGroupChange
+	RevertSecurityGroupChange(changeID string) (*models.SecurityGroupChange, error)
+	StartSecurityGroupRemediation(input models.SecurityGroupRemediationInput) (models.Job, error)
+	StartUnusedSecurityGroupCleanup(input models.SecurityGroupCleanupInput) (models.Job, error)
+	ListSecurityGroupFindings(accountID, minSeverity, sortBy string) ([]models.SecurityGroupFinding, error)
+	GetSecurityGroupGraph(accountID, region, vpcID string) (*models.SecurityGroupGraph, error)
+	GetPortRiskCatalogue() []models.PortRisk
 	// Snapshot management
 	ListSnapshots() ([]models.Snapshot, error)
 	ListSnapshotsByAccount(accountID string) ([]models.Snapshot, error)
 	DeleteSnapshot(accountID, region, snapshotID string) error
-	DeleteOldSnapshots(accountID string, olderThanMonths int) ([]string, error)
+	DeleteOldSnapshots(accountID string, olderThanMonths int) (*models.SnapshotCleanupResult, error)
+	GetSnapshotCostReport(accountID string, estimateIncremental bool) (*models.SnapshotCostReport, error)
+	ArchiveSnapshot(accountID, region, snapshotID string) error
+	RestoreArchivedSnapshot(accountID, region, snapshotID string, input models.SnapshotRestoreInput) error
+	ListRetentionPolicies() []models.SnapshotRetentionPolicy
+	GetRetentionPolicy(policyID string) (*models.SnapshotRetentionPolicy, error)
+	CreateRetentionPolicy(policy models.SnapshotRetentionPolicy) (*models.SnapshotRetentionPolicy, error)
+	UpdateRetentionPolicy(policyID string, policy models.SnapshotRetentionPolicy) (*models.SnapshotRetentionPolicy, error)
+	DeleteRetentionPolicy(policyID string) error
+	PreviewRetentionPolicy(policyID string, accountIDs []string) (*models.SnapshotRetentionPreview, error)
+	StartRetentionPolicyRun(policyID string, input models.SnapshotRetentionRunInput) (models.Job, error)
+	// RDS and Aurora snapshot management
+	ListDBSnapshots() ([]models.DBSnapshot, error)
+	ListDBSnapshotsByAccount(accountID string) ([]models.DBSnapshot, error)
+	DeleteDBSnapshot(accountID, region, snapshotID, kind string) error
+	DeleteOldDBSnapshots(accountID string, olderThanMonths int) (*models.SnapshotCleanupResult, error)
+	ListDBSnapshotFindings(accountID, minSeverity string) ([]models.DBSnapshotFinding, error)
+	// AMI management
+	ListAMIs() ([]models.AMI, error)
+	ListAMIsByAccount(accountID string) ([]models.AMI, error)
+	DeregisterAMI(accountID, region, imageID string) (*models.AMICleanupResult, error)
+	DeleteUnusedAMIs(accountID string, unusedDays int) (*models.AMICleanupResult, error)
+	ListAMIFindings(accountID, minSeverity string) ([]models.AMIFinding, error)
 	// EC2 instance management
 	ListEC2Instances() ([]models.EC2Instance, error)
 	StopEC2Instance(accountID, region, instanceID string) error
 	TerminateEC2Instance(accountID, region, instanceID string) error
+	StartEC2Instance(accountID, region, instanceID string) error
+	RebootEC2Instance(accountID, region, instanceID string) error
+	HibernateEC2Instance(accountID, region, instanceID string) error
+	GetEC2Instance(accountID, region, instanceID string) (*models.EC2InstanceDetails, error)
+	SetEC2InstanceProtection(accountID, region, instanceID string, input models.EC2ProtectionInput) error
+	UpdateEC2InstanceTags(accountID, region, instanceID string, input models.EC2TagInput) error
+	StartEC2BulkAction(input models.EC2BulkActionInput) (models.Job, error)
+	AnalyzeEC2Utilization(windowDays int) (models.Job, error)
 	InvalidateEC2InstancesCache()
 	// EBS volume management
 	ListEBSVolumes() ([]models.EBSVolume, error)
@@ -49,8 +113,30 @@ type AWSServiceInterface interface {
 	ListAllRoles() ([]models.RoleWithAccount, error)
 	GetRole(accountID, roleName string) (*models.IAMRole, error)
 	DeleteRole(accountID, roleName string) error
+	CreateRole(accountID string, input models.CreateRoleInput) (*models.IAMRole, []string, error)
+	UpdateRoleTrustPolicy(accountID, roleName string, input models.UpdateTrustPolicyInput) (*models.IAMRole, error)
+	AttachRolePolicy(accountID, roleName, policyArn string) error
+	DetachRolePolicy(accountID, roleName, policyArn string) error
 	InvalidateRolesCache()
 	InvalidateAccountRolesCache(accountID string)
+	ListRoleTrustFindings(accountID string) ([]models.TrustFinding, error)
+	ListAllRoleTrustFindings() ([]models.TrustFinding, error)
+	// Unused role detection and staged cleanup
+	ListUnusedRoles(accountID string, unusedDays, minAgeDays int) ([]models.UnusedRole, error)
+	ListAllUnusedRoles(unusedDays, minAgeDays int) ([]models.UnusedRole, error)
+	StartRoleCleanup(targets []models.RoleCleanupTarget, force bool) (models.Job, error)
+	RestoreRoleCleanup(accountID, roleName string) error
+	// Customer-managed policy management
+	ListPolicies(accountID string) ([]models.IAMPolicy, error)
+	ListAllPolicies() ([]models.IAMPolicy, error)
+	GetPolicy(accountID, policyName string) (*models.IAMPolicy, error)
+	ListPolicyVersions(accountID, policyName string) ([]models.PolicyVersion, error)
+	DiffPolicyVersions(accountID, policyName, fromVersion, toVersion string) (*models.PolicyVersionDiff, error)
+	DeleteNonDefaultPolicyVersions(accountID, policyName string) ([]string, error)
+	DeletePolicy(accountID, policyName string) error
+	DeleteUnattachedPolicies(accountID string) ([]string, []string, error)
+	InvalidatePoliciesCache()
+	InvalidateAccountPoliciesCache(accountID string)
 	// Load balancer management
 	ListAllLoadBalancers() ([]models.LoadBalancer, error)
 	ListLoadBalancersByAccount(accountID string) ([]models.LoadBalancer, error)
@@ -123,3 +209,21 @@ type SSOServiceInterface interface {
 	InvalidateSSOGroupCache(groupID string)
 	InvalidateAccountAssignmentsCache(accountID string)
 }
+
+type SearchServiceInterface interface {
+	Search(query string, types []string, limit int, refresh bool) (*models.SearchResponse, error)
+	InvalidateSearchIndex()
+}
+
+type ScheduleServiceInterface interface {
+	ListSchedules() []models.Schedule
+	GetSchedule(scheduleID string) (*models.Schedule, error)
+	CreateSchedule(schedule models.Schedule) (*models.Schedule, error)
+	UpdateSchedule(scheduleID string, schedule models.Schedule) (*models.Schedule, error)
+	DeleteSchedule(scheduleID string) error
+	SnoozeSchedule(scheduleID string, input models.ScheduleSnoozeInput) (*models.Schedule, error)
+	ClearScheduleOverrides(scheduleID string) (*models.Schedule, error)
+	PreviewSchedule(scheduleID string, count int) ([]models.ScheduleRun, error)
+	ListScheduleAudit(scheduleID string, limit int) []models.ScheduleAuditEntry
+	GetSchedulerStatus() models.SchedulerStatus
+}
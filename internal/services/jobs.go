@@ -0,0 +1,200 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// BACKGROUND JOBS
+// ============================================================================
+
+// ErrJobNotFound is returned for unknown job IDs
+var ErrJobNotFound = errors.New("job not found")
+
+// maxRetainedJobs bounds how many finished jobs are kept in memory and on disk
+const maxRetainedJobs = 200
+
+// jobManager runs background jobs and keeps their progress and results
+type jobManager struct {
+	mu   sync.Mutex
+	jobs map[string]*models.Job
+	file *stateFile
+}
+
+func newJobManager(stateDir string) *jobManager {
+	m := &jobManager{
+		jobs: make(map[string]*models.Job),
+		file: newStateFile(stateDir, "jobs.json"),
+	}
+
+	var stored []*models.Job
+	if err := m.file.Load(&stored); err != nil {
+		fmt.Printf("[WARNING] Failed to load job state: %v\n", err)
+	}
+	for _, job := range stored {
+		// Jobs cannot survive a restart; mark interrupted ones as failed
+		if job.Status == models.JobStatusRunning {
+			job.Status = models.JobStatusFailed
+			job.Error = "interrupted by server restart"
+		}
+		m.jobs[job.ID] = job
+	}
+
+	return m
+}
+
+// jobContext is handed to a running job to report progress
+type jobContext struct {
+	manager *jobManager
+	job     *models.Job
+}
+
+// AddTotal increases the number of items the job will process
+func (jc *jobContext) AddTotal(count int) {
+	jc.manager.mu.Lock()
+	defer jc.manager.mu.Unlock()
+
+	jc.job.Total += count
+}
+
+// AddResult records the outcome for a single item
+func (jc *jobContext) AddResult(result models.JobItemResult) {
+	jc.manager.mu.Lock()
+	defer jc.manager.mu.Unlock()
+
+	jc.job.Results = append(jc.job.Results, result)
+	jc.job.Processed++
+}
+
+// start launches run in the background and returns the job record immediately
+func (m *jobManager) start(jobType, description string, run func(jc *jobContext) (any, error)) models.Job {
+	job := &models.Job{
+		ID:          uuid.NewString(),
+		Type:        jobType,
+		Description: description,
+		Status:      models.JobStatusRunning,
+		CreatedAt:   time.Now(),
+	}
+
+	m.mu.Lock()
+	m.jobs[job.ID] = job
+	snapshot := *job
+	m.mu.Unlock()
+
+	go func() {
+		jc := &jobContext{manager: m, job: job}
+
+		var output any
+		var err error
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("job panicked: %v", r)
+				}
+			}()
+			output, err = run(jc)
+		}()
+
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		now := time.Now()
+		job.CompletedAt = &now
+		job.Output = output
+		if err != nil {
+			job.Status = models.JobStatusFailed
+			job.Error = err.Error()
+			fmt.Printf("[WARNING] Job %s (%s) failed: %v\n", job.ID, job.Type, err)
+		} else {
+			job.Status = models.JobStatusCompleted
+		}
+		m.prune()
+		m.save()
+	}()
+
+	return snapshot
+}
+
+// prune drops the oldest finished jobs beyond maxRetainedJobs; callers must hold mu
+func (m *jobManager) prune() {
+	if len(m.jobs) <= maxRetainedJobs {
+		return
+	}
+
+	var finished []*models.Job
+	for _, job := range m.jobs {
+		if job.Status != models.JobStatusRunning {
+			finished = append(finished, job)
+		}
+	}
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].CreatedAt.Before(finished[j].CreatedAt)
+	})
+	for _, job := range finished {
+		if len(m.jobs) <= maxRetainedJobs {
+			break
+		}
+		delete(m.jobs, job.ID)
+	}
+}
+
+// save persists finished jobs; callers must hold mu
+func (m *jobManager) save() {
+	list := make([]*models.Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		list = append(list, job)
+	}
+	if err := m.file.Save(list); err != nil {
+		fmt.Printf("[WARNING] Failed to persist job state: %v\n", err)
+	}
+}
+
+// list returns all jobs without their outputs, newest first
+func (m *jobManager) list() []models.Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := make([]models.Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		summary := *job
+		summary.Results = nil
+		summary.Output = nil
+		jobs = append(jobs, summary)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].CreatedAt.After(jobs[j].CreatedAt)
+	})
+	return jobs
+}
+
+// get returns a copy of a job including its results and output
+func (m *jobManager) get(jobID string) (*models.Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	job, exists := m.jobs[jobID]
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+	result := *job
+	result.Results = append([]models.JobItemResult(nil), job.Results...)
+	return &result, nil
+}
+
+// ListJobs returns all background jobs, newest first
+func (s *AWSService) ListJobs() []models.Job {
+	return s.jobs.list()
+}
+
+// GetJob returns a background job with its results
+func (s *AWSService) GetJob(jobID string) (*models.Job, error) {
+	return s.jobs.get(jobID)
+}
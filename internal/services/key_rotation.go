@@ -0,0 +1,457 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// STAGED ACCESS KEY ROTATION
+// ============================================================================
+//
+// A staged rotation moves through three phases so workloads never lose a working key:
+//   1. new_key_created     - a second key is created and handed off; the old key stays active
+//   2. old_key_deactivated - once the delay has passed and the new key shows usage, the old key is disabled
+//   3. completed           - after a further grace period the old key is deleted
+// Until the old key is deleted, the rotation can be rolled back.
+
+// ErrKeyRotationNotFound is returned for unknown rotation IDs
+var ErrKeyRotationNotFound = errors.New("key rotation not found")
+
+// ErrKeyRotationNotReady is returned when a rotation cannot advance yet
+var ErrKeyRotationNotReady = errors.New("key rotation is not ready to advance")
+
+// keyRotationTracker holds rotation state and persists it to the state directory.
+// IAM calls are made outside mu; a rotation being updated is claimed in busy so concurrent
+// requests for the same rotation or old key are rejected instead of racing.
+type keyRotationTracker struct {
+	mu        sync.Mutex
+	rotations map[string]*models.KeyRotation
+	busy      map[string]bool
+	file      *stateFile
+}
+
+func newKeyRotationTracker(stateDir string) *keyRotationTracker {
+	tracker := &keyRotationTracker{
+		rotations: make(map[string]*models.KeyRotation),
+		busy:      make(map[string]bool),
+		file:      newStateFile(stateDir, "key-rotations.json"),
+	}
+
+	var stored []*models.KeyRotation
+	if err := tracker.file.Load(&stored); err != nil {
+		fmt.Printf("[WARNING] Failed to load key rotation state: %v\n", err)
+	}
+	for _, rotation := range stored {
+		tracker.rotations[rotation.ID] = rotation
+	}
+
+	return tracker
+}
+
+// save persists all rotations; callers must hold mu
+func (t *keyRotationTracker) save() {
+	list := make([]*models.KeyRotation, 0, len(t.rotations))
+	for _, rotation := range t.rotations {
+		list = append(list, rotation)
+	}
+	if err := t.file.Save(list); err != nil {
+		fmt.Printf("[WARNING] Failed to persist key rotation state: %v\n", err)
+	}
+}
+
+// claimStart reserves an old key for a new rotation
+func (t *keyRotationTracker) claimStart(accountID, oldKeyID string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	claim := fmt.Sprintf("start:%s:%s", accountID, oldKeyID)
+	if t.busy[claim] {
+		return "", fmt.Errorf("key %s is already being rotated", oldKeyID)
+	}
+	for _, rotation := range t.rotations {
+		if rotation.AccountID == accountID && rotation.OldKeyID == oldKeyID && isActiveKeyRotation(rotation) {
+			return "", fmt.Errorf("key %s already has rotation %s in progress", oldKeyID, rotation.ID)
+		}
+	}
+	t.busy[claim] = true
+	return claim, nil
+}
+
+// begin claims a rotation for an update and returns a copy to work on outside the lock
+func (t *keyRotationTracker) begin(rotationID string) (models.KeyRotation, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rotation, exists := t.rotations[rotationID]
+	if !exists {
+		return models.KeyRotation{}, ErrKeyRotationNotFound
+	}
+	if t.busy[rotationID] {
+		return models.KeyRotation{}, fmt.Errorf("%w: key rotation %s is already being updated", ErrKeyRotationNotReady, rotationID)
+	}
+	t.busy[rotationID] = true
+
+	working := *rotation
+	working.History = append([]models.KeyRotationEvent(nil), rotation.History...)
+	return working, nil
+}
+
+// finish stores an updated rotation, persists it and releases the given claim
+func (t *keyRotationTracker) finish(rotation models.KeyRotation, claim string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.rotations[rotation.ID] = &rotation
+	delete(t.busy, claim)
+	t.save()
+}
+
+// release drops a claim without changing any rotation
+func (t *keyRotationTracker) release(claim string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.busy, claim)
+}
+
+// transitionKeyRotation moves a rotation to a new state and records the event
+func transitionKeyRotation(rotation *models.KeyRotation, to, message string) {
+	now := time.Now()
+	rotation.History = append(rotation.History, models.KeyRotationEvent{
+		Time:    now,
+		From:    rotation.State,
+		To:      to,
+		Message: message,
+	})
+	rotation.State = to
+	rotation.UpdatedAt = now
+	rotation.LastError = ""
+}
+
+func isActiveKeyRotation(rotation *models.KeyRotation) bool {
+	return rotation.State == models.KeyRotationNewKeyCreated || rotation.State == models.KeyRotationOldKeyDeactivated
+}
+
+// invalidateUserKeyCaches drops cached data that includes a user's access keys
+func (s *AWSService) invalidateUserKeyCaches(accountID, username string) {
+	s.cache.Delete(fmt.Sprintf("user:%s:%s", accountID, username))
+	s.cache.Delete(fmt.Sprintf("users:%s", accountID))
+	s.cache.Delete("all-users")
+	s.cache.Delete("accounts")
+}
+
+// iamClientFactory returns an IAM client for an account
+type iamClientFactory func(accountID string) (iamiface.IAMAPI, error)
+
+// accountIAMClient returns an IAM client for an account
+func (s *AWSService) accountIAMClient(accountID string) (iamiface.IAMAPI, error) {
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+	return iam.New(sess), nil
+}
+
+// StartKeyRotation begins a staged rotation by creating a new key alongside the old one
+func (s *AWSService) StartKeyRotation(accountID, username, oldKeyID string) (*models.KeyRotation, error) {
+	return s.startKeyRotation(s.accountIAMClient, accountID, username, oldKeyID)
+}
+
+func (s *AWSService) startKeyRotation(iamClients iamClientFactory, accountID, username, oldKeyID string) (*models.KeyRotation, error) {
+	tracker := s.keyRotations
+	claim, err := tracker.claimStart(accountID, oldKeyID)
+	if err != nil {
+		return nil, err
+	}
+	defer tracker.release(claim)
+
+	iamClient, err := iamClients(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure the old key exists and belongs to this user
+	keysResult, err := iamClient.ListAccessKeys(&iam.ListAccessKeysInput{
+		UserName: aws.String(username),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access keys: %v", err)
+	}
+	found := false
+	for _, key := range keysResult.AccessKeyMetadata {
+		if aws.StringValue(key.AccessKeyId) == oldKeyID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("access key %s not found for user %s", oldKeyID, username)
+	}
+
+	createResult, err := iamClient.CreateAccessKey(&iam.CreateAccessKeyInput{
+		UserName: aws.String(username),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new access key: %v", err)
+	}
+	newKey := createResult.AccessKey
+
+	secretRef, err := s.secrets.Store("access_key", accountID, username, map[string]string{
+		"access_key_id":     *newKey.AccessKeyId,
+		"secret_access_key": *newKey.SecretAccessKey,
+	})
+	if err != nil {
+		// Without a way to hand over the secret the new key is useless
+		_, _ = iamClient.DeleteAccessKey(&iam.DeleteAccessKeyInput{
+			UserName:    aws.String(username),
+			AccessKeyId: newKey.AccessKeyId,
+		})
+		return nil, fmt.Errorf("failed to store new key secret: %v", err)
+	}
+
+	now := time.Now()
+	rotation := models.KeyRotation{
+		ID:              uuid.NewString(),
+		AccountID:       accountID,
+		Username:        username,
+		OldKeyID:        oldKeyID,
+		NewKeyID:        *newKey.AccessKeyId,
+		CreatedAt:       now,
+		DeactivateAfter: now.Add(s.config.KeyRotationDeactivateDelay),
+	}
+	transitionKeyRotation(&rotation, models.KeyRotationNewKeyCreated, fmt.Sprintf("Created new key %s", rotation.NewKeyID))
+	tracker.finish(rotation, claim)
+
+	s.invalidateUserKeyCaches(accountID, username)
+
+	response := rotation
+	response.SecretReference = secretRef
+	return &response, nil
+}
+
+// ListKeyRotations returns all tracked key rotations, newest first
+func (s *AWSService) ListKeyRotations() []models.KeyRotation {
+	tracker := s.keyRotations
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	rotations := make([]models.KeyRotation, 0, len(tracker.rotations))
+	for _, rotation := range tracker.rotations {
+		rotations = append(rotations, *rotation)
+	}
+	sort.Slice(rotations, func(i, j int) bool {
+		return rotations[i].CreatedAt.After(rotations[j].CreatedAt)
+	})
+	return rotations
+}
+
+// GetKeyRotation returns a single key rotation
+func (s *AWSService) GetKeyRotation(rotationID string) (*models.KeyRotation, error) {
+	tracker := s.keyRotations
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	rotation, exists := tracker.rotations[rotationID]
+	if !exists {
+		return nil, ErrKeyRotationNotFound
+	}
+	result := *rotation
+	return &result, nil
+}
+
+// AdvanceKeyRotation moves a rotation to its next phase.
+// Without force, the old key is only deactivated after the delay has passed and the new key
+// has been used, and only deleted after the delete grace period.
+func (s *AWSService) AdvanceKeyRotation(rotationID string, force bool) (*models.KeyRotation, error) {
+	return s.advanceKeyRotation(s.accountIAMClient, rotationID, force)
+}
+
+func (s *AWSService) advanceKeyRotation(iamClients iamClientFactory, rotationID string, force bool) (*models.KeyRotation, error) {
+	tracker := s.keyRotations
+	rotation, err := tracker.begin(rotationID)
+	if err != nil {
+		return nil, err
+	}
+	if !isActiveKeyRotation(&rotation) {
+		tracker.release(rotationID)
+		return nil, fmt.Errorf("key rotation %s is already %s", rotationID, rotation.State)
+	}
+
+	iamClient, err := iamClients(rotation.AccountID)
+	if err != nil {
+		tracker.release(rotationID)
+		return nil, err
+	}
+
+	// Every path below records its outcome on the copy and commits it
+	result, err := advanceKeyRotationState(iamClient, &rotation, force, s.config.KeyRotationDeleteDelay)
+	tracker.finish(rotation, rotationID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateUserKeyCaches(rotation.AccountID, rotation.Username)
+	return result, nil
+}
+
+// advanceKeyRotationState performs the IAM call for a rotation's next phase and updates the rotation.
+// IAM failures are recorded as LastError.
+func advanceKeyRotationState(iamClient iamiface.IAMAPI, rotation *models.KeyRotation, force bool, deleteDelay time.Duration) (*models.KeyRotation, error) {
+	now := time.Now()
+
+	switch rotation.State {
+	case models.KeyRotationNewKeyCreated:
+		lastUsed, err := iamClient.GetAccessKeyLastUsed(&iam.GetAccessKeyLastUsedInput{
+			AccessKeyId: aws.String(rotation.NewKeyID),
+		})
+		if err != nil {
+			return nil, failKeyRotation(rotation, fmt.Errorf("failed to get last used info for key %s: %v", rotation.NewKeyID, err))
+		}
+		if lastUsed.AccessKeyLastUsed != nil && lastUsed.AccessKeyLastUsed.LastUsedDate != nil {
+			rotation.NewKeyLastUsed = lastUsed.AccessKeyLastUsed.LastUsedDate
+		}
+
+		if !force {
+			if now.Before(rotation.DeactivateAfter) {
+				return nil, fmt.Errorf("%w: old key can be deactivated after %s", ErrKeyRotationNotReady, rotation.DeactivateAfter.Format(time.RFC3339))
+			}
+			if rotation.NewKeyLastUsed == nil {
+				return nil, fmt.Errorf("%w: new key %s has not been used yet", ErrKeyRotationNotReady, rotation.NewKeyID)
+			}
+		}
+
+		_, err = iamClient.UpdateAccessKey(&iam.UpdateAccessKeyInput{
+			UserName:    aws.String(rotation.Username),
+			AccessKeyId: aws.String(rotation.OldKeyID),
+			Status:      aws.String(iam.StatusTypeInactive),
+		})
+		if err != nil {
+			return nil, failKeyRotation(rotation, fmt.Errorf("failed to deactivate old key %s: %v", rotation.OldKeyID, err))
+		}
+
+		deleteAfter := now.Add(deleteDelay)
+		rotation.DeleteAfter = &deleteAfter
+		transitionKeyRotation(rotation, models.KeyRotationOldKeyDeactivated, fmt.Sprintf("Deactivated old key %s", rotation.OldKeyID))
+
+	case models.KeyRotationOldKeyDeactivated:
+		if !force && rotation.DeleteAfter != nil && now.Before(*rotation.DeleteAfter) {
+			return nil, fmt.Errorf("%w: old key can be deleted after %s", ErrKeyRotationNotReady, rotation.DeleteAfter.Format(time.RFC3339))
+		}
+
+		_, err := iamClient.DeleteAccessKey(&iam.DeleteAccessKeyInput{
+			UserName:    aws.String(rotation.Username),
+			AccessKeyId: aws.String(rotation.OldKeyID),
+		})
+		if err != nil {
+			return nil, failKeyRotation(rotation, fmt.Errorf("failed to delete old key %s: %v", rotation.OldKeyID, err))
+		}
+
+		transitionKeyRotation(rotation, models.KeyRotationCompleted, fmt.Sprintf("Deleted old key %s", rotation.OldKeyID))
+	}
+
+	result := *rotation
+	return &result, nil
+}
+
+// failKeyRotation records an error on a rotation without changing its state
+func failKeyRotation(rotation *models.KeyRotation, err error) error {
+	rotation.LastError = err.Error()
+	rotation.UpdatedAt = time.Now()
+	return err
+}
+
+// RollbackKeyRotation restores the old key and removes the new one.
+// Rollback is only possible until the old key has been deleted.
+func (s *AWSService) RollbackKeyRotation(rotationID string) (*models.KeyRotation, error) {
+	return s.rollbackKeyRotation(s.accountIAMClient, rotationID)
+}
+
+func (s *AWSService) rollbackKeyRotation(iamClients iamClientFactory, rotationID string) (*models.KeyRotation, error) {
+	tracker := s.keyRotations
+	rotation, err := tracker.begin(rotationID)
+	if err != nil {
+		return nil, err
+	}
+	if !isActiveKeyRotation(&rotation) {
+		tracker.release(rotationID)
+		return nil, fmt.Errorf("cannot roll back key rotation %s: it is already %s", rotationID, rotation.State)
+	}
+
+	iamClient, err := iamClients(rotation.AccountID)
+	if err != nil {
+		tracker.release(rotationID)
+		return nil, err
+	}
+
+	err = rollbackKeyRotationState(iamClient, &rotation)
+	tracker.finish(rotation, rotationID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateUserKeyCaches(rotation.AccountID, rotation.Username)
+
+	result := rotation
+	return &result, nil
+}
+
+// rollbackKeyRotationState re-enables the old key, deletes the new one and updates the rotation
+func rollbackKeyRotationState(iamClient iamiface.IAMAPI, rotation *models.KeyRotation) error {
+	// Re-enable the old key first so there is never a moment without a working key
+	if rotation.State == models.KeyRotationOldKeyDeactivated {
+		_, err := iamClient.UpdateAccessKey(&iam.UpdateAccessKeyInput{
+			UserName:    aws.String(rotation.Username),
+			AccessKeyId: aws.String(rotation.OldKeyID),
+			Status:      aws.String(iam.StatusTypeActive),
+		})
+		if err != nil {
+			return failKeyRotation(rotation, fmt.Errorf("failed to reactivate old key %s: %v", rotation.OldKeyID, err))
+		}
+	}
+
+	_, err := iamClient.DeleteAccessKey(&iam.DeleteAccessKeyInput{
+		UserName:    aws.String(rotation.Username),
+		AccessKeyId: aws.String(rotation.NewKeyID),
+	})
+	if err != nil {
+		return failKeyRotation(rotation, fmt.Errorf("failed to delete new key %s: %v", rotation.NewKeyID, err))
+	}
+
+	transitionKeyRotation(rotation, models.KeyRotationRolledBack, fmt.Sprintf("Restored old key %s and deleted new key %s", rotation.OldKeyID, rotation.NewKeyID))
+	return nil
+}
+
+// ProcessKeyRotations advances every in-progress rotation that is ready
+func (s *AWSService) ProcessKeyRotations() {
+	for _, rotation := range s.ListKeyRotations() {
+		if !isActiveKeyRotation(&rotation) {
+			continue
+		}
+		_, err := s.AdvanceKeyRotation(rotation.ID, false)
+		if err != nil && !errors.Is(err, ErrKeyRotationNotReady) {
+			fmt.Printf("[WARNING] Failed to advance key rotation %s: %v\n", rotation.ID, err)
+		}
+	}
+}
+
+// StartKeyRotationWorker periodically advances key rotations in the background
+func (s *AWSService) StartKeyRotationWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.ProcessKeyRotations()
+		}
+	}()
+}
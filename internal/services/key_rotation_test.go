@@ -0,0 +1,279 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/config"
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeKeyRotationIAM keeps access keys in memory; failOn makes the named operation fail
+type fakeKeyRotationIAM struct {
+	iamiface.IAMAPI
+	keys     map[string]string // key ID -> status
+	lastUsed map[string]time.Time
+	failOn   string
+	calls    []string
+}
+
+func newFakeKeyRotationIAM() *fakeKeyRotationIAM {
+	return &fakeKeyRotationIAM{
+		keys:     map[string]string{"AKIAOLD": iam.StatusTypeActive},
+		lastUsed: map[string]time.Time{},
+	}
+}
+
+func (f *fakeKeyRotationIAM) call(op string) error {
+	f.calls = append(f.calls, op)
+	if f.failOn == op {
+		return errors.New("AccessDenied")
+	}
+	return nil
+}
+
+func (f *fakeKeyRotationIAM) ListAccessKeys(input *iam.ListAccessKeysInput) (*iam.ListAccessKeysOutput, error) {
+	if err := f.call("ListAccessKeys"); err != nil {
+		return nil, err
+	}
+	output := &iam.ListAccessKeysOutput{}
+	for keyID, status := range f.keys {
+		output.AccessKeyMetadata = append(output.AccessKeyMetadata, &iam.AccessKeyMetadata{AccessKeyId: aws.String(keyID), Status: aws.String(status)})
+	}
+	return output, nil
+}
+
+func (f *fakeKeyRotationIAM) CreateAccessKey(input *iam.CreateAccessKeyInput) (*iam.CreateAccessKeyOutput, error) {
+	if err := f.call("CreateAccessKey"); err != nil {
+		return nil, err
+	}
+	f.keys["AKIANEW"] = iam.StatusTypeActive
+	return &iam.CreateAccessKeyOutput{AccessKey: &iam.AccessKey{
+		AccessKeyId:     aws.String("AKIANEW"),
+		SecretAccessKey: aws.String("secret"),
+		Status:          aws.String(iam.StatusTypeActive),
+		UserName:        input.UserName,
+	}}, nil
+}
+
+func (f *fakeKeyRotationIAM) GetAccessKeyLastUsed(input *iam.GetAccessKeyLastUsedInput) (*iam.GetAccessKeyLastUsedOutput, error) {
+	if err := f.call("GetAccessKeyLastUsed"); err != nil {
+		return nil, err
+	}
+	output := &iam.GetAccessKeyLastUsedOutput{AccessKeyLastUsed: &iam.AccessKeyLastUsed{}}
+	if used, ok := f.lastUsed[aws.StringValue(input.AccessKeyId)]; ok {
+		output.AccessKeyLastUsed.LastUsedDate = aws.Time(used)
+	}
+	return output, nil
+}
+
+func (f *fakeKeyRotationIAM) UpdateAccessKey(input *iam.UpdateAccessKeyInput) (*iam.UpdateAccessKeyOutput, error) {
+	if err := f.call("UpdateAccessKey"); err != nil {
+		return nil, err
+	}
+	f.keys[aws.StringValue(input.AccessKeyId)] = aws.StringValue(input.Status)
+	return &iam.UpdateAccessKeyOutput{}, nil
+}
+
+func (f *fakeKeyRotationIAM) DeleteAccessKey(input *iam.DeleteAccessKeyInput) (*iam.DeleteAccessKeyOutput, error) {
+	if err := f.call("DeleteAccessKey"); err != nil {
+		return nil, err
+	}
+	delete(f.keys, aws.StringValue(input.AccessKeyId))
+	return &iam.DeleteAccessKeyOutput{}, nil
+}
+
+func newKeyRotationTestService(t *testing.T, stateDir string, deactivateDelay, deleteDelay time.Duration) *AWSService {
+	return &AWSService{
+		cache: NewCache(),
+		config: config.Config{
+			KeyRotationDeactivateDelay: deactivateDelay,
+			KeyRotationDeleteDelay:     deleteDelay,
+		},
+		secrets:      newTestSecretHandoff(t, NewMemorySecretStore(), time.Minute),
+		keyRotations: newKeyRotationTracker(stateDir),
+	}
+}
+
+func fakeIAMClients(client iamiface.IAMAPI) iamClientFactory {
+	return func(accountID string) (iamiface.IAMAPI, error) { return client, nil }
+}
+
+func TestKeyRotationAdvancesThroughStages(t *testing.T) {
+	s := newKeyRotationTestService(t, "", 0, 0)
+	client := newFakeKeyRotationIAM()
+	clients := fakeIAMClients(client)
+
+	rotation, err := s.startKeyRotation(clients, "123456789012", "alice", "AKIAOLD")
+	require.NoError(t, err)
+	assert.Equal(t, models.KeyRotationNewKeyCreated, rotation.State)
+	assert.Equal(t, "AKIANEW", rotation.NewKeyID)
+	require.NotNil(t, rotation.SecretReference)
+
+	_, err = s.startKeyRotation(clients, "123456789012", "alice", "AKIAOLD")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already has rotation")
+
+	// The new key has not been used yet
+	_, err = s.advanceKeyRotation(clients, rotation.ID, false)
+	assert.ErrorIs(t, err, ErrKeyRotationNotReady)
+
+	client.lastUsed["AKIANEW"] = time.Now()
+	advanced, err := s.advanceKeyRotation(clients, rotation.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, models.KeyRotationOldKeyDeactivated, advanced.State)
+	assert.NotNil(t, advanced.NewKeyLastUsed)
+	assert.Equal(t, iam.StatusTypeInactive, client.keys["AKIAOLD"])
+
+	completed, err := s.advanceKeyRotation(clients, rotation.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, models.KeyRotationCompleted, completed.State)
+	assert.NotContains(t, client.keys, "AKIAOLD")
+	require.Len(t, completed.History, 3)
+	assert.Equal(t, models.KeyRotationOldKeyDeactivated, completed.History[2].From)
+
+	_, err = s.advanceKeyRotation(clients, rotation.ID, false)
+	assert.Error(t, err)
+	_, err = s.rollbackKeyRotation(clients, rotation.ID)
+	assert.Error(t, err)
+}
+
+func TestKeyRotationWaitsForDelays(t *testing.T) {
+	s := newKeyRotationTestService(t, "", time.Hour, time.Hour)
+	client := newFakeKeyRotationIAM()
+	client.lastUsed["AKIANEW"] = time.Now()
+	clients := fakeIAMClients(client)
+
+	rotation, err := s.startKeyRotation(clients, "123456789012", "alice", "AKIAOLD")
+	require.NoError(t, err)
+
+	_, err = s.advanceKeyRotation(clients, rotation.ID, false)
+	assert.ErrorIs(t, err, ErrKeyRotationNotReady)
+
+	advanced, err := s.advanceKeyRotation(clients, rotation.ID, true)
+	require.NoError(t, err)
+	assert.Equal(t, models.KeyRotationOldKeyDeactivated, advanced.State)
+
+	_, err = s.advanceKeyRotation(clients, rotation.ID, false)
+	assert.ErrorIs(t, err, ErrKeyRotationNotReady)
+	assert.Contains(t, client.keys, "AKIAOLD")
+}
+
+func TestKeyRotationRollback(t *testing.T) {
+	tests := []struct {
+		name      string
+		advance   bool
+		wantCalls []string
+	}{
+		{"new key created", false, []string{"DeleteAccessKey"}},
+		{"old key deactivated", true, []string{"UpdateAccessKey", "DeleteAccessKey"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newKeyRotationTestService(t, "", 0, 0)
+			client := newFakeKeyRotationIAM()
+			clients := fakeIAMClients(client)
+
+			rotation, err := s.startKeyRotation(clients, "123456789012", "alice", "AKIAOLD")
+			require.NoError(t, err)
+			if tt.advance {
+				_, err = s.advanceKeyRotation(clients, rotation.ID, true)
+				require.NoError(t, err)
+			}
+
+			client.calls = nil
+			rolledBack, err := s.rollbackKeyRotation(clients, rotation.ID)
+			require.NoError(t, err)
+			assert.Equal(t, models.KeyRotationRolledBack, rolledBack.State)
+			assert.Equal(t, tt.wantCalls, client.calls)
+			assert.Equal(t, map[string]string{"AKIAOLD": iam.StatusTypeActive}, client.keys)
+
+			// The old key can be rotated again
+			_, err = s.startKeyRotation(clients, "123456789012", "alice", "AKIAOLD")
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestKeyRotationRecordsFailures(t *testing.T) {
+	s := newKeyRotationTestService(t, "", 0, 0)
+	client := newFakeKeyRotationIAM()
+	clients := fakeIAMClients(client)
+
+	rotation, err := s.startKeyRotation(clients, "123456789012", "alice", "AKIAOLD")
+	require.NoError(t, err)
+
+	client.failOn = "UpdateAccessKey"
+	_, err = s.advanceKeyRotation(clients, rotation.ID, true)
+	require.Error(t, err)
+
+	stored, err := s.GetKeyRotation(rotation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.KeyRotationNewKeyCreated, stored.State)
+	assert.Contains(t, stored.LastError, "failed to deactivate old key")
+
+	// A failed rollback keeps the rotation active so it can be retried
+	client.failOn = "DeleteAccessKey"
+	_, err = s.rollbackKeyRotation(clients, rotation.ID)
+	require.Error(t, err)
+	stored, err = s.GetKeyRotation(rotation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.KeyRotationNewKeyCreated, stored.State)
+
+	client.failOn = ""
+	advanced, err := s.advanceKeyRotation(clients, rotation.ID, true)
+	require.NoError(t, err)
+	assert.Empty(t, advanced.LastError)
+}
+
+func TestKeyRotationRejectsConcurrentUpdates(t *testing.T) {
+	s := newKeyRotationTestService(t, "", 0, 0)
+	clients := fakeIAMClients(newFakeKeyRotationIAM())
+
+	rotation, err := s.startKeyRotation(clients, "123456789012", "alice", "AKIAOLD")
+	require.NoError(t, err)
+
+	_, err = s.keyRotations.begin(rotation.ID)
+	require.NoError(t, err)
+
+	_, err = s.advanceKeyRotation(clients, rotation.ID, true)
+	assert.ErrorIs(t, err, ErrKeyRotationNotReady)
+
+	s.keyRotations.release(rotation.ID)
+	_, err = s.advanceKeyRotation(clients, rotation.ID, true)
+	assert.NoError(t, err)
+}
+
+func TestKeyRotationReloadsFromStateFile(t *testing.T) {
+	stateDir := t.TempDir()
+	s := newKeyRotationTestService(t, stateDir, 0, 0)
+	clients := fakeIAMClients(newFakeKeyRotationIAM())
+
+	rotation, err := s.startKeyRotation(clients, "123456789012", "alice", "AKIAOLD")
+	require.NoError(t, err)
+	_, err = s.advanceKeyRotation(clients, rotation.ID, true)
+	require.NoError(t, err)
+
+	reloaded := newKeyRotationTestService(t, stateDir, 0, 0)
+	stored, err := reloaded.GetKeyRotation(rotation.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.KeyRotationOldKeyDeactivated, stored.State)
+	assert.NotNil(t, stored.DeleteAfter)
+	assert.Len(t, stored.History, 2)
+	assert.Nil(t, stored.SecretReference)
+
+	// The reloaded rotation still blocks a second rotation of the same key and can finish
+	_, err = reloaded.startKeyRotation(clients, "123456789012", "alice", "AKIAOLD")
+	assert.Error(t, err)
+	completed, err := reloaded.advanceKeyRotation(clients, rotation.ID, false)
+	require.NoError(t, err)
+	assert.Equal(t, models.KeyRotationCompleted, completed.State)
+}
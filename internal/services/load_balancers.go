@@ -131,11 +131,11 @@ func (s *AWSService) ListLoadBalancersByAccount(accountID string) ([]models.Load
 		return nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
 	}
 
-	// Get all regions
-	regions, err := s.getRegions(sess)
+	scan, err := s.startRegionScan(scannerLoadBalancers, accountID, sess)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get regions: %v", err)
+		return nil, err
 	}
+	regions := scan.Regions()
 
 	// Channel to collect results from region goroutines
 	type regionResult struct {
@@ -173,10 +173,12 @@ func (s *AWSService) ListLoadBalancersByAccount(accountID string) ([]models.Load
 	for result := range resultChan {
 		if result.err != nil {
 			fmt.Printf("[WARNING] Failed to get load balancers in region %s for account %s: %v\n", result.regionName, accountID, result.err)
+			scan.Fail(result.regionName, result.err)
 			continue
 		}
 		allLBs = append(allLBs, result.lbs...)
 	}
+	scan.Finish()
 
 	// Cache the result
 	s.cache.Set(cacheKey, allLBs, s.cacheTTL)
@@ -249,6 +251,7 @@ func (s *AWSService) getELBv2LoadBalancers(sess *session.Session, account models
 		loadBalancer.HealthyTargetCount = healthyCount
 		loadBalancer.ListenerCount = listenerCount
 		loadBalancer.IsUnused = isUnused
+		loadBalancer.MonthlyCost, loadBalancer.CostSource = s.loadBalancerMonthlyCost(region, lbType)
 
 		lbs = append(lbs, loadBalancer)
 	}
@@ -355,6 +358,7 @@ func (s *AWSService) getClassicLoadBalancers(sess *session.Session, account mode
 
 		// Consider unused if no instances or no healthy instances
 		loadBalancer.IsUnused = instanceCount == 0 || loadBalancer.HealthyTargetCount == 0
+		loadBalancer.MonthlyCost, loadBalancer.CostSource = s.loadBalancerMonthlyCost(region, "classic")
 
 		lbs = append(lbs, loadBalancer)
 	}
@@ -115,19 +115,18 @@ func (s *AWSService) ListNATGatewaysByAccount(accountID string) ([]models.NATGat
 		return nil, fmt.Errorf("cannot access account %s: %v", accountID, err)
 	}
 
-	// Get all regions
-	ec2Client := ec2.New(sess)
-	regionsResult, err := ec2Client.DescribeRegions(&ec2.DescribeRegionsInput{})
+	scan, err := s.startRegionScan(scannerNATGateways, accountID, sess)
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe regions: %v", err)
+		return nil, err
 	}
+	regions := scan.Regions()
 
 	var allNATs []models.NATGateway
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
 	// Process all regions in parallel
-	for _, region := range regionsResult.Regions {
+	for _, region := range regions {
 		wg.Add(1)
 		go func(regionName string) {
 			defer wg.Done()
@@ -136,16 +135,18 @@ func (s *AWSService) ListNATGatewaysByAccount(accountID string) ([]models.NATGat
 			nats, err := s.getNATGatewaysInRegion(regionSess, accountID, accountName, regionName)
 			if err != nil {
 				fmt.Printf("[WARNING] Failed to get NAT Gateways in region %s for account %s: %v\n", regionName, accountID, err)
+				scan.Fail(regionName, err)
 				return
 			}
 
 			mu.Lock()
 			allNATs = append(allNATs, nats...)
 			mu.Unlock()
-		}(*region.RegionName)
+		}(region)
 	}
 
 	wg.Wait()
+	scan.Finish()
 
 	// Cache the result
 	s.cache.Set(cacheKey, allNATs, s.cacheTTL)
@@ -178,6 +179,7 @@ func (s *AWSService) getNATGatewaysInRegion(sess *session.Session, accountID, ac
 		if nat.CreateTime != nil {
 			n.CreateTime = nat.CreateTime
 		}
+		n.MonthlyCost, n.CostSource = s.natGatewayMonthlyCost(region, n.State)
 
 		// Get public and private IPs from addresses
 		for _, addr := range nat.NatGatewayAddresses {
@@ -0,0 +1,468 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+// ============================================================================
+// CUSTOMER-MANAGED POLICY MANAGEMENT
+// ============================================================================
+
+// ListPolicies returns all customer-managed policies in an account
+func (s *AWSService) ListPolicies(accountID string) ([]models.IAMPolicy, error) {
+	cacheKey := fmt.Sprintf("policies:%s", accountID)
+
+	// Check cache first
+	if cached, found := s.cache.Get(cacheKey); found {
+		if policies, ok := cached.([]models.IAMPolicy); ok {
+			return policies, nil
+		}
+	}
+
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+
+	iamClient := iam.New(sess)
+	accountName := s.lookupAccountName(accountID)
+
+	var policies []models.IAMPolicy
+	err = iamClient.ListPoliciesPages(&iam.ListPoliciesInput{
+		Scope: aws.String(iam.PolicyScopeTypeLocal),
+	}, func(page *iam.ListPoliciesOutput, lastPage bool) bool {
+		for _, policy := range page.Policies {
+			policies = append(policies, s.getPolicyDetails(iamClient, policy, accountID, accountName))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policies: %w", err)
+	}
+
+	// Cache the result
+	s.cache.Set(cacheKey, policies, s.cacheTTL)
+
+	return policies, nil
+}
+
+// ListAllPolicies returns customer-managed policies from all accessible accounts in parallel
+func (s *AWSService) ListAllPolicies() ([]models.IAMPolicy, error) {
+	const cacheKey = "all-policies"
+
+	// Check cache first
+	if cached, found := s.cache.Get(cacheKey); found {
+		if policies, ok := cached.([]models.IAMPolicy); ok {
+			return policies, nil
+		}
+	}
+
+	accounts, err := s.listAccessibleAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(accounts) == 0 {
+		return []models.IAMPolicy{}, nil
+	}
+
+	// Channel to collect results from goroutines
+	type accountResult struct {
+		policies  []models.IAMPolicy
+		err       error
+		accountID string
+	}
+
+	resultChan := make(chan accountResult, len(accounts))
+	var wg sync.WaitGroup
+
+	for _, account := range accounts {
+		wg.Add(1)
+		go func(acc models.Account) {
+			defer wg.Done()
+
+			policies, err := s.ListPolicies(acc.ID)
+			resultChan <- accountResult{
+				policies:  policies,
+				err:       err,
+				accountID: acc.ID,
+			}
+		}(account)
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	var allPolicies []models.IAMPolicy
+	for result := range resultChan {
+		if result.err != nil {
+			fmt.Printf("[WARNING] Failed to get policies for account %s: %v\n", result.accountID, result.err)
+			continue
+		}
+		allPolicies = append(allPolicies, result.policies...)
+	}
+
+	// Cache the result
+	s.cache.Set(cacheKey, allPolicies, s.cacheTTL)
+
+	return allPolicies, nil
+}
+
+// GetPolicy returns a customer-managed policy by name
+func (s *AWSService) GetPolicy(accountID, policyName string) (*models.IAMPolicy, error) {
+	policies, err := s.ListPolicies(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range policies {
+		if policies[i].PolicyName == policyName {
+			return &policies[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("policy %s not found in account %s", policyName, accountID)
+}
+
+// getPolicyDetails fills in the default version document and version count for a policy
+func (s *AWSService) getPolicyDetails(iamClient *iam.IAM, policy *iam.Policy, accountID, accountName string) models.IAMPolicy {
+	policyModel := models.IAMPolicy{
+		PolicyName:                    aws.StringValue(policy.PolicyName),
+		PolicyID:                      aws.StringValue(policy.PolicyId),
+		Arn:                           aws.StringValue(policy.Arn),
+		Path:                          aws.StringValue(policy.Path),
+		Description:                   aws.StringValue(policy.Description),
+		AccountID:                     accountID,
+		AccountName:                   accountName,
+		CreateDate:                    aws.TimeValue(policy.CreateDate),
+		UpdateDate:                    aws.TimeValue(policy.UpdateDate),
+		DefaultVersionID:              aws.StringValue(policy.DefaultVersionId),
+		AttachmentCount:               aws.Int64Value(policy.AttachmentCount),
+		PermissionsBoundaryUsageCount: aws.Int64Value(policy.PermissionsBoundaryUsageCount),
+	}
+	policyModel.Unattached = policyModel.AttachmentCount == 0 && policyModel.PermissionsBoundaryUsageCount == 0
+
+	// Get default version document
+	versionResult, err := iamClient.GetPolicyVersion(&iam.GetPolicyVersionInput{
+		PolicyArn: policy.Arn,
+		VersionId: policy.DefaultVersionId,
+	})
+	if err == nil && versionResult.PolicyVersion != nil {
+		policyModel.DefaultVersionDocument = decodePolicyDocument(aws.StringValue(versionResult.PolicyVersion.Document))
+	}
+
+	// Count versions
+	versionsResult, err := iamClient.ListPolicyVersions(&iam.ListPolicyVersionsInput{
+		PolicyArn: policy.Arn,
+	})
+	if err == nil {
+		policyModel.VersionCount = len(versionsResult.Versions)
+	}
+
+	return policyModel
+}
+
+// ListPolicyVersions returns all versions of a customer-managed policy, including documents
+func (s *AWSService) ListPolicyVersions(accountID, policyName string) ([]models.PolicyVersion, error) {
+	policy, err := s.GetPolicy(accountID, policyName)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+	iamClient := iam.New(sess)
+
+	result, err := iamClient.ListPolicyVersions(&iam.ListPolicyVersionsInput{
+		PolicyArn: aws.String(policy.Arn),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policy versions: %w", err)
+	}
+
+	var versions []models.PolicyVersion
+	for _, version := range result.Versions {
+		document, err := s.getPolicyVersionDocument(iamClient, policy.Arn, aws.StringValue(version.VersionId))
+		if err != nil {
+			fmt.Printf("[WARNING] Failed to get version %s of policy %s: %v\n", aws.StringValue(version.VersionId), policy.Arn, err)
+		}
+		versions = append(versions, models.PolicyVersion{
+			VersionID:        aws.StringValue(version.VersionId),
+			IsDefaultVersion: aws.BoolValue(version.IsDefaultVersion),
+			CreateDate:       aws.TimeValue(version.CreateDate),
+			Document:         document,
+		})
+	}
+
+	return versions, nil
+}
+
+// getPolicyVersionDocument returns the decoded document of a policy version
+func (s *AWSService) getPolicyVersionDocument(iamClient *iam.IAM, policyArn, versionID string) (string, error) {
+	result, err := iamClient.GetPolicyVersion(&iam.GetPolicyVersionInput{
+		PolicyArn: aws.String(policyArn),
+		VersionId: aws.String(versionID),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), iam.ErrCodeNoSuchEntityException) {
+			return "", fmt.Errorf("version %s of policy %s not found", versionID, policyArn)
+		}
+		return "", fmt.Errorf("failed to get policy version %s: %w", versionID, err)
+	}
+	return decodePolicyDocument(aws.StringValue(result.PolicyVersion.Document)), nil
+}
+
+// DiffPolicyVersions compares two versions of a customer-managed policy line by line
+func (s *AWSService) DiffPolicyVersions(accountID, policyName, fromVersion, toVersion string) (*models.PolicyVersionDiff, error) {
+	policy, err := s.GetPolicy(accountID, policyName)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+	iamClient := iam.New(sess)
+
+	fromDocument, err := s.getPolicyVersionDocument(iamClient, policy.Arn, fromVersion)
+	if err != nil {
+		return nil, err
+	}
+	toDocument, err := s.getPolicyVersionDocument(iamClient, policy.Arn, toVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := diffPolicyDocuments(fromDocument, toDocument)
+	identical := true
+	for _, line := range lines {
+		if line.Op != "equal" {
+			identical = false
+			break
+		}
+	}
+
+	return &models.PolicyVersionDiff{
+		PolicyArn:   policy.Arn,
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		Identical:   identical,
+		Lines:       lines,
+	}, nil
+}
+
+// DeleteNonDefaultPolicyVersions deletes every version of a policy except the default one
+func (s *AWSService) DeleteNonDefaultPolicyVersions(accountID, policyName string) ([]string, error) {
+	policy, err := s.GetPolicy(accountID, policyName)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+
+	deleted, err := s.deleteNonDefaultPolicyVersions(iam.New(sess), policy.Arn)
+
+	s.InvalidateAccountPoliciesCache(accountID)
+
+	return deleted, err
+}
+
+// deleteNonDefaultPolicyVersions deletes all non-default versions of a policy by ARN
+func (s *AWSService) deleteNonDefaultPolicyVersions(iamClient *iam.IAM, policyArn string) ([]string, error) {
+	result, err := iamClient.ListPolicyVersions(&iam.ListPolicyVersionsInput{
+		PolicyArn: aws.String(policyArn),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policy versions: %w", err)
+	}
+
+	deleted := []string{}
+	for _, version := range result.Versions {
+		if aws.BoolValue(version.IsDefaultVersion) {
+			continue
+		}
+		_, err := iamClient.DeletePolicyVersion(&iam.DeletePolicyVersionInput{
+			PolicyArn: aws.String(policyArn),
+			VersionId: version.VersionId,
+		})
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete policy version %s: %v", aws.StringValue(version.VersionId), err)
+		}
+		deleted = append(deleted, aws.StringValue(version.VersionId))
+	}
+
+	return deleted, nil
+}
+
+// DeletePolicy deletes an unattached customer-managed policy and all of its versions
+func (s *AWSService) DeletePolicy(accountID, policyName string) error {
+	policy, err := s.GetPolicy(accountID, policyName)
+	if err != nil {
+		return err
+	}
+
+	if !policy.Unattached {
+		return fmt.Errorf("policy %s is still attached to %d entities and used as a permissions boundary by %d", policyName, policy.AttachmentCount, policy.PermissionsBoundaryUsageCount)
+	}
+
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+
+	err = s.deletePolicyByArn(iam.New(sess), policy.Arn)
+
+	s.InvalidateAccountPoliciesCache(accountID)
+
+	return err
+}
+
+// deletePolicyByArn deletes non-default versions and then the policy itself
+func (s *AWSService) deletePolicyByArn(iamClient *iam.IAM, policyArn string) error {
+	// IAM refuses to delete a policy that still has non-default versions
+	if _, err := s.deleteNonDefaultPolicyVersions(iamClient, policyArn); err != nil {
+		return err
+	}
+
+	_, err := iamClient.DeletePolicy(&iam.DeletePolicyInput{
+		PolicyArn: aws.String(policyArn),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), iam.ErrCodeDeleteConflictException) {
+			return fmt.Errorf("policy %s is still attached: %v", policyArn, err)
+		}
+		return fmt.Errorf("failed to delete policy: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteUnattachedPolicies deletes all customer-managed policies that are not attached anywhere
+func (s *AWSService) DeleteUnattachedPolicies(accountID string) ([]string, []string, error) {
+	// Always work from fresh attachment counts before deleting
+	s.InvalidateAccountPoliciesCache(accountID)
+
+	policies, err := s.ListPolicies(accountID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+	iamClient := iam.New(sess)
+
+	deletedPolicies := []string{}
+	var failedPolicies []string
+	for _, policy := range policies {
+		if !policy.Unattached {
+			continue
+		}
+		if err := s.deletePolicyByArn(iamClient, policy.Arn); err != nil {
+			fmt.Printf("[WARNING] Failed to delete unattached policy %s in account %s: %v\n", policy.Arn, accountID, err)
+			failedPolicies = append(failedPolicies, policy.PolicyName)
+			continue
+		}
+		deletedPolicies = append(deletedPolicies, policy.PolicyName)
+	}
+
+	s.InvalidateAccountPoliciesCache(accountID)
+
+	return deletedPolicies, failedPolicies, nil
+}
+
+// InvalidatePoliciesCache invalidates the policies cache
+func (s *AWSService) InvalidatePoliciesCache() {
+	s.cache.Delete("all-policies")
+	s.cache.DeletePattern("policies:")
+}
+
+// InvalidateAccountPoliciesCache invalidates policies cache for a specific account
+func (s *AWSService) InvalidateAccountPoliciesCache(accountID string) {
+	s.cache.Delete(fmt.Sprintf("policies:%s", accountID))
+	s.cache.Delete("all-policies")
+}
+
+// decodePolicyDocument URL-decodes a policy document as returned by IAM. It is applied once where
+// documents leave the IAM API. Path unescaping keeps a literal "+", which is valid in IAM names.
+func decodePolicyDocument(document string) string {
+	decoded, err := url.PathUnescape(document)
+	if err != nil {
+		return document
+	}
+	return decoded
+}
+
+// diffPolicyDocuments produces a line diff of two policy documents after normalizing their formatting
+func diffPolicyDocuments(from, to string) []models.PolicyDiffLine {
+	fromLines := strings.Split(normalizePolicyDocument(from), "\n")
+	toLines := strings.Split(normalizePolicyDocument(to), "\n")
+
+	// Longest common subsequence table
+	lcs := make([][]int, len(fromLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(toLines)+1)
+	}
+	for i := len(fromLines) - 1; i >= 0; i-- {
+		for j := len(toLines) - 1; j >= 0; j-- {
+			if fromLines[i] == toLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var lines []models.PolicyDiffLine
+	i, j := 0, 0
+	for i < len(fromLines) && j < len(toLines) {
+		switch {
+		case fromLines[i] == toLines[j]:
+			lines = append(lines, models.PolicyDiffLine{Op: "equal", Text: fromLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, models.PolicyDiffLine{Op: "removed", Text: fromLines[i]})
+			i++
+		default:
+			lines = append(lines, models.PolicyDiffLine{Op: "added", Text: toLines[j]})
+			j++
+		}
+	}
+	for ; i < len(fromLines); i++ {
+		lines = append(lines, models.PolicyDiffLine{Op: "removed", Text: fromLines[i]})
+	}
+	for ; j < len(toLines); j++ {
+		lines = append(lines, models.PolicyDiffLine{Op: "added", Text: toLines[j]})
+	}
+
+	return lines
+}
+
+// normalizePolicyDocument pretty-prints a JSON policy so formatting differences do not show up in diffs
+func normalizePolicyDocument(document string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(document), "", "  "); err != nil {
+		return document
+	}
+	return buf.String()
+}
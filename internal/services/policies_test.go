@@ -0,0 +1,40 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffPolicyDocuments(t *testing.T) {
+	from := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:GetObject","Resource":"*"}]}`
+	to := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"s3:PutObject","Resource":"*"}]}`
+
+	lines := diffPolicyDocuments(from, to)
+
+	var added, removed []string
+	for _, line := range lines {
+		switch line.Op {
+		case "added":
+			added = append(added, line.Text)
+		case "removed":
+			removed = append(removed, line.Text)
+		}
+	}
+	assert.Equal(t, []string{`      "Action": "s3:PutObject",`}, added)
+	assert.Equal(t, []string{`      "Action": "s3:GetObject",`}, removed)
+}
+
+func TestDiffPolicyDocumentsIgnoresFormatting(t *testing.T) {
+	from := `{"Version":"2012-10-17"}`
+	to := "{\n    \"Version\": \"2012-10-17\"\n}"
+
+	for _, line := range diffPolicyDocuments(from, to) {
+		assert.Equal(t, "equal", line.Op)
+	}
+}
+
+func TestDecodePolicyDocument(t *testing.T) {
+	assert.Equal(t, `{"Version":"2012-10-17"}`, decodePolicyDocument("%7B%22Version%22%3A%222012-10-17%22%7D"))
+	assert.Equal(t, `{"Resource":"arn:aws:iam::123456789012:user/a+b"}`, decodePolicyDocument("%7B%22Resource%22%3A%22arn%3Aaws%3Aiam%3A%3A123456789012%3Auser%2Fa+b%22%7D"))
+}
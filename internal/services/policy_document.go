@@ -0,0 +1,229 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ============================================================================
+// IAM POLICY DOCUMENT PARSING
+// ============================================================================
+
+// policyDocument is a parsed IAM policy document
+type policyDocument struct {
+	Version   string            `json:"Version"`
+	Statement []policyStatement `json:"Statement"`
+}
+
+// policyStatement is a single statement of an IAM policy document
+type policyStatement struct {
+	Sid          string                    `json:"Sid,omitempty"`
+	Effect       string                    `json:"Effect"`
+	Principal    policyPrincipal           `json:"Principal,omitempty"`
+	NotPrincipal policyPrincipal           `json:"NotPrincipal,omitempty"`
+	Action       stringOrSlice             `json:"Action,omitempty"`
+	NotAction    stringOrSlice             `json:"NotAction,omitempty"`
+	Resource     stringOrSlice             `json:"Resource,omitempty"`
+	NotResource  stringOrSlice             `json:"NotResource,omitempty"`
+	Condition    map[string]map[string]any `json:"Condition,omitempty"`
+	raw          json.RawMessage
+}
+
+// stringOrSlice accepts either a single string or a list of strings
+type stringOrSlice []string
+
+// policyPrincipal maps principal types (AWS, Service, Federated) to identifiers.
+// A bare "*" principal is stored under the "*" key.
+type policyPrincipal map[string]stringOrSlice
+
+func (s *stringOrSlice) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = stringOrSlice{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("expected string or list of strings: %w", err)
+	}
+	*s = multiple
+	return nil
+}
+
+func (p *policyPrincipal) UnmarshalJSON(data []byte) error {
+	var wildcard string
+	if err := json.Unmarshal(data, &wildcard); err == nil {
+		*p = policyPrincipal{"*": stringOrSlice{wildcard}}
+		return nil
+	}
+
+	var principals map[string]stringOrSlice
+	if err := json.Unmarshal(data, &principals); err != nil {
+		return fmt.Errorf("invalid principal: %w", err)
+	}
+	*p = principals
+	return nil
+}
+
+func (s *policyStatement) UnmarshalJSON(data []byte) error {
+	type statementAlias policyStatement
+	var alias statementAlias
+	if err := json.Unmarshal(data, &alias); err != nil {
+		return err
+	}
+	*s = policyStatement(alias)
+	s.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+func (d *policyDocument) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Version   string          `json:"Version"`
+		Statement json.RawMessage `json:"Statement"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	d.Version = raw.Version
+
+	if len(raw.Statement) == 0 {
+		return nil
+	}
+
+	// Statement may be a single object or a list of objects
+	if strings.HasPrefix(strings.TrimSpace(string(raw.Statement)), "{") {
+		var statement policyStatement
+		if err := json.Unmarshal(raw.Statement, &statement); err != nil {
+			return err
+		}
+		d.Statement = []policyStatement{statement}
+		return nil
+	}
+
+	return json.Unmarshal(raw.Statement, &d.Statement)
+}
+
+// parsePolicyDocument parses an IAM policy document that has already been URL-decoded
+func parsePolicyDocument(document string) (*policyDocument, error) {
+	var doc policyDocument
+	if err := json.Unmarshal([]byte(document), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse policy document: %w", err)
+	}
+	return &doc, nil
+}
+
+// String returns the statement as it appeared in the source document
+func (s policyStatement) String() string {
+	return string(s.raw)
+}
+
+// isAllow reports whether the statement allows access
+func (s policyStatement) isAllow() bool {
+	return strings.EqualFold(s.Effect, "Allow")
+}
+
+// hasCondition reports whether the statement only applies under conditions
+func (s policyStatement) hasCondition() bool {
+	return len(s.Condition) > 0
+}
+
+// matchesAction reports whether the statement covers an action, honoring NotAction
+func (s policyStatement) matchesAction(action string) bool {
+	if len(s.NotAction) > 0 {
+		for _, pattern := range s.NotAction {
+			if wildcardMatch(pattern, action) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for _, pattern := range s.Action {
+		if wildcardMatch(pattern, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// coversResourcesOf reports whether the statement covers every resource the other statement does,
+// honoring NotResource on both. Resource patterns are compared as patterns, so a deny on
+// "arn:aws:iam::*:role/*" covers an allow on "arn:aws:iam::123456789012:role/app" but not the reverse.
+func (s policyStatement) coversResourcesOf(other policyStatement) bool {
+	// Only a statement on every resource can cover a NotResource statement's open-ended set
+	if len(other.NotResource) > 0 {
+		return s.appliesToAllResources()
+	}
+
+	for _, resource := range other.Resource {
+		covered := false
+		if len(s.NotResource) > 0 {
+			// Covered unless the resource may overlap an excluded pattern
+			covered = true
+			for _, excluded := range s.NotResource {
+				if wildcardMatch(excluded, resource) || wildcardMatch(resource, excluded) {
+					covered = false
+					break
+				}
+			}
+		} else {
+			for _, pattern := range s.Resource {
+				if wildcardMatch(pattern, resource) {
+					covered = true
+					break
+				}
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return len(other.Resource) > 0
+}
+
+// appliesToAllResources reports whether the statement covers every resource
+func (s policyStatement) appliesToAllResources() bool {
+	if len(s.NotResource) > 0 {
+		return false
+	}
+	for _, resource := range s.Resource {
+		if resource == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcardMatch matches IAM-style patterns where * matches any run of characters
+// and ? matches a single character. Matching is case-insensitive, as IAM actions are.
+func wildcardMatch(pattern, value string) bool {
+	pattern = strings.ToLower(pattern)
+	value = strings.ToLower(value)
+
+	p, v := 0, 0
+	starP, starV := -1, 0
+	for v < len(value) {
+		switch {
+		case p < len(pattern) && (pattern[p] == '?' || pattern[p] == value[v]):
+			p++
+			v++
+		case p < len(pattern) && pattern[p] == '*':
+			starP = p
+			starV = v
+			p++
+		case starP != -1:
+			p = starP + 1
+			starV++
+			v = starV
+		default:
+			return false
+		}
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
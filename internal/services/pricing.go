@@ -0,0 +1,199 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+	"github.com/rusik69/aws-iam-manager/internal/pricing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// ============================================================================
+// PRICING
+// ============================================================================
+//
+// Cost estimates use the price of the resource's region, operating system, tenancy and license
+// from the price list files in PRICE_LIST_FILES. Resources the price list does not cover, or every
+// resource when no price list is loaded, fall back to the built-in us-east-1 Linux prices and
+// report CostSourceBuiltin.
+
+// Built-in us-east-1 hourly prices for NAT gateways and load balancers
+const builtinNATGatewayHourly = 0.045
+
+var builtinLoadBalancerHourly = map[string]float64{
+	"application": 0.0225,
+	"network":     0.0225,
+	"gateway":     0.0125,
+	"classic":     0.025,
+}
+
+// priceCatalog holds the price list loaded from the configured files
+type priceCatalog struct {
+	mu       sync.RWMutex
+	files    []string
+	prices   *pricing.PriceList
+	loadedAt time.Time
+	loadErr  error
+}
+
+// newPriceCatalog loads the configured price list files. Built-in prices are used if none load.
+func newPriceCatalog(files []string) *priceCatalog {
+	c := &priceCatalog{files: files}
+	if len(files) == 0 {
+		return c
+	}
+
+	if err := c.reload(); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			fmt.Printf("[INFO] Price list %v not found, using built-in prices\n", files)
+		} else {
+			fmt.Printf("[WARNING] Failed to load price list, using built-in prices: %v\n", err)
+		}
+		return c
+	}
+
+	ec2Prices, ebsPrices, _, _ := c.prices.Counts()
+	fmt.Printf("[INFO] Loaded price list with %d EC2 and %d EBS prices\n", ec2Prices, ebsPrices)
+	return c
+}
+
+// reload reads the price list files again. The previous prices stay in use if loading fails.
+func (c *priceCatalog) reload() error {
+	if len(c.files) == 0 {
+		return fmt.Errorf("no price list files configured; set PRICE_LIST_FILES or STATE_DIR")
+	}
+
+	prices, err := pricing.Load(c.files...)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.loadErr = err
+	if err != nil {
+		return err
+	}
+	c.prices = prices
+	c.loadedAt = time.Now()
+	return nil
+}
+
+// list returns the loaded price list, or nil if none is loaded
+func (c *priceCatalog) list() *pricing.PriceList {
+	if c == nil {
+		return nil
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.prices
+}
+
+// status describes the loaded price list
+func (c *priceCatalog) status() models.PriceListStatus {
+	status := models.PriceListStatus{Files: []string{}}
+	if c == nil {
+		return status
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	status.Files = append(status.Files, c.files...)
+	if c.loadErr != nil {
+		status.Error = c.loadErr.Error()
+	}
+	if c.prices != nil {
+		status.Loaded = true
+		status.GeneratedAt = c.prices.GeneratedAt
+		status.LoadedAt = c.loadedAt
+		status.EC2Prices, status.EBSPrices, status.NATGatewayPrices, status.LoadBalancerPrices = c.prices.Counts()
+	}
+	return status
+}
+
+// GetPriceListStatus returns the price list used for cost estimates
+func (s *AWSService) GetPriceListStatus() models.PriceListStatus {
+	return s.prices.status()
+}
+
+// ReloadPriceList reads the price list files again and drops cached resources so their costs are recalculated
+func (s *AWSService) ReloadPriceList() (models.PriceListStatus, error) {
+	if err := s.prices.reload(); err != nil {
+		return s.prices.status(), fmt.Errorf("failed to load price list: %w", err)
+	}
+
+	for _, prefix := range []string{"ec2-instances", "ebs-volumes", "nat-gateways", "all-nat-gateways", "load-balancers"} {
+		s.cache.DeletePattern(prefix)
+	}
+	return s.prices.status(), nil
+}
+
+// ec2InstanceMonthlyCost estimates the monthly cost of an instance; only running instances incur compute costs
+func (s *AWSService) ec2InstanceMonthlyCost(region string, instance *ec2.Instance) (float64, string) {
+	instanceType := aws.StringValue(instance.InstanceType)
+	state := ""
+	if instance.State != nil {
+		state = aws.StringValue(instance.State.Name)
+	}
+
+	if prices := s.prices.list(); prices != nil {
+		tenancy := ""
+		if instance.Placement != nil {
+			tenancy = aws.StringValue(instance.Placement.Tenancy)
+		}
+		key := pricing.EC2KeyFor(region, instanceType, aws.StringValue(instance.PlatformDetails), tenancy, aws.StringValue(instance.UsageOperation))
+		if hourly, found := prices.EC2Hourly(key); found {
+			if state != "running" {
+				return 0, models.CostSourcePriceList
+			}
+			return roundCost(hourly * pricing.HoursPerMonth), models.CostSourcePriceList
+		}
+	}
+
+	return calculateEC2InstanceMonthlyCost(instanceType, state), models.CostSourceBuiltin
+}
+
+// ebsVolumeMonthlyCost estimates the monthly cost of a volume
+func (s *AWSService) ebsVolumeMonthlyCost(region, volumeType string, sizeGB, iops, throughputMBs int64) (float64, string) {
+	if prices := s.prices.list(); prices != nil {
+		if price, found := prices.EBS(region, volumeType); found {
+			return roundCost(price.MonthlyCost(sizeGB, iops, throughputMBs)), models.CostSourcePriceList
+		}
+	}
+	return calculateEBSVolumeMonthlyCost(volumeType, sizeGB, iops, throughputMBs), models.CostSourceBuiltin
+}
+
+// natGatewayMonthlyCost estimates the hourly charge of a NAT gateway per month; only available gateways are billed
+func (s *AWSService) natGatewayMonthlyCost(region, state string) (float64, string) {
+	hourly, source := builtinNATGatewayHourly, models.CostSourceBuiltin
+	if prices := s.prices.list(); prices != nil {
+		if price, found := prices.NATGateway(region); found {
+			hourly, source = price.Hourly, models.CostSourcePriceList
+		}
+	}
+
+	if state != "available" {
+		return 0, source
+	}
+	return roundCost(hourly * pricing.HoursPerMonth), source
+}
+
+// loadBalancerMonthlyCost estimates the hourly charge of a load balancer per month
+func (s *AWSService) loadBalancerMonthlyCost(region, lbType string) (float64, string) {
+	if prices := s.prices.list(); prices != nil {
+		if price, found := prices.LoadBalancer(region, lbType); found {
+			return roundCost(price.Hourly * pricing.HoursPerMonth), models.CostSourcePriceList
+		}
+	}
+	return roundCost(builtinLoadBalancerHourly[lbType] * pricing.HoursPerMonth), models.CostSourceBuiltin
+}
+
+// roundCost rounds a cost to cents
+func roundCost(cost float64) float64 {
+	return math.Round(cost*100) / 100
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPriceFile = `{
+  "generated_at": "2026-10-01T00:00:00Z",
+  "currency": "USD",
+  "ec2": [
+    {"region": "eu-west-1", "instance_type": "m5.large", "operating_system": "Linux", "pre_installed_sw": "NA", "tenancy": "Shared", "license_model": "No License required", "hourly": 0.107},
+    {"region": "eu-west-1", "instance_type": "m5.large", "operating_system": "Windows", "pre_installed_sw": "NA", "tenancy": "Shared", "license_model": "No License required", "hourly": 0.199},
+    {"region": "eu-west-1", "instance_type": "m5.large", "operating_system": "Windows", "pre_installed_sw": "NA", "tenancy": "Shared", "license_model": "Bring your own license", "hourly": 0.107}
+  ],
+  "ebs": [
+    {"region": "eu-west-1", "volume_type": "gp3", "gb_month": 0.088, "iops_month": 0.0055, "throughput_month": 0.044}
+  ],
+  "nat_gateways": [
+    {"region": "eu-west-1", "hourly": 0.048, "per_gb": 0.048}
+  ],
+  "load_balancers": [
+    {"region": "eu-west-1", "type": "application", "hourly": 0.0252, "capacity_unit_hourly": 0.008}
+  ]
+}`
+
+func newTestPricedService(t *testing.T) *AWSService {
+	path := filepath.Join(t.TempDir(), "aws-prices.json")
+	require.NoError(t, os.WriteFile(path, []byte(testPriceFile), 0600))
+
+	s := &AWSService{cache: NewCache(), prices: newPriceCatalog([]string{path})}
+	require.True(t, s.GetPriceListStatus().Loaded)
+	return s
+}
+
+func TestEC2InstanceMonthlyCost(t *testing.T) {
+	s := newTestPricedService(t)
+
+	instance := func(platform, state string) *ec2.Instance {
+		return &ec2.Instance{
+			InstanceType:    aws.String("m5.large"),
+			PlatformDetails: aws.String(platform),
+			State:           &ec2.InstanceState{Name: aws.String(state)},
+			Placement:       &ec2.Placement{Tenancy: aws.String("default")},
+			UsageOperation:  aws.String("RunInstances"),
+		}
+	}
+
+	cost, source := s.ec2InstanceMonthlyCost("eu-west-1", instance("Linux/UNIX", "running"))
+	assert.Equal(t, 77.04, cost)
+	assert.Equal(t, models.CostSourcePriceList, source)
+
+	cost, source = s.ec2InstanceMonthlyCost("eu-west-1", instance("Windows", "running"))
+	assert.Equal(t, 143.28, cost)
+	assert.Equal(t, models.CostSourcePriceList, source)
+
+	cost, source = s.ec2InstanceMonthlyCost("eu-west-1", instance("Windows", "stopped"))
+	assert.Equal(t, 0.0, cost)
+	assert.Equal(t, models.CostSourcePriceList, source)
+
+	// Regions missing from the price list fall back to the built-in prices
+	cost, source = s.ec2InstanceMonthlyCost("ap-south-1", instance("Linux/UNIX", "running"))
+	assert.Equal(t, calculateEC2InstanceMonthlyCost("m5.large", "running"), cost)
+	assert.Equal(t, models.CostSourceBuiltin, source)
+}
+
+func TestResourceMonthlyCostsWithoutPriceList(t *testing.T) {
+	s := &AWSService{cache: NewCache()}
+
+	cost, source := s.ebsVolumeMonthlyCost("eu-west-1", "gp3", 100, 3000, 125)
+	assert.Equal(t, 8.0, cost)
+	assert.Equal(t, models.CostSourceBuiltin, source)
+
+	cost, source = s.natGatewayMonthlyCost("eu-west-1", "available")
+	assert.Equal(t, 32.4, cost)
+	assert.Equal(t, models.CostSourceBuiltin, source)
+
+	cost, _ = s.loadBalancerMonthlyCost("eu-west-1", "classic")
+	assert.Equal(t, 18.0, cost)
+
+	status := s.GetPriceListStatus()
+	assert.False(t, status.Loaded)
+	assert.Empty(t, status.Files)
+}
+
+func TestResourceMonthlyCostsFromPriceList(t *testing.T) {
+	s := newTestPricedService(t)
+
+	// 100 GiB plus 1,000 IOPS and 25 MiB/s above the gp3 baseline
+	cost, source := s.ebsVolumeMonthlyCost("eu-west-1", "gp3", 100, 4000, 150)
+	assert.Equal(t, 15.40, cost)
+	assert.Equal(t, models.CostSourcePriceList, source)
+
+	cost, source = s.natGatewayMonthlyCost("eu-west-1", "available")
+	assert.Equal(t, 34.56, cost)
+	assert.Equal(t, models.CostSourcePriceList, source)
+
+	cost, _ = s.natGatewayMonthlyCost("eu-west-1", "deleted")
+	assert.Equal(t, 0.0, cost)
+
+	cost, source = s.loadBalancerMonthlyCost("eu-west-1", "application")
+	assert.Equal(t, 18.14, cost)
+	assert.Equal(t, models.CostSourcePriceList, source)
+
+	_, source = s.loadBalancerMonthlyCost("eu-west-1", "network")
+	assert.Equal(t, models.CostSourceBuiltin, source)
+}
+
+func TestReloadPriceList(t *testing.T) {
+	s := newTestPricedService(t)
+	s.cache.Set("ec2-instances", []models.EC2Instance{}, time.Minute)
+	s.cache.Set("nat-gateways-123456789012", []models.NATGateway{}, time.Minute)
+	s.cache.Set("all-nat-gateways", []models.NATGateway{}, time.Minute)
+
+	status, err := s.ReloadPriceList()
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.EC2Prices)
+
+	_, found := s.cache.Get("ec2-instances")
+	assert.False(t, found)
+	_, found = s.cache.Get("nat-gateways-123456789012")
+	assert.False(t, found)
+	_, found = s.cache.Get("all-nat-gateways")
+	assert.False(t, found)
+
+	// A failed reload keeps the previous prices
+	require.NoError(t, os.Remove(s.prices.files[0]))
+	status, err = s.ReloadPriceList()
+	require.Error(t, err)
+	assert.True(t, status.Loaded)
+	assert.NotEmpty(t, status.Error)
+}
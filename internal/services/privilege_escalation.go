@@ -0,0 +1,405 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+// ============================================================================
+// PRIVILEGE ESCALATION ANALYSIS
+// ============================================================================
+
+// escalationTechnique is a known combination of permissions that lets a principal gain more privileges
+type escalationTechnique struct {
+	id          string
+	description string
+	severity    string
+	actions     []string
+	// wildcardResource requires every action to be granted on all resources ("*")
+	wildcardResource bool
+}
+
+// escalationTechniques lists the privilege escalation paths the analyzer checks for
+var escalationTechniques = []escalationTechnique{
+	{id: "create_policy_version", severity: "high", actions: []string{"iam:CreatePolicyVersion"},
+		description: "Can publish a new default version of any managed policy it is allowed to modify, including ones attached to itself"},
+	{id: "set_default_policy_version", severity: "high", actions: []string{"iam:SetDefaultPolicyVersion"},
+		description: "Can switch a managed policy to an older, possibly more permissive version"},
+	{id: "create_access_key", severity: "high", actions: []string{"iam:CreateAccessKey"},
+		description: "Can create access keys for other users and act as them"},
+	{id: "create_login_profile", severity: "high", actions: []string{"iam:CreateLoginProfile"},
+		description: "Can set a console password for users that do not have one and sign in as them"},
+	{id: "update_login_profile", severity: "high", actions: []string{"iam:UpdateLoginProfile"},
+		description: "Can change other users' console passwords and sign in as them"},
+	{id: "attach_user_policy", severity: "high", actions: []string{"iam:AttachUserPolicy"},
+		description: "Can attach any managed policy, such as AdministratorAccess, to a user"},
+	{id: "attach_group_policy", severity: "high", actions: []string{"iam:AttachGroupPolicy"},
+		description: "Can attach any managed policy to a group"},
+	{id: "attach_role_policy", severity: "high", actions: []string{"iam:AttachRolePolicy"},
+		description: "Can attach any managed policy to a role"},
+	{id: "put_user_policy", severity: "high", actions: []string{"iam:PutUserPolicy"},
+		description: "Can write arbitrary inline policies on a user"},
+	{id: "put_group_policy", severity: "high", actions: []string{"iam:PutGroupPolicy"},
+		description: "Can write arbitrary inline policies on a group"},
+	{id: "put_role_policy", severity: "high", actions: []string{"iam:PutRolePolicy"},
+		description: "Can write arbitrary inline policies on a role"},
+	{id: "add_user_to_group", severity: "high", actions: []string{"iam:AddUserToGroup"},
+		description: "Can add users to more privileged groups"},
+	{id: "update_assume_role_policy", severity: "high", actions: []string{"iam:UpdateAssumeRolePolicy", "sts:AssumeRole"},
+		description: "Can rewrite a role's trust policy to trust itself and then assume the role"},
+	{id: "assume_any_role", severity: "high", actions: []string{"sts:AssumeRole"}, wildcardResource: true,
+		description: "Can assume any role whose trust policy allows it"},
+	{id: "pass_role_ec2", severity: "high", actions: []string{"iam:PassRole", "ec2:RunInstances"},
+		description: "Can launch an instance with a more privileged instance profile and use its credentials"},
+	{id: "pass_role_lambda", severity: "high", actions: []string{"iam:PassRole", "lambda:CreateFunction", "lambda:InvokeFunction"},
+		description: "Can create and invoke a Lambda function running as a more privileged role"},
+	{id: "pass_role_cloudformation", severity: "high", actions: []string{"iam:PassRole", "cloudformation:CreateStack"},
+		description: "Can create a CloudFormation stack that provisions resources as a more privileged role"},
+	{id: "pass_role_glue", severity: "high", actions: []string{"iam:PassRole", "glue:CreateDevEndpoint"},
+		description: "Can create a Glue development endpoint running as a more privileged role"},
+	{id: "pass_role_ecs", severity: "high", actions: []string{"iam:PassRole", "ecs:RegisterTaskDefinition", "ecs:RunTask"},
+		description: "Can run an ECS task with a more privileged task role"},
+	{id: "update_lambda_code", severity: "medium", actions: []string{"lambda:UpdateFunctionCode"},
+		description: "Can replace the code of existing Lambda functions and run as their execution roles"},
+}
+
+// escalationPrincipal is a user or role together with every statement that applies to it
+type escalationPrincipal struct {
+	principalType string
+	name          string
+	arn           string
+	statements    []attributedStatement
+}
+
+// attributedStatement is a policy statement together with where it came from
+type attributedStatement struct {
+	statement policyStatement
+	source    models.GrantingStatement
+}
+
+// AnalyzePrivilegeEscalation reports users and roles in an account that can escalate their privileges
+func (s *AWSService) AnalyzePrivilegeEscalation(accountID string) ([]models.PrivilegeEscalationFinding, error) {
+	cacheKey := fmt.Sprintf("privesc:%s", accountID)
+
+	// Check cache first
+	if cached, found := s.cache.Get(cacheKey); found {
+		if findings, ok := cached.([]models.PrivilegeEscalationFinding); ok {
+			return findings, nil
+		}
+	}
+
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+
+	details, err := getAccountAuthorizationDetails(iam.New(sess))
+	if err != nil {
+		return nil, err
+	}
+
+	account := models.Account{ID: accountID, Name: s.lookupAccountName(accountID)}
+	findings := []models.PrivilegeEscalationFinding{}
+	for _, principal := range buildEscalationPrincipals(details) {
+		findings = append(findings, analyzeEscalationPrincipal(principal, account)...)
+	}
+
+	// Cache the result
+	s.cache.Set(cacheKey, findings, s.cacheTTL)
+
+	return findings, nil
+}
+
+// AnalyzeOrgPrivilegeEscalation runs the privilege escalation analysis across all accessible accounts
+func (s *AWSService) AnalyzeOrgPrivilegeEscalation() ([]models.PrivilegeEscalationFinding, error) {
+	accounts, err := s.listAccessibleAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	type accountResult struct {
+		findings  []models.PrivilegeEscalationFinding
+		err       error
+		accountID string
+	}
+
+	resultChan := make(chan accountResult, len(accounts))
+	var wg sync.WaitGroup
+
+	for _, account := range accounts {
+		wg.Add(1)
+		go func(acc models.Account) {
+			defer wg.Done()
+			findings, err := s.AnalyzePrivilegeEscalation(acc.ID)
+			resultChan <- accountResult{findings: findings, err: err, accountID: acc.ID}
+		}(account)
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	allFindings := []models.PrivilegeEscalationFinding{}
+	for result := range resultChan {
+		if result.err != nil {
+			fmt.Printf("[WARNING] Failed to analyze privilege escalation for account %s: %v\n", result.accountID, result.err)
+			continue
+		}
+		allFindings = append(allFindings, result.findings...)
+	}
+
+	return allFindings, nil
+}
+
+// getAccountAuthorizationDetails fetches all users, groups, roles and managed policies in one paginated call
+func getAccountAuthorizationDetails(iamClient *iam.IAM) (*iam.GetAccountAuthorizationDetailsOutput, error) {
+	details := &iam.GetAccountAuthorizationDetailsOutput{}
+	err := iamClient.GetAccountAuthorizationDetailsPages(&iam.GetAccountAuthorizationDetailsInput{},
+		func(page *iam.GetAccountAuthorizationDetailsOutput, lastPage bool) bool {
+			details.UserDetailList = append(details.UserDetailList, page.UserDetailList...)
+			details.GroupDetailList = append(details.GroupDetailList, page.GroupDetailList...)
+			details.RoleDetailList = append(details.RoleDetailList, page.RoleDetailList...)
+			details.Policies = append(details.Policies, page.Policies...)
+			return true
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account authorization details: %w", err)
+	}
+	return details, nil
+}
+
+// buildEscalationPrincipals resolves every user's and role's effective policy statements
+func buildEscalationPrincipals(details *iam.GetAccountAuthorizationDetailsOutput) []escalationPrincipal {
+	// Default version documents of managed policies, by ARN
+	managedPolicies := make(map[string]string)
+	for _, policy := range details.Policies {
+		for _, version := range policy.PolicyVersionList {
+			if aws.BoolValue(version.IsDefaultVersion) {
+				managedPolicies[aws.StringValue(policy.Arn)] = decodePolicyDocument(aws.StringValue(version.Document))
+			}
+		}
+	}
+
+	addManaged := func(statements []attributedStatement, attached []*iam.AttachedPolicy, groupName string) []attributedStatement {
+		for _, policy := range attached {
+			document, found := managedPolicies[aws.StringValue(policy.PolicyArn)]
+			if !found {
+				continue
+			}
+			statements = append(statements, attributeStatements(document, models.GrantingStatement{
+				PolicyName: aws.StringValue(policy.PolicyName),
+				PolicyArn:  aws.StringValue(policy.PolicyArn),
+				PolicyType: "managed",
+				Source:     groupName,
+			})...)
+		}
+		return statements
+	}
+
+	groupStatements := make(map[string][]attributedStatement)
+	for _, group := range details.GroupDetailList {
+		groupName := aws.StringValue(group.GroupName)
+		var statements []attributedStatement
+		for _, policy := range group.GroupPolicyList {
+			statements = append(statements, attributeStatements(decodePolicyDocument(aws.StringValue(policy.PolicyDocument)), models.GrantingStatement{
+				PolicyName: aws.StringValue(policy.PolicyName),
+				PolicyType: "inline",
+				Source:     groupName,
+			})...)
+		}
+		groupStatements[groupName] = addManaged(statements, group.AttachedManagedPolicies, groupName)
+	}
+
+	var principals []escalationPrincipal
+	for _, user := range details.UserDetailList {
+		principal := escalationPrincipal{
+			principalType: "user",
+			name:          aws.StringValue(user.UserName),
+			arn:           aws.StringValue(user.Arn),
+		}
+		for _, policy := range user.UserPolicyList {
+			principal.statements = append(principal.statements, attributeStatements(decodePolicyDocument(aws.StringValue(policy.PolicyDocument)), models.GrantingStatement{
+				PolicyName: aws.StringValue(policy.PolicyName),
+				PolicyType: "inline",
+			})...)
+		}
+		principal.statements = addManaged(principal.statements, user.AttachedManagedPolicies, "")
+		for _, groupName := range user.GroupList {
+			principal.statements = append(principal.statements, groupStatements[aws.StringValue(groupName)]...)
+		}
+		principals = append(principals, principal)
+	}
+
+	for _, role := range details.RoleDetailList {
+		// Service-linked roles are managed by AWS and cannot be assumed by users
+		if strings.HasPrefix(aws.StringValue(role.Path), "/aws-service-role/") {
+			continue
+		}
+		principal := escalationPrincipal{
+			principalType: "role",
+			name:          aws.StringValue(role.RoleName),
+			arn:           aws.StringValue(role.Arn),
+		}
+		for _, policy := range role.RolePolicyList {
+			principal.statements = append(principal.statements, attributeStatements(decodePolicyDocument(aws.StringValue(policy.PolicyDocument)), models.GrantingStatement{
+				PolicyName: aws.StringValue(policy.PolicyName),
+				PolicyType: "inline",
+			})...)
+		}
+		principal.statements = addManaged(principal.statements, role.AttachedManagedPolicies, "")
+		principals = append(principals, principal)
+	}
+
+	return principals
+}
+
+// attributeStatements parses a policy document and tags each statement with its source
+func attributeStatements(document string, source models.GrantingStatement) []attributedStatement {
+	doc, err := parsePolicyDocument(document)
+	if err != nil {
+		fmt.Printf("[WARNING] Skipping unparseable policy %s: %v\n", source.PolicyName, err)
+		return nil
+	}
+
+	statements := make([]attributedStatement, 0, len(doc.Statement))
+	for _, statement := range doc.Statement {
+		attributed := attributedStatement{statement: statement, source: source}
+		attributed.source.Sid = statement.Sid
+		attributed.source.Statement = statement.String()
+		statements = append(statements, attributed)
+	}
+	return statements
+}
+
+// analyzeEscalationPrincipal checks a principal against every known escalation technique
+func analyzeEscalationPrincipal(principal escalationPrincipal, account models.Account) []models.PrivilegeEscalationFinding {
+	newFinding := func(technique, description, severity string, actions []string, grants []attributedStatement) models.PrivilegeEscalationFinding {
+		finding := models.PrivilegeEscalationFinding{
+			AccountID:       account.ID,
+			AccountName:     account.Name,
+			PrincipalType:   principal.principalType,
+			PrincipalName:   principal.name,
+			PrincipalArn:    principal.arn,
+			Technique:       technique,
+			Description:     description,
+			Severity:        severity,
+			RequiredActions: actions,
+		}
+		for _, grant := range grants {
+			finding.GrantingStatements = append(finding.GrantingStatements, grant.source)
+			if grant.statement.hasCondition() {
+				finding.Conditional = true
+			}
+		}
+		// Conditions may well prevent the escalation, so do not report it at full severity
+		if finding.Conditional {
+			finding.Severity = "medium"
+		}
+		return finding
+	}
+
+	// Full administrators can do everything; reporting each technique separately is just noise
+	if grants := findAdminStatements(principal.statements); len(grants) > 0 {
+		return []models.PrivilegeEscalationFinding{
+			newFinding("administrator_access", "Has unrestricted access to all actions on all resources", "critical", []string{"*"}, grants),
+		}
+	}
+
+	var findings []models.PrivilegeEscalationFinding
+	for _, technique := range escalationTechniques {
+		var grants []attributedStatement
+		granted := true
+		for _, action := range technique.actions {
+			actionGrants := findGrantingStatements(principal.statements, action, technique.wildcardResource)
+			if len(actionGrants) == 0 {
+				granted = false
+				break
+			}
+			grants = append(grants, actionGrants...)
+		}
+		if granted {
+			findings = append(findings, newFinding(technique.id, technique.description, technique.severity, technique.actions, grants))
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Technique < findings[j].Technique
+	})
+
+	return findings
+}
+
+// findAdminStatements returns unconditional statements that allow every action on every resource.
+// Any explicit deny, conditional or not, means the access is not unrestricted.
+func findAdminStatements(statements []attributedStatement) []attributedStatement {
+	for _, attributed := range statements {
+		if !attributed.statement.isAllow() {
+			return nil
+		}
+	}
+
+	var grants []attributedStatement
+	for _, attributed := range statements {
+		statement := attributed.statement
+		if statement.hasCondition() || len(statement.NotAction) > 0 || !statement.appliesToAllResources() {
+			continue
+		}
+		for _, action := range statement.Action {
+			if action == "*" || action == "*:*" {
+				grants = append(grants, withAction(attributed, "*"))
+				break
+			}
+		}
+	}
+	return grants
+}
+
+// findGrantingStatements returns the statements that allow an action, unless an unconditional deny blocks it
+func findGrantingStatements(statements []attributedStatement, action string, wildcardResource bool) []attributedStatement {
+	// Only unconditional denies reliably remove a permission
+	var denies []policyStatement
+	for _, attributed := range statements {
+		statement := attributed.statement
+		if !statement.isAllow() && !statement.hasCondition() && statement.matchesAction(action) {
+			denies = append(denies, statement)
+		}
+	}
+
+	var grants []attributedStatement
+	for _, attributed := range statements {
+		statement := attributed.statement
+		if !statement.isAllow() || !statement.matchesAction(action) {
+			continue
+		}
+		if wildcardResource && !statement.appliesToAllResources() {
+			continue
+		}
+
+		// An allow is removed only when a deny covers every resource it grants
+		denied := false
+		for _, deny := range denies {
+			if deny.coversResourcesOf(statement) {
+				denied = true
+				break
+			}
+		}
+		if !denied {
+			grants = append(grants, withAction(attributed, action))
+		}
+	}
+	return grants
+}
+
+// withAction returns a copy of the statement attributed to a specific action
+func withAction(attributed attributedStatement, action string) attributedStatement {
+	attributed.source.Action = action
+	return attributed
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWildcardMatch(t *testing.T) {
+	assert.True(t, wildcardMatch("iam:*", "iam:PassRole"))
+	assert.True(t, wildcardMatch("iam:pass*", "iam:PassRole"))
+	assert.True(t, wildcardMatch("*", "ec2:RunInstances"))
+	assert.True(t, wildcardMatch("ec2:Run?nstances", "ec2:RunInstances"))
+	assert.False(t, wildcardMatch("iam:Get*", "iam:PassRole"))
+	assert.False(t, wildcardMatch("s3:*", "iam:PassRole"))
+}
+
+func TestParsePolicyDocumentSingleStatement(t *testing.T) {
+	doc, err := parsePolicyDocument(`{"Version":"2012-10-17","Statement":{"Effect":"Allow","NotAction":"iam:*","Resource":"*"}}`)
+	require.NoError(t, err)
+	require.Len(t, doc.Statement, 1)
+
+	statement := doc.Statement[0]
+	assert.False(t, statement.matchesAction("iam:PassRole"))
+	assert.True(t, statement.matchesAction("ec2:RunInstances"))
+	assert.True(t, statement.appliesToAllResources())
+}
+
+func escalationDetails(userPolicy string) *iam.GetAccountAuthorizationDetailsOutput {
+	return &iam.GetAccountAuthorizationDetailsOutput{
+		UserDetailList: []*iam.UserDetail{
+			{
+				UserName: aws.String("alice"),
+				Arn:      aws.String("arn:aws:iam::123456789012:user/alice"),
+				UserPolicyList: []*iam.PolicyDetail{
+					{PolicyName: aws.String("inline"), PolicyDocument: aws.String(userPolicy)},
+				},
+			},
+		},
+	}
+}
+
+func TestAnalyzeEscalationPassRoleWithRunInstances(t *testing.T) {
+	policy := `{"Version":"2012-10-17","Statement":[
+		{"Sid":"Pass","Effect":"Allow","Action":"iam:PassRole","Resource":"*"},
+		{"Sid":"Ec2","Effect":"Allow","Action":["ec2:Run*"],"Resource":"*"}]}`
+
+	principals := buildEscalationPrincipals(escalationDetails(policy))
+	require.Len(t, principals, 1)
+
+	findings := analyzeEscalationPrincipal(principals[0], models.Account{ID: "123456789012"})
+	require.Len(t, findings, 1)
+	assert.Equal(t, "pass_role_ec2", findings[0].Technique)
+	assert.Equal(t, "high", findings[0].Severity)
+	require.Len(t, findings[0].GrantingStatements, 2)
+	assert.Equal(t, "Pass", findings[0].GrantingStatements[0].Sid)
+	assert.Equal(t, "ec2:RunInstances", findings[0].GrantingStatements[1].Action)
+}
+
+func TestAnalyzeEscalationHonorsDenyAndConditions(t *testing.T) {
+	policy := `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Action":"iam:*","Resource":"*","Condition":{"Bool":{"aws:MultiFactorAuthPresent":"true"}}},
+		{"Effect":"Deny","Action":["iam:Attach*","iam:Put*"],"Resource":"*"}]}`
+
+	principals := buildEscalationPrincipals(escalationDetails(policy))
+	findings := analyzeEscalationPrincipal(principals[0], models.Account{ID: "123456789012"})
+
+	techniques := make(map[string]models.PrivilegeEscalationFinding)
+	for _, finding := range findings {
+		techniques[finding.Technique] = finding
+	}
+
+	assert.NotContains(t, techniques, "attach_user_policy")
+	assert.NotContains(t, techniques, "put_role_policy")
+	require.Contains(t, techniques, "create_policy_version")
+	assert.True(t, techniques["create_policy_version"].Conditional)
+	assert.Equal(t, "medium", techniques["create_policy_version"].Severity)
+}
+
+func TestAnalyzeEscalationAdministrator(t *testing.T) {
+	policy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"*","Resource":"*"}]}`
+
+	principals := buildEscalationPrincipals(escalationDetails(policy))
+	findings := analyzeEscalationPrincipal(principals[0], models.Account{ID: "123456789012"})
+
+	require.Len(t, findings, 1)
+	assert.Equal(t, "administrator_access", findings[0].Technique)
+	assert.Equal(t, "critical", findings[0].Severity)
+}
+
+func TestAnalyzeEscalationDenyWithNotResource(t *testing.T) {
+	// The deny spares every role but break-glass, so iam:PassRole on other roles still escalates
+	policy := `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Action":["iam:PassRole","ec2:RunInstances"],"Resource":"*"},
+		{"Effect":"Deny","Action":"iam:PassRole","NotResource":"arn:aws:iam::123456789012:role/break-glass"}]}`
+
+	principals := buildEscalationPrincipals(escalationDetails(policy))
+	findings := analyzeEscalationPrincipal(principals[0], models.Account{ID: "123456789012"})
+	require.Len(t, findings, 1)
+	assert.Equal(t, "pass_role_ec2", findings[0].Technique)
+
+	// A deny whose NotResource cannot overlap the allowed roles removes the grant
+	policy = `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Action":"iam:PassRole","Resource":"arn:aws:iam::123456789012:role/app-*"},
+		{"Effect":"Allow","Action":"ec2:RunInstances","Resource":"*"},
+		{"Effect":"Deny","Action":"iam:PassRole","NotResource":"arn:aws:iam::123456789012:role/break-glass"}]}`
+
+	principals = buildEscalationPrincipals(escalationDetails(policy))
+	assert.Empty(t, analyzeEscalationPrincipal(principals[0], models.Account{ID: "123456789012"}))
+}
+
+func TestAnalyzeEscalationAdministratorWithDeny(t *testing.T) {
+	policy := `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Action":"*","Resource":"*"},
+		{"Effect":"Deny","Action":"iam:*","Resource":"*"}]}`
+
+	principals := buildEscalationPrincipals(escalationDetails(policy))
+	findings := analyzeEscalationPrincipal(principals[0], models.Account{ID: "123456789012"})
+
+	require.NotEmpty(t, findings, "techniques outside IAM are still reported")
+	for _, finding := range findings {
+		assert.NotEqual(t, "administrator_access", finding.Technique)
+		for _, action := range finding.RequiredActions {
+			assert.NotContains(t, action, "iam:", "iam actions are denied")
+		}
+	}
+}
+
+func TestAnalyzeEscalationAssumeRoleRequiresWildcard(t *testing.T) {
+	policy := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Action":"sts:AssumeRole","Resource":"arn:aws:iam::123456789012:role/app"}]}`
+
+	principals := buildEscalationPrincipals(escalationDetails(policy))
+	findings := analyzeEscalationPrincipal(principals[0], models.Account{ID: "123456789012"})
+
+	assert.Empty(t, findings)
+}
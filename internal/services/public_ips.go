@@ -100,14 +100,13 @@ func (s *AWSService) getPublicIPsForAccount(account models.Account) ([]models.Pu
 		return nil, fmt.Errorf("cannot access account %s: %w", account.ID, err)
 	}
 
-	// Get all regions
-	ec2Client := ec2.New(sess)
-	regionsResult, err := ec2Client.DescribeRegions(&ec2.DescribeRegionsInput{})
+	scan, err := s.startRegionScan(scannerPublicIPs, account.ID, sess)
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe regions: %v", err)
+		return nil, err
 	}
-
-	if len(regionsResult.Regions) == 0 {
+	regions := scan.Regions()
+	if len(regions) == 0 {
+		scan.Finish()
 		return []models.PublicIP{}, nil
 	}
 
@@ -117,11 +116,11 @@ func (s *AWSService) getPublicIPsForAccount(account models.Account) ([]models.Pu
 		regionName string
 	}
 
-	resultChan := make(chan regionResult, len(regionsResult.Regions))
+	resultChan := make(chan regionResult, len(regions))
 	var wg sync.WaitGroup
 
 	// Process each region in parallel
-	for _, region := range regionsResult.Regions {
+	for _, region := range regions {
 		wg.Add(1)
 		go func(regionName string) {
 			defer wg.Done()
@@ -134,6 +133,7 @@ func (s *AWSService) getPublicIPsForAccount(account models.Account) ([]models.Pu
 			ec2IPs, err := s.getEC2PublicIPs(regionSess, account, regionName)
 			if err != nil {
 				fmt.Printf("[WARNING] Failed to get EC2 IPs in region %s for account %s: %v\n", regionName, account.ID, err)
+				scan.Fail(regionName, err)
 			} else {
 				regionIPs = append(regionIPs, ec2IPs...)
 			}
@@ -142,6 +142,7 @@ func (s *AWSService) getPublicIPsForAccount(account models.Account) ([]models.Pu
 			elbIPs, err := s.getELBPublicIPs(regionSess, account, regionName)
 			if err != nil {
 				fmt.Printf("[WARNING] Failed to get ELB IPs in region %s for account %s: %v\n", regionName, account.ID, err)
+				scan.Fail(regionName, err)
 			} else {
 				regionIPs = append(regionIPs, elbIPs...)
 			}
@@ -150,6 +151,7 @@ func (s *AWSService) getPublicIPsForAccount(account models.Account) ([]models.Pu
 			natIPs, err := s.getNATPublicIPs(regionSess, account, regionName)
 			if err != nil {
 				fmt.Printf("[WARNING] Failed to get NAT IPs in region %s for account %s: %v\n", regionName, account.ID, err)
+				scan.Fail(regionName, err)
 			} else {
 				regionIPs = append(regionIPs, natIPs...)
 			}
@@ -158,12 +160,13 @@ func (s *AWSService) getPublicIPsForAccount(account models.Account) ([]models.Pu
 				ips: regionIPs,
 				regionName: regionName,
 			}
-		}(*region.RegionName)
+		}(region)
 	}
 
 	// Wait for all goroutines to complete and close channel
 	go func() {
 		wg.Wait()
+		scan.Finish()
 		close(resultChan)
 	}()
 
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// ============================================================================
+// REGION DISCOVERY
+// ============================================================================
+//
+// Every resource scanner asks startRegionScan which regions to cover. Regions come from
+// DescribeRegions for the account, so newly launched and opt-in regions are picked up;
+// regions the account has not opted into and regions outside SCAN_REGIONS are skipped.
+// The regions each scan covered, skipped and failed are kept per scanner and account.
+
+// Scanner names used for coverage reporting
+const (
+	scannerSecurityGroups = "security_groups"
+	scannerSnapshots      = "snapshots"
+	scannerDBSnapshots    = "db_snapshots"
+	scannerEBSVolumes     = "ebs_volumes"
+	scannerEC2Instances   = "ec2_instances"
+	scannerNATGateways    = "nat_gateways"
+	scannerVPCs           = "vpcs"
+	scannerPublicIPs      = "public_ips"
+	scannerLoadBalancers  = "load_balancers"
+	scannerAMIs           = "amis"
+	scannerSGGraph        = "security_group_graph"
+)
+
+// regionCacheTTL is how long an account's region list is reused; regions rarely change
+const regionCacheTTL = time.Hour
+
+// GetAccountRegions returns every region of an account with its opt-in status and whether scans cover it
+func (s *AWSService) GetAccountRegions(accountID string) ([]models.AccountRegion, error) {
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+	return s.accountRegions(accountID, sess)
+}
+
+// accountRegions describes all regions of an account and applies the configured region scope
+func (s *AWSService) accountRegions(accountID string, sess *session.Session) ([]models.AccountRegion, error) {
+	cacheKey := fmt.Sprintf("regions:%s", accountID)
+	if cached, found := s.cache.Get(cacheKey); found {
+		if regions, ok := cached.([]models.AccountRegion); ok {
+			return regions, nil
+		}
+	}
+
+	result, err := ec2.New(sess).DescribeRegions(&ec2.DescribeRegionsInput{AllRegions: aws.Bool(true)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe regions: %v", err)
+	}
+
+	regions := planRegions(result.Regions, s.config.RegionScope)
+	s.cache.Set(cacheKey, regions, regionCacheTTL)
+	return regions, nil
+}
+
+// planRegions decides which regions to scan, skipping those not opted into or out of scope
+func planRegions(regions []*ec2.Region, scope []string) []models.AccountRegion {
+	planned := make([]models.AccountRegion, 0, len(regions))
+	for _, region := range regions {
+		name := aws.StringValue(region.RegionName)
+		if name == "" {
+			continue
+		}
+
+		entry := models.AccountRegion{Region: name, OptInStatus: aws.StringValue(region.OptInStatus), Scanned: true}
+		switch {
+		case entry.OptInStatus == "not-opted-in":
+			entry.Scanned = false
+			entry.SkipReason = "region is not enabled for the account"
+		case !regionInScope(name, scope):
+			entry.Scanned = false
+			entry.SkipReason = "outside the configured region scope"
+		}
+		planned = append(planned, entry)
+	}
+
+	sort.Slice(planned, func(i, j int) bool {
+		return planned[i].Region < planned[j].Region
+	})
+	return planned
+}
+
+// regionInScope reports whether a region matches the scope; an empty scope includes every region
+func regionInScope(region string, scope []string) bool {
+	if len(scope) == 0 {
+		return true
+	}
+	for _, entry := range scope {
+		if entry == region || (strings.HasSuffix(entry, "*") && strings.HasPrefix(region, strings.TrimSuffix(entry, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanCoverageTracker keeps the coverage of the latest scan per scanner and account
+type scanCoverageTracker struct {
+	mu       sync.Mutex
+	coverage map[string]models.ScanCoverage
+}
+
+func newScanCoverageTracker() *scanCoverageTracker {
+	return &scanCoverageTracker{coverage: make(map[string]models.ScanCoverage)}
+}
+
+// regionScan is one scanner's pass over an account's regions
+type regionScan struct {
+	tracker  *scanCoverageTracker
+	regions  []string
+	mu       sync.Mutex
+	coverage models.ScanCoverage
+}
+
+// startRegionScan discovers the regions a scanner should cover in an account.
+// Call Fail for each region that errors and Finish once all regions are done.
+func (s *AWSService) startRegionScan(scanner, accountID string, sess *session.Session) (*regionScan, error) {
+	regions, err := s.accountRegions(accountID, sess)
+	if err != nil {
+		return nil, err
+	}
+
+	scan := &regionScan{
+		tracker: s.coverage,
+		coverage: models.ScanCoverage{
+			Scanner:   scanner,
+			AccountID: accountID,
+			StartedAt: time.Now(),
+		},
+	}
+	for _, region := range regions {
+		if region.Scanned {
+			scan.regions = append(scan.regions, region.Region)
+		} else {
+			scan.coverage.SkippedRegions = append(scan.coverage.SkippedRegions, models.RegionSkip{Region: region.Region, Reason: region.SkipReason})
+		}
+	}
+	return scan, nil
+}
+
+// Regions returns the regions to scan
+func (rs *regionScan) Regions() []string {
+	return rs.regions
+}
+
+// Fail records that a region could not be scanned
+func (rs *regionScan) Fail(region string, err error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if rs.coverage.FailedRegions == nil {
+		rs.coverage.FailedRegions = make(map[string]string)
+	}
+	rs.coverage.FailedRegions[region] = err.Error()
+}
+
+// Finish records the scan's coverage
+func (rs *regionScan) Finish() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.coverage.ScannedRegions = []string{}
+	for _, region := range rs.regions {
+		if _, failed := rs.coverage.FailedRegions[region]; !failed {
+			rs.coverage.ScannedRegions = append(rs.coverage.ScannedRegions, region)
+		}
+	}
+	rs.coverage.CompletedAt = time.Now()
+
+	rs.tracker.mu.Lock()
+	defer rs.tracker.mu.Unlock()
+	rs.tracker.coverage[rs.coverage.Scanner+":"+rs.coverage.AccountID] = rs.coverage
+}
+
+// ListScanCoverage returns the coverage of the latest scans, optionally for one scanner or account
+func (s *AWSService) ListScanCoverage(scanner, accountID string) []models.ScanCoverage {
+	s.coverage.mu.Lock()
+	defer s.coverage.mu.Unlock()
+
+	result := []models.ScanCoverage{}
+	for _, coverage := range s.coverage.coverage {
+		if (scanner != "" && coverage.Scanner != scanner) || (accountID != "" && coverage.AccountID != accountID) {
+			continue
+		}
+		result = append(result, coverage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Scanner != result[j].Scanner {
+			return result[i].Scanner < result[j].Scanner
+		}
+		return result[i].AccountID < result[j].AccountID
+	})
+	return result
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegionInScope(t *testing.T) {
+	assert.True(t, regionInScope("ap-south-2", nil))
+	assert.True(t, regionInScope("us-east-1", []string{"us-east-1", "eu-*"}))
+	assert.True(t, regionInScope("eu-central-2", []string{"us-east-1", "eu-*"}))
+	assert.False(t, regionInScope("us-east-2", []string{"us-east-1", "eu-*"}))
+	assert.False(t, regionInScope("ap-southeast-1", []string{"us-east-1", "eu-*"}))
+}
+
+func TestPlanRegions(t *testing.T) {
+	regions := []*ec2.Region{
+		{RegionName: aws.String("us-east-1"), OptInStatus: aws.String("opt-in-not-required")},
+		{RegionName: aws.String("eu-south-1"), OptInStatus: aws.String("opted-in")},
+		{RegionName: aws.String("af-south-1"), OptInStatus: aws.String("not-opted-in")},
+		{RegionName: aws.String("ap-northeast-1"), OptInStatus: aws.String("opt-in-not-required")},
+	}
+
+	planned := planRegions(regions, []string{"us-east-1", "eu-*", "af-*"})
+	assert.Equal(t, []models.AccountRegion{
+		{Region: "af-south-1", OptInStatus: "not-opted-in", SkipReason: "region is not enabled for the account"},
+		{Region: "ap-northeast-1", OptInStatus: "opt-in-not-required", SkipReason: "outside the configured region scope"},
+		{Region: "eu-south-1", OptInStatus: "opted-in", Scanned: true},
+		{Region: "us-east-1", OptInStatus: "opt-in-not-required", Scanned: true},
+	}, planned)
+
+	// Without a scope every enabled region is scanned
+	scanned := 0
+	for _, region := range planRegions(regions, nil) {
+		if region.Scanned {
+			scanned++
+		}
+	}
+	assert.Equal(t, 3, scanned)
+}
+
+func TestRegionScanCoverage(t *testing.T) {
+	s := &AWSService{cache: NewCache(), coverage: newScanCoverageTracker()}
+	s.cache.Set("regions:111111111111", []models.AccountRegion{
+		{Region: "af-south-1", SkipReason: "region is not enabled for the account"},
+		{Region: "eu-west-1", Scanned: true},
+		{Region: "us-east-1", Scanned: true},
+	}, time.Hour)
+
+	scan, err := s.startRegionScan(scannerSnapshots, "111111111111", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"eu-west-1", "us-east-1"}, scan.Regions())
+
+	scan.Fail("eu-west-1", errors.New("UnauthorizedOperation"))
+	scan.Finish()
+
+	coverage := s.ListScanCoverage("", "111111111111")
+	if assert.Len(t, coverage, 1) {
+		assert.Equal(t, scannerSnapshots, coverage[0].Scanner)
+		assert.Equal(t, []string{"us-east-1"}, coverage[0].ScannedRegions)
+		assert.Equal(t, map[string]string{"eu-west-1": "UnauthorizedOperation"}, coverage[0].FailedRegions)
+		assert.Equal(t, []models.RegionSkip{{Region: "af-south-1", Reason: "region is not enabled for the account"}}, coverage[0].SkippedRegions)
+	}
+	assert.Empty(t, s.ListScanCoverage(scannerVPCs, ""))
+}
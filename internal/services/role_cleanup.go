@@ -0,0 +1,371 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+// ============================================================================
+// UNUSED ROLE DETECTION AND STAGED CLEANUP
+// ============================================================================
+
+const (
+	// roleCleanupStageTag records the current cleanup stage on the role
+	roleCleanupStageTag = "iam-manager:cleanup-stage"
+	// roleCleanupStageDateTag records when the role entered its current stage (RFC 3339)
+	roleCleanupStageDateTag = "iam-manager:cleanup-stage-date"
+	// roleCleanupDenyPolicyName is the inline policy that disables a role before deletion
+	roleCleanupDenyPolicyName = "IAMManagerCleanupDeny"
+
+	roleCleanupDenyPolicy = `{"Version":"2012-10-17","Statement":[{"Sid":"IAMManagerCleanupDeny","Effect":"Deny","Action":"*","Resource":"*"}]}`
+)
+
+// unusedRoleOptions controls which roles count as unused
+type unusedRoleOptions struct {
+	unusedDays     int
+	minAgeDays     int
+	allowlistTags  []string
+	allowlistPaths []string
+	gracePeriod    time.Duration
+	// protectedRoles are never cleanup candidates, such as the role this service assumes
+	protectedRoles []string
+}
+
+// unusedRoleOptions returns the configured detection options. A positive unusedDays and a
+// non-negative minAgeDays override the configured thresholds.
+func (s *AWSService) unusedRoleOptions(unusedDays, minAgeDays int) unusedRoleOptions {
+	opts := unusedRoleOptions{
+		unusedDays:     s.config.UnusedRoleDays,
+		minAgeDays:     s.config.UnusedRoleMinAgeDays,
+		allowlistTags:  s.config.RoleCleanupAllowlistTags,
+		allowlistPaths: s.config.RoleCleanupAllowlistPaths,
+		gracePeriod:    s.config.RoleCleanupGracePeriod,
+		protectedRoles: []string{s.config.RoleName, "OrganizationAccountAccessRole"},
+	}
+	if unusedDays > 0 {
+		opts.unusedDays = unusedDays
+	}
+	if minAgeDays >= 0 {
+		opts.minAgeDays = minAgeDays
+	}
+	return opts
+}
+
+// ListUnusedRoles returns roles in an account that have not been used within unusedDays
+func (s *AWSService) ListUnusedRoles(accountID string, unusedDays, minAgeDays int) ([]models.UnusedRole, error) {
+	roles, err := s.ListRoles(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := s.unusedRoleOptions(unusedDays, minAgeDays)
+	now := time.Now()
+
+	unused := []models.UnusedRole{}
+	for _, role := range roles {
+		if candidate, ok := classifyUnusedRole(role, now, opts); ok {
+			unused = append(unused, candidate)
+		}
+	}
+	return unused, nil
+}
+
+// ListAllUnusedRoles returns unused roles across all accessible accounts
+func (s *AWSService) ListAllUnusedRoles(unusedDays, minAgeDays int) ([]models.UnusedRole, error) {
+	roles, err := s.ListAllRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := s.unusedRoleOptions(unusedDays, minAgeDays)
+	now := time.Now()
+
+	unused := []models.UnusedRole{}
+	for _, role := range roles {
+		if candidate, ok := classifyUnusedRole(role.IAMRole, now, opts); ok {
+			unused = append(unused, candidate)
+		}
+	}
+	return unused, nil
+}
+
+// StartRoleCleanup starts a background job that advances each role one cleanup stage:
+// tag, then attach a deny policy, then delete. Each step after tagging waits for the grace
+// period unless force is set. With no targets, every currently unused role is advanced.
+func (s *AWSService) StartRoleCleanup(targets []models.RoleCleanupTarget, force bool) (models.Job, error) {
+	if len(targets) == 0 {
+		unused, err := s.ListAllUnusedRoles(0, -1)
+		if err != nil {
+			return models.Job{}, err
+		}
+		for _, role := range unused {
+			targets = append(targets, models.RoleCleanupTarget{AccountID: role.AccountID, RoleName: role.RoleName})
+		}
+	}
+
+	opts := s.unusedRoleOptions(0, -1)
+
+	job := s.jobs.start("role_cleanup", fmt.Sprintf("Staged cleanup of %d unused role(s)", len(targets)), func(jc *jobContext) (any, error) {
+		jc.AddTotal(len(targets))
+
+		for _, target := range targets {
+			stage, message, err := s.advanceRoleCleanup(target, opts, force)
+
+			result := models.JobItemResult{
+				AccountID:  target.AccountID,
+				ResourceID: target.RoleName,
+				Status:     "succeeded",
+				Message:    message,
+			}
+			switch {
+			case err != nil:
+				result.Status = "failed"
+				result.Message = err.Error()
+			case stage == "":
+				result.Status = "skipped"
+			}
+			jc.AddResult(result)
+		}
+
+		s.InvalidateRolesCache()
+		return nil, nil
+	})
+
+	return job, nil
+}
+
+// advanceRoleCleanup moves a single role to its next cleanup stage.
+// It returns the new stage, or an empty stage with a reason when the role was skipped.
+func (s *AWSService) advanceRoleCleanup(target models.RoleCleanupTarget, opts unusedRoleOptions, force bool) (string, string, error) {
+	sess, err := s.getSessionForAccount(target.AccountID)
+	if err != nil {
+		return "", "", fmt.Errorf("cannot access account %s: %w", target.AccountID, err)
+	}
+	iamClient := iam.New(sess)
+
+	// Always decide from the live role, not a cached listing
+	result, err := iamClient.GetRole(&iam.GetRoleInput{RoleName: aws.String(target.RoleName)})
+	if err != nil {
+		if strings.Contains(err.Error(), iam.ErrCodeNoSuchEntityException) {
+			return "", "", fmt.Errorf("role %s not found", target.RoleName)
+		}
+		return "", "", fmt.Errorf("failed to get role: %w", err)
+	}
+
+	role := roleFromIAM(result.Role, target.AccountID)
+	now := time.Now()
+
+	// Exclusions always apply; force only skips the usage and grace period checks
+	if isRoleCleanupExcluded(role, opts) {
+		return "", "role is excluded from cleanup", nil
+	}
+
+	candidate, ok := classifyUnusedRole(role, now, opts)
+	if !ok {
+		if !force {
+			return "", "role has been used recently or was created recently", nil
+		}
+		candidate.CleanupStage, candidate.CleanupStageDate = roleCleanupStage(role.Tags)
+	}
+
+	if candidate.CleanupStage != "" && !force {
+		// Any use since the stage started means someone still needs the role
+		if role.LastUsedDate != nil && candidate.CleanupStageDate != nil && role.LastUsedDate.After(*candidate.CleanupStageDate) {
+			return "", fmt.Sprintf("role was used on %s after entering the %s stage; restore it to cancel the cleanup", role.LastUsedDate.Format(time.RFC3339), candidate.CleanupStage), nil
+		}
+		if candidate.NextStageAfter != nil && now.Before(*candidate.NextStageAfter) {
+			return "", fmt.Sprintf("grace period for the %s stage ends at %s", candidate.CleanupStage, candidate.NextStageAfter.Format(time.RFC3339)), nil
+		}
+	}
+
+	switch candidate.CleanupStage {
+	case "":
+		if err := tagRoleCleanupStage(iamClient, target.RoleName, models.RoleCleanupStageTagged, now); err != nil {
+			return "", "", err
+		}
+		return models.RoleCleanupStageTagged, "tagged for cleanup", nil
+
+	case models.RoleCleanupStageTagged:
+		_, err := iamClient.PutRolePolicy(&iam.PutRolePolicyInput{
+			RoleName:       aws.String(target.RoleName),
+			PolicyName:     aws.String(roleCleanupDenyPolicyName),
+			PolicyDocument: aws.String(roleCleanupDenyPolicy),
+		})
+		if err != nil {
+			return "", "", fmt.Errorf("failed to attach deny policy: %v", err)
+		}
+		if err := tagRoleCleanupStage(iamClient, target.RoleName, models.RoleCleanupStageDisabled, now); err != nil {
+			return "", "", err
+		}
+		return models.RoleCleanupStageDisabled, "deny-all policy attached", nil
+
+	case models.RoleCleanupStageDisabled:
+		if err := s.DeleteRole(target.AccountID, target.RoleName); err != nil {
+			return "", "", err
+		}
+		return models.RoleCleanupStageDeleted, "role deleted", nil
+	}
+
+	return "", "", fmt.Errorf("unknown cleanup stage %q on role %s", candidate.CleanupStage, target.RoleName)
+}
+
+// RestoreRoleCleanup cancels a staged cleanup by removing the deny policy and cleanup tags
+func (s *AWSService) RestoreRoleCleanup(accountID, roleName string) error {
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+	iamClient := iam.New(sess)
+
+	_, err = iamClient.DeleteRolePolicy(&iam.DeleteRolePolicyInput{
+		RoleName:   aws.String(roleName),
+		PolicyName: aws.String(roleCleanupDenyPolicyName),
+	})
+	if err != nil && !strings.Contains(err.Error(), iam.ErrCodeNoSuchEntityException) {
+		return fmt.Errorf("failed to remove deny policy: %v", err)
+	}
+
+	_, err = iamClient.UntagRole(&iam.UntagRoleInput{
+		RoleName: aws.String(roleName),
+		TagKeys:  []*string{aws.String(roleCleanupStageTag), aws.String(roleCleanupStageDateTag)},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), iam.ErrCodeNoSuchEntityException) {
+			return fmt.Errorf("role %s not found", roleName)
+		}
+		return fmt.Errorf("failed to remove cleanup tags: %v", err)
+	}
+
+	s.InvalidateAccountRolesCache(accountID)
+
+	return nil
+}
+
+// tagRoleCleanupStage records a cleanup stage and its start time on a role
+func tagRoleCleanupStage(iamClient *iam.IAM, roleName, stage string, at time.Time) error {
+	_, err := iamClient.TagRole(&iam.TagRoleInput{
+		RoleName: aws.String(roleName),
+		Tags: []*iam.Tag{
+			{Key: aws.String(roleCleanupStageTag), Value: aws.String(stage)},
+			{Key: aws.String(roleCleanupStageDateTag), Value: aws.String(at.UTC().Format(time.RFC3339))},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag role: %v", err)
+	}
+	return nil
+}
+
+// roleFromIAM converts the fields of an IAM role needed for unused role classification
+func roleFromIAM(role *iam.Role, accountID string) models.IAMRole {
+	roleModel := models.IAMRole{
+		RoleName:   aws.StringValue(role.RoleName),
+		Arn:        aws.StringValue(role.Arn),
+		Path:       aws.StringValue(role.Path),
+		CreateDate: aws.TimeValue(role.CreateDate),
+		AccountID:  accountID,
+	}
+	for _, tag := range role.Tags {
+		roleModel.Tags = append(roleModel.Tags, models.Tag{Key: aws.StringValue(tag.Key), Value: aws.StringValue(tag.Value)})
+	}
+	if role.RoleLastUsed != nil {
+		roleModel.LastUsedDate = role.RoleLastUsed.LastUsedDate
+		roleModel.LastUsedRegion = aws.StringValue(role.RoleLastUsed.Region)
+	}
+	return roleModel
+}
+
+// isRoleCleanupExcluded reports whether a role must never be cleaned up
+func isRoleCleanupExcluded(role models.IAMRole, opts unusedRoleOptions) bool {
+	// Service-linked roles are managed by AWS
+	if strings.HasPrefix(role.Path, "/aws-service-role/") {
+		return true
+	}
+	for _, name := range opts.protectedRoles {
+		if role.RoleName == name {
+			return true
+		}
+	}
+	for _, prefix := range opts.allowlistPaths {
+		if strings.HasPrefix(role.Path, prefix) {
+			return true
+		}
+	}
+	return roleHasAllowlistTag(role.Tags, opts.allowlistTags)
+}
+
+// classifyUnusedRole reports whether a role is an unused cleanup candidate
+func classifyUnusedRole(role models.IAMRole, now time.Time, opts unusedRoleOptions) (models.UnusedRole, bool) {
+	if isRoleCleanupExcluded(role, opts) {
+		return models.UnusedRole{}, false
+	}
+
+	// Recently created roles may simply not have been used yet
+	if now.Sub(role.CreateDate) < time.Duration(opts.minAgeDays)*24*time.Hour {
+		return models.UnusedRole{}, false
+	}
+
+	lastActivity := role.CreateDate
+	if role.LastUsedDate != nil {
+		lastActivity = *role.LastUsedDate
+	}
+	daysUnused := int(now.Sub(lastActivity).Hours() / 24)
+	if daysUnused < opts.unusedDays {
+		return models.UnusedRole{}, false
+	}
+
+	candidate := models.UnusedRole{
+		AccountID:      role.AccountID,
+		AccountName:    role.AccountName,
+		RoleName:       role.RoleName,
+		Arn:            role.Arn,
+		Path:           role.Path,
+		CreateDate:     role.CreateDate,
+		LastUsedDate:   role.LastUsedDate,
+		LastUsedRegion: role.LastUsedRegion,
+		DaysUnused:     daysUnused,
+	}
+	candidate.CleanupStage, candidate.CleanupStageDate = roleCleanupStage(role.Tags)
+	if candidate.CleanupStageDate != nil {
+		next := candidate.CleanupStageDate.Add(opts.gracePeriod)
+		candidate.NextStageAfter = &next
+	}
+
+	return candidate, true
+}
+
+// roleHasAllowlistTag reports whether any tag matches an allowlist entry ("key" or "key=value")
+func roleHasAllowlistTag(tags []models.Tag, allowlist []string) bool {
+	for _, entry := range allowlist {
+		key, value, hasValue := strings.Cut(entry, "=")
+		for _, tag := range tags {
+			if tag.Key == key && (!hasValue || tag.Value == value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// roleCleanupStage reads the cleanup stage tags of a role
+func roleCleanupStage(tags []models.Tag) (string, *time.Time) {
+	var stage string
+	var stageDate *time.Time
+	for _, tag := range tags {
+		switch tag.Key {
+		case roleCleanupStageTag:
+			stage = tag.Value
+		case roleCleanupStageDateTag:
+			if parsed, err := time.Parse(time.RFC3339, tag.Value); err == nil {
+				stageDate = &parsed
+			}
+		}
+	}
+	return stage, stageDate
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyUnusedRole(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	opts := unusedRoleOptions{
+		unusedDays:     90,
+		minAgeDays:     30,
+		allowlistTags:  []string{"iam-manager:keep", "team=platform"},
+		allowlistPaths: []string{"/breakglass/"},
+		gracePeriod:    14 * 24 * time.Hour,
+		protectedRoles: []string{"IAMManagerCrossAccountRole"},
+	}
+	old := now.AddDate(-1, 0, 0)
+	recentUse := now.AddDate(0, 0, -10)
+
+	tests := []struct {
+		name   string
+		role   models.IAMRole
+		unused bool
+	}{
+		{"never used old role", models.IAMRole{RoleName: "a", Path: "/", CreateDate: old}, true},
+		{"recently used", models.IAMRole{RoleName: "b", Path: "/", CreateDate: old, LastUsedDate: &recentUse}, false},
+		{"recently created", models.IAMRole{RoleName: "c", Path: "/", CreateDate: now.AddDate(0, 0, -5)}, false},
+		{"service-linked", models.IAMRole{RoleName: "d", Path: "/aws-service-role/ecs.amazonaws.com/", CreateDate: old}, false},
+		{"allowlisted path", models.IAMRole{RoleName: "e", Path: "/breakglass/", CreateDate: old}, false},
+		{"allowlisted tag key", models.IAMRole{RoleName: "f", Path: "/", CreateDate: old, Tags: []models.Tag{{Key: "iam-manager:keep", Value: "yes"}}}, false},
+		{"allowlisted tag value", models.IAMRole{RoleName: "g", Path: "/", CreateDate: old, Tags: []models.Tag{{Key: "team", Value: "platform"}}}, false},
+		{"other tag value", models.IAMRole{RoleName: "h", Path: "/", CreateDate: old, Tags: []models.Tag{{Key: "team", Value: "data"}}}, true},
+		{"protected role", models.IAMRole{RoleName: "IAMManagerCrossAccountRole", Path: "/", CreateDate: old}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, unused := classifyUnusedRole(tt.role, now, opts)
+			assert.Equal(t, tt.unused, unused)
+		})
+	}
+}
+
+func TestClassifyUnusedRoleCleanupStage(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	opts := unusedRoleOptions{unusedDays: 90, minAgeDays: 30, gracePeriod: 14 * 24 * time.Hour}
+
+	role := models.IAMRole{
+		RoleName:   "stale",
+		Path:       "/",
+		CreateDate: now.AddDate(-1, 0, 0),
+		Tags: []models.Tag{
+			{Key: roleCleanupStageTag, Value: models.RoleCleanupStageTagged},
+			{Key: roleCleanupStageDateTag, Value: "2025-05-25T00:00:00Z"},
+		},
+	}
+
+	candidate, unused := classifyUnusedRole(role, now, opts)
+	require.True(t, unused)
+	assert.Equal(t, models.RoleCleanupStageTagged, candidate.CleanupStage)
+	require.NotNil(t, candidate.NextStageAfter)
+	assert.Equal(t, time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC), *candidate.NextStageAfter)
+	assert.Equal(t, 365, candidate.DaysUnused)
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+)
+
+// ============================================================================
+// ROLE TRUST POLICY ANALYSIS
+// ============================================================================
+
+// accountIDPattern extracts the account ID from an account principal (ARN or bare ID)
+var accountIDPattern = regexp.MustCompile(`^(?:arn:aws[a-zA-Z-]*:(?:iam|sts)::)?(\d{12})(?::|$)`)
+
+// wildcardRestrictingConditionKeys limit who a "*" principal can actually be
+var wildcardRestrictingConditionKeys = []string{
+	"aws:principalorgid",
+	"aws:principalorgpaths",
+	"aws:principalaccount",
+	"aws:principalarn",
+	"aws:sourceaccount",
+	"aws:sourcearn",
+	"aws:sourceorgid",
+}
+
+// ListRoleTrustFindings returns trust policy findings for every role in an account
+func (s *AWSService) ListRoleTrustFindings(accountID string) ([]models.TrustFinding, error) {
+	roles, err := s.ListRoles(accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	findings := []models.TrustFinding{}
+	for _, role := range roles {
+		findings = append(findings, role.TrustFindings...)
+	}
+	return findings, nil
+}
+
+// ListAllRoleTrustFindings returns trust policy findings for every role across all accessible accounts
+func (s *AWSService) ListAllRoleTrustFindings() ([]models.TrustFinding, error) {
+	roles, err := s.ListAllRoles()
+	if err != nil {
+		return nil, err
+	}
+
+	findings := []models.TrustFinding{}
+	for _, role := range roles {
+		findings = append(findings, role.TrustFindings...)
+	}
+	return findings, nil
+}
+
+// organizationAccountIDs returns the set of account IDs in the organization
+func (s *AWSService) organizationAccountIDs() (map[string]bool, error) {
+	accounts, err := s.ListAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organization accounts: %w", err)
+	}
+	ids := make(map[string]bool, len(accounts))
+	for _, acc := range accounts {
+		ids[acc.ID] = true
+	}
+	return ids, nil
+}
+
+// analyzeTrustPolicy classifies each principal in a role's trust policy and flags risky trusts.
+// A nil orgAccountIDs means the organization is unknown, so other accounts are left unclassified.
+func analyzeTrustPolicy(role models.IAMRole, orgAccountIDs map[string]bool) ([]models.TrustedPrincipal, []models.TrustFinding, error) {
+	if role.AssumeRolePolicyDocument == "" {
+		return nil, nil, nil
+	}
+
+	doc, err := parsePolicyDocument(role.AssumeRolePolicyDocument)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var principals []models.TrustedPrincipal
+	var findings []models.TrustFinding
+
+	addFinding := func(principal models.TrustedPrincipal, issue, severity, description string) {
+		findings = append(findings, models.TrustFinding{
+			AccountID:     role.AccountID,
+			AccountName:   role.AccountName,
+			RoleName:      role.RoleName,
+			RoleArn:       role.Arn,
+			Principal:     principal.Principal,
+			PrincipalType: principal.Type,
+			Issue:         issue,
+			Severity:      severity,
+			Description:   description,
+		})
+	}
+
+	for _, statement := range doc.Statement {
+		if !statement.isAllow() {
+			continue
+		}
+
+		conditionKeys := statementConditionKeys(statement)
+
+		for principalKind, values := range statement.Principal {
+			for _, value := range values {
+				principal := models.TrustedPrincipal{
+					Principal:     value,
+					Actions:       statement.Action,
+					ConditionKeys: conditionKeys,
+					HasExternalID: hasConditionKey(conditionKeys, "sts:externalid"),
+				}
+
+				switch {
+				case principalKind == "*" || value == "*":
+					principal.Type = models.TrustPrincipalWildcard
+				case principalKind == "Service":
+					principal.Type = models.TrustPrincipalAWSService
+				case principalKind == "Federated":
+					principal.Type = models.TrustPrincipalFederated
+				case principalKind == "AWS":
+					principal.AccountID = principalAccountID(value)
+					switch {
+					case principal.AccountID == role.AccountID:
+						principal.Type = models.TrustPrincipalSameAccount
+					case orgAccountIDs == nil:
+						principal.Type = models.TrustPrincipalUnclassifiedAccount
+					case orgAccountIDs[principal.AccountID]:
+						principal.Type = models.TrustPrincipalOrgAccount
+					default:
+						principal.Type = models.TrustPrincipalExternalAccount
+					}
+				default:
+					principal.Type = strings.ToLower(principalKind)
+				}
+
+				principals = append(principals, principal)
+
+				switch principal.Type {
+				case models.TrustPrincipalWildcard:
+					if hasAnyConditionKey(conditionKeys, wildcardRestrictingConditionKeys) {
+						addFinding(principal, "wildcard_principal", "medium",
+							"Any AWS principal can assume this role, limited only by trust policy conditions")
+					} else {
+						addFinding(principal, "wildcard_principal", "critical",
+							"Any AWS principal can assume this role")
+					}
+				case models.TrustPrincipalExternalAccount:
+					if !principal.HasExternalID {
+						addFinding(principal, "missing_external_id", "high",
+							fmt.Sprintf("Account %s outside the organization can assume this role without an sts:ExternalId condition", principal.AccountID))
+					}
+				case models.TrustPrincipalFederated:
+					if isOIDCProvider(value) && !hasOIDCSubjectCondition(value, conditionKeys) {
+						addFinding(principal, "oidc_without_sub_condition", "critical",
+							"Any identity issued by this OIDC provider can assume this role because the trust policy does not restrict the token subject")
+					}
+				}
+			}
+		}
+	}
+
+	return principals, findings, nil
+}
+
+// statementConditionKeys returns the sorted, de-duplicated condition keys of a statement
+func statementConditionKeys(statement policyStatement) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, conditions := range statement.Condition {
+		for key := range conditions {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// hasConditionKey reports whether a condition key is present (keys are case-insensitive)
+func hasConditionKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAnyConditionKey reports whether any of the wanted condition keys is present
+func hasAnyConditionKey(keys []string, wanted []string) bool {
+	for _, key := range wanted {
+		if hasConditionKey(keys, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// principalAccountID extracts the account ID from an AWS principal
+func principalAccountID(principal string) string {
+	match := accountIDPattern.FindStringSubmatch(principal)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// isOIDCProvider reports whether a federated principal is an OIDC (web identity) provider rather than SAML
+func isOIDCProvider(provider string) bool {
+	if strings.Contains(provider, ":saml-provider/") {
+		return false
+	}
+	return strings.Contains(provider, ":oidc-provider/") || !strings.HasPrefix(provider, "arn:")
+}
+
+// hasOIDCSubjectCondition reports whether the trust restricts which token subjects may assume the role.
+// Cognito identity pools identify callers by audience instead of subject.
+func hasOIDCSubjectCondition(provider string, conditionKeys []string) bool {
+	host := provider
+	if idx := strings.Index(provider, ":oidc-provider/"); idx != -1 {
+		host = provider[idx+len(":oidc-provider/"):]
+	}
+
+	if host == "cognito-identity.amazonaws.com" {
+		return hasConditionKey(conditionKeys, host+":aud")
+	}
+	return hasConditionKey(conditionKeys, host+":sub")
+}
+
+// resolveTrustPolicy returns a raw trust policy document if given, otherwise builds one from the inputs
+func resolveTrustPolicy(trust models.RoleTrustInput, document string) (string, error) {
+	if document != "" {
+		if _, err := parsePolicyDocument(document); err != nil {
+			return "", fmt.Errorf("invalid trust policy document: %v", err)
+		}
+		return document, nil
+	}
+	return buildTrustPolicy(trust)
+}
+
+// buildTrustPolicy builds a trust policy document from account, service and OIDC inputs
+func buildTrustPolicy(trust models.RoleTrustInput) (string, error) {
+	var statements []map[string]any
+
+	if len(trust.AccountIDs) > 0 {
+		var principals []string
+		for _, accountID := range trust.AccountIDs {
+			if principalAccountID(accountID) != accountID {
+				return "", fmt.Errorf("invalid trust input: %q is not a 12-digit account ID", accountID)
+			}
+			principals = append(principals, fmt.Sprintf("arn:aws:iam::%s:root", accountID))
+		}
+		statement := map[string]any{
+			"Effect":    "Allow",
+			"Principal": map[string]any{"AWS": principals},
+			"Action":    "sts:AssumeRole",
+		}
+		if trust.ExternalID != "" {
+			statement["Condition"] = map[string]any{
+				"StringEquals": map[string]any{"sts:ExternalId": trust.ExternalID},
+			}
+		}
+		statements = append(statements, statement)
+	}
+
+	if len(trust.Services) > 0 {
+		statements = append(statements, map[string]any{
+			"Effect":    "Allow",
+			"Principal": map[string]any{"Service": trust.Services},
+			"Action":    "sts:AssumeRole",
+		})
+	}
+
+	if trust.OIDCProviderArn != "" {
+		idx := strings.Index(trust.OIDCProviderArn, ":oidc-provider/")
+		if idx == -1 {
+			return "", fmt.Errorf("invalid trust input: %q is not an OIDC provider ARN", trust.OIDCProviderArn)
+		}
+		// Without a subject condition any identity from the provider could assume the role
+		if len(trust.OIDCSubjects) == 0 {
+			return "", fmt.Errorf("invalid trust input: oidc_subjects are required with an OIDC provider")
+		}
+		host := trust.OIDCProviderArn[idx+len(":oidc-provider/"):]
+
+		conditions := map[string]any{
+			"StringLike": map[string]any{host + ":sub": trust.OIDCSubjects},
+		}
+		if len(trust.OIDCAudiences) > 0 {
+			conditions["StringEquals"] = map[string]any{host + ":aud": trust.OIDCAudiences}
+		}
+		statements = append(statements, map[string]any{
+			"Effect":    "Allow",
+			"Principal": map[string]any{"Federated": trust.OIDCProviderArn},
+			"Action":    "sts:AssumeRoleWithWebIdentity",
+			"Condition": conditions,
+		})
+	}
+
+	if len(statements) == 0 {
+		return "", fmt.Errorf("invalid trust input: at least one account, service or OIDC provider is required")
+	}
+
+	document, err := json.Marshal(map[string]any{
+		"Version":   "2012-10-17",
+		"Statement": statements,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to build trust policy: %v", err)
+	}
+	return string(document), nil
+}
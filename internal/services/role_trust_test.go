@@ -0,0 +1,143 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func trustTestRole(document string) models.IAMRole {
+	return models.IAMRole{
+		RoleName:                 "test-role",
+		Arn:                      "arn:aws:iam::111111111111:role/test-role",
+		AccountID:                "111111111111",
+		AssumeRolePolicyDocument: document,
+	}
+}
+
+func TestAnalyzeTrustPolicyClassifiesPrincipals(t *testing.T) {
+	document := `{"Version":"2012-10-17","Statement":[
+		{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::111111111111:root","222222222222","arn:aws:iam::333333333333:role/vendor"]},"Action":"sts:AssumeRole"},
+		{"Effect":"Allow","Principal":{"Service":"ec2.amazonaws.com"},"Action":"sts:AssumeRole"}]}`
+
+	principals, findings, err := analyzeTrustPolicy(trustTestRole(document), map[string]bool{"111111111111": true, "222222222222": true})
+	require.NoError(t, err)
+
+	types := make(map[string]string)
+	for _, principal := range principals {
+		types[principal.Principal] = principal.Type
+	}
+	assert.Equal(t, models.TrustPrincipalSameAccount, types["arn:aws:iam::111111111111:root"])
+	assert.Equal(t, models.TrustPrincipalOrgAccount, types["222222222222"])
+	assert.Equal(t, models.TrustPrincipalExternalAccount, types["arn:aws:iam::333333333333:role/vendor"])
+	assert.Equal(t, models.TrustPrincipalAWSService, types["ec2.amazonaws.com"])
+
+	require.Len(t, findings, 1)
+	assert.Equal(t, "missing_external_id", findings[0].Issue)
+	assert.Equal(t, "test-role", findings[0].RoleName)
+}
+
+func TestAnalyzeTrustPolicyUnknownOrganization(t *testing.T) {
+	document := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::111111111111:root","222222222222"]},"Action":"sts:AssumeRole"}]}`
+
+	principals, findings, err := analyzeTrustPolicy(trustTestRole(document), nil)
+	require.NoError(t, err)
+	require.Len(t, principals, 2)
+	assert.Equal(t, models.TrustPrincipalSameAccount, principals[0].Type)
+	assert.Equal(t, models.TrustPrincipalUnclassifiedAccount, principals[1].Type)
+	assert.Empty(t, findings, "accounts are not reported as external when the organization is unknown")
+}
+
+func TestAnalyzeTrustPolicyExternalIDSatisfiesThirdParty(t *testing.T) {
+	document := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::333333333333:root"},"Action":"sts:AssumeRole","Condition":{"StringEquals":{"sts:ExternalId":"abc"}}}]}`
+
+	principals, findings, err := analyzeTrustPolicy(trustTestRole(document), map[string]bool{})
+	require.NoError(t, err)
+	require.Len(t, principals, 1)
+	assert.True(t, principals[0].HasExternalID)
+	assert.Empty(t, findings)
+}
+
+func TestAnalyzeTrustPolicyWildcard(t *testing.T) {
+	document := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":"*","Action":"sts:AssumeRole"}]}`
+
+	_, findings, err := analyzeTrustPolicy(trustTestRole(document), map[string]bool{})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "wildcard_principal", findings[0].Issue)
+	assert.Equal(t, "critical", findings[0].Severity)
+
+	restricted := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":"*"},"Action":"sts:AssumeRole","Condition":{"StringEquals":{"aws:PrincipalOrgID":"o-123"}}}]}`
+	_, findings, err = analyzeTrustPolicy(trustTestRole(restricted), map[string]bool{})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "medium", findings[0].Severity)
+}
+
+func TestAnalyzeTrustPolicyOIDCSubject(t *testing.T) {
+	provider := "arn:aws:iam::111111111111:oidc-provider/token.actions.githubusercontent.com"
+	open := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Federated":"` + provider + `"},"Action":"sts:AssumeRoleWithWebIdentity","Condition":{"StringEquals":{"token.actions.githubusercontent.com:aud":"sts.amazonaws.com"}}}]}`
+
+	_, findings, err := analyzeTrustPolicy(trustTestRole(open), map[string]bool{})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "oidc_without_sub_condition", findings[0].Issue)
+
+	restricted := `{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Federated":"` + provider + `"},"Action":"sts:AssumeRoleWithWebIdentity","Condition":{"StringLike":{"token.actions.githubusercontent.com:sub":"repo:org/repo:*"}}}]}`
+	_, findings, err = analyzeTrustPolicy(trustTestRole(restricted), map[string]bool{})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestAnalyzeTrustPolicyURLEncoded(t *testing.T) {
+	document := "%7B%22Version%22%3A%222012-10-17%22%2C%22Statement%22%3A%5B%7B%22Effect%22%3A%22Allow%22%2C%22Principal%22%3A%7B%22Service%22%3A%22lambda.amazonaws.com%22%7D%2C%22Action%22%3A%22sts%3AAssumeRole%22%7D%5D%7D"
+
+	principals, findings, err := analyzeTrustPolicy(trustTestRole(decodePolicyDocument(document)), map[string]bool{})
+	require.NoError(t, err)
+	require.Len(t, principals, 1)
+	assert.Equal(t, models.TrustPrincipalAWSService, principals[0].Type)
+	assert.Empty(t, findings)
+
+	// A "+" in a role name survives decoding
+	document = "%7B%22Version%22%3A%222012-10-17%22%2C%22Statement%22%3A%5B%7B%22Effect%22%3A%22Allow%22%2C%22Principal%22%3A%7B%22AWS%22%3A%22arn%3Aaws%3Aiam%3A%3A333333333333%3Arole%2Fci+deploy%22%7D%2C%22Action%22%3A%22sts%3AAssumeRole%22%7D%5D%7D"
+	principals, _, err = analyzeTrustPolicy(trustTestRole(decodePolicyDocument(document)), map[string]bool{})
+	require.NoError(t, err)
+	require.Len(t, principals, 1)
+	assert.Equal(t, "arn:aws:iam::333333333333:role/ci+deploy", principals[0].Principal)
+}
+
+func TestBuildTrustPolicy(t *testing.T) {
+	document, err := buildTrustPolicy(models.RoleTrustInput{
+		AccountIDs:      []string{"333333333333"},
+		ExternalID:      "partner",
+		Services:        []string{"ec2.amazonaws.com"},
+		OIDCProviderArn: "arn:aws:iam::111111111111:oidc-provider/token.actions.githubusercontent.com",
+		OIDCSubjects:    []string{"repo:org/repo:*"},
+		OIDCAudiences:   []string{"sts.amazonaws.com"},
+	})
+	require.NoError(t, err)
+
+	principals, findings, err := analyzeTrustPolicy(trustTestRole(document), map[string]bool{})
+	require.NoError(t, err)
+	assert.Len(t, principals, 3)
+	assert.Empty(t, findings)
+}
+
+func TestBuildTrustPolicyValidation(t *testing.T) {
+	_, err := buildTrustPolicy(models.RoleTrustInput{})
+	assert.Error(t, err)
+
+	_, err = buildTrustPolicy(models.RoleTrustInput{AccountIDs: []string{"12345"}})
+	assert.Error(t, err)
+
+	_, err = buildTrustPolicy(models.RoleTrustInput{
+		OIDCProviderArn: "arn:aws:iam::111111111111:oidc-provider/token.actions.githubusercontent.com",
+	})
+	assert.Error(t, err)
+
+	_, err = resolveTrustPolicy(models.RoleTrustInput{}, "not json")
+	assert.Error(t, err)
+}
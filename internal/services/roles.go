@@ -2,6 +2,7 @@ package services
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/rusik69/aws-iam-manager/internal/models"
@@ -44,6 +45,12 @@ func (s *AWSService) ListRoles(accountID string) ([]models.IAMRole, error) {
 		}
 	}
 
+	// Organization accounts for trust classification; unknown when the organization cannot be listed
+	orgAccountIDs, err := s.organizationAccountIDs()
+	if err != nil {
+		fmt.Printf("[WARNING] Cannot classify trusted accounts for account %s: %v\n", accountID, err)
+	}
+
 	// Paginate through all roles
 	for {
 		input := &iam.ListRolesInput{}
@@ -58,7 +65,7 @@ func (s *AWSService) ListRoles(accountID string) ([]models.IAMRole, error) {
 
 		// Process roles from this page
 		for _, role := range result.Roles {
-			roleDetail, err := s.getRoleDetails(iamClient, role, accountID, accountName)
+			roleDetail, err := s.getRoleDetails(iamClient, role, accountID, accountName, orgAccountIDs)
 			if err != nil {
 				fmt.Printf("[WARNING] Failed to get details for role %s: %v\n", *role.RoleName, err)
 				continue
@@ -205,7 +212,13 @@ func (s *AWSService) GetRole(accountID, roleName string) (*models.IAMRole, error
 		}
 	}
 
-	roleDetail, err := s.getRoleDetails(iamClient, result.Role, accountID, accountName)
+	// Organization accounts for trust classification; unknown when the organization cannot be listed
+	orgAccountIDs, err := s.organizationAccountIDs()
+	if err != nil {
+		fmt.Printf("[WARNING] Cannot classify trusted accounts for account %s: %v\n", accountID, err)
+	}
+
+	roleDetail, err := s.getRoleDetails(iamClient, result.Role, accountID, accountName, orgAccountIDs)
 	if err != nil {
 		return nil, err
 	}
@@ -217,7 +230,7 @@ func (s *AWSService) GetRole(accountID, roleName string) (*models.IAMRole, error
 }
 
 // getRoleDetails gets detailed information about a specific IAM role
-func (s *AWSService) getRoleDetails(iamClient *iam.IAM, role *iam.Role, accountID, accountName string) (models.IAMRole, error) {
+func (s *AWSService) getRoleDetails(iamClient *iam.IAM, role *iam.Role, accountID, accountName string, orgAccountIDs map[string]bool) (models.IAMRole, error) {
 	roleName := *role.RoleName
 
 	roleModel := models.IAMRole{
@@ -239,9 +252,17 @@ func (s *AWSService) getRoleDetails(iamClient *iam.IAM, role *iam.Role, accountI
 
 	// Get assume role policy document
 	if role.AssumeRolePolicyDocument != nil {
-		roleModel.AssumeRolePolicyDocument = *role.AssumeRolePolicyDocument
+		roleModel.AssumeRolePolicyDocument = decodePolicyDocument(*role.AssumeRolePolicyDocument)
 	}
 
+	// Classify who can assume the role
+	trustedPrincipals, trustFindings, err := analyzeTrustPolicy(roleModel, orgAccountIDs)
+	if err != nil {
+		fmt.Printf("[WARNING] Failed to analyze trust policy for role %s: %v\n", roleName, err)
+	}
+	roleModel.TrustedPrincipals = trustedPrincipals
+	roleModel.TrustFindings = trustFindings
+
 	// Get attached managed policies
 	attachedPolicies, err := iamClient.ListAttachedRolePolicies(&iam.ListAttachedRolePoliciesInput{
 		RoleName: aws.String(roleName),
@@ -387,6 +408,175 @@ func (s *AWSService) DeleteRole(accountID, roleName string) error {
 	return nil
 }
 
+// CreateRole creates an IAM role and attaches the requested managed policies.
+// Policies that fail to attach are reported without undoing the role creation.
+func (s *AWSService) CreateRole(accountID string, input models.CreateRoleInput) (*models.IAMRole, []string, error) {
+	if input.RoleName == "" {
+		return nil, nil, fmt.Errorf("invalid role input: role_name is required")
+	}
+	if input.MaxSessionDuration != 0 && (input.MaxSessionDuration < 3600 || input.MaxSessionDuration > 43200) {
+		return nil, nil, fmt.Errorf("invalid role input: max_session_duration must be between 3600 and 43200 seconds")
+	}
+
+	trustPolicy, err := resolveTrustPolicy(input.Trust, input.TrustPolicyDocument)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+
+	iamClient := iam.New(sess)
+
+	path := input.Path
+	if path == "" {
+		path = "/"
+	}
+
+	createInput := &iam.CreateRoleInput{
+		RoleName:                 aws.String(input.RoleName),
+		AssumeRolePolicyDocument: aws.String(trustPolicy),
+		Path:                     aws.String(path),
+	}
+	if input.Description != "" {
+		createInput.Description = aws.String(input.Description)
+	}
+	if input.MaxSessionDuration != 0 {
+		createInput.MaxSessionDuration = aws.Int64(input.MaxSessionDuration)
+	}
+	if input.PermissionsBoundary != "" {
+		createInput.PermissionsBoundary = aws.String(input.PermissionsBoundary)
+	}
+	hasCreatedBy := false
+	for _, tag := range input.Tags {
+		createInput.Tags = append(createInput.Tags, &iam.Tag{Key: aws.String(tag.Key), Value: aws.String(tag.Value)})
+		hasCreatedBy = hasCreatedBy || tag.Key == "CreatedBy"
+	}
+	if !hasCreatedBy {
+		createInput.Tags = append(createInput.Tags, &iam.Tag{Key: aws.String("CreatedBy"), Value: aws.String("iam-manager")})
+	}
+
+	_, err = iamClient.CreateRole(createInput)
+	if err != nil {
+		if strings.Contains(err.Error(), iam.ErrCodeEntityAlreadyExistsException) {
+			return nil, nil, fmt.Errorf("role %s already exists", input.RoleName)
+		}
+		if strings.Contains(err.Error(), iam.ErrCodeMalformedPolicyDocumentException) {
+			return nil, nil, fmt.Errorf("invalid trust policy document: %v", err)
+		}
+		return nil, nil, fmt.Errorf("failed to create role: %w", err)
+	}
+
+	// Attach policies one by one so a single bad ARN does not block the rest
+	var failedPolicies []string
+	for _, policyArn := range input.PolicyArns {
+		_, err := iamClient.AttachRolePolicy(&iam.AttachRolePolicyInput{
+			RoleName:  aws.String(input.RoleName),
+			PolicyArn: aws.String(policyArn),
+		})
+		if err != nil {
+			fmt.Printf("[WARNING] Failed to attach policy %s to role %s in account %s: %v\n", policyArn, input.RoleName, accountID, err)
+			failedPolicies = append(failedPolicies, fmt.Sprintf("%s: %v", policyArn, err))
+		}
+	}
+
+	s.InvalidateAccountRolesCache(accountID)
+
+	role, err := s.GetRole(accountID, input.RoleName)
+	if err != nil {
+		return nil, failedPolicies, err
+	}
+
+	return role, failedPolicies, nil
+}
+
+// UpdateRoleTrustPolicy replaces the trust policy of a role
+func (s *AWSService) UpdateRoleTrustPolicy(accountID, roleName string, input models.UpdateTrustPolicyInput) (*models.IAMRole, error) {
+	trustPolicy, err := resolveTrustPolicy(input.Trust, input.TrustPolicyDocument)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+
+	iamClient := iam.New(sess)
+
+	_, err = iamClient.UpdateAssumeRolePolicy(&iam.UpdateAssumeRolePolicyInput{
+		RoleName:       aws.String(roleName),
+		PolicyDocument: aws.String(trustPolicy),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), iam.ErrCodeNoSuchEntityException) {
+			return nil, fmt.Errorf("role %s not found", roleName)
+		}
+		if strings.Contains(err.Error(), iam.ErrCodeMalformedPolicyDocumentException) {
+			return nil, fmt.Errorf("invalid trust policy document: %v", err)
+		}
+		return nil, fmt.Errorf("failed to update trust policy: %w", err)
+	}
+
+	s.InvalidateAccountRolesCache(accountID)
+
+	return s.GetRole(accountID, roleName)
+}
+
+// AttachRolePolicy attaches a managed policy to a role
+func (s *AWSService) AttachRolePolicy(accountID, roleName, policyArn string) error {
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+
+	iamClient := iam.New(sess)
+
+	_, err = iamClient.AttachRolePolicy(&iam.AttachRolePolicyInput{
+		RoleName:  aws.String(roleName),
+		PolicyArn: aws.String(policyArn),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), iam.ErrCodeNoSuchEntityException) {
+			return fmt.Errorf("role %s or policy %s not found", roleName, policyArn)
+		}
+		return fmt.Errorf("failed to attach policy %s: %v", policyArn, err)
+	}
+
+	s.InvalidateAccountRolesCache(accountID)
+	s.InvalidateAccountPoliciesCache(accountID)
+
+	return nil
+}
+
+// DetachRolePolicy detaches a managed policy from a role
+func (s *AWSService) DetachRolePolicy(accountID, roleName, policyArn string) error {
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+
+	iamClient := iam.New(sess)
+
+	_, err = iamClient.DetachRolePolicy(&iam.DetachRolePolicyInput{
+		RoleName:  aws.String(roleName),
+		PolicyArn: aws.String(policyArn),
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), iam.ErrCodeNoSuchEntityException) {
+			return fmt.Errorf("policy %s is not attached to role %s or was not found", policyArn, roleName)
+		}
+		return fmt.Errorf("failed to detach policy %s: %v", policyArn, err)
+	}
+
+	s.InvalidateAccountRolesCache(accountID)
+	s.InvalidateAccountPoliciesCache(accountID)
+
+	return nil
+}
+
 // InvalidateRolesCache invalidates the roles cache
 func (s *AWSService) InvalidateRolesCache() {
 	s.cache.Delete("all-roles")
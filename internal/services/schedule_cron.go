@@ -0,0 +1,128 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronExpression is a parsed five-field cron expression
+type cronExpression struct {
+	minutes, hours, days, months, weekdays uint64 // Bit sets of allowed values
+	anyDay, anyWeekday                     bool
+}
+
+var cronMonthNames = map[string]int{
+	"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+	"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+}
+
+var cronWeekdayNames = map[string]int{
+	"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+}
+
+// parseCron parses "minute hour day-of-month month day-of-week". Fields accept "*", values,
+// ranges ("MON-FRI"), lists ("1,15") and steps ("*/15", "8-18/2"). Sunday is 0 or 7.
+func parseCron(expression string) (*cronExpression, error) {
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields", expression)
+	}
+
+	cron := &cronExpression{
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+	var err error
+	if cron.minutes, err = parseCronField(fields[0], 0, 59, nil); err != nil {
+		return nil, fmt.Errorf("invalid cron minute %q: %v", fields[0], err)
+	}
+	if cron.hours, err = parseCronField(fields[1], 0, 23, nil); err != nil {
+		return nil, fmt.Errorf("invalid cron hour %q: %v", fields[1], err)
+	}
+	if cron.days, err = parseCronField(fields[2], 1, 31, nil); err != nil {
+		return nil, fmt.Errorf("invalid cron day of month %q: %v", fields[2], err)
+	}
+	if cron.months, err = parseCronField(fields[3], 1, 12, cronMonthNames); err != nil {
+		return nil, fmt.Errorf("invalid cron month %q: %v", fields[3], err)
+	}
+	if cron.weekdays, err = parseCronField(fields[4], 0, 7, cronWeekdayNames); err != nil {
+		return nil, fmt.Errorf("invalid cron day of week %q: %v", fields[4], err)
+	}
+	if cron.weekdays&(1<<7) != 0 {
+		cron.weekdays |= 1 // 7 is Sunday
+	}
+	return cron, nil
+}
+
+// parseCronField returns the bit set of values a field allows
+func parseCronField(field string, low, high int, names map[string]int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if base, stepValue, found := strings.Cut(part, "/"); found {
+			parsed, err := strconv.Atoi(stepValue)
+			if err != nil || parsed <= 0 {
+				return 0, fmt.Errorf("bad step %q", stepValue)
+			}
+			part, step = base, parsed
+		}
+
+		start, end := low, high
+		if part != "*" {
+			from, to, isRange := strings.Cut(part, "-")
+			var err error
+			if start, err = parseCronValue(from, names); err != nil {
+				return 0, err
+			}
+			end = start
+			if isRange {
+				if end, err = parseCronValue(to, names); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				end = high // "5/15" means from 5 to the end in steps of 15
+			}
+		}
+		if start < low || end > high || start > end {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, low, high)
+		}
+
+		for value := start; value <= end; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+func parseCronValue(value string, names map[string]int) (int, error) {
+	if number, found := names[strings.ToUpper(value)]; found {
+		return number, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("bad value %q", value)
+	}
+	return number, nil
+}
+
+// matches reports whether the expression fires at a time's minute. As in cron, a restricted
+// day of month and day of week match when either one does.
+func (c *cronExpression) matches(t time.Time) bool {
+	if c.minutes&(1<<uint(t.Minute())) == 0 || c.hours&(1<<uint(t.Hour())) == 0 || c.months&(1<<uint(t.Month())) == 0 {
+		return false
+	}
+
+	dayMatches := c.days&(1<<uint(t.Day())) != 0
+	weekdayMatches := c.weekdays&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.anyDay && c.anyWeekday:
+		return true
+	case c.anyDay:
+		return weekdayMatches
+	case c.anyWeekday:
+		return dayMatches
+	}
+	return dayMatches || weekdayMatches
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// errScheduleLeaseBusy is returned while another replica is acquiring the lease
+var errScheduleLeaseBusy = errors.New("schedule lease is being acquired by another instance")
+
+// scheduleLease elects one scheduler among replicas sharing the state directory. The lease file
+// names the holder and when the lease expires; an exclusively created lock file serializes
+// reading and renewing it. Without a state directory the only replica is always the leader.
+type scheduleLease struct {
+	path   string
+	holder string
+	ttl    time.Duration
+}
+
+// scheduleLeaseRecord is the content of the lease file
+type scheduleLeaseRecord struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+func newScheduleLease(stateDir, holder string, ttl time.Duration) *scheduleLease {
+	lease := &scheduleLease{holder: holder, ttl: ttl}
+	if stateDir != "" {
+		lease.path = filepath.Join(stateDir, "schedule-leader.json")
+	}
+	return lease
+}
+
+// acquire takes over an expired or free lease or renews our own, and reports whether this instance leads
+func (l *scheduleLease) acquire(now time.Time) (bool, scheduleLeaseRecord, error) {
+	next := scheduleLeaseRecord{Holder: l.holder, Expires: now.Add(l.ttl)}
+	if l.path == "" {
+		return true, next, nil
+	}
+
+	unlock, err := l.lock()
+	if err != nil {
+		return false, scheduleLeaseRecord{}, err
+	}
+	defer unlock()
+
+	var current scheduleLeaseRecord
+	data, err := os.ReadFile(l.path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &current); err != nil {
+			fmt.Printf("[WARNING] Ignoring unreadable schedule lease %s: %v\n", l.path, err)
+		}
+	case !os.IsNotExist(err):
+		return false, scheduleLeaseRecord{}, fmt.Errorf("failed to read schedule lease: %w", err)
+	}
+
+	if current.Holder != "" && current.Holder != l.holder && now.Before(current.Expires) {
+		return false, current, nil
+	}
+
+	data, err = json.Marshal(next)
+	if err != nil {
+		return false, scheduleLeaseRecord{}, fmt.Errorf("failed to encode schedule lease: %w", err)
+	}
+	tmpPath := l.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return false, scheduleLeaseRecord{}, fmt.Errorf("failed to write schedule lease: %w", err)
+	}
+	if err := os.Rename(tmpPath, l.path); err != nil {
+		return false, scheduleLeaseRecord{}, fmt.Errorf("failed to replace schedule lease: %w", err)
+	}
+	return true, next, nil
+}
+
+// lock creates the lock file. A lock older than the lease TTL was left behind by a crashed replica and is removed.
+func (l *scheduleLease) lock() (func(), error) {
+	lockPath := l.path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock schedule lease: %w", err)
+		}
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > l.ttl {
+			os.Remove(lockPath)
+			continue
+		}
+		break
+	}
+	return nil, errScheduleLeaseBusy
+}
@@ -0,0 +1,860 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/config"
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// SCHEDULED START/STOP
+// ============================================================================
+//
+// Schedules start and stop EC2 instances and Azure VMs on cron rules evaluated in the
+// schedule's time zone. Every minute the leader (see scheduleLease) looks for rules that fired
+// since the schedule was last evaluated. After downtime, runs within the catch-up window are
+// applied late, but only the latest one: a missed 08:00 start followed by a missed 19:00 stop
+// leaves the machines stopped. Overrides suspend actions for a window. A schedule is marked
+// evaluated before its actions run, so a crash never repeats a run.
+
+// ErrScheduleNotFound is returned for unknown schedule IDs
+var ErrScheduleNotFound = errors.New("schedule not found")
+
+const (
+	defaultScheduleCatchUp = 12 * time.Hour
+	scheduleRunGrace       = 2 * time.Minute // Runs applied later than this are recorded as catch-up
+	scheduleAuditLimit     = 1000
+	scheduleActionTimeout  = 15 * time.Minute
+	scheduleWorkers        = 10
+)
+
+// ScheduleService runs start/stop schedules for EC2 instances and Azure VMs
+type ScheduleService struct {
+	awsService   AWSServiceInterface
+	azureService AzureRMServiceInterface // nil when Azure Resource Manager is not configured
+	store        *scheduleStore
+	audit        *scheduleAuditLog
+	lease        *scheduleLease
+	instanceID   string
+	enabled      bool
+
+	statusMu sync.Mutex
+	status   models.SchedulerStatus
+}
+
+// NewScheduleService creates a schedule service. The Azure Resource Manager service is optional.
+func NewScheduleService(awsService AWSServiceInterface, azureService AzureRMServiceInterface, cfg config.Config) *ScheduleService {
+	hostname, _ := os.Hostname()
+	instanceID := fmt.Sprintf("%s-%s", hostname, uuid.New().String()[:8])
+
+	return &ScheduleService{
+		awsService:   awsService,
+		azureService: azureService,
+		store:        newScheduleStore(cfg.StateDir),
+		audit:        newScheduleAuditLog(cfg.StateDir),
+		lease:        newScheduleLease(cfg.StateDir, instanceID, 3*time.Minute),
+		instanceID:   instanceID,
+		enabled:      cfg.SchedulerEnabled,
+	}
+}
+
+// scheduleStore holds schedules and persists them to the state directory. Replicas share the
+// file, so it is re-read before every change and every evaluation.
+type scheduleStore struct {
+	mu        sync.Mutex
+	schedules map[string]*models.Schedule
+	file      *stateFile
+}
+
+func newScheduleStore(stateDir string) *scheduleStore {
+	store := &scheduleStore{
+		schedules: make(map[string]*models.Schedule),
+		file:      newStateFile(stateDir, "schedules.json"),
+	}
+	store.load()
+	return store
+}
+
+// load replaces the schedules with the stored ones; callers must hold mu except during construction
+func (st *scheduleStore) load() {
+	if st.file.path == "" {
+		return
+	}
+	var stored []*models.Schedule
+	if err := st.file.Load(&stored); err != nil {
+		fmt.Printf("[WARNING] Failed to load schedules: %v\n", err)
+		return
+	}
+	st.schedules = make(map[string]*models.Schedule, len(stored))
+	for _, schedule := range stored {
+		st.schedules[schedule.ID] = schedule
+	}
+}
+
+// save persists all schedules; callers must hold mu
+func (st *scheduleStore) save() {
+	list := make([]*models.Schedule, 0, len(st.schedules))
+	for _, schedule := range st.schedules {
+		list = append(list, schedule)
+	}
+	if err := st.file.Save(list); err != nil {
+		fmt.Printf("[WARNING] Failed to persist schedules: %v\n", err)
+	}
+}
+
+// scheduleAuditLog keeps the most recent scheduled actions
+type scheduleAuditLog struct {
+	mu      sync.Mutex
+	entries []models.ScheduleAuditEntry
+	file    *stateFile
+}
+
+func newScheduleAuditLog(stateDir string) *scheduleAuditLog {
+	log := &scheduleAuditLog{file: newStateFile(stateDir, "schedule-audit.json")}
+	log.load()
+	return log
+}
+
+// load re-reads entries written by the leader, which may be another replica; callers must hold mu
+// except during construction
+func (a *scheduleAuditLog) load() {
+	if a.file.path == "" {
+		return
+	}
+	var entries []models.ScheduleAuditEntry
+	if err := a.file.Load(&entries); err != nil {
+		fmt.Printf("[WARNING] Failed to load schedule audit log: %v\n", err)
+		return
+	}
+	a.entries = entries
+}
+
+// add appends entries, dropping the oldest beyond the limit
+func (a *scheduleAuditLog) add(entries ...models.ScheduleAuditEntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.load()
+
+	a.entries = append(a.entries, entries...)
+	if len(a.entries) > scheduleAuditLimit {
+		a.entries = a.entries[len(a.entries)-scheduleAuditLimit:]
+	}
+	if err := a.file.Save(a.entries); err != nil {
+		fmt.Printf("[WARNING] Failed to persist schedule audit log: %v\n", err)
+	}
+}
+
+// ListSchedules returns all schedules ordered by name
+func (s *ScheduleService) ListSchedules() []models.Schedule {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	s.store.load()
+
+	schedules := make([]models.Schedule, 0, len(s.store.schedules))
+	for _, schedule := range s.store.schedules {
+		schedules = append(schedules, *schedule)
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].Name < schedules[j].Name
+	})
+	return schedules
+}
+
+// GetSchedule returns a schedule by ID
+func (s *ScheduleService) GetSchedule(scheduleID string) (*models.Schedule, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	s.store.load()
+
+	schedule, ok := s.store.schedules[scheduleID]
+	if !ok {
+		return nil, ErrScheduleNotFound
+	}
+	copied := *schedule
+	return &copied, nil
+}
+
+// CreateSchedule validates and stores a new schedule
+func (s *ScheduleService) CreateSchedule(schedule models.Schedule) (*models.Schedule, error) {
+	if schedule.TimeZone == "" {
+		schedule.TimeZone = "UTC"
+	}
+	if err := validateSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	schedule.ID = uuid.New().String()
+	schedule.CreatedAt = now
+	schedule.UpdatedAt = now
+	schedule.LastEvaluated = now // Rules that fired before the schedule existed are not caught up
+	schedule.LastRunAt = time.Time{}
+	schedule.LastAction = ""
+
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	s.store.load()
+	s.store.schedules[schedule.ID] = &schedule
+	s.store.save()
+
+	copied := schedule
+	return &copied, nil
+}
+
+// UpdateSchedule replaces the definition of a schedule. Overrides are kept unless given.
+func (s *ScheduleService) UpdateSchedule(scheduleID string, schedule models.Schedule) (*models.Schedule, error) {
+	if schedule.TimeZone == "" {
+		schedule.TimeZone = "UTC"
+	}
+	if err := validateSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	s.store.load()
+
+	existing, ok := s.store.schedules[scheduleID]
+	if !ok {
+		return nil, ErrScheduleNotFound
+	}
+
+	existing.Name = schedule.Name
+	existing.Description = schedule.Description
+	existing.TimeZone = schedule.TimeZone
+	existing.Rules = schedule.Rules
+	existing.Targets = schedule.Targets
+	existing.Selector = schedule.Selector
+	existing.CatchUpMinutes = schedule.CatchUpMinutes
+	if schedule.Overrides != nil {
+		existing.Overrides = schedule.Overrides
+	}
+	if schedule.Enabled && !existing.Enabled {
+		// Runs missed while the schedule was disabled are not caught up
+		existing.LastEvaluated = time.Now()
+	}
+	existing.Enabled = schedule.Enabled
+	existing.UpdatedAt = time.Now()
+	s.store.save()
+
+	copied := *existing
+	return &copied, nil
+}
+
+// DeleteSchedule removes a schedule
+func (s *ScheduleService) DeleteSchedule(scheduleID string) error {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	s.store.load()
+
+	if _, ok := s.store.schedules[scheduleID]; !ok {
+		return ErrScheduleNotFound
+	}
+	delete(s.store.schedules, scheduleID)
+	s.store.save()
+	return nil
+}
+
+// SnoozeSchedule suspends a schedule's actions, or only the given ones, from now for a duration
+func (s *ScheduleService) SnoozeSchedule(scheduleID string, input models.ScheduleSnoozeInput) (*models.Schedule, error) {
+	duration, err := time.ParseDuration(input.Duration)
+	if err != nil || duration <= 0 {
+		return nil, fmt.Errorf("invalid snooze duration %q", input.Duration)
+	}
+	for _, action := range input.Actions {
+		if action != models.ScheduleActionStart && action != models.ScheduleActionStop {
+			return nil, fmt.Errorf("invalid snooze action %q", action)
+		}
+	}
+
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	s.store.load()
+
+	schedule, ok := s.store.schedules[scheduleID]
+	if !ok {
+		return nil, ErrScheduleNotFound
+	}
+
+	now := time.Now()
+	schedule.Overrides = append(schedule.Overrides, models.ScheduleOverride{
+		Start:   now,
+		End:     now.Add(duration),
+		Actions: input.Actions,
+		Reason:  input.Reason,
+	})
+	schedule.UpdatedAt = now
+	s.store.save()
+
+	copied := *schedule
+	return &copied, nil
+}
+
+// ClearScheduleOverrides removes every override window of a schedule
+func (s *ScheduleService) ClearScheduleOverrides(scheduleID string) (*models.Schedule, error) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	s.store.load()
+
+	schedule, ok := s.store.schedules[scheduleID]
+	if !ok {
+		return nil, ErrScheduleNotFound
+	}
+	schedule.Overrides = nil
+	schedule.UpdatedAt = time.Now()
+	s.store.save()
+
+	copied := *schedule
+	return &copied, nil
+}
+
+// PreviewSchedule returns the next runs of a schedule
+func (s *ScheduleService) PreviewSchedule(scheduleID string, count int) ([]models.ScheduleRun, error) {
+	schedule, err := s.GetSchedule(scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	rules, location, err := compileSchedule(*schedule)
+	if err != nil {
+		return nil, err
+	}
+	return nextScheduleRuns(rules, location, time.Now(), count), nil
+}
+
+// ListScheduleAudit returns the most recent scheduled actions first, optionally for one schedule
+func (s *ScheduleService) ListScheduleAudit(scheduleID string, limit int) []models.ScheduleAuditEntry {
+	s.audit.mu.Lock()
+	defer s.audit.mu.Unlock()
+	s.audit.load()
+
+	entries := []models.ScheduleAuditEntry{}
+	for i := len(s.audit.entries) - 1; i >= 0; i-- {
+		if scheduleID != "" && s.audit.entries[i].ScheduleID != scheduleID {
+			continue
+		}
+		entries = append(entries, s.audit.entries[i])
+		if limit > 0 && len(entries) == limit {
+			break
+		}
+	}
+	return entries
+}
+
+// GetSchedulerStatus reports whether this instance runs schedules
+func (s *ScheduleService) GetSchedulerStatus() models.SchedulerStatus {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+
+	status := s.status
+	status.Enabled = s.enabled
+	status.InstanceID = s.instanceID
+	return status
+}
+
+// Start evaluates schedules in the background
+func (s *ScheduleService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.ProcessSchedules(time.Now())
+		}
+	}()
+}
+
+// ProcessSchedules runs every due schedule if this instance holds the scheduler lease. The lease is
+// renewed before each schedule because a schedule's actions can outlast the lease TTL.
+func (s *ScheduleService) ProcessSchedules(now time.Time) {
+	s.statusMu.Lock()
+	s.status.LastTick = now
+	s.statusMu.Unlock()
+
+	if !s.holdLease(now) {
+		return
+	}
+
+	evaluated := false
+	for _, schedule := range s.ListSchedules() {
+		if !schedule.Enabled {
+			continue
+		}
+		if evaluated && !s.holdLease(time.Now()) {
+			fmt.Printf("[WARNING] Lost the schedule lease, leaving remaining schedules to the new leader\n")
+			return
+		}
+		s.evaluateSchedule(schedule.ID, now)
+		evaluated = true
+	}
+}
+
+// holdLease acquires or renews the scheduler lease, records the outcome in the status and
+// reports whether this instance leads
+func (s *ScheduleService) holdLease(now time.Time) bool {
+	leader, record, err := s.lease.acquire(now)
+
+	s.statusMu.Lock()
+	s.status.IsLeader = leader
+	s.status.Leader = record.Holder
+	s.status.LeaseExpires = record.Expires
+	s.statusMu.Unlock()
+
+	if err != nil {
+		if !errors.Is(err, errScheduleLeaseBusy) {
+			fmt.Printf("[WARNING] Failed to acquire schedule lease: %v\n", err)
+		}
+		return false
+	}
+	return leader
+}
+
+// evaluateSchedule applies the latest run of a schedule that is due
+func (s *ScheduleService) evaluateSchedule(scheduleID string, now time.Time) {
+	s.store.mu.Lock()
+	// Another replica may have changed or evaluated the schedule since it was listed
+	s.store.load()
+	schedule, ok := s.store.schedules[scheduleID]
+	if !ok || !schedule.Enabled {
+		s.store.mu.Unlock()
+		return
+	}
+
+	rules, location, err := compileSchedule(*schedule)
+	if err != nil {
+		s.store.mu.Unlock()
+		fmt.Printf("[WARNING] Skipping invalid schedule %s: %v\n", schedule.Name, err)
+		return
+	}
+
+	from := schedule.LastEvaluated
+	if from.IsZero() {
+		from = schedule.CreatedAt
+	}
+	window := defaultScheduleCatchUp
+	if schedule.CatchUpMinutes > 0 {
+		window = time.Duration(schedule.CatchUpMinutes) * time.Minute
+	} else if schedule.CatchUpMinutes < 0 {
+		window = scheduleRunGrace
+	}
+	if earliest := now.Add(-window); from.Before(earliest) {
+		from = earliest
+	}
+
+	run, due := latestScheduleRun(rules, location, from, now)
+
+	// Mark the schedule evaluated before acting so a crash or a new leader never repeats the run
+	schedule.LastEvaluated = now
+	schedule.Overrides = activeScheduleOverrides(schedule.Overrides, now)
+	if due {
+		schedule.LastRunAt = run.Time
+		schedule.LastAction = run.Action
+	}
+	snapshot := *schedule
+	s.store.save()
+	s.store.mu.Unlock()
+
+	if !due {
+		return
+	}
+
+	entry := models.ScheduleAuditEntry{
+		Time:         now,
+		ScheduleID:   snapshot.ID,
+		ScheduleName: snapshot.Name,
+		ScheduledFor: run.Time,
+		CatchUp:      now.Sub(run.Time) > scheduleRunGrace,
+		Action:       run.Action,
+		Leader:       s.instanceID,
+	}
+
+	if override := scheduleOverrideFor(snapshot.Overrides, run); override != nil {
+		entry.Status = "skipped"
+		entry.Message = fmt.Sprintf("Snoozed until %s", override.End.Format(time.RFC3339))
+		if override.Reason != "" {
+			entry.Message += ": " + override.Reason
+		}
+		s.audit.add(entry)
+		return
+	}
+
+	fmt.Printf("[INFO] Running schedule %s: %s (scheduled for %s)\n", snapshot.Name, run.Action, run.Time.Format(time.RFC3339))
+	s.audit.add(s.applyScheduleRun(snapshot, run.Action, entry)...)
+}
+
+// scheduledMachine is an EC2 instance or Azure VM a schedule acts on
+type scheduledMachine struct {
+	provider string
+	account  string // Account or subscription ID
+	region   string // Region or resource group
+	name     string // Instance ID or VM name
+	state    string
+	err      error // Set when the machine could not be resolved
+}
+
+func (m scheduledMachine) resource() string {
+	if m.provider == models.ScheduleProviderAzure {
+		return m.region + "/" + m.name
+	}
+	return m.name
+}
+
+// applyScheduleRun starts or stops every machine of a schedule and returns an audit entry for each
+func (s *ScheduleService) applyScheduleRun(schedule models.Schedule, action string, base models.ScheduleAuditEntry) []models.ScheduleAuditEntry {
+	machines := s.resolveScheduleMachines(schedule)
+	if len(machines) == 0 {
+		entry := base
+		entry.Status = "skipped"
+		entry.Message = "No machines match the schedule"
+		return []models.ScheduleAuditEntry{entry}
+	}
+
+	entries := make([]models.ScheduleAuditEntry, len(machines))
+	sem := make(chan struct{}, scheduleWorkers)
+	var wg sync.WaitGroup
+	for i, machine := range machines {
+		wg.Add(1)
+		go func(i int, machine scheduledMachine) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			entry := base
+			entry.Provider = machine.provider
+			entry.Account = machine.account
+			entry.Resource = machine.resource()
+			entry.Status, entry.Message = s.applyScheduleAction(machine, action)
+			entries[i] = entry
+		}(i, machine)
+	}
+	wg.Wait()
+	return entries
+}
+
+// applyScheduleAction starts or stops one machine unless it is already in the desired state
+func (s *ScheduleService) applyScheduleAction(machine scheduledMachine, action string) (string, string) {
+	if machine.err != nil {
+		return "failed", machine.err.Error()
+	}
+	if !scheduleActionNeeded(machine.provider, machine.state, action) {
+		return "skipped", fmt.Sprintf("Already %s", machine.state)
+	}
+
+	var err error
+	switch machine.provider {
+	case models.ScheduleProviderAWS:
+		if action == models.ScheduleActionStart {
+			err = s.awsService.StartEC2Instance(machine.account, machine.region, machine.name)
+		} else {
+			err = s.awsService.StopEC2Instance(machine.account, machine.region, machine.name)
+		}
+		if errors.Is(err, ErrEC2InstanceProtected) {
+			return "skipped", err.Error()
+		}
+	case models.ScheduleProviderAzure:
+		ctx, cancel := context.WithTimeout(context.Background(), scheduleActionTimeout)
+		defer cancel()
+		if action == models.ScheduleActionStart {
+			err = s.azureService.StartVM(ctx, machine.account, machine.region, machine.name)
+		} else {
+			err = s.azureService.StopVM(ctx, machine.account, machine.region, machine.name)
+		}
+	}
+	if err != nil {
+		return "failed", err.Error()
+	}
+
+	verb := "Started"
+	if action == models.ScheduleActionStop {
+		verb = "Stopped"
+	}
+	return "succeeded", fmt.Sprintf("%s %s", verb, machine.resource())
+}
+
+// scheduleActionNeeded reports whether a machine in a state needs the action. Terminated
+// instances are never started; Azure VMs that are only powered off still bill and are deallocated.
+func scheduleActionNeeded(provider, state, action string) bool {
+	switch provider {
+	case models.ScheduleProviderAWS:
+		if action == models.ScheduleActionStart {
+			return state == "stopped" || state == "stopping"
+		}
+		return state == "running" || state == "pending"
+	case models.ScheduleProviderAzure:
+		if action == models.ScheduleActionStart {
+			return state != "running" && state != "starting"
+		}
+		return state != "deallocated" && state != "deallocating"
+	}
+	return false
+}
+
+// resolveScheduleMachines returns the explicitly selected and tag-matched machines of a schedule with their current state
+func (s *ScheduleService) resolveScheduleMachines(schedule models.Schedule) []scheduledMachine {
+	useAWS, useAzure := false, false
+	for _, target := range schedule.Targets {
+		useAWS = useAWS || target.Provider == models.ScheduleProviderAWS
+		useAzure = useAzure || target.Provider == models.ScheduleProviderAzure
+	}
+	if selector := schedule.Selector; selector != nil {
+		useAWS = useAWS || len(selector.Providers) == 0 || containsString(selector.Providers, models.ScheduleProviderAWS)
+		// A selector without providers only covers Azure when it is configured
+		useAzure = useAzure || (len(selector.Providers) == 0 && s.azureService != nil) || containsString(selector.Providers, models.ScheduleProviderAzure)
+	}
+
+	var instances []models.EC2Instance
+	var awsErr error
+	if useAWS {
+		// States must be current to decide whether an instance needs the action
+		s.awsService.InvalidateEC2InstancesCache()
+		instances, awsErr = s.awsService.ListEC2Instances()
+	}
+
+	var vms []models.AzureVM
+	var azureErr error
+	if useAzure {
+		if s.azureService == nil {
+			azureErr = fmt.Errorf("Azure Resource Manager is not configured")
+		} else {
+			s.azureService.InvalidateVMsCache()
+			ctx, cancel := context.WithTimeout(context.Background(), scheduleActionTimeout)
+			vms, azureErr = s.azureService.ListVMs(ctx, "")
+			cancel()
+		}
+	}
+
+	return matchScheduleMachines(schedule, instances, awsErr, vms, azureErr)
+}
+
+// matchScheduleMachines selects a schedule's machines from the current inventories. A failed
+// inventory is reported once for the selector and once for each explicit target it affects.
+func matchScheduleMachines(schedule models.Schedule, instances []models.EC2Instance, awsErr error, vms []models.AzureVM, azureErr error) []scheduledMachine {
+	var machines []scheduledMachine
+	seen := make(map[string]bool)
+	add := func(machine scheduledMachine) {
+		key := machine.provider + "|" + machine.account + "|" + machine.resource()
+		if !seen[key] {
+			seen[key] = true
+			machines = append(machines, machine)
+		}
+	}
+
+	for _, target := range schedule.Targets {
+		switch target.Provider {
+		case models.ScheduleProviderAWS:
+			machine := scheduledMachine{provider: target.Provider, account: target.AccountID, region: target.Region, name: target.InstanceID}
+			machine.err = awsErr
+			if awsErr == nil {
+				machine.err = fmt.Errorf("instance %s not found", target.InstanceID)
+				for _, instance := range instances {
+					if instance.AccountID == target.AccountID && instance.InstanceID == target.InstanceID {
+						machine.region, machine.state, machine.err = instance.Region, instance.State, nil
+						break
+					}
+				}
+			}
+			add(machine)
+		case models.ScheduleProviderAzure:
+			machine := scheduledMachine{provider: target.Provider, account: target.SubscriptionID, region: target.ResourceGroup, name: target.VMName}
+			machine.err = azureErr
+			if azureErr == nil {
+				machine.err = fmt.Errorf("VM %s/%s not found", target.ResourceGroup, target.VMName)
+				for _, vm := range vms {
+					if vm.SubscriptionID == target.SubscriptionID && strings.EqualFold(vm.ResourceGroup, target.ResourceGroup) && vm.Name == target.VMName {
+						machine.region, machine.state, machine.err = vm.ResourceGroup, vm.Status, nil
+						break
+					}
+				}
+			}
+			add(machine)
+		}
+	}
+
+	selector := schedule.Selector
+	if selector == nil || len(selector.Tags) == 0 {
+		return machines
+	}
+
+	if len(selector.Providers) == 0 || containsString(selector.Providers, models.ScheduleProviderAWS) {
+		if awsErr != nil {
+			add(scheduledMachine{provider: models.ScheduleProviderAWS, name: "tag selector", err: awsErr})
+		}
+		for _, instance := range instances {
+			if len(selector.AccountIDs) > 0 && !containsString(selector.AccountIDs, instance.AccountID) {
+				continue
+			}
+			tags := make(map[string]string, len(instance.Tags))
+			for _, tag := range instance.Tags {
+				tags[tag.Key] = tag.Value
+			}
+			if scheduleTagsMatch(selector.Tags, tags) {
+				add(scheduledMachine{provider: models.ScheduleProviderAWS, account: instance.AccountID, region: instance.Region, name: instance.InstanceID, state: instance.State})
+			}
+		}
+	}
+
+	if len(selector.Providers) == 0 || containsString(selector.Providers, models.ScheduleProviderAzure) {
+		if azureErr != nil && containsString(selector.Providers, models.ScheduleProviderAzure) {
+			add(scheduledMachine{provider: models.ScheduleProviderAzure, region: "tag", name: "selector", err: azureErr})
+		}
+		for _, vm := range vms {
+			if len(selector.SubscriptionIDs) > 0 && !containsString(selector.SubscriptionIDs, vm.SubscriptionID) {
+				continue
+			}
+			if scheduleTagsMatch(selector.Tags, vm.Tags) {
+				add(scheduledMachine{provider: models.ScheduleProviderAzure, account: vm.SubscriptionID, region: vm.ResourceGroup, name: vm.Name, state: vm.Status})
+			}
+		}
+	}
+
+	return machines
+}
+
+// scheduleTagsMatch reports whether tags carry every wanted tag; "*" matches any value
+func scheduleTagsMatch(want, tags map[string]string) bool {
+	for key, value := range want {
+		actual, found := tags[key]
+		if !found || (value != "*" && actual != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// compiledScheduleRule is a rule with its cron expression parsed
+type compiledScheduleRule struct {
+	cron   *cronExpression
+	action string
+}
+
+// compileSchedule parses a schedule's rules and time zone
+func compileSchedule(schedule models.Schedule) ([]compiledScheduleRule, *time.Location, error) {
+	location, err := time.LoadLocation(schedule.TimeZone)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid time zone %q", schedule.TimeZone)
+	}
+	if len(schedule.Rules) == 0 {
+		return nil, nil, fmt.Errorf("invalid schedule: at least one rule is required")
+	}
+
+	rules := make([]compiledScheduleRule, 0, len(schedule.Rules))
+	for _, rule := range schedule.Rules {
+		if rule.Action != models.ScheduleActionStart && rule.Action != models.ScheduleActionStop {
+			return nil, nil, fmt.Errorf("invalid schedule action %q", rule.Action)
+		}
+		cron, err := parseCron(rule.Cron)
+		if err != nil {
+			return nil, nil, err
+		}
+		rules = append(rules, compiledScheduleRule{cron: cron, action: rule.Action})
+	}
+	return rules, location, nil
+}
+
+// validateSchedule checks a schedule's rules, time zone, targets and overrides
+func validateSchedule(schedule models.Schedule) error {
+	if strings.TrimSpace(schedule.Name) == "" {
+		return fmt.Errorf("invalid schedule: name is required")
+	}
+	if _, _, err := compileSchedule(schedule); err != nil {
+		return err
+	}
+
+	hasTagSelector := schedule.Selector != nil && len(schedule.Selector.Tags) > 0
+	if len(schedule.Targets) == 0 && !hasTagSelector {
+		return fmt.Errorf("invalid schedule: set targets or a tag selector")
+	}
+	for _, target := range schedule.Targets {
+		switch target.Provider {
+		case models.ScheduleProviderAWS:
+			if target.AccountID == "" || target.InstanceID == "" {
+				return fmt.Errorf("invalid schedule target: aws targets need account_id and instance_id")
+			}
+		case models.ScheduleProviderAzure:
+			if target.SubscriptionID == "" || target.ResourceGroup == "" || target.VMName == "" {
+				return fmt.Errorf("invalid schedule target: azure targets need subscription_id, resource_group and vm_name")
+			}
+		default:
+			return fmt.Errorf("invalid schedule target provider %q", target.Provider)
+		}
+	}
+	if schedule.Selector != nil {
+		for _, provider := range schedule.Selector.Providers {
+			if provider != models.ScheduleProviderAWS && provider != models.ScheduleProviderAzure {
+				return fmt.Errorf("invalid selector provider %q", provider)
+			}
+		}
+	}
+	for _, override := range schedule.Overrides {
+		if !override.End.After(override.Start) {
+			return fmt.Errorf("invalid schedule override: end must be after start")
+		}
+	}
+	return nil
+}
+
+// latestScheduleRun returns the latest run in (from, to]. When several rules fire in the
+// same minute, the first one wins.
+func latestScheduleRun(rules []compiledScheduleRule, location *time.Location, from, to time.Time) (models.ScheduleRun, bool) {
+	for t := to.Truncate(time.Minute); t.After(from); t = t.Add(-time.Minute) {
+		local := t.In(location)
+		for _, rule := range rules {
+			if rule.cron.matches(local) {
+				return models.ScheduleRun{Time: t, Action: rule.action}, true
+			}
+		}
+	}
+	return models.ScheduleRun{}, false
+}
+
+// nextScheduleRuns returns up to count runs after a time, looking at most a year ahead
+func nextScheduleRuns(rules []compiledScheduleRule, location *time.Location, after time.Time, count int) []models.ScheduleRun {
+	if count <= 0 || count > 50 {
+		count = 10
+	}
+
+	runs := []models.ScheduleRun{}
+	end := after.AddDate(1, 0, 0)
+	for t := after.Truncate(time.Minute).Add(time.Minute); t.Before(end) && len(runs) < count; t = t.Add(time.Minute) {
+		local := t.In(location)
+		for _, rule := range rules {
+			if rule.cron.matches(local) {
+				runs = append(runs, models.ScheduleRun{Time: local, Action: rule.action})
+				break
+			}
+		}
+	}
+	return runs
+}
+
+// activeScheduleOverrides drops overrides that have ended
+func activeScheduleOverrides(overrides []models.ScheduleOverride, now time.Time) []models.ScheduleOverride {
+	var active []models.ScheduleOverride
+	for _, override := range overrides {
+		if override.End.After(now) {
+			active = append(active, override)
+		}
+	}
+	return active
+}
+
+// scheduleOverrideFor returns the override that suspends a run, if any
+func scheduleOverrideFor(overrides []models.ScheduleOverride, run models.ScheduleRun) *models.ScheduleOverride {
+	for i, override := range overrides {
+		if run.Time.Before(override.Start) || !run.Time.Before(override.End) {
+			continue
+		}
+		if len(override.Actions) == 0 || containsString(override.Actions, run.Action) {
+			return &overrides[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,375 @@
+package services
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeScheduleAWS implements the EC2 calls schedules make
+type fakeScheduleAWS struct {
+	AWSServiceInterface
+	mu        sync.Mutex
+	instances []models.EC2Instance
+	started   []string
+	stopped   []string
+	onStop    func() // Called before each stop, e.g. to simulate another replica
+}
+
+func (f *fakeScheduleAWS) InvalidateEC2InstancesCache() {}
+
+func (f *fakeScheduleAWS) ListEC2Instances() ([]models.EC2Instance, error) {
+	return f.instances, nil
+}
+
+func (f *fakeScheduleAWS) StartEC2Instance(accountID, region, instanceID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.started = append(f.started, instanceID)
+	return nil
+}
+
+func (f *fakeScheduleAWS) StopEC2Instance(accountID, region, instanceID string) error {
+	if f.onStop != nil {
+		f.onStop()
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = append(f.stopped, instanceID)
+	return nil
+}
+
+func newTestScheduleService(aws AWSServiceInterface) *ScheduleService {
+	return &ScheduleService{
+		awsService: aws,
+		store:      newScheduleStore(""),
+		audit:      newScheduleAuditLog(""),
+		lease:      newScheduleLease("", "test", time.Minute),
+		instanceID: "test",
+		enabled:    true,
+	}
+}
+
+func TestParseCron(t *testing.T) {
+	cron, err := parseCron("0 8 * * MON-FRI")
+	require.NoError(t, err)
+	assert.True(t, cron.matches(time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)))  // Monday
+	assert.False(t, cron.matches(time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC))) // Sunday
+	assert.False(t, cron.matches(time.Date(2026, 10, 19, 8, 1, 0, 0, time.UTC)))
+
+	cron, err = parseCron("*/15 9-17/4 1,15 * 7")
+	require.NoError(t, err)
+	assert.True(t, cron.matches(time.Date(2026, 10, 15, 13, 45, 0, 0, time.UTC)))
+	assert.True(t, cron.matches(time.Date(2026, 10, 18, 9, 30, 0, 0, time.UTC)), "day of month and Sunday (7) match either way")
+	assert.False(t, cron.matches(time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)))
+	assert.False(t, cron.matches(time.Date(2026, 10, 15, 11, 0, 0, 0, time.UTC)))
+
+	for _, expression := range []string{"0 8 * *", "60 8 * * *", "0 8 * * FUNDAY", "0 8 */0 * *", "0 18-8 * * *"} {
+		_, err := parseCron(expression)
+		assert.Error(t, err, expression)
+	}
+}
+
+func TestLatestScheduleRunUsesTimeZone(t *testing.T) {
+	schedule := models.Schedule{
+		TimeZone: "Europe/Berlin",
+		Rules: []models.ScheduleRule{
+			{Cron: "0 8 * * MON-FRI", Action: models.ScheduleActionStart},
+			{Cron: "0 19 * * MON-FRI", Action: models.ScheduleActionStop},
+		},
+	}
+	rules, location, err := compileSchedule(schedule)
+	require.NoError(t, err)
+
+	// 08:00 in Berlin is 06:00 UTC in summer time
+	from := time.Date(2026, 7, 6, 5, 58, 0, 0, time.UTC)
+	run, due := latestScheduleRun(rules, location, from, from.Add(3*time.Minute))
+	require.True(t, due)
+	assert.Equal(t, models.ScheduleActionStart, run.Action)
+	assert.Equal(t, time.Date(2026, 7, 6, 6, 0, 0, 0, time.UTC), run.Time.UTC())
+
+	_, due = latestScheduleRun(rules, location, from.Add(5*time.Minute), from.Add(10*time.Minute))
+	assert.False(t, due)
+
+	// Only the latest of several missed runs is returned
+	run, due = latestScheduleRun(rules, location, from, time.Date(2026, 7, 6, 20, 0, 0, 0, time.UTC))
+	require.True(t, due)
+	assert.Equal(t, models.ScheduleActionStop, run.Action)
+
+	runs := nextScheduleRuns(rules, location, time.Date(2026, 7, 10, 12, 0, 0, 0, time.UTC), 3)
+	require.Len(t, runs, 3)
+	assert.Equal(t, models.ScheduleActionStop, runs[0].Action) // Friday 19:00
+	assert.Equal(t, time.Monday, runs[1].Time.Weekday())       // Weekend skipped
+	assert.Equal(t, models.ScheduleActionStart, runs[1].Action)
+	assert.Equal(t, "Europe/Berlin", runs[1].Time.Location().String())
+}
+
+func TestValidateSchedule(t *testing.T) {
+	valid := models.Schedule{
+		Name:     "office hours",
+		TimeZone: "UTC",
+		Rules:    []models.ScheduleRule{{Cron: "0 8 * * *", Action: models.ScheduleActionStart}},
+		Selector: &models.ScheduleSelector{Tags: map[string]string{"schedule": "office"}},
+	}
+	assert.NoError(t, validateSchedule(valid))
+
+	invalid := map[string]func(s *models.Schedule){
+		"no name":      func(s *models.Schedule) { s.Name = "" },
+		"bad zone":     func(s *models.Schedule) { s.TimeZone = "Mars/Olympus" },
+		"no rules":     func(s *models.Schedule) { s.Rules = nil },
+		"bad action":   func(s *models.Schedule) { s.Rules[0].Action = "reboot" },
+		"bad cron":     func(s *models.Schedule) { s.Rules[0].Cron = "daily" },
+		"no selection": func(s *models.Schedule) { s.Selector = nil },
+		"bad target":   func(s *models.Schedule) { s.Targets = []models.ScheduleTarget{{Provider: "aws", AccountID: "1"}} },
+		"bad provider": func(s *models.Schedule) { s.Targets = []models.ScheduleTarget{{Provider: "gcp"}} },
+		"bad override": func(s *models.Schedule) {
+			start := time.Date(2026, 10, 19, 18, 0, 0, 0, time.UTC)
+			s.Overrides = []models.ScheduleOverride{{Start: start, End: start.Add(-time.Hour)}}
+		},
+		"bad selection": func(s *models.Schedule) { s.Selector.Providers = []string{"gcp"} },
+	}
+	for name, mutate := range invalid {
+		schedule := valid
+		schedule.Rules = append([]models.ScheduleRule(nil), valid.Rules...)
+		schedule.Selector = &models.ScheduleSelector{Tags: valid.Selector.Tags}
+		mutate(&schedule)
+		assert.Error(t, validateSchedule(schedule), name)
+	}
+}
+
+func TestEvaluateScheduleCatchUpAndSkipsMachinesInState(t *testing.T) {
+	aws := &fakeScheduleAWS{instances: []models.EC2Instance{
+		{InstanceID: "i-running", AccountID: "111111111111", Region: "us-east-1", State: "running", Tags: []models.Tag{{Key: "schedule", Value: "office"}}},
+		{InstanceID: "i-stopped", AccountID: "111111111111", Region: "us-east-1", State: "stopped", Tags: []models.Tag{{Key: "schedule", Value: "office"}}},
+		{InstanceID: "i-other", AccountID: "111111111111", Region: "us-east-1", State: "running", Tags: []models.Tag{{Key: "schedule", Value: "batch"}}},
+		{InstanceID: "i-other-account", AccountID: "222222222222", Region: "us-east-1", State: "running", Tags: []models.Tag{{Key: "schedule", Value: "office"}}},
+	}}
+	service := newTestScheduleService(aws)
+
+	now := time.Date(2026, 10, 19, 21, 30, 0, 0, time.UTC)
+	service.store.schedules["s1"] = &models.Schedule{
+		ID:       "s1",
+		Name:     "office hours",
+		TimeZone: "UTC",
+		Enabled:  true,
+		Rules: []models.ScheduleRule{
+			{Cron: "0 8 * * *", Action: models.ScheduleActionStart},
+			{Cron: "0 19 * * *", Action: models.ScheduleActionStop},
+		},
+		Selector:       &models.ScheduleSelector{Tags: map[string]string{"schedule": "office"}, AccountIDs: []string{"111111111111"}},
+		CatchUpMinutes: 15 * 60,
+		LastEvaluated:  now.Add(-14 * time.Hour), // Down since 07:30: the 08:00 start and 19:00 stop were missed
+	}
+
+	service.ProcessSchedules(now)
+
+	assert.Equal(t, []string{"i-running"}, aws.stopped)
+	assert.Empty(t, aws.started)
+
+	audit := service.ListScheduleAudit("s1", 0)
+	require.Len(t, audit, 2)
+	for _, entry := range audit {
+		assert.True(t, entry.CatchUp)
+		assert.Equal(t, models.ScheduleActionStop, entry.Action)
+		assert.Equal(t, time.Date(2026, 10, 19, 19, 0, 0, 0, time.UTC), entry.ScheduledFor)
+	}
+	statuses := map[string]string{audit[0].Resource: audit[0].Status, audit[1].Resource: audit[1].Status}
+	assert.Equal(t, map[string]string{"i-running": "succeeded", "i-stopped": "skipped"}, statuses)
+
+	schedule, err := service.GetSchedule("s1")
+	require.NoError(t, err)
+	assert.Equal(t, now, schedule.LastEvaluated)
+	assert.Equal(t, models.ScheduleActionStop, schedule.LastAction)
+
+	// The run is applied once
+	service.ProcessSchedules(now.Add(time.Minute))
+	assert.Len(t, aws.stopped, 1)
+	assert.Len(t, service.ListScheduleAudit("", 0), 2)
+}
+
+func TestEvaluateScheduleCatchUpWindow(t *testing.T) {
+	aws := &fakeScheduleAWS{instances: []models.EC2Instance{
+		{InstanceID: "i-1", AccountID: "111111111111", Region: "us-east-1", State: "stopped"},
+	}}
+	service := newTestScheduleService(aws)
+
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	service.store.schedules["s1"] = &models.Schedule{
+		ID:             "s1",
+		Name:           "morning",
+		TimeZone:       "UTC",
+		Enabled:        true,
+		CatchUpMinutes: 60,
+		Rules:          []models.ScheduleRule{{Cron: "0 8 * * *", Action: models.ScheduleActionStart}},
+		Targets:        []models.ScheduleTarget{{Provider: models.ScheduleProviderAWS, AccountID: "111111111111", InstanceID: "i-1"}},
+		LastEvaluated:  now.Add(-5 * time.Hour),
+	}
+
+	// The 08:00 run is four hours late, outside the one hour window
+	service.ProcessSchedules(now)
+	assert.Empty(t, aws.started)
+	assert.Empty(t, service.ListScheduleAudit("s1", 0))
+}
+
+func TestEvaluateScheduleOverride(t *testing.T) {
+	aws := &fakeScheduleAWS{instances: []models.EC2Instance{
+		{InstanceID: "i-1", AccountID: "111111111111", Region: "us-east-1", State: "running"},
+	}}
+	service := newTestScheduleService(aws)
+
+	now := time.Date(2026, 10, 19, 19, 0, 30, 0, time.UTC)
+	service.store.schedules["s1"] = &models.Schedule{
+		ID:       "s1",
+		Name:     "evening",
+		TimeZone: "UTC",
+		Enabled:  true,
+		Rules:    []models.ScheduleRule{{Cron: "0 19 * * *", Action: models.ScheduleActionStop}},
+		Targets:  []models.ScheduleTarget{{Provider: models.ScheduleProviderAWS, AccountID: "111111111111", InstanceID: "i-1"}},
+		Overrides: []models.ScheduleOverride{
+			{Start: now.Add(-time.Hour), End: now.Add(2 * time.Hour), Actions: []string{models.ScheduleActionStop}, Reason: "release"},
+			{Start: now.Add(-3 * time.Hour), End: now.Add(-2 * time.Hour)},
+		},
+		LastEvaluated: now.Add(-time.Minute),
+	}
+
+	service.ProcessSchedules(now)
+	assert.Empty(t, aws.stopped)
+
+	audit := service.ListScheduleAudit("s1", 0)
+	require.Len(t, audit, 1)
+	assert.Equal(t, "skipped", audit[0].Status)
+	assert.False(t, audit[0].CatchUp)
+	assert.Contains(t, audit[0].Message, "release")
+
+	// Expired overrides are pruned
+	schedule, err := service.GetSchedule("s1")
+	require.NoError(t, err)
+	assert.Len(t, schedule.Overrides, 1)
+}
+
+func TestMatchScheduleMachinesReportsMissingTargets(t *testing.T) {
+	schedule := models.Schedule{
+		Targets: []models.ScheduleTarget{
+			{Provider: models.ScheduleProviderAWS, AccountID: "111111111111", InstanceID: "i-gone"},
+			{Provider: models.ScheduleProviderAzure, SubscriptionID: "sub", ResourceGroup: "RG", VMName: "vm1"},
+		},
+		Selector: &models.ScheduleSelector{Tags: map[string]string{"env": "*"}, Providers: []string{models.ScheduleProviderAzure}},
+	}
+	vms := []models.AzureVM{
+		{Name: "vm1", ResourceGroup: "rg", SubscriptionID: "sub", Status: "running", Tags: map[string]string{"env": "dev"}},
+		{Name: "vm2", ResourceGroup: "rg", SubscriptionID: "sub", Status: "deallocated", Tags: map[string]string{"env": "prod"}},
+		{Name: "vm3", ResourceGroup: "rg", SubscriptionID: "sub", Status: "running"},
+	}
+
+	machines := matchScheduleMachines(schedule, nil, nil, vms, nil)
+	require.Len(t, machines, 3)
+	assert.EqualError(t, machines[0].err, "instance i-gone not found")
+	assert.NoError(t, machines[1].err)
+	assert.Equal(t, "running", machines[1].state)
+	assert.Equal(t, "vm2", machines[2].name)
+
+	assert.True(t, scheduleActionNeeded(models.ScheduleProviderAzure, "stopped", models.ScheduleActionStop), "powered off VMs are still deallocated")
+	assert.False(t, scheduleActionNeeded(models.ScheduleProviderAWS, "terminated", models.ScheduleActionStart))
+}
+
+func TestScheduleLease(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	first := newScheduleLease(dir, "first", time.Minute)
+	second := newScheduleLease(dir, "second", time.Minute)
+
+	leader, _, err := first.acquire(now)
+	require.NoError(t, err)
+	assert.True(t, leader)
+
+	leader, record, err := second.acquire(now.Add(30 * time.Second))
+	require.NoError(t, err)
+	assert.False(t, leader)
+	assert.Equal(t, "first", record.Holder)
+
+	// The first instance renews its lease
+	leader, _, err = first.acquire(now.Add(45 * time.Second))
+	require.NoError(t, err)
+	assert.True(t, leader)
+
+	// Once it stops renewing, the lease expires and the second instance takes over
+	leader, _, err = second.acquire(now.Add(2 * time.Minute))
+	require.NoError(t, err)
+	assert.True(t, leader)
+
+	leader, _, err = first.acquire(now.Add(2*time.Minute + time.Second))
+	require.NoError(t, err)
+	assert.False(t, leader)
+}
+
+func TestEvaluateScheduleReloadsStore(t *testing.T) {
+	dir := t.TempDir()
+	aws := &fakeScheduleAWS{instances: []models.EC2Instance{
+		{InstanceID: "i-1", AccountID: "111111111111", Region: "us-east-1", State: "running"},
+	}}
+	first := newTestScheduleService(aws)
+	first.store = newScheduleStore(dir)
+	second := newTestScheduleService(aws)
+	second.store = newScheduleStore(dir)
+
+	created, err := first.CreateSchedule(models.Schedule{
+		Name:    "evening",
+		Enabled: true,
+		Rules:   []models.ScheduleRule{{Cron: "0 19 * * *", Action: models.ScheduleActionStop}},
+		Targets: []models.ScheduleTarget{{Provider: models.ScheduleProviderAWS, AccountID: "111111111111", InstanceID: "i-1"}},
+	})
+	require.NoError(t, err)
+
+	// Another replica snoozes the schedule after this one last read the store
+	_, err = second.SnoozeSchedule(created.ID, models.ScheduleSnoozeInput{Duration: "1h", Reason: "release"})
+	require.NoError(t, err)
+
+	first.evaluateSchedule(created.ID, time.Now())
+
+	schedule, err := second.GetSchedule(created.ID)
+	require.NoError(t, err)
+	require.Len(t, schedule.Overrides, 1, "the snooze is not overwritten")
+	assert.Equal(t, "release", schedule.Overrides[0].Reason)
+}
+
+func TestProcessSchedulesStopsWhenLeaseIsLost(t *testing.T) {
+	dir := t.TempDir()
+	aws := &fakeScheduleAWS{instances: []models.EC2Instance{
+		{InstanceID: "i-1", AccountID: "111111111111", Region: "us-east-1", State: "running"},
+		{InstanceID: "i-2", AccountID: "111111111111", Region: "us-east-1", State: "running"},
+	}}
+	service := newTestScheduleService(aws)
+	service.lease = newScheduleLease(dir, "first", time.Minute)
+	other := newScheduleLease(dir, "second", time.Minute)
+
+	// The first schedule's actions outlast the lease, and another replica takes over meanwhile
+	aws.onStop = func() {
+		leader, _, err := other.acquire(time.Now())
+		require.NoError(t, err)
+		require.True(t, leader)
+	}
+
+	tick := time.Now().Add(-2 * time.Minute)
+	for id, instanceID := range map[string]string{"a": "i-1", "b": "i-2"} {
+		service.store.schedules[id] = &models.Schedule{
+			ID:            id,
+			Name:          id,
+			TimeZone:      "UTC",
+			Enabled:       true,
+			Rules:         []models.ScheduleRule{{Cron: "* * * * *", Action: models.ScheduleActionStop}},
+			Targets:       []models.ScheduleTarget{{Provider: models.ScheduleProviderAWS, AccountID: "111111111111", InstanceID: instanceID}},
+			LastEvaluated: tick.Add(-time.Minute),
+		}
+	}
+
+	service.ProcessSchedules(tick)
+
+	assert.Equal(t, []string{"i-1"}, aws.stopped, "schedules after the lease was lost are left to the new leader")
+	assert.False(t, service.GetSchedulerStatus().IsLeader)
+	assert.Equal(t, "second", service.GetSchedulerStatus().Leader)
+}
@@ -0,0 +1,411 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+)
+
+// ============================================================================
+// PRINCIPAL SEARCH
+// ============================================================================
+
+const searchIndexCacheKey = "search-index"
+
+// SearchService searches IAM users and roles, SSO users and groups and Azure service principals
+type SearchService struct {
+	awsService   AWSServiceInterface
+	ssoService   SSOServiceInterface   // nil when Identity Center is not configured
+	azureService AzureServiceInterface // nil when Azure AD is not configured
+	cache        *Cache
+	cacheTTL     time.Duration
+	buildMu      sync.Mutex // Serializes index rebuilds
+}
+
+// searchIndex is a snapshot of all searchable principals
+type searchIndex struct {
+	entries []searchEntry
+	builtAt time.Time
+	errors  map[string]string
+}
+
+// searchEntry is one principal with the lowercased values it can be found by
+type searchEntry struct {
+	result models.SearchResult
+	fields []searchField
+}
+
+type searchField struct {
+	name  string
+	value string
+}
+
+// NewSearchService creates a search service. The SSO and Azure services are optional.
+func NewSearchService(awsService AWSServiceInterface, ssoService SSOServiceInterface, azureService AzureServiceInterface) *SearchService {
+	return &SearchService{
+		awsService:   awsService,
+		ssoService:   ssoService,
+		azureService: azureService,
+		cache:        NewCache(),
+		cacheTTL:     5 * time.Minute,
+	}
+}
+
+// Search finds principals whose name, ARN, email, app ID or tags match the query.
+// Types restricts results to the given principal types; limit <= 0 returns all matches.
+func (s *SearchService) Search(query string, types []string, limit int, refresh bool) (*models.SearchResponse, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, fmt.Errorf("invalid search query: q is required")
+	}
+
+	typeFilter := make(map[string]bool)
+	for _, t := range types {
+		switch t {
+		case models.SearchTypeIAMUser, models.SearchTypeIAMRole, models.SearchTypeSSOUser,
+			models.SearchTypeSSOGroup, models.SearchTypeAzureServicePrincipal:
+			typeFilter[t] = true
+		default:
+			return nil, fmt.Errorf("invalid search type %q", t)
+		}
+	}
+
+	index := s.getIndex(refresh)
+	results := searchEntries(index.entries, query, typeFilter)
+
+	response := &models.SearchResponse{
+		Query:        query,
+		Total:        len(results),
+		Results:      results,
+		IndexedAt:    index.builtAt,
+		SourceErrors: index.errors,
+	}
+	if limit > 0 && len(response.Results) > limit {
+		response.Results = response.Results[:limit]
+	}
+	return response, nil
+}
+
+// InvalidateSearchIndex forces the next search to rebuild the index
+func (s *SearchService) InvalidateSearchIndex() {
+	s.cache.Delete(searchIndexCacheKey)
+}
+
+// getIndex returns the cached index, rebuilding it when it has expired or a refresh is requested
+func (s *SearchService) getIndex(refresh bool) *searchIndex {
+	if !refresh {
+		if cached, found := s.cache.Get(searchIndexCacheKey); found {
+			if index, ok := cached.(*searchIndex); ok {
+				return index
+			}
+		}
+	}
+
+	s.buildMu.Lock()
+	defer s.buildMu.Unlock()
+
+	// Another request may have rebuilt the index while we waited
+	if !refresh {
+		if cached, found := s.cache.Get(searchIndexCacheKey); found {
+			if index, ok := cached.(*searchIndex); ok {
+				return index
+			}
+		}
+	}
+
+	index := s.buildIndex()
+	s.cache.Set(searchIndexCacheKey, index, s.cacheTTL)
+	return index
+}
+
+// buildIndex collects principals from every configured source. The source services serve
+// their own caches, so a rebuild only calls the cloud APIs for data that has expired there.
+func (s *SearchService) buildIndex() *searchIndex {
+	index := &searchIndex{builtAt: time.Now(), errors: make(map[string]string)}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	collect := func(source string, fetch func() ([]searchEntry, error)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entries, err := fetch()
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				fmt.Printf("[WARNING] Search index: failed to load %s: %v\n", source, err)
+				index.errors[source] = err.Error()
+				return
+			}
+			index.entries = append(index.entries, entries...)
+		}()
+	}
+
+	collect(models.SearchTypeIAMUser, func() ([]searchEntry, error) {
+		users, err := s.awsService.ListAllUsers()
+		if err != nil {
+			return nil, err
+		}
+		return indexIAMUsers(users), nil
+	})
+	collect(models.SearchTypeIAMRole, func() ([]searchEntry, error) {
+		roles, err := s.awsService.ListAllRoles()
+		if err != nil {
+			return nil, err
+		}
+		return indexIAMRoles(roles), nil
+	})
+	if s.ssoService != nil {
+		collect(models.SearchTypeSSOUser, func() ([]searchEntry, error) {
+			users, err := s.ssoService.ListSSOUsers()
+			if err != nil {
+				return nil, err
+			}
+			return indexSSOUsers(users), nil
+		})
+		collect(models.SearchTypeSSOGroup, func() ([]searchEntry, error) {
+			groups, err := s.ssoService.ListSSOGroups()
+			if err != nil {
+				return nil, err
+			}
+			return indexSSOGroups(groups), nil
+		})
+	}
+	if s.azureService != nil {
+		collect(models.SearchTypeAzureServicePrincipal, func() ([]searchEntry, error) {
+			apps, err := s.azureService.ListEnterpriseApplications(context.Background())
+			if err != nil {
+				return nil, err
+			}
+			return indexAzureServicePrincipals(apps), nil
+		})
+	}
+
+	wg.Wait()
+
+	if len(index.errors) == 0 {
+		index.errors = nil
+	}
+	fmt.Printf("[INFO] Search index built with %d principals\n", len(index.entries))
+	return index
+}
+
+// newSearchEntry builds an entry, skipping empty field values
+func newSearchEntry(result models.SearchResult, fields ...searchField) searchEntry {
+	entry := searchEntry{result: result}
+	for _, field := range fields {
+		if field.value != "" {
+			field.value = strings.ToLower(field.value)
+			entry.fields = append(entry.fields, field)
+		}
+	}
+	for _, tag := range result.Tags {
+		entry.fields = append(entry.fields, searchField{name: "tag", value: strings.ToLower(tag)})
+	}
+	return entry
+}
+
+// searchTags formats tags as key=value for matching
+func searchTags(tags []models.Tag) []string {
+	var formatted []string
+	for _, tag := range tags {
+		formatted = append(formatted, tag.Key+"="+tag.Value)
+	}
+	return formatted
+}
+
+func indexIAMUsers(users []models.UserWithAccount) []searchEntry {
+	var entries []searchEntry
+	for _, user := range users {
+		entries = append(entries, newSearchEntry(models.SearchResult{
+			Type:        models.SearchTypeIAMUser,
+			ID:          user.UserID,
+			Name:        user.Username,
+			Arn:         user.Arn,
+			AccountID:   user.AccountID,
+			AccountName: user.AccountName,
+			Tags:        searchTags(user.Tags),
+		},
+			searchField{"name", user.Username},
+			searchField{"arn", user.Arn},
+			searchField{"id", user.UserID},
+		))
+	}
+	return entries
+}
+
+func indexIAMRoles(roles []models.RoleWithAccount) []searchEntry {
+	var entries []searchEntry
+	for _, role := range roles {
+		entries = append(entries, newSearchEntry(models.SearchResult{
+			Type:        models.SearchTypeIAMRole,
+			ID:          role.RoleID,
+			Name:        role.RoleName,
+			Arn:         role.Arn,
+			AccountID:   role.AccountID,
+			AccountName: role.AccountName,
+			Tags:        searchTags(role.Tags),
+		},
+			searchField{"name", role.RoleName},
+			searchField{"arn", role.Arn},
+			searchField{"id", role.RoleID},
+		))
+	}
+	return entries
+}
+
+func indexSSOUsers(users []models.SSOUser) []searchEntry {
+	var entries []searchEntry
+	for _, user := range users {
+		fields := []searchField{
+			{"name", user.UserName},
+			{"display_name", user.DisplayName},
+			{"id", user.UserID},
+		}
+		for _, email := range user.Emails {
+			fields = append(fields, searchField{"email", email})
+		}
+		entries = append(entries, newSearchEntry(models.SearchResult{
+			Type:        models.SearchTypeSSOUser,
+			ID:          user.UserID,
+			Name:        user.UserName,
+			DisplayName: user.DisplayName,
+			Emails:      user.Emails,
+		}, fields...))
+	}
+	return entries
+}
+
+func indexSSOGroups(groups []models.SSOGroup) []searchEntry {
+	var entries []searchEntry
+	for _, group := range groups {
+		entries = append(entries, newSearchEntry(models.SearchResult{
+			Type: models.SearchTypeSSOGroup,
+			ID:   group.GroupID,
+			Name: group.DisplayName,
+		},
+			searchField{"name", group.DisplayName},
+			searchField{"id", group.GroupID},
+		))
+	}
+	return entries
+}
+
+func indexAzureServicePrincipals(apps []models.AzureEnterpriseApplication) []searchEntry {
+	var entries []searchEntry
+	for _, app := range apps {
+		entries = append(entries, newSearchEntry(models.SearchResult{
+			Type:  models.SearchTypeAzureServicePrincipal,
+			ID:    app.ID,
+			Name:  app.DisplayName,
+			AppID: app.AppID,
+			Tags:  app.Tags,
+		},
+			searchField{"name", app.DisplayName},
+			searchField{"app_id", app.AppID},
+			searchField{"id", app.ID},
+		))
+	}
+	return entries
+}
+
+// searchEntries returns the matching entries ordered by score, then type and name
+func searchEntries(entries []searchEntry, query string, typeFilter map[string]bool) []models.SearchResult {
+	query = strings.ToLower(query)
+
+	results := []models.SearchResult{}
+	for _, entry := range entries {
+		if len(typeFilter) > 0 && !typeFilter[entry.result.Type] {
+			continue
+		}
+
+		score := 0
+		var matched []string
+		for _, field := range entry.fields {
+			fieldScore := matchScore(field.value, query)
+			if fieldScore == 0 {
+				continue
+			}
+			// Prefer principals whose name matches over those matching only by ARN, email or tag
+			if field.name == "name" || field.name == "display_name" {
+				fieldScore++
+			}
+			if fieldScore > score {
+				score = fieldScore
+			}
+			if !containsString(matched, field.name) {
+				matched = append(matched, field.name)
+			}
+		}
+		if score == 0 {
+			continue
+		}
+
+		result := entry.result
+		result.MatchedFields = matched
+		result.Score = score
+		results = append(results, result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Type != results[j].Type {
+			return results[i].Type < results[j].Type
+		}
+		if results[i].Name != results[j].Name {
+			return results[i].Name < results[j].Name
+		}
+		return results[i].AccountID < results[j].AccountID
+	})
+	return results
+}
+
+// matchScore rates how well a lowercased value matches a lowercased query:
+// exact 40, prefix 30, prefix of a word inside the value (e.g. the name part of an ARN) 20,
+// any other substring 10, no match 0
+func matchScore(value, query string) int {
+	switch {
+	case value == query:
+		return 40
+	case strings.HasPrefix(value, query):
+		return 30
+	}
+
+	idx := strings.Index(value, query)
+	if idx == -1 {
+		return 0
+	}
+	for ; idx != -1; idx = nextIndex(value, query, idx) {
+		if r := rune(value[idx-1]); !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return 20
+		}
+	}
+	return 10
+}
+
+// nextIndex finds the next occurrence of query in value after position idx
+func nextIndex(value, query string, idx int) int {
+	next := strings.Index(value[idx+1:], query)
+	if next == -1 {
+		return -1
+	}
+	return idx + 1 + next
+}
+
+// containsString reports whether a slice contains a value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
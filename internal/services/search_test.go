@@ -0,0 +1,72 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testSearchEntries() []searchEntry {
+	var entries []searchEntry
+	entries = append(entries, indexIAMUsers([]models.UserWithAccount{
+		{User: models.User{Username: "alice", UserID: "AIDA1", Arn: "arn:aws:iam::111111111111:user/alice"}, AccountID: "111111111111"},
+		{User: models.User{Username: "malice-bot", UserID: "AIDA2", Arn: "arn:aws:iam::111111111111:user/malice-bot",
+			Tags: []models.Tag{{Key: "team", Value: "payments"}}}, AccountID: "111111111111"},
+	})...)
+	entries = append(entries, indexIAMRoles([]models.RoleWithAccount{
+		{IAMRole: models.IAMRole{RoleName: "deploy", RoleID: "AROA1", Arn: "arn:aws:iam::222222222222:role/deploy", AccountID: "222222222222",
+			Tags: []models.Tag{{Key: "owner", Value: "alice"}}}},
+	})...)
+	entries = append(entries, indexSSOUsers([]models.SSOUser{
+		{UserID: "sso-1", UserName: "alice.smith", DisplayName: "Alice Smith", Emails: []string{"alice@example.com"}},
+	})...)
+	entries = append(entries, indexAzureServicePrincipals([]models.AzureEnterpriseApplication{
+		{ID: "sp-1", AppID: "0f1e2d3c-aaaa", DisplayName: "Payroll"},
+	})...)
+	return entries
+}
+
+func TestSearchEntriesRanking(t *testing.T) {
+	results := searchEntries(testSearchEntries(), "Alice", nil)
+	require.Len(t, results, 4)
+
+	// Exact name match first, then the SSO user whose name starts with the query
+	assert.Equal(t, "alice", results[0].Name)
+	assert.Equal(t, models.SearchTypeIAMUser, results[0].Type)
+	assert.Equal(t, "alice.smith", results[1].Name)
+	assert.Contains(t, results[1].MatchedFields, "email")
+
+	// A role found through its owner tag ranks above a substring match inside another name
+	assert.Equal(t, "deploy", results[2].Name)
+	assert.Equal(t, []string{"tag"}, results[2].MatchedFields)
+	assert.Equal(t, "malice-bot", results[3].Name)
+}
+
+func TestSearchEntriesMatchUserTags(t *testing.T) {
+	results := searchEntries(testSearchEntries(), "payments", nil)
+	require.Len(t, results, 1)
+	assert.Equal(t, "malice-bot", results[0].Name)
+	assert.Equal(t, []string{"tag"}, results[0].MatchedFields)
+}
+
+func TestSearchEntriesTypeFilterAndAppID(t *testing.T) {
+	results := searchEntries(testSearchEntries(), "alice", map[string]bool{models.SearchTypeSSOUser: true})
+	require.Len(t, results, 1)
+	assert.Equal(t, models.SearchTypeSSOUser, results[0].Type)
+
+	results = searchEntries(testSearchEntries(), "0f1e2d3c", nil)
+	require.Len(t, results, 1)
+	assert.Equal(t, "Payroll", results[0].Name)
+	assert.Equal(t, []string{"app_id"}, results[0].MatchedFields)
+}
+
+func TestMatchScore(t *testing.T) {
+	assert.Equal(t, 40, matchScore("alice", "alice"))
+	assert.Equal(t, 30, matchScore("alice-admin", "alice"))
+	assert.Equal(t, 20, matchScore("arn:aws:iam::111111111111:user/alice", "alice"))
+	assert.Equal(t, 10, matchScore("malice", "alice"))
+	assert.Equal(t, 0, matchScore("bob", "alice"))
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/config"
+	"github.com/rusik69/aws-iam-manager/internal/models"
+)
+
+// ============================================================================
+// SECRET HANDOFF
+// ============================================================================
+
+// ErrSecretNotFound is returned when a secret reference is unknown or was already redeemed
+var ErrSecretNotFound = errors.New("secret not found or already retrieved")
+
+// ErrSecretExpired is returned when a secret reference is redeemed after its TTL
+var ErrSecretExpired = errors.New("secret reference has expired")
+
+// SecretRecord is an encrypted secret waiting to be picked up.
+// The ID is a hash of the retrieval token, so the store never sees the token itself.
+type SecretRecord struct {
+	ID         string    `json:"id"`
+	Kind       string    `json:"kind"`
+	AccountID  string    `json:"account_id"`
+	Username   string    `json:"username"`
+	Nonce      []byte    `json:"nonce"`
+	Ciphertext []byte    `json:"ciphertext"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// SecretStore persists encrypted secret records until they are redeemed
+type SecretStore interface {
+	Put(record SecretRecord) error
+	// Take returns the record and removes it from the store in one step
+	Take(id string) (*SecretRecord, error)
+	// Purge removes all records that expired before the given time
+	Purge(before time.Time) error
+}
+
+// MemorySecretStore keeps secret records in process memory
+type MemorySecretStore struct {
+	mu      sync.Mutex
+	records map[string]SecretRecord
+}
+
+// NewMemorySecretStore creates an in-memory secret store
+func NewMemorySecretStore() *MemorySecretStore {
+	return &MemorySecretStore{
+		records: make(map[string]SecretRecord),
+	}
+}
+
+// Put stores a record
+func (m *MemorySecretStore) Put(record SecretRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records[record.ID] = record
+	return nil
+}
+
+// Take returns and removes a record
+func (m *MemorySecretStore) Take(id string) (*SecretRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, exists := m.records[id]
+	if !exists {
+		return nil, ErrSecretNotFound
+	}
+	delete(m.records, id)
+	return &record, nil
+}
+
+// Purge removes expired records
+func (m *MemorySecretStore) Purge(before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, record := range m.records {
+		if record.ExpiresAt.Before(before) {
+			delete(m.records, id)
+		}
+	}
+	return nil
+}
+
+// FileSecretStore keeps each secret record in its own file inside a directory.
+// It stands in for an external secret manager and survives process restarts
+// as long as the encryption key is configured.
+type FileSecretStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// NewFileSecretStore creates a file-backed secret store, creating the directory if needed
+func NewFileSecretStore(dir string) (*FileSecretStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create secret store directory: %w", err)
+	}
+	return &FileSecretStore{dir: dir}, nil
+}
+
+func (f *FileSecretStore) path(id string) string {
+	return filepath.Join(f.dir, id+".json")
+}
+
+// Put writes a record to disk
+func (f *FileSecretStore) Put(record SecretRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode secret record: %w", err)
+	}
+	if err := os.WriteFile(f.path(record.ID), data, 0o600); err != nil {
+		return fmt.Errorf("failed to write secret record: %w", err)
+	}
+	return nil
+}
+
+// Take reads a record from disk and deletes the file
+func (f *FileSecretStore) Take(id string) (*SecretRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// IDs are hex hashes; anything else cannot be a valid record
+	if _, err := hex.DecodeString(id); err != nil {
+		return nil, ErrSecretNotFound
+	}
+
+	data, err := os.ReadFile(f.path(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrSecretNotFound
+		}
+		return nil, fmt.Errorf("failed to read secret record: %w", err)
+	}
+	if err := os.Remove(f.path(id)); err != nil {
+		return nil, fmt.Errorf("failed to remove secret record: %w", err)
+	}
+
+	var record SecretRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to decode secret record: %w", err)
+	}
+	return &record, nil
+}
+
+// Purge deletes expired record files
+func (f *FileSecretStore) Purge(before time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	entries, err := os.ReadDir(f.dir)
+	if err != nil {
+		return fmt.Errorf("failed to list secret store: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(f.dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var record SecretRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			continue
+		}
+		if record.ExpiresAt.Before(before) {
+			_ = os.Remove(path)
+		}
+	}
+	return nil
+}
+
+// SecretHandoff encrypts newly created credentials and hands out one-time retrieval tokens
+type SecretHandoff struct {
+	store SecretStore
+	aead  cipher.AEAD
+	ttl   time.Duration
+}
+
+// NewSecretHandoff creates a secret handoff using the given store and 32-byte AES key
+func NewSecretHandoff(store SecretStore, key []byte, ttl time.Duration) (*SecretHandoff, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret encryption key: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize secret cipher: %w", err)
+	}
+
+	return &SecretHandoff{
+		store: store,
+		aead:  aead,
+		ttl:   ttl,
+	}, nil
+}
+
+// newSecretHandoffFromConfig builds the secret handoff described by the application config
+func newSecretHandoffFromConfig(cfg config.Config) (*SecretHandoff, error) {
+	var key []byte
+	if cfg.SecretEncryptionKey != "" {
+		decoded, err := base64.StdEncoding.DecodeString(cfg.SecretEncryptionKey)
+		if err != nil {
+			return nil, fmt.Errorf("SECRET_ENCRYPTION_KEY must be base64 encoded: %w", err)
+		}
+		key = decoded
+	} else {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate secret encryption key: %w", err)
+		}
+		if cfg.SecretStoreDir != "" {
+			fmt.Printf("[WARNING] SECRET_STORE_DIR is set without SECRET_ENCRYPTION_KEY; stored secrets will be unreadable after a restart\n")
+		}
+	}
+
+	var store SecretStore = NewMemorySecretStore()
+	if cfg.SecretStoreDir != "" {
+		fileStore, err := NewFileSecretStore(cfg.SecretStoreDir)
+		if err != nil {
+			return nil, err
+		}
+		store = fileStore
+	}
+
+	ttl := cfg.SecretHandoffTTL
+	if ttl <= 0 {
+		ttl = 15 * time.Minute
+	}
+
+	return NewSecretHandoff(store, key, ttl)
+}
+
+// secretID derives the storage ID from a retrieval token
+func secretID(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// secretAssociatedData binds the ciphertext to the record metadata
+func secretAssociatedData(record *SecretRecord) []byte {
+	return []byte(strings.Join([]string{record.ID, record.Kind, record.AccountID, record.Username}, "|"))
+}
+
+// Store encrypts the secret values and returns a reference for one-time retrieval
+func (h *SecretHandoff) Store(kind, accountID, username string, values map[string]string) (*models.SecretReference, error) {
+	// Opportunistically drop anything that was never picked up
+	if err := h.store.Purge(time.Now()); err != nil {
+		fmt.Printf("[WARNING] Failed to purge expired secrets: %v\n", err)
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate secret token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(tokenBytes)
+
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode secret: %w", err)
+	}
+
+	nonce := make([]byte, h.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	now := time.Now()
+	record := SecretRecord{
+		ID:        secretID(token),
+		Kind:      kind,
+		AccountID: accountID,
+		Username:  username,
+		Nonce:     nonce,
+		CreatedAt: now,
+		ExpiresAt: now.Add(h.ttl),
+	}
+	record.Ciphertext = h.aead.Seal(nil, nonce, plaintext, secretAssociatedData(&record))
+
+	if err := h.store.Put(record); err != nil {
+		return nil, err
+	}
+
+	return &models.SecretReference{
+		Token:     token,
+		URL:       fmt.Sprintf("/api/secrets/%s/redeem", token),
+		ExpiresAt: record.ExpiresAt,
+	}, nil
+}
+
+// Discard drops a stored secret that will never be valid, e.g. a password the IAM call did not set
+func (h *SecretHandoff) Discard(ref *models.SecretReference) {
+	if ref == nil {
+		return
+	}
+	if _, err := h.store.Take(secretID(ref.Token)); err != nil && !errors.Is(err, ErrSecretNotFound) {
+		fmt.Printf("[WARNING] Failed to discard secret: %v\n", err)
+	}
+}
+
+// Retrieve decrypts and returns the secret for a token. A token can only be redeemed once.
+func (h *SecretHandoff) Retrieve(token string) (*models.RetrievedSecret, error) {
+	if token == "" {
+		return nil, ErrSecretNotFound
+	}
+
+	record, err := h.store.Take(secretID(token))
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(record.ExpiresAt) {
+		return nil, ErrSecretExpired
+	}
+
+	plaintext, err := h.aead.Open(nil, record.Nonce, record.Ciphertext, secretAssociatedData(record))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+
+	var values map[string]string
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("failed to decode secret: %w", err)
+	}
+
+	return &models.RetrievedSecret{
+		Kind:      record.Kind,
+		AccountID: record.AccountID,
+		Username:  record.Username,
+		Values:    values,
+		CreatedAt: record.CreatedAt,
+	}, nil
+}
+
+// RetrieveSecret redeems a one-time secret reference
+func (s *AWSService) RetrieveSecret(token string) (*models.RetrievedSecret, error) {
+	return s.secrets.Retrieve(token)
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// ============================================================================
+// SECURITY GROUP REFERENCE GRAPH
+// ============================================================================
+
+// GetSecurityGroupGraph returns the groups of an account, the groups their rules reference
+// (including groups owned by other accounts over VPC peering) and the ENIs and instances they
+// are attached to. An empty region covers all regions; vpcID limits the graph to one VPC.
+func (s *AWSService) GetSecurityGroupGraph(accountID, region, vpcID string) (*models.SecurityGroupGraph, error) {
+	// Kept under the account's security group prefix so cache invalidation covers it
+	cacheKey := fmt.Sprintf("security-group:%s:graph:%s:%s", accountID, region, vpcID)
+	if cached, found := s.cache.Get(cacheKey); found {
+		if graph, ok := cached.(*models.SecurityGroupGraph); ok {
+			return graph, nil
+		}
+	}
+
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+
+	var scan *regionScan
+	regions := []string{region}
+	if region == "" {
+		if scan, err = s.startRegionScan(scannerSGGraph, accountID, sess); err != nil {
+			return nil, err
+		}
+		regions = scan.Regions()
+	}
+
+	graph := &models.SecurityGroupGraph{
+		AccountID:   accountID,
+		Region:      region,
+		VpcID:       vpcID,
+		Nodes:       []models.SecurityGroupGraphNode{},
+		Edges:       []models.SecurityGroupGraphEdge{},
+		GeneratedAt: time.Now(),
+	}
+
+	regionErrs := s.addSecurityGroupGraphRegions(graph, regions, func(regionName string) ec2iface.EC2API {
+		return ec2.New(sess.Copy(&aws.Config{Region: aws.String(regionName)}))
+	})
+
+	// A single requested region has nothing to fall back on
+	if region != "" {
+		if err := regionErrs[region]; err != nil {
+			return nil, err
+		}
+	}
+
+	for regionName, err := range regionErrs {
+		fmt.Printf("[WARNING] Failed to build security group graph in region %s for account %s: %v\n", regionName, accountID, err)
+		if graph.FailedRegions == nil {
+			graph.FailedRegions = make(map[string]string)
+		}
+		graph.FailedRegions[regionName] = err.Error()
+	}
+	if scan != nil {
+		for regionName, err := range regionErrs {
+			scan.Fail(regionName, err)
+		}
+		scan.Finish()
+	}
+
+	sortSecurityGroupGraph(graph)
+	// A partial graph is returned with its failed regions but not cached, so the next request retries them
+	if len(graph.FailedRegions) == 0 {
+		s.cache.Set(cacheKey, graph, s.cacheTTL)
+	}
+	return graph, nil
+}
+
+// addSecurityGroupGraphRegions scans the regions in parallel, adds their nodes and edges to the
+// graph and returns the error of each region that could not be scanned
+func (s *AWSService) addSecurityGroupGraphRegions(graph *models.SecurityGroupGraph, regions []string, regionClient func(region string) ec2iface.EC2API) map[string]error {
+	regionErrs := make(map[string]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, regionName := range regions {
+		wg.Add(1)
+		go func(regionName string) {
+			defer wg.Done()
+
+			inventory, err := scanSecurityGroupInventory(regionClient(regionName), "")
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				regionErrs[regionName] = err
+				return
+			}
+			nodes, edges := s.buildSecurityGroupGraph(graph.AccountID, regionName, graph.VpcID, inventory)
+			graph.Nodes = append(graph.Nodes, nodes...)
+			graph.Edges = append(graph.Edges, edges...)
+		}(regionName)
+	}
+	wg.Wait()
+	return regionErrs
+}
+
+// buildSecurityGroupGraph turns a region's inventory into nodes and edges.
+// Groups referenced by in-scope rules are included even when they are outside the VPC or account.
+func (s *AWSService) buildSecurityGroupGraph(accountID, region, vpcID string, inv *securityGroupInventory) ([]models.SecurityGroupGraphNode, []models.SecurityGroupGraphEdge) {
+	nodes := make(map[string]models.SecurityGroupGraphNode)
+	var edges []models.SecurityGroupGraphEdge
+
+	groupsByID := make(map[string]*ec2.SecurityGroup, len(inv.groups))
+	for _, sg := range inv.groups {
+		groupsByID[aws.StringValue(sg.GroupId)] = sg
+	}
+	groupNode := func(sg *ec2.SecurityGroup) models.SecurityGroupGraphNode {
+		return models.SecurityGroupGraphNode{
+			ID:        aws.StringValue(sg.GroupId),
+			Type:      models.GraphNodeSecurityGroup,
+			Label:     fmt.Sprintf("%s (%s)", aws.StringValue(sg.GroupName), aws.StringValue(sg.GroupId)),
+			AccountID: accountID,
+			Region:    region,
+			VpcID:     aws.StringValue(sg.VpcId),
+		}
+	}
+
+	inScope := make(map[string]bool)
+	for _, sg := range inv.groups {
+		if vpcID != "" && aws.StringValue(sg.VpcId) != vpcID {
+			continue
+		}
+		inScope[aws.StringValue(sg.GroupId)] = true
+		nodes[aws.StringValue(sg.GroupId)] = groupNode(sg)
+	}
+
+	// Rule references, one edge per referenced group and direction
+	for _, sg := range inv.groups {
+		groupID := aws.StringValue(sg.GroupId)
+		if !inScope[groupID] {
+			continue
+		}
+		for _, direction := range []string{models.SecurityGroupIngress, models.SecurityGroupEgress} {
+			permissions := sg.IpPermissions
+			if direction == models.SecurityGroupEgress {
+				permissions = sg.IpPermissionsEgress
+			}
+
+			edgeIndex := make(map[string]int)
+			for _, rule := range s.convertEC2RulesToModel(permissions) {
+				if rule.GroupID == "" {
+					continue
+				}
+
+				crossAccount := rule.GroupOwner != "" && rule.GroupOwner != accountID
+				if _, ok := nodes[rule.GroupID]; !ok {
+					if referenced, found := groupsByID[rule.GroupID]; found && !crossAccount {
+						nodes[rule.GroupID] = groupNode(referenced)
+					} else {
+						nodes[rule.GroupID] = models.SecurityGroupGraphNode{
+							ID:        rule.GroupID,
+							Type:      models.GraphNodeExternalSecurityGroup,
+							Label:     fmt.Sprintf("%s (%s)", rule.GroupID, rule.GroupOwner),
+							AccountID: rule.GroupOwner,
+						}
+					}
+				}
+
+				idx, ok := edgeIndex[rule.GroupID]
+				if !ok {
+					idx = len(edges)
+					edgeIndex[rule.GroupID] = idx
+					edges = append(edges, models.SecurityGroupGraphEdge{
+						From:         groupID,
+						To:           rule.GroupID,
+						Type:         models.GraphEdgeRuleReference,
+						Direction:    direction,
+						CrossAccount: crossAccount,
+					})
+				}
+				edges[idx].Rules = appendUnique(edges[idx].Rules, ruleGraphLabel(rule))
+			}
+		}
+	}
+
+	// Attachments: ENI -> group and instance -> ENI
+	instanceNames := make(map[string]string)
+	for _, instance := range inv.instances {
+		for _, tag := range instance.Tags {
+			if aws.StringValue(tag.Key) == "Name" {
+				instanceNames[aws.StringValue(instance.InstanceId)] = aws.StringValue(tag.Value)
+			}
+		}
+	}
+	for _, eni := range inv.networkInterfaces {
+		eniID := aws.StringValue(eni.NetworkInterfaceId)
+		attached := false
+		for _, group := range eni.Groups {
+			groupID := aws.StringValue(group.GroupId)
+			if !inScope[groupID] {
+				continue
+			}
+			attached = true
+			edges = append(edges, models.SecurityGroupGraphEdge{From: eniID, To: groupID, Type: models.GraphEdgeAttachment})
+		}
+		if !attached {
+			continue
+		}
+
+		owner, resource := classifyNetworkInterface(eni)
+		label := eniID
+		if owner != "" && owner != eniOwnerInstance && resource != eniID {
+			label = fmt.Sprintf("%s (%s)", resource, eniID)
+		}
+		nodes[eniID] = models.SecurityGroupGraphNode{
+			ID:        eniID,
+			Type:      models.GraphNodeNetworkInterface,
+			Label:     label,
+			AccountID: accountID,
+			Region:    region,
+			VpcID:     aws.StringValue(eni.VpcId),
+			Owner:     owner,
+		}
+
+		if eni.Attachment != nil && aws.StringValue(eni.Attachment.InstanceId) != "" {
+			instanceID := aws.StringValue(eni.Attachment.InstanceId)
+			label := instanceID
+			if name := instanceNames[instanceID]; name != "" {
+				label = fmt.Sprintf("%s (%s)", name, instanceID)
+			}
+			nodes[instanceID] = models.SecurityGroupGraphNode{
+				ID:        instanceID,
+				Type:      models.GraphNodeInstance,
+				Label:     label,
+				AccountID: accountID,
+				Region:    region,
+				VpcID:     aws.StringValue(eni.VpcId),
+			}
+			edges = append(edges, models.SecurityGroupGraphEdge{From: instanceID, To: eniID, Type: models.GraphEdgeAttachment})
+		}
+	}
+
+	nodeList := make([]models.SecurityGroupGraphNode, 0, len(nodes))
+	for _, node := range nodes {
+		nodeList = append(nodeList, node)
+	}
+	return nodeList, edges
+}
+
+// ruleGraphLabel describes a rule's protocol and ports, e.g. "tcp 443" or "all traffic"
+func ruleGraphLabel(rule models.SecurityGroupRule) string {
+	if rule.IpProtocol == "-1" {
+		return "all traffic"
+	}
+	if ports := rulePortRange(rule); ports != "" {
+		return rule.IpProtocol + " " + ports
+	}
+	return rule.IpProtocol
+}
+
+// sortSecurityGroupGraph orders nodes and edges so output is stable between requests
+func sortSecurityGroupGraph(graph *models.SecurityGroupGraph) {
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		if graph.Nodes[i].Type != graph.Nodes[j].Type {
+			return graph.Nodes[i].Type > graph.Nodes[j].Type // Security groups first
+		}
+		return graph.Nodes[i].ID < graph.Nodes[j].ID
+	})
+	sort.SliceStable(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.Type != b.Type {
+			return a.Type > b.Type // Rule references first
+		}
+		if a.From != b.From {
+			return a.From < b.From
+		}
+		if a.To != b.To {
+			return a.To < b.To
+		}
+		return a.Direction < b.Direction
+	})
+}
+
+// SecurityGroupGraphDOT renders a graph in Graphviz DOT format
+func SecurityGroupGraphDOT(graph *models.SecurityGroupGraph) string {
+	var b strings.Builder
+	b.WriteString("digraph \"security-groups\" {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [fontname=\"Helvetica\", fontsize=10];\n")
+	b.WriteString("  edge [fontname=\"Helvetica\", fontsize=9];\n")
+
+	failed := make([]string, 0, len(graph.FailedRegions))
+	for region := range graph.FailedRegions {
+		failed = append(failed, region)
+	}
+	sort.Strings(failed)
+	for _, region := range failed {
+		fmt.Fprintf(&b, "  // region %s missing: %s\n", region, strings.ReplaceAll(graph.FailedRegions[region], "\n", " "))
+	}
+
+	for _, node := range graph.Nodes {
+		attrs := "shape=box"
+		switch node.Type {
+		case models.GraphNodeExternalSecurityGroup:
+			attrs = "shape=box, style=dashed"
+		case models.GraphNodeNetworkInterface:
+			attrs = "shape=ellipse"
+		case models.GraphNodeInstance:
+			attrs = "shape=component"
+		}
+		fmt.Fprintf(&b, "  %s [label=%s, %s];\n", dotQuote(node.ID), dotQuote(node.Label), attrs)
+	}
+
+	for _, edge := range graph.Edges {
+		attrs := "style=dotted, arrowhead=none"
+		if edge.Type == models.GraphEdgeRuleReference {
+			attrs = fmt.Sprintf("label=%s", dotQuote(edge.Direction+": "+strings.Join(edge.Rules, "\n")))
+			if edge.CrossAccount {
+				attrs += ", color=red"
+			}
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", dotQuote(edge.From), dotQuote(edge.To), attrs)
+	}
+
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// dotQuote returns a double-quoted DOT identifier
+func dotQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return `"` + value + `"`
+}
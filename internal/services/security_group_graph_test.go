@@ -0,0 +1,133 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func graphTestInventory() *securityGroupInventory {
+	return &securityGroupInventory{
+		groups: []*ec2.SecurityGroup{
+			{
+				GroupId: aws.String("sg-web"), GroupName: aws.String("web"), VpcId: aws.String("vpc-1"),
+				IpPermissions: []*ec2.IpPermission{
+					{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(443), ToPort: aws.Int64(443),
+						IpRanges: []*ec2.IpRange{{CidrIp: aws.String("0.0.0.0/0")}}},
+				},
+			},
+			{
+				GroupId: aws.String("sg-db"), GroupName: aws.String("db"), VpcId: aws.String("vpc-1"),
+				IpPermissions: []*ec2.IpPermission{
+					{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(5432), ToPort: aws.Int64(5432),
+						UserIdGroupPairs: []*ec2.UserIdGroupPair{
+							{GroupId: aws.String("sg-web"), UserId: aws.String("111111111111")},
+							{GroupId: aws.String("sg-peer"), UserId: aws.String("222222222222"), VpcPeeringConnectionId: aws.String("pcx-1")},
+						}},
+					{IpProtocol: aws.String("tcp"), FromPort: aws.Int64(6432), ToPort: aws.Int64(6432),
+						UserIdGroupPairs: []*ec2.UserIdGroupPair{{GroupId: aws.String("sg-web"), UserId: aws.String("111111111111")}}},
+				},
+			},
+			{GroupId: aws.String("sg-other"), GroupName: aws.String("other"), VpcId: aws.String("vpc-2")},
+		},
+		instances: []*ec2.Instance{
+			{InstanceId: aws.String("i-1"), Tags: []*ec2.Tag{{Key: aws.String("Name"), Value: aws.String("api")}}},
+		},
+		networkInterfaces: []*ec2.NetworkInterface{
+			{NetworkInterfaceId: aws.String("eni-1"), VpcId: aws.String("vpc-1"),
+				Attachment: &ec2.NetworkInterfaceAttachment{InstanceId: aws.String("i-1")},
+				Groups:     []*ec2.GroupIdentifier{{GroupId: aws.String("sg-web")}}},
+			{NetworkInterfaceId: aws.String("eni-2"), VpcId: aws.String("vpc-2"),
+				Groups: []*ec2.GroupIdentifier{{GroupId: aws.String("sg-other")}}},
+		},
+	}
+}
+
+func TestBuildSecurityGroupGraph(t *testing.T) {
+	nodes, edges := (&AWSService{}).buildSecurityGroupGraph("111111111111", "us-east-1", "vpc-1", graphTestInventory())
+	graph := &models.SecurityGroupGraph{Nodes: nodes, Edges: edges}
+	sortSecurityGroupGraph(graph)
+
+	var ids []string
+	for _, node := range graph.Nodes {
+		ids = append(ids, node.ID)
+	}
+	assert.Equal(t, []string{"sg-db", "sg-web", "eni-1", "i-1", "sg-peer"}, ids, "sg-other and eni-2 are in another VPC")
+
+	require.Len(t, graph.Edges, 4)
+	assert.Equal(t, models.SecurityGroupGraphEdge{
+		From: "sg-db", To: "sg-peer", Type: models.GraphEdgeRuleReference, Direction: models.SecurityGroupIngress,
+		Rules: []string{"tcp 5432"}, CrossAccount: true,
+	}, graph.Edges[0])
+	assert.Equal(t, models.SecurityGroupGraphEdge{
+		From: "sg-db", To: "sg-web", Type: models.GraphEdgeRuleReference, Direction: models.SecurityGroupIngress,
+		Rules: []string{"tcp 5432", "tcp 6432"},
+	}, graph.Edges[1])
+	assert.Equal(t, models.SecurityGroupGraphEdge{From: "eni-1", To: "sg-web", Type: models.GraphEdgeAttachment}, graph.Edges[2])
+	assert.Equal(t, models.SecurityGroupGraphEdge{From: "i-1", To: "eni-1", Type: models.GraphEdgeAttachment}, graph.Edges[3])
+
+	for _, node := range graph.Nodes {
+		switch node.ID {
+		case "sg-peer":
+			assert.Equal(t, models.GraphNodeExternalSecurityGroup, node.Type)
+			assert.Equal(t, "222222222222", node.AccountID)
+		case "i-1":
+			assert.Equal(t, "api (i-1)", node.Label)
+		}
+	}
+}
+
+// unreachableEC2 fails every security group listing, like a region the role cannot reach
+type unreachableEC2 struct {
+	ec2iface.EC2API
+}
+
+func (unreachableEC2) DescribeSecurityGroupsPages(input *ec2.DescribeSecurityGroupsInput, fn func(*ec2.DescribeSecurityGroupsOutput, bool) bool) error {
+	return errors.New("AuthFailure")
+}
+
+func TestAddSecurityGroupGraphRegionsReportsFailedRegions(t *testing.T) {
+	graph := &models.SecurityGroupGraph{AccountID: "111111111111"}
+	regionErrs := (&AWSService{}).addSecurityGroupGraphRegions(graph, []string{"us-east-1", "eu-west-1"}, func(region string) ec2iface.EC2API {
+		if region == "eu-west-1" {
+			return unreachableEC2{}
+		}
+		return newFakeRegion(2, sgScanPageSize)
+	})
+
+	require.Len(t, regionErrs, 1)
+	assert.Contains(t, regionErrs["eu-west-1"].Error(), "AuthFailure")
+	assert.Len(t, graph.Nodes, 2+2+2, "groups, ENIs and instances of the reachable region")
+}
+
+func TestSecurityGroupGraphDOT(t *testing.T) {
+	graph := &models.SecurityGroupGraph{
+		Nodes: []models.SecurityGroupGraphNode{
+			{ID: "sg-db", Type: models.GraphNodeSecurityGroup, Label: `db "primary" (sg-db)`},
+			{ID: "sg-peer", Type: models.GraphNodeExternalSecurityGroup, Label: "sg-peer (222222222222)"},
+			{ID: "eni-1", Type: models.GraphNodeNetworkInterface, Label: "eni-1"},
+		},
+		Edges: []models.SecurityGroupGraphEdge{
+			{From: "sg-db", To: "sg-peer", Type: models.GraphEdgeRuleReference, Direction: "ingress", Rules: []string{"tcp 5432", "tcp 6432"}, CrossAccount: true},
+			{From: "eni-1", To: "sg-db", Type: models.GraphEdgeAttachment},
+		},
+	}
+
+	dot := SecurityGroupGraphDOT(graph)
+	assert.Contains(t, dot, `digraph "security-groups" {`)
+	assert.Contains(t, dot, `"sg-db" [label="db \"primary\" (sg-db)", shape=box];`)
+	assert.Contains(t, dot, `"sg-peer" [label="sg-peer (222222222222)", shape=box, style=dashed];`)
+	assert.Contains(t, dot, `"sg-db" -> "sg-peer" [label="ingress: tcp 5432\ntcp 6432", color=red];`)
+	assert.Contains(t, dot, `"eni-1" -> "sg-db" [style=dotted, arrowhead=none];`)
+	assert.NotContains(t, dot, "missing")
+
+	graph.FailedRegions = map[string]string{"eu-west-1": "AuthFailure"}
+	assert.Contains(t, SecurityGroupGraphDOT(graph), "  // region eu-west-1 missing: AuthFailure\n")
+}
@@ -0,0 +1,609 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// SECURITY GROUP RULE REMEDIATION
+// ============================================================================
+//
+// Every revoke or narrow records the group's rules beforehand together with the rules
+// removed and added, so the change can be reverted later.
+
+// ErrSecurityGroupChangeNotFound is returned for unknown change IDs
+var ErrSecurityGroupChangeNotFound = errors.New("security group change not found")
+
+// securityGroupChangeTracker holds recorded rule changes and persists them to the state directory.
+// EC2 calls are made outside mu; a change being reverted is claimed in busy so concurrent
+// reverts of the same change are rejected instead of racing.
+type securityGroupChangeTracker struct {
+	mu      sync.Mutex
+	changes map[string]*models.SecurityGroupChange
+	busy    map[string]bool
+	file    *stateFile
+}
+
+func newSecurityGroupChangeTracker(stateDir string) *securityGroupChangeTracker {
+	tracker := &securityGroupChangeTracker{
+		changes: make(map[string]*models.SecurityGroupChange),
+		busy:    make(map[string]bool),
+		file:    newStateFile(stateDir, "security-group-changes.json"),
+	}
+
+	var stored []*models.SecurityGroupChange
+	if err := tracker.file.Load(&stored); err != nil {
+		fmt.Printf("[WARNING] Failed to load security group change state: %v\n", err)
+	}
+	for _, change := range stored {
+		tracker.changes[change.ID] = change
+	}
+
+	return tracker
+}
+
+// save persists all changes; callers must hold mu
+func (t *securityGroupChangeTracker) save() {
+	list := make([]*models.SecurityGroupChange, 0, len(t.changes))
+	for _, change := range t.changes {
+		list = append(list, change)
+	}
+	if err := t.file.Save(list); err != nil {
+		fmt.Printf("[WARNING] Failed to persist security group change state: %v\n", err)
+	}
+}
+
+func (t *securityGroupChangeTracker) record(change *models.SecurityGroupChange) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.changes[change.ID] = change
+	t.save()
+}
+
+// beginRevert claims a change for reverting and returns a copy to work on outside the lock
+func (t *securityGroupChangeTracker) beginRevert(changeID string) (models.SecurityGroupChange, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	change, exists := t.changes[changeID]
+	if !exists {
+		return models.SecurityGroupChange{}, ErrSecurityGroupChangeNotFound
+	}
+	if change.RevertedAt != nil {
+		return models.SecurityGroupChange{}, fmt.Errorf("security group change %s was already reverted", changeID)
+	}
+	if t.busy[changeID] {
+		return models.SecurityGroupChange{}, fmt.Errorf("security group change %s is already being reverted", changeID)
+	}
+	t.busy[changeID] = true
+	return *change, nil
+}
+
+// finishRevert stores the outcome of a revert, persists it and releases the change
+func (t *securityGroupChangeTracker) finishRevert(change models.SecurityGroupChange) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.changes[change.ID] = &change
+	delete(t.busy, change.ID)
+	t.save()
+}
+
+// release drops a revert claim without changing the change
+func (t *securityGroupChangeTracker) release(changeID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.busy, changeID)
+}
+
+// RevokeSecurityGroupRule removes a single ingress or egress rule from a security group
+func (s *AWSService) RevokeSecurityGroupRule(accountID, region, groupID string, input models.RevokeRuleInput) (*models.SecurityGroupChange, error) {
+	direction, err := normalizeRuleDirection(input.Direction)
+	if err != nil {
+		return nil, err
+	}
+	return s.changeSecurityGroupRules(accountID, region, groupID, models.SecurityGroupChangeRevoke, direction, input.Rule, nil)
+}
+
+// NarrowSecurityGroupRule replaces the CIDR of a rule with the given CIDRs in one operation.
+// The replacement rules are added before the old rule is removed so traffic from the new CIDRs is never cut off.
+func (s *AWSService) NarrowSecurityGroupRule(accountID, region, groupID string, input models.NarrowRuleInput) (*models.SecurityGroupChange, error) {
+	direction, err := normalizeRuleDirection(input.Direction)
+	if err != nil {
+		return nil, err
+	}
+	replacements, err := narrowedRules(input.Rule, input.ReplacementCidrs)
+	if err != nil {
+		return nil, err
+	}
+	return s.changeSecurityGroupRules(accountID, region, groupID, models.SecurityGroupChangeNarrow, direction, input.Rule, replacements)
+}
+
+// changeSecurityGroupRules adds rules and removes one rule, recording the group's previous rules
+func (s *AWSService) changeSecurityGroupRules(accountID, region, groupID, action, direction string, remove models.SecurityGroupRule, add []models.SecurityGroupRule) (*models.SecurityGroupChange, error) {
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+	ec2Client := ec2.New(sess.Copy(&aws.Config{Region: aws.String(region)}))
+
+	// Always work from the live rules, not a cached listing
+	sgResult, err := ec2Client.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{
+		GroupIds: []*string{aws.String(groupID)},
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), "InvalidGroup.NotFound") {
+			return nil, fmt.Errorf("security group %s not found", groupID)
+		}
+		return nil, fmt.Errorf("failed to describe security group %s: %v", groupID, err)
+	}
+	if len(sgResult.SecurityGroups) == 0 {
+		return nil, fmt.Errorf("security group %s not found", groupID)
+	}
+	sg := sgResult.SecurityGroups[0]
+
+	change := &models.SecurityGroupChange{
+		ID:                   uuid.NewString(),
+		AccountID:            accountID,
+		Region:               region,
+		GroupID:              groupID,
+		Action:               action,
+		Direction:            direction,
+		PreviousIngressRules: s.convertEC2RulesToModel(sg.IpPermissions),
+		PreviousEgressRules:  s.convertEC2RulesToModel(sg.IpPermissionsEgress),
+		CreatedAt:            time.Now(),
+	}
+
+	current := change.PreviousIngressRules
+	if direction == models.SecurityGroupEgress {
+		current = change.PreviousEgressRules
+	}
+	// Record the live rule so its description and source details are restored on revert
+	live, found := findRule(current, remove)
+	if !found {
+		return nil, fmt.Errorf("rule %s not found in %s rules of security group %s", describeRule(remove), direction, groupID)
+	}
+	remove = live
+
+	// Rules that already exist cannot be authorized again and must not be revoked on revert
+	for _, rule := range add {
+		if rule.Description == "" {
+			rule.Description = remove.Description
+		}
+		if !containsRule(current, rule) && !containsRule(change.AddedRules, rule) {
+			change.AddedRules = append(change.AddedRules, rule)
+		}
+	}
+
+	if err := authorizeRules(ec2Client, groupID, direction, change.AddedRules); err != nil {
+		return nil, err
+	}
+	if err := revokeRules(ec2Client, groupID, direction, []models.SecurityGroupRule{remove}); err != nil {
+		// Leave the group as it was rather than with both the old and new rules
+		if rollbackErr := revokeRules(ec2Client, groupID, direction, change.AddedRules); rollbackErr != nil {
+			fmt.Printf("[WARNING] Failed to roll back added rules on security group %s: %v\n", groupID, rollbackErr)
+		}
+		return nil, err
+	}
+	change.RemovedRules = []models.SecurityGroupRule{remove}
+
+	s.sgChanges.record(change)
+	s.InvalidateSecurityGroupCache(accountID, region, groupID)
+
+	fmt.Printf("[INFO] Security group %s in account %s, region %s: %s %s rule %s\n", groupID, accountID, region, action, direction, describeRule(remove))
+	return change, nil
+}
+
+// ListSecurityGroupChanges returns all recorded rule changes, newest first
+func (s *AWSService) ListSecurityGroupChanges() []models.SecurityGroupChange {
+	tracker := s.sgChanges
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	changes := make([]models.SecurityGroupChange, 0, len(tracker.changes))
+	for _, change := range tracker.changes {
+		changes = append(changes, *change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].CreatedAt.After(changes[j].CreatedAt)
+	})
+	return changes
+}
+
+// RevertSecurityGroupChange restores the rules removed by a change and removes the rules it added
+func (s *AWSService) RevertSecurityGroupChange(changeID string) (*models.SecurityGroupChange, error) {
+	change, err := s.sgChanges.beginRevert(changeID)
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.getSessionForAccount(change.AccountID)
+	if err != nil {
+		s.sgChanges.release(changeID)
+		return nil, fmt.Errorf("cannot access account %s: %w", change.AccountID, err)
+	}
+	ec2Client := ec2.New(sess.Copy(&aws.Config{Region: aws.String(change.Region)}))
+
+	err = revertSecurityGroupRules(ec2Client, &change)
+	s.sgChanges.finishRevert(change)
+	s.InvalidateSecurityGroupCache(change.AccountID, change.Region, change.GroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// revertSecurityGroupRules restores the removed rules and then revokes the added ones, recording the
+// outcome on the change. A failure after some rules were changed marks the change partially reverted;
+// reverting it again finishes the job because already restored and already revoked rules are skipped.
+func revertSecurityGroupRules(ec2Client ec2iface.EC2API, change *models.SecurityGroupChange) error {
+	applied := 0
+	fail := func(err error) error {
+		if applied > 0 {
+			change.RevertStatus = models.SecurityGroupChangePartiallyReverted
+		}
+		change.RevertError = err.Error()
+		return err
+	}
+
+	// Restore first so that narrowed CIDRs keep access until the original rule is back
+	for _, rule := range change.RemovedRules {
+		err := authorizeRules(ec2Client, change.GroupID, change.Direction, []models.SecurityGroupRule{rule})
+		if err != nil && !strings.Contains(err.Error(), "InvalidPermission.Duplicate") {
+			return fail(err)
+		}
+		applied++
+	}
+	for _, rule := range change.AddedRules {
+		err := revokeRules(ec2Client, change.GroupID, change.Direction, []models.SecurityGroupRule{rule})
+		if err != nil && !strings.Contains(err.Error(), "InvalidPermission.NotFound") {
+			return fail(err)
+		}
+		applied++
+	}
+
+	now := time.Now()
+	change.RevertedAt = &now
+	change.RevertStatus = models.SecurityGroupChangeReverted
+	change.RevertError = ""
+	return nil
+}
+
+// StartSecurityGroupRemediation revokes or narrows every open ingress rule matching the input as a background job
+func (s *AWSService) StartSecurityGroupRemediation(input models.SecurityGroupRemediationInput) (models.Job, error) {
+	if input.Source == "" {
+		input.Source = "0.0.0.0/0"
+	}
+	switch input.Action {
+	case models.SecurityGroupChangeRevoke:
+	case models.SecurityGroupChangeNarrow:
+		// Validate once up front rather than failing every item
+		if _, err := narrowedRules(models.SecurityGroupRule{CidrIPv4: input.Source}, input.ReplacementCidrs); err != nil {
+			return models.Job{}, err
+		}
+	default:
+		return models.Job{}, fmt.Errorf("invalid remediation action %q: must be revoke or narrow", input.Action)
+	}
+
+	var groups []models.SecurityGroup
+	if len(input.AccountIDs) > 0 {
+		for _, accountID := range input.AccountIDs {
+			accountGroups, err := s.ListSecurityGroupsByAccount(accountID)
+			if err != nil {
+				return models.Job{}, err
+			}
+			groups = append(groups, accountGroups...)
+		}
+	} else {
+		var err error
+		if groups, err = s.ListSecurityGroups(); err != nil {
+			return models.Job{}, err
+		}
+	}
+
+	targets := findRemediationTargets(groups, input)
+
+	description := fmt.Sprintf("%s %d rule(s) open to %s", input.Action, len(targets), input.Source)
+	if input.DryRun {
+		description = "Dry run: " + description
+	}
+
+	job := s.jobs.start("security_group_remediation", description, func(jc *jobContext) (any, error) {
+		jc.AddTotal(len(targets))
+
+		for _, target := range targets {
+			result := models.JobItemResult{
+				AccountID:  target.group.AccountID,
+				Region:     target.group.Region,
+				ResourceID: target.group.GroupID,
+				Status:     "succeeded",
+			}
+
+			if input.DryRun {
+				result.Status = "skipped"
+				result.Message = fmt.Sprintf("Would %s ingress rule %s", input.Action, describeRule(target.rule))
+				jc.AddResult(result)
+				continue
+			}
+
+			var change *models.SecurityGroupChange
+			var err error
+			if input.Action == models.SecurityGroupChangeNarrow {
+				change, err = s.NarrowSecurityGroupRule(target.group.AccountID, target.group.Region, target.group.GroupID, models.NarrowRuleInput{
+					Rule:             target.rule,
+					ReplacementCidrs: input.ReplacementCidrs,
+				})
+			} else {
+				change, err = s.RevokeSecurityGroupRule(target.group.AccountID, target.group.Region, target.group.GroupID, models.RevokeRuleInput{
+					Rule: target.rule,
+				})
+			}
+
+			switch {
+			case err != nil && strings.Contains(err.Error(), "not found"):
+				// The cached listing was stale: the rule or group is already gone
+				result.Status = "skipped"
+				result.Message = err.Error()
+			case err != nil:
+				result.Status = "failed"
+				result.Message = err.Error()
+			default:
+				result.Message = fmt.Sprintf("%s ingress rule %s (change %s)", input.Action, describeRule(target.rule), change.ID)
+			}
+			jc.AddResult(result)
+		}
+		return nil, nil
+	})
+
+	return job, nil
+}
+
+// sgRemediationTarget is an open rule selected for bulk remediation
+type sgRemediationTarget struct {
+	group models.SecurityGroup
+	rule  models.SecurityGroupRule
+}
+
+// findRemediationTargets selects the ingress rules open to the input's source CIDR
+func findRemediationTargets(groups []models.SecurityGroup, input models.SecurityGroupRemediationInput) []sgRemediationTarget {
+	var targets []sgRemediationTarget
+	for _, group := range groups {
+		if len(input.AccountIDs) > 0 && !containsString(input.AccountIDs, group.AccountID) {
+			continue
+		}
+		if len(input.GroupIDs) > 0 && !containsString(input.GroupIDs, group.GroupID) {
+			continue
+		}
+
+		for _, rule := range group.IngressRules {
+			if rule.CidrIPv4 != input.Source && rule.CidrIPv6 != input.Source {
+				continue
+			}
+			// "All traffic" rules cover every protocol and port
+			if input.Protocol != "" && rule.IpProtocol != "-1" && rule.IpProtocol != input.Protocol {
+				continue
+			}
+			if input.Port != nil && rule.IpProtocol != "-1" && (*input.Port < rule.FromPort || *input.Port > rule.ToPort) {
+				continue
+			}
+			targets = append(targets, sgRemediationTarget{group: group, rule: rule})
+		}
+	}
+	return targets
+}
+
+// narrowedRules builds the replacement rules for a CIDR rule
+func narrowedRules(rule models.SecurityGroupRule, cidrs []string) ([]models.SecurityGroupRule, error) {
+	if rule.CidrIPv4 == "" && rule.CidrIPv6 == "" {
+		return nil, fmt.Errorf("invalid rule: only CIDR rules can be narrowed")
+	}
+	if len(cidrs) == 0 {
+		return nil, fmt.Errorf("invalid input: replacement_cidrs is required")
+	}
+
+	var rules []models.SecurityGroupRule
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", cidr)
+		}
+		if ones, _ := network.Mask.Size(); ones == 0 {
+			return nil, fmt.Errorf("invalid CIDR %q: replacement must be narrower than the whole internet", cidr)
+		}
+
+		replacement := models.SecurityGroupRule{
+			IpProtocol: rule.IpProtocol,
+			FromPort:   rule.FromPort,
+			ToPort:     rule.ToPort,
+		}
+		if network.IP.To4() != nil {
+			replacement.CidrIPv4 = network.String()
+		} else {
+			replacement.CidrIPv6 = network.String()
+		}
+		rules = append(rules, replacement)
+	}
+	return rules, nil
+}
+
+// normalizeRuleDirection defaults the rule direction to ingress
+func normalizeRuleDirection(direction string) (string, error) {
+	switch direction {
+	case "", models.SecurityGroupIngress:
+		return models.SecurityGroupIngress, nil
+	case models.SecurityGroupEgress:
+		return models.SecurityGroupEgress, nil
+	}
+	return "", fmt.Errorf("invalid rule direction %q: must be ingress or egress", direction)
+}
+
+// convertEC2RulesToModel converts a list of EC2 permissions to one model rule per source
+func (s *AWSService) convertEC2RulesToModel(permissions []*ec2.IpPermission) []models.SecurityGroupRule {
+	rules := []models.SecurityGroupRule{}
+	for _, permission := range permissions {
+		rules = append(rules, s.convertEC2RuleToModel(permission)...)
+	}
+	return rules
+}
+
+// ruleHasPorts reports whether a protocol carries port (or ICMP type/code) ranges
+func ruleHasPorts(protocol string) bool {
+	switch protocol {
+	case "tcp", "udp", "icmp", "icmpv6", "6", "17", "1", "58":
+		return true
+	}
+	return false
+}
+
+// sameRule reports whether two model rules describe the same permission. Descriptions are ignored,
+// as EC2 does when matching rules to revoke.
+func sameRule(a, b models.SecurityGroupRule) bool {
+	if a.IpProtocol != b.IpProtocol || a.CidrIPv4 != b.CidrIPv4 || a.CidrIPv6 != b.CidrIPv6 || a.GroupID != b.GroupID || a.PrefixListID != b.PrefixListID {
+		return false
+	}
+	if ruleHasPorts(a.IpProtocol) {
+		return a.FromPort == b.FromPort && a.ToPort == b.ToPort
+	}
+	return true
+}
+
+func containsRule(rules []models.SecurityGroupRule, rule models.SecurityGroupRule) bool {
+	_, found := findRule(rules, rule)
+	return found
+}
+
+// findRule returns the rule in rules that matches rule, with its description
+func findRule(rules []models.SecurityGroupRule, rule models.SecurityGroupRule) (models.SecurityGroupRule, bool) {
+	for _, r := range rules {
+		if sameRule(r, rule) {
+			return r, true
+		}
+	}
+	return models.SecurityGroupRule{}, false
+}
+
+// describeRule renders a rule for logs and job messages, e.g. "tcp 22 from 0.0.0.0/0"
+func describeRule(rule models.SecurityGroupRule) string {
+	protocol := rule.IpProtocol
+	ports := ""
+	switch {
+	case protocol == "-1":
+		protocol = "all traffic"
+	case !ruleHasPorts(protocol):
+	case rule.FromPort == rule.ToPort:
+		ports = fmt.Sprintf(" %d", rule.FromPort)
+	default:
+		ports = fmt.Sprintf(" %d-%d", rule.FromPort, rule.ToPort)
+	}
+
+	source := rule.CidrIPv4
+	if rule.CidrIPv6 != "" {
+		source = rule.CidrIPv6
+	} else if rule.GroupID != "" {
+		source = rule.GroupID
+	} else if rule.PrefixListID != "" {
+		source = rule.PrefixListID
+	}
+	return fmt.Sprintf("%s%s from %s", protocol, ports, source)
+}
+
+// ruleToIPPermission converts a model rule back to an EC2 permission
+func ruleToIPPermission(rule models.SecurityGroupRule) *ec2.IpPermission {
+	permission := &ec2.IpPermission{IpProtocol: aws.String(rule.IpProtocol)}
+	if ruleHasPorts(rule.IpProtocol) {
+		permission.FromPort = aws.Int64(rule.FromPort)
+		permission.ToPort = aws.Int64(rule.ToPort)
+	}
+
+	var description *string
+	if rule.Description != "" {
+		description = aws.String(rule.Description)
+	}
+
+	switch {
+	case rule.CidrIPv4 != "":
+		permission.IpRanges = []*ec2.IpRange{{CidrIp: aws.String(rule.CidrIPv4), Description: description}}
+	case rule.CidrIPv6 != "":
+		permission.Ipv6Ranges = []*ec2.Ipv6Range{{CidrIpv6: aws.String(rule.CidrIPv6), Description: description}}
+	case rule.GroupID != "":
+		pair := &ec2.UserIdGroupPair{GroupId: aws.String(rule.GroupID), Description: description}
+		if rule.GroupOwner != "" {
+			pair.UserId = aws.String(rule.GroupOwner)
+		}
+		permission.UserIdGroupPairs = []*ec2.UserIdGroupPair{pair}
+	case rule.PrefixListID != "":
+		permission.PrefixListIds = []*ec2.PrefixListId{{PrefixListId: aws.String(rule.PrefixListID), Description: description}}
+	}
+	return permission
+}
+
+func rulesToIPPermissions(rules []models.SecurityGroupRule) []*ec2.IpPermission {
+	var permissions []*ec2.IpPermission
+	for _, rule := range rules {
+		permissions = append(permissions, ruleToIPPermission(rule))
+	}
+	return permissions
+}
+
+// authorizeRules adds rules to a security group
+func authorizeRules(ec2Client ec2iface.EC2API, groupID, direction string, rules []models.SecurityGroupRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var err error
+	if direction == models.SecurityGroupEgress {
+		_, err = ec2Client.AuthorizeSecurityGroupEgress(&ec2.AuthorizeSecurityGroupEgressInput{
+			GroupId:       aws.String(groupID),
+			IpPermissions: rulesToIPPermissions(rules),
+		})
+	} else {
+		_, err = ec2Client.AuthorizeSecurityGroupIngress(&ec2.AuthorizeSecurityGroupIngressInput{
+			GroupId:       aws.String(groupID),
+			IpPermissions: rulesToIPPermissions(rules),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to authorize %s rules on security group %s: %v", direction, groupID, err)
+	}
+	return nil
+}
+
+// revokeRules removes rules from a security group
+func revokeRules(ec2Client ec2iface.EC2API, groupID, direction string, rules []models.SecurityGroupRule) error {
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var err error
+	if direction == models.SecurityGroupEgress {
+		_, err = ec2Client.RevokeSecurityGroupEgress(&ec2.RevokeSecurityGroupEgressInput{
+			GroupId:       aws.String(groupID),
+			IpPermissions: rulesToIPPermissions(rules),
+		})
+	} else {
+		_, err = ec2Client.RevokeSecurityGroupIngress(&ec2.RevokeSecurityGroupIngressInput{
+			GroupId:       aws.String(groupID),
+			IpPermissions: rulesToIPPermissions(rules),
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke %s rules on security group %s: %v", direction, groupID, err)
+	}
+	return nil
+}
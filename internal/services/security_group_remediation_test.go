@@ -0,0 +1,183 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNarrowedRules(t *testing.T) {
+	open := models.SecurityGroupRule{IpProtocol: "tcp", FromPort: 22, ToPort: 22, CidrIPv4: "0.0.0.0/0"}
+
+	rules, err := narrowedRules(open, []string{"10.1.2.3/16", "2001:db8::/32"})
+	require.NoError(t, err)
+	assert.Equal(t, []models.SecurityGroupRule{
+		{IpProtocol: "tcp", FromPort: 22, ToPort: 22, CidrIPv4: "10.1.0.0/16"},
+		{IpProtocol: "tcp", FromPort: 22, ToPort: 22, CidrIPv6: "2001:db8::/32"},
+	}, rules)
+
+	_, err = narrowedRules(open, []string{"0.0.0.0/0"})
+	assert.ErrorContains(t, err, "invalid")
+	_, err = narrowedRules(open, []string{"not-a-cidr"})
+	assert.ErrorContains(t, err, "invalid")
+	_, err = narrowedRules(models.SecurityGroupRule{IpProtocol: "tcp", GroupID: "sg-1"}, []string{"10.0.0.0/8"})
+	assert.ErrorContains(t, err, "invalid")
+}
+
+func TestFindRemediationTargets(t *testing.T) {
+	groups := []models.SecurityGroup{
+		{GroupID: "sg-ssh", AccountID: "111111111111", IngressRules: []models.SecurityGroupRule{
+			{IpProtocol: "tcp", FromPort: 22, ToPort: 22, CidrIPv4: "0.0.0.0/0"},
+			{IpProtocol: "tcp", FromPort: 443, ToPort: 443, CidrIPv4: "0.0.0.0/0"},
+			{IpProtocol: "tcp", FromPort: 22, ToPort: 22, CidrIPv4: "10.0.0.0/8"},
+		}},
+		{GroupID: "sg-all", AccountID: "222222222222", IngressRules: []models.SecurityGroupRule{
+			{IpProtocol: "-1", CidrIPv4: "0.0.0.0/0"},
+			{IpProtocol: "tcp", FromPort: 0, ToPort: 65535, CidrIPv6: "::/0"},
+		}},
+	}
+
+	targets := findRemediationTargets(groups, models.SecurityGroupRemediationInput{
+		Source:   "0.0.0.0/0",
+		Protocol: "tcp",
+		Port:     aws.Int64(22),
+	})
+	require.Len(t, targets, 2)
+	assert.Equal(t, "sg-ssh", targets[0].group.GroupID)
+	assert.Equal(t, int64(22), targets[0].rule.FromPort)
+	assert.Equal(t, "sg-all", targets[1].group.GroupID)
+	assert.Equal(t, "-1", targets[1].rule.IpProtocol)
+
+	targets = findRemediationTargets(groups, models.SecurityGroupRemediationInput{
+		Source:     "::/0",
+		AccountIDs: []string{"222222222222"},
+	})
+	require.Len(t, targets, 1)
+	assert.Equal(t, "::/0", targets[0].rule.CidrIPv6)
+}
+
+func TestRuleToIPPermissionRoundTrip(t *testing.T) {
+	s := &AWSService{}
+	rules := []models.SecurityGroupRule{
+		{IpProtocol: "tcp", FromPort: 3306, ToPort: 3306, GroupID: "sg-1", GroupOwner: "111111111111"},
+		{IpProtocol: "-1", CidrIPv6: "::/0"},
+	}
+	for _, rule := range rules {
+		converted := s.convertEC2RuleToModel(ruleToIPPermission(rule))
+		require.Len(t, converted, 1)
+		assert.True(t, sameRule(rule, converted[0]), describeRule(rule))
+	}
+	assert.Equal(t, "tcp 3306 from sg-1", describeRule(rules[0]))
+	assert.Equal(t, "all traffic from ::/0", describeRule(rules[1]))
+}
+
+func TestConvertEC2RuleKeepsDescriptionsAndPrefixLists(t *testing.T) {
+	s := &AWSService{}
+	permission := &ec2.IpPermission{
+		IpProtocol:    aws.String("tcp"),
+		FromPort:      aws.Int64(443),
+		ToPort:        aws.Int64(443),
+		Ipv6Ranges:    []*ec2.Ipv6Range{{CidrIpv6: aws.String("2001:db8::/32"), Description: aws.String("office v6")}},
+		PrefixListIds: []*ec2.PrefixListId{{PrefixListId: aws.String("pl-123"), Description: aws.String("cloudfront")}},
+	}
+
+	rules := s.convertEC2RuleToModel(permission)
+	require.Len(t, rules, 2)
+	assert.Equal(t, "office v6", rules[0].Description)
+	assert.Equal(t, "pl-123", rules[1].PrefixListID)
+	assert.Equal(t, "tcp 443 from pl-123", describeRule(rules[1]))
+
+	for _, rule := range rules {
+		assert.Equal(t, []models.SecurityGroupRule{rule}, s.convertEC2RuleToModel(ruleToIPPermission(rule)))
+	}
+}
+
+// fakeRevertEC2 records authorized and revoked ingress permissions; failRevoke makes revokes fail
+type fakeRevertEC2 struct {
+	ec2iface.EC2API
+	authorized []*ec2.IpPermission
+	revoked    []*ec2.IpPermission
+	failRevoke bool
+}
+
+func (f *fakeRevertEC2) AuthorizeSecurityGroupIngress(input *ec2.AuthorizeSecurityGroupIngressInput) (*ec2.AuthorizeSecurityGroupIngressOutput, error) {
+	f.authorized = append(f.authorized, input.IpPermissions...)
+	return &ec2.AuthorizeSecurityGroupIngressOutput{}, nil
+}
+
+func (f *fakeRevertEC2) RevokeSecurityGroupIngress(input *ec2.RevokeSecurityGroupIngressInput) (*ec2.RevokeSecurityGroupIngressOutput, error) {
+	if f.failRevoke {
+		return nil, errors.New("UnauthorizedOperation")
+	}
+	f.revoked = append(f.revoked, input.IpPermissions...)
+	return &ec2.RevokeSecurityGroupIngressOutput{}, nil
+}
+
+func TestRevertSecurityGroupRulesRecordsPartialFailure(t *testing.T) {
+	change := &models.SecurityGroupChange{
+		GroupID:      "sg-1",
+		Direction:    models.SecurityGroupIngress,
+		RemovedRules: []models.SecurityGroupRule{{IpProtocol: "tcp", FromPort: 22, ToPort: 22, CidrIPv4: "0.0.0.0/0", Description: "ssh"}},
+		AddedRules:   []models.SecurityGroupRule{{IpProtocol: "tcp", FromPort: 22, ToPort: 22, CidrIPv4: "10.0.0.0/8", Description: "ssh"}},
+	}
+	client := &fakeRevertEC2{failRevoke: true}
+
+	err := revertSecurityGroupRules(client, change)
+	require.Error(t, err)
+	assert.Equal(t, models.SecurityGroupChangePartiallyReverted, change.RevertStatus)
+	assert.Contains(t, change.RevertError, "UnauthorizedOperation")
+	assert.Nil(t, change.RevertedAt)
+
+	// The original rule is restored with its description
+	require.Len(t, client.authorized, 1)
+	assert.Equal(t, "ssh", aws.StringValue(client.authorized[0].IpRanges[0].Description))
+
+	client.failRevoke = false
+	require.NoError(t, revertSecurityGroupRules(client, change))
+	assert.Equal(t, models.SecurityGroupChangeReverted, change.RevertStatus)
+	assert.Empty(t, change.RevertError)
+	assert.NotNil(t, change.RevertedAt)
+	require.Len(t, client.revoked, 1)
+	assert.Equal(t, "10.0.0.0/8", aws.StringValue(client.revoked[0].IpRanges[0].CidrIp))
+}
+
+func TestSecurityGroupChangeTrackerClaimsRevert(t *testing.T) {
+	tracker := newSecurityGroupChangeTracker(t.TempDir())
+	tracker.record(&models.SecurityGroupChange{ID: "change-1", GroupID: "sg-1"})
+
+	change, err := tracker.beginRevert("change-1")
+	require.NoError(t, err)
+
+	// A second revert is rejected while the first is in flight
+	_, err = tracker.beginRevert("change-1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already being reverted")
+
+	now := time.Now()
+	change.RevertedAt = &now
+	change.RevertStatus = models.SecurityGroupChangeReverted
+	tracker.finishRevert(change)
+
+	_, err = tracker.beginRevert("change-1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "already reverted")
+
+	_, err = tracker.beginRevert("missing")
+	assert.ErrorIs(t, err, ErrSecurityGroupChangeNotFound)
+
+	// Releasing a claim makes the change revertible again
+	tracker.record(&models.SecurityGroupChange{ID: "change-2", GroupID: "sg-2"})
+	_, err = tracker.beginRevert("change-2")
+	require.NoError(t, err)
+	tracker.release("change-2")
+	_, err = tracker.beginRevert("change-2")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,393 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+)
+
+// ============================================================================
+// SECURITY GROUP RISK SCORING
+// ============================================================================
+//
+// Each ingress rule open to a wide CIDR is scored 0-100 as
+//   port severity weight x CIDR breadth x exposure
+// where exposure reflects whether the group is attached to a public-facing interface.
+
+// defaultPortRisks is the built-in sensitive port catalogue; SG_PORT_RISKS adds to or overrides it
+var defaultPortRisks = []models.PortRisk{
+	{FromPort: 22, ToPort: 22, Service: "SSH", Severity: "critical"},
+	{FromPort: 23, ToPort: 23, Service: "Telnet", Severity: "critical"},
+	{FromPort: 3389, ToPort: 3389, Service: "RDP", Severity: "critical"},
+	{FromPort: 5900, ToPort: 5900, Service: "VNC", Severity: "critical"},
+	{FromPort: 445, ToPort: 445, Service: "SMB", Severity: "critical"},
+	{FromPort: 135, ToPort: 135, Service: "MS RPC", Severity: "critical"},
+	{FromPort: 139, ToPort: 139, Service: "NetBIOS", Severity: "critical"},
+	{FromPort: 1433, ToPort: 1433, Service: "SQL Server", Severity: "critical"},
+	{FromPort: 1521, ToPort: 1521, Service: "Oracle", Severity: "critical"},
+	{FromPort: 3306, ToPort: 3306, Service: "MySQL", Severity: "critical"},
+	{FromPort: 5432, ToPort: 5432, Service: "PostgreSQL", Severity: "critical"},
+	{FromPort: 6379, ToPort: 6379, Service: "Redis", Severity: "critical"},
+	{FromPort: 9200, ToPort: 9300, Service: "Elasticsearch", Severity: "critical"},
+	{FromPort: 27017, ToPort: 27019, Service: "MongoDB", Severity: "critical"},
+	{FromPort: 11211, ToPort: 11211, Service: "Memcached", Severity: "critical"},
+	{FromPort: 9042, ToPort: 9042, Service: "Cassandra", Severity: "critical"},
+	{FromPort: 5984, ToPort: 5984, Service: "CouchDB", Severity: "critical"},
+	{FromPort: 2375, ToPort: 2376, Service: "Docker API", Severity: "critical"},
+	{FromPort: 2379, ToPort: 2380, Service: "etcd", Severity: "critical"},
+	{FromPort: 10250, ToPort: 10250, Service: "Kubelet", Severity: "critical"},
+	{FromPort: 21, ToPort: 21, Service: "FTP", Severity: "high"},
+	{FromPort: 161, ToPort: 162, Service: "SNMP", Severity: "high"},
+	{FromPort: 5601, ToPort: 5601, Service: "Kibana", Severity: "high"},
+	{FromPort: 9092, ToPort: 9092, Service: "Kafka", Severity: "high"},
+	{FromPort: 25, ToPort: 25, Service: "SMTP", Severity: "medium"},
+	{FromPort: 53, ToPort: 53, Service: "DNS", Severity: "medium"},
+	{FromPort: 80, ToPort: 80, Service: "HTTP", Severity: "low"},
+	{FromPort: 443, ToPort: 443, Service: "HTTPS", Severity: "low"},
+}
+
+// unlistedPortSeverity applies to exposed ports that are not in the catalogue
+const unlistedPortSeverity = "medium"
+
+var severityWeights = map[string]float64{
+	"critical": 1.0,
+	"high":     0.7,
+	"medium":   0.4,
+	"low":      0.15,
+}
+
+var severityRanks = map[string]int{
+	"critical": 4,
+	"high":     3,
+	"medium":   2,
+	"low":      1,
+}
+
+// buildPortRiskCatalogue applies "port=severity[:service]" or "from-to=severity[:service]" overrides to the defaults.
+// An override for the same range as a default entry replaces it; invalid entries are logged and ignored.
+func buildPortRiskCatalogue(overrides []string) []models.PortRisk {
+	catalogue := append([]models.PortRisk{}, defaultPortRisks...)
+
+	for _, entry := range overrides {
+		risk, err := parsePortRisk(entry)
+		if err != nil {
+			fmt.Printf("[WARNING] Ignoring SG_PORT_RISKS entry %q: %v\n", entry, err)
+			continue
+		}
+
+		replaced := false
+		for i, existing := range catalogue {
+			if existing.FromPort == risk.FromPort && existing.ToPort == risk.ToPort {
+				if risk.Service == "" {
+					risk.Service = existing.Service
+				}
+				catalogue[i] = risk
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			catalogue = append(catalogue, risk)
+		}
+	}
+
+	return catalogue
+}
+
+// parsePortRisk parses a single catalogue override
+func parsePortRisk(entry string) (models.PortRisk, error) {
+	ports, value, ok := strings.Cut(entry, "=")
+	if !ok {
+		return models.PortRisk{}, fmt.Errorf("expected port=severity")
+	}
+
+	severity, service, _ := strings.Cut(strings.TrimSpace(value), ":")
+	severity = strings.ToLower(strings.TrimSpace(severity))
+	if _, valid := severityWeights[severity]; !valid {
+		return models.PortRisk{}, fmt.Errorf("unknown severity %q", severity)
+	}
+
+	fromValue, toValue, isRange := strings.Cut(strings.TrimSpace(ports), "-")
+	if !isRange {
+		toValue = fromValue
+	}
+	from, err := strconv.ParseInt(strings.TrimSpace(fromValue), 10, 64)
+	if err != nil {
+		return models.PortRisk{}, fmt.Errorf("invalid port %q", fromValue)
+	}
+	to, err := strconv.ParseInt(strings.TrimSpace(toValue), 10, 64)
+	if err != nil {
+		return models.PortRisk{}, fmt.Errorf("invalid port %q", toValue)
+	}
+	if from < 0 || to > 65535 || from > to {
+		return models.PortRisk{}, fmt.Errorf("invalid port range %d-%d", from, to)
+	}
+
+	return models.PortRisk{FromPort: from, ToPort: to, Service: strings.TrimSpace(service), Severity: severity}, nil
+}
+
+// portRiskCatalogue returns the configured catalogue
+func (s *AWSService) portRiskCatalogue() []models.PortRisk {
+	if s.portRisks == nil {
+		return defaultPortRisks
+	}
+	return s.portRisks
+}
+
+// GetPortRiskCatalogue returns the port catalogue used to score security group findings
+func (s *AWSService) GetPortRiskCatalogue() []models.PortRisk {
+	catalogue := append([]models.PortRisk{}, s.portRiskCatalogue()...)
+	sort.Slice(catalogue, func(i, j int) bool {
+		return catalogue[i].FromPort < catalogue[j].FromPort
+	})
+	return catalogue
+}
+
+// ListSecurityGroupFindings returns scored findings across all accounts, or one account if accountID is set.
+// minSeverity drops lower findings; sortBy is "score" (default), "account", "region", "group" or "port".
+func (s *AWSService) ListSecurityGroupFindings(accountID, minSeverity, sortBy string) ([]models.SecurityGroupFinding, error) {
+	if minSeverity != "" && severityRanks[minSeverity] == 0 {
+		return nil, fmt.Errorf("invalid severity %q", minSeverity)
+	}
+	switch sortBy {
+	case "", "score", "account", "region", "group", "port":
+	default:
+		return nil, fmt.Errorf("invalid sort %q", sortBy)
+	}
+
+	var groups []models.SecurityGroup
+	var err error
+	if accountID != "" {
+		groups, err = s.ListSecurityGroupsByAccount(accountID)
+	} else {
+		groups, err = s.ListSecurityGroups()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	findings := []models.SecurityGroupFinding{}
+	for _, group := range groups {
+		for _, finding := range group.Findings {
+			if severityRanks[finding.Severity] >= severityRanks[minSeverity] {
+				findings = append(findings, finding)
+			}
+		}
+	}
+
+	sortSecurityGroupFindings(findings, sortBy)
+	return findings, nil
+}
+
+// sortSecurityGroupFindings orders findings by the requested key, highest score first within ties
+func sortSecurityGroupFindings(findings []models.SecurityGroupFinding, sortBy string) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		switch sortBy {
+		case "account":
+			if a.AccountID != b.AccountID {
+				return a.AccountID < b.AccountID
+			}
+		case "region":
+			if a.Region != b.Region {
+				return a.Region < b.Region
+			}
+		case "group":
+			if a.GroupID != b.GroupID {
+				return a.GroupID < b.GroupID
+			}
+		case "port":
+			if a.PortRange != b.PortRange {
+				return a.PortRange < b.PortRange
+			}
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.GroupID < b.GroupID
+	})
+}
+
+// assessSecurityGroupRisk scores a group's ingress rules and records the findings on the group
+func assessSecurityGroupRisk(sg *models.SecurityGroup, catalogue []models.PortRisk) {
+	sg.Findings = nil
+	sg.RiskScore = 0
+	sg.Severity = ""
+
+	publicFacing := len(sg.UsageInfo.PublicNetworkInterfaces) > 0
+	attached := len(sg.UsageInfo.AttachedToInstances) > 0 || len(sg.UsageInfo.AttachedToNetworkInterfaces) > 0 ||
+		len(sg.UsageInfo.AttachedToLoadBalancers) > 0
+
+	exposure := 0.3
+	switch {
+	case publicFacing:
+		exposure = 1.0
+	case attached:
+		exposure = 0.6
+	}
+
+	for _, rule := range sg.IngressRules {
+		source := rule.CidrIPv4
+		if source == "" {
+			source = rule.CidrIPv6
+		}
+		if source == "" {
+			continue
+		}
+
+		prefixLength, private, breadth, wide := cidrBreadth(source)
+		if !wide {
+			continue
+		}
+		if private {
+			breadth *= 0.4
+		}
+
+		severity, service := rulePortRisk(rule, catalogue)
+		score := int(math.Round(100 * severityWeights[severity] * breadth * exposure))
+		if score == 0 {
+			continue
+		}
+
+		finding := models.SecurityGroupFinding{
+			AccountID:    sg.AccountID,
+			AccountName:  sg.AccountName,
+			Region:       sg.Region,
+			GroupID:      sg.GroupID,
+			GroupName:    sg.GroupName,
+			VpcID:        sg.VpcID,
+			Protocol:     rule.IpProtocol,
+			PortRange:    rulePortRange(rule),
+			Source:       source,
+			Service:      service,
+			PortSeverity: severity,
+			PrefixLength: prefixLength,
+			Private:      private,
+			PublicFacing: publicFacing,
+			Attached:     attached,
+			Score:        score,
+			Severity:     scoreSeverity(score),
+		}
+		finding.Description = describeFinding(finding)
+		sg.Findings = append(sg.Findings, finding)
+
+		if score > sg.RiskScore {
+			sg.RiskScore = score
+			sg.Severity = finding.Severity
+		}
+	}
+}
+
+// cidrBreadth returns the prefix length of a CIDR, whether it is a private range, a breadth
+// factor between 0 and 1, and whether it is wide enough to be reported at all
+func cidrBreadth(cidr string) (int, bool, float64, bool) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return 0, false, 0, false
+	}
+	ones, _ := network.Mask.Size()
+	private := ones > 0 && network.IP.IsPrivate()
+
+	// IPv6 allocations are much larger, so the same breadth needs a shorter prefix length
+	steps := []int{0, 8, 16, 24}
+	if network.IP.To4() == nil {
+		steps = []int{0, 32, 48, 64}
+	}
+
+	switch {
+	case ones <= steps[0]:
+		return ones, private, 1.0, true
+	case ones <= steps[1]:
+		return ones, private, 0.8, true
+	case ones <= steps[2]:
+		return ones, private, 0.5, true
+	case ones <= steps[3]:
+		return ones, private, 0.25, true
+	}
+	return ones, private, 0, false
+}
+
+// rulePortRisk returns the highest catalogue severity among the ports a rule exposes
+func rulePortRisk(rule models.SecurityGroupRule, catalogue []models.PortRisk) (string, string) {
+	switch rule.IpProtocol {
+	case "-1":
+		return "critical", "All traffic"
+	case "icmp", "icmpv6", "1", "58":
+		return "low", "ICMP"
+	}
+	if !ruleHasPorts(rule.IpProtocol) {
+		return unlistedPortSeverity, ""
+	}
+
+	severity, service := "", ""
+	var services []string
+	for _, risk := range catalogue {
+		if risk.FromPort > rule.ToPort || risk.ToPort < rule.FromPort {
+			continue
+		}
+		if severityRanks[risk.Severity] > severityRanks[severity] {
+			severity, service = risk.Severity, risk.Service
+		}
+		if risk.Service != "" && risk.Severity == "critical" && !containsString(services, risk.Service) {
+			services = append(services, risk.Service)
+		}
+	}
+	if severity == "" {
+		return unlistedPortSeverity, ""
+	}
+	// A wide range exposing several critical services names them all
+	if len(services) > 1 {
+		service = strings.Join(services, ", ")
+	}
+	return severity, service
+}
+
+// rulePortRange renders a rule's ports, e.g. "22", "8000-8080" or "All ports"
+func rulePortRange(rule models.SecurityGroupRule) string {
+	switch {
+	case rule.IpProtocol == "-1":
+		return "All ports"
+	case !ruleHasPorts(rule.IpProtocol):
+		return ""
+	case rule.FromPort == rule.ToPort:
+		return fmt.Sprintf("%d", rule.FromPort)
+	}
+	return fmt.Sprintf("%d-%d", rule.FromPort, rule.ToPort)
+}
+
+// scoreSeverity maps a 0-100 score to a severity
+func scoreSeverity(score int) string {
+	switch {
+	case score >= 75:
+		return "critical"
+	case score >= 50:
+		return "high"
+	case score >= 25:
+		return "medium"
+	}
+	return "low"
+}
+
+func describeFinding(finding models.SecurityGroupFinding) string {
+	what := fmt.Sprintf("%s port %s", finding.Protocol, finding.PortRange)
+	if finding.Protocol == "-1" {
+		what = "All traffic"
+	}
+	if finding.Service != "" && finding.Protocol != "-1" {
+		what = fmt.Sprintf("%s (%s)", what, finding.Service)
+	}
+
+	exposure := "not attached to any resource"
+	switch {
+	case finding.PublicFacing:
+		exposure = "attached to a public-facing interface"
+	case finding.Attached:
+		exposure = "attached only to private interfaces"
+	}
+	return fmt.Sprintf("%s is open to %s; the group is %s", what, finding.Source, exposure)
+}
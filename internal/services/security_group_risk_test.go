@@ -0,0 +1,110 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAssessSecurityGroupRisk(t *testing.T) {
+	sg := &models.SecurityGroup{
+		GroupID: "sg-1",
+		IngressRules: []models.SecurityGroupRule{
+			{IpProtocol: "tcp", FromPort: 443, ToPort: 443, CidrIPv4: "0.0.0.0/0"},
+			{IpProtocol: "tcp", FromPort: 22, ToPort: 22, CidrIPv4: "0.0.0.0/0"},
+			{IpProtocol: "tcp", FromPort: 5432, ToPort: 5432, CidrIPv4: "10.0.0.0/8"},
+			{IpProtocol: "tcp", FromPort: 3306, ToPort: 3306, CidrIPv4: "203.0.113.10/32"},
+			{IpProtocol: "tcp", FromPort: 443, ToPort: 443, GroupID: "sg-2"},
+		},
+		UsageInfo: models.SecurityGroupUsage{
+			AttachedToNetworkInterfaces: []string{"eni-1"},
+			PublicNetworkInterfaces:     []string{"eni-1"},
+		},
+	}
+
+	assessSecurityGroupRisk(sg, defaultPortRisks)
+	require.Len(t, sg.Findings, 3)
+
+	https, ssh, postgres := sg.Findings[0], sg.Findings[1], sg.Findings[2]
+	assert.Equal(t, "low", https.Severity)
+	assert.Equal(t, "HTTPS", https.Service)
+	assert.Equal(t, 100, ssh.Score)
+	assert.Equal(t, "critical", ssh.Severity)
+	assert.True(t, ssh.PublicFacing)
+	assert.True(t, postgres.Private)
+	assert.Equal(t, 8, postgres.PrefixLength)
+	assert.Less(t, postgres.Score, ssh.Score)
+
+	assert.Equal(t, 100, sg.RiskScore)
+	assert.Equal(t, "critical", sg.Severity)
+}
+
+func TestAssessSecurityGroupRiskExposure(t *testing.T) {
+	rules := []models.SecurityGroupRule{{IpProtocol: "tcp", FromPort: 22, ToPort: 22, CidrIPv4: "0.0.0.0/0"}}
+
+	unattached := &models.SecurityGroup{IngressRules: rules}
+	assessSecurityGroupRisk(unattached, defaultPortRisks)
+
+	private := &models.SecurityGroup{IngressRules: rules, UsageInfo: models.SecurityGroupUsage{AttachedToInstances: []string{"i-1"}}}
+	assessSecurityGroupRisk(private, defaultPortRisks)
+
+	assert.Equal(t, 30, unattached.RiskScore)
+	assert.Equal(t, 60, private.RiskScore)
+	assert.Equal(t, "high", private.Severity)
+}
+
+func TestRulePortRisk(t *testing.T) {
+	severity, service := rulePortRisk(models.SecurityGroupRule{IpProtocol: "tcp", FromPort: 0, ToPort: 65535}, defaultPortRisks)
+	assert.Equal(t, "critical", severity)
+	assert.Contains(t, service, "SSH")
+	assert.Contains(t, service, "RDP")
+
+	severity, service = rulePortRisk(models.SecurityGroupRule{IpProtocol: "tcp", FromPort: 8443, ToPort: 8443}, defaultPortRisks)
+	assert.Equal(t, unlistedPortSeverity, severity)
+	assert.Empty(t, service)
+
+	severity, _ = rulePortRisk(models.SecurityGroupRule{IpProtocol: "-1"}, defaultPortRisks)
+	assert.Equal(t, "critical", severity)
+}
+
+func TestBuildPortRiskCatalogue(t *testing.T) {
+	catalogue := buildPortRiskCatalogue([]string{"443=high", "8080-8090=critical:Jenkins", "bogus", "22=unknown"})
+
+	severity, service := rulePortRisk(models.SecurityGroupRule{IpProtocol: "tcp", FromPort: 443, ToPort: 443}, catalogue)
+	assert.Equal(t, "high", severity)
+	assert.Equal(t, "HTTPS", service)
+
+	severity, service = rulePortRisk(models.SecurityGroupRule{IpProtocol: "tcp", FromPort: 8085, ToPort: 8085}, catalogue)
+	assert.Equal(t, "critical", severity)
+	assert.Equal(t, "Jenkins", service)
+
+	// Invalid overrides leave the defaults untouched
+	assert.Len(t, catalogue, len(defaultPortRisks)+1)
+}
+
+func TestCIDRBreadth(t *testing.T) {
+	tests := []struct {
+		cidr    string
+		breadth float64
+		private bool
+		wide    bool
+	}{
+		{"0.0.0.0/0", 1.0, false, true},
+		{"10.0.0.0/8", 0.8, true, true},
+		{"52.0.0.0/12", 0.5, false, true},
+		{"192.168.1.0/24", 0.25, true, true},
+		{"203.0.113.0/28", 0, false, false},
+		{"::/0", 1.0, false, true},
+		{"2001:db8::/40", 0.5, false, true},
+		{"2001:db8::/128", 0, false, false},
+	}
+	for _, tt := range tests {
+		_, private, breadth, wide := cidrBreadth(tt.cidr)
+		assert.Equal(t, tt.breadth, breadth, tt.cidr)
+		assert.Equal(t, tt.private, private, tt.cidr)
+		assert.Equal(t, tt.wide, wide, tt.cidr)
+	}
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// ============================================================================
+// SECURITY GROUP REGION SCAN
+// ============================================================================
+//
+// A region is scanned with one paginated listing each of security groups, instances,
+// network interfaces and launch templates, plus one listing of versions per launch template.
+// Usage and rule references for every group are then worked out in memory, so the number of
+// API calls no longer grows with the number of groups.
+
+// sgScanPageSize is the page size requested from the paginated EC2 describe calls
+const sgScanPageSize = 1000
+
+// sgScanLaunchTemplatePageSize is the maximum page size DescribeLaunchTemplates and DescribeLaunchTemplateVersions accept
+const sgScanLaunchTemplatePageSize = 200
+
+// securityGroupInventory holds everything fetched for a region and the usage derived from it
+type securityGroupInventory struct {
+	groups                 []*ec2.SecurityGroup
+	instances              []*ec2.Instance
+	networkInterfaces      []*ec2.NetworkInterface
+	launchTemplateVersions []*ec2.LaunchTemplateVersion
+	usage                  map[string]*models.SecurityGroupUsage // Keyed by group ID
+}
+
+// usageFor returns a group's usage, or an empty usage for a group that was not scanned
+func (inv *securityGroupInventory) usageFor(groupID string) models.SecurityGroupUsage {
+	if usage, ok := inv.usage[groupID]; ok {
+		return *usage
+	}
+	return models.SecurityGroupUsage{}
+}
+
+// scanSecurityGroupInventory fetches a region's groups, instances, ENIs and launch template
+// versions and computes the usage of every group. When groupID is set, instances and ENIs are
+// filtered server-side to that group and only its usage is complete.
+func scanSecurityGroupInventory(ec2Client ec2iface.EC2API, groupID string) (*securityGroupInventory, error) {
+	inv := &securityGroupInventory{}
+
+	// Every group is needed to find the rules that reference the scanned groups
+	err := ec2Client.DescribeSecurityGroupsPages(&ec2.DescribeSecurityGroupsInput{
+		MaxResults: aws.Int64(sgScanPageSize),
+	}, func(page *ec2.DescribeSecurityGroupsOutput, lastPage bool) bool {
+		inv.groups = append(inv.groups, page.SecurityGroups...)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe security groups: %v", err)
+	}
+
+	instancesInput := &ec2.DescribeInstancesInput{MaxResults: aws.Int64(sgScanPageSize)}
+	enisInput := &ec2.DescribeNetworkInterfacesInput{MaxResults: aws.Int64(sgScanPageSize)}
+	if groupID != "" {
+		instancesInput.Filters = []*ec2.Filter{{Name: aws.String("instance.group-id"), Values: aws.StringSlice([]string{groupID})}}
+		enisInput.Filters = []*ec2.Filter{{Name: aws.String("group-id"), Values: aws.StringSlice([]string{groupID})}}
+	}
+
+	err = ec2Client.DescribeInstancesPages(instancesInput, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, reservation := range page.Reservations {
+			inv.instances = append(inv.instances, reservation.Instances...)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe instances: %v", err)
+	}
+
+	err = ec2Client.DescribeNetworkInterfacesPages(enisInput, func(page *ec2.DescribeNetworkInterfacesOutput, lastPage bool) bool {
+		inv.networkInterfaces = append(inv.networkInterfaces, page.NetworkInterfaces...)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe network interfaces: %v", err)
+	}
+
+	// Without launch template versions a group may look unused while a template still
+	// launches instances into it, so its usage is marked incomplete
+	inv.launchTemplateVersions, err = describeLaunchTemplateVersions(ec2Client)
+	if err != nil {
+		fmt.Printf("[WARNING] Launch template usage of security groups unknown: %v\n", err)
+	}
+
+	inv.usage = buildSecurityGroupUsage(inv.groups, inv.instances, inv.networkInterfaces, inv.launchTemplateVersions)
+	if err != nil {
+		for _, usage := range inv.usage {
+			usage.IncompleteReason = "launch template usage could not be determined"
+		}
+	}
+	return inv, nil
+}
+
+// buildSecurityGroupUsage works out every group's usage from a region's resources
+func buildSecurityGroupUsage(groups []*ec2.SecurityGroup, instances []*ec2.Instance, enis []*ec2.NetworkInterface, versions []*ec2.LaunchTemplateVersion) map[string]*models.SecurityGroupUsage {
+	usage := make(map[string]*models.SecurityGroupUsage, len(groups))
+	get := func(groupID string) *models.SecurityGroupUsage {
+		u, ok := usage[groupID]
+		if !ok {
+			u = &models.SecurityGroupUsage{}
+			usage[groupID] = u
+		}
+		return u
+	}
+	for _, sg := range groups {
+		get(aws.StringValue(sg.GroupId))
+	}
+
+	for _, instance := range instances {
+		instanceID := aws.StringValue(instance.InstanceId)
+		// Terminated instances stay listed for a while but no longer hold their groups
+		if instanceID == "" || (instance.State != nil && aws.StringValue(instance.State.Name) == ec2.InstanceStateNameTerminated) {
+			continue
+		}
+		for _, groupID := range instanceGroupIDs(instance) {
+			u := get(groupID)
+			if !containsString(u.AttachedToInstances, instanceID) {
+				u.AttachedToInstances = append(u.AttachedToInstances, instanceID)
+				u.TotalAttachments++
+			}
+		}
+	}
+
+	for _, eni := range enis {
+		for _, group := range eni.Groups {
+			recordNetworkInterfaceUsage(get(aws.StringValue(group.GroupId)), eni)
+		}
+	}
+
+	for groupID, refs := range launchTemplateReferencesByGroup(versions) {
+		u := get(groupID)
+		u.ReferencedByLaunchTemplates = refs
+		u.TotalAttachments += len(refs)
+	}
+
+	references := securityGroupReferences(groups)
+	for _, sg := range groups {
+		referencingID := aws.StringValue(sg.GroupId)
+		for _, groupID := range references[referencingID] {
+			u := get(groupID)
+			u.ReferencedBySecurityGroups = append(u.ReferencedBySecurityGroups, referencingID)
+			u.TotalAttachments++
+		}
+	}
+
+	return usage
+}
+
+// instanceGroupIDs returns the groups of an instance and of all its network interfaces
+func instanceGroupIDs(instance *ec2.Instance) []string {
+	var groupIDs []string
+	for _, group := range instance.SecurityGroups {
+		groupIDs = appendUnique(groupIDs, aws.StringValue(group.GroupId))
+	}
+	for _, eni := range instance.NetworkInterfaces {
+		for _, group := range eni.Groups {
+			groupIDs = appendUnique(groupIDs, aws.StringValue(group.GroupId))
+		}
+	}
+	return groupIDs
+}
+
+// securityGroupReferences maps each group to the other groups its ingress or egress rules reference.
+// Self references are left out since they do not keep a group in use.
+func securityGroupReferences(groups []*ec2.SecurityGroup) map[string][]string {
+	references := make(map[string][]string)
+	for _, sg := range groups {
+		groupID := aws.StringValue(sg.GroupId)
+		permissions := append(append([]*ec2.IpPermission{}, sg.IpPermissions...), sg.IpPermissionsEgress...)
+		for _, permission := range permissions {
+			for _, pair := range permission.UserIdGroupPairs {
+				referencedID := aws.StringValue(pair.GroupId)
+				if referencedID == "" || referencedID == groupID {
+					continue
+				}
+				references[groupID] = appendUnique(references[groupID], referencedID)
+			}
+		}
+	}
+	return references
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSecurityGroupEC2 serves a region's resources in small pages and counts API calls
+type fakeSecurityGroupEC2 struct {
+	ec2iface.EC2API
+	groups      []*ec2.SecurityGroup
+	instances   []*ec2.Instance
+	enis        []*ec2.NetworkInterface
+	versions    []*ec2.LaunchTemplateVersion
+	templateErr error
+	pageSize    int
+	calls       int
+
+	versionInputs []*ec2.DescribeLaunchTemplateVersionsInput
+}
+
+// page returns the bounds of the page starting at token and the next token
+func (f *fakeSecurityGroupEC2) page(total int, token *string) (int, int, *string) {
+	start, _ := strconv.Atoi(aws.StringValue(token))
+	end := start + f.pageSize
+	if end >= total {
+		return start, total, nil
+	}
+	return start, end, aws.String(strconv.Itoa(end))
+}
+
+// filterValue returns the value of a single-valued filter
+func filterValue(filters []*ec2.Filter, name string) string {
+	for _, filter := range filters {
+		if aws.StringValue(filter.Name) == name && len(filter.Values) > 0 {
+			return aws.StringValue(filter.Values[0])
+		}
+	}
+	return ""
+}
+
+func (f *fakeSecurityGroupEC2) DescribeSecurityGroupsPages(input *ec2.DescribeSecurityGroupsInput, fn func(*ec2.DescribeSecurityGroupsOutput, bool) bool) error {
+	var token *string
+	for {
+		f.calls++
+		start, end, next := f.page(len(f.groups), token)
+		if !fn(&ec2.DescribeSecurityGroupsOutput{SecurityGroups: f.groups[start:end], NextToken: next}, next == nil) || next == nil {
+			return nil
+		}
+		token = next
+	}
+}
+
+func (f *fakeSecurityGroupEC2) DescribeInstancesPages(input *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool) error {
+	instances := f.instances
+	if groupID := filterValue(input.Filters, "instance.group-id"); groupID != "" {
+		instances = nil
+		for _, instance := range f.instances {
+			if containsString(instanceGroupIDs(instance), groupID) {
+				instances = append(instances, instance)
+			}
+		}
+	}
+
+	var token *string
+	for {
+		f.calls++
+		start, end, next := f.page(len(instances), token)
+		output := &ec2.DescribeInstancesOutput{NextToken: next}
+		if start < end {
+			output.Reservations = []*ec2.Reservation{{Instances: instances[start:end]}}
+		}
+		if !fn(output, next == nil) || next == nil {
+			return nil
+		}
+		token = next
+	}
+}
+
+func (f *fakeSecurityGroupEC2) DescribeNetworkInterfacesPages(input *ec2.DescribeNetworkInterfacesInput, fn func(*ec2.DescribeNetworkInterfacesOutput, bool) bool) error {
+	enis := f.enis
+	if groupID := filterValue(input.Filters, "group-id"); groupID != "" {
+		enis = nil
+		for _, eni := range f.enis {
+			for _, group := range eni.Groups {
+				if aws.StringValue(group.GroupId) == groupID {
+					enis = append(enis, eni)
+					break
+				}
+			}
+		}
+	}
+
+	var token *string
+	for {
+		f.calls++
+		start, end, next := f.page(len(enis), token)
+		if !fn(&ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: enis[start:end], NextToken: next}, next == nil) || next == nil {
+			return nil
+		}
+		token = next
+	}
+}
+
+func (f *fakeSecurityGroupEC2) DescribeLaunchTemplatesPages(input *ec2.DescribeLaunchTemplatesInput, fn func(*ec2.DescribeLaunchTemplatesOutput, bool) bool) error {
+	f.calls++
+	if f.templateErr != nil {
+		return f.templateErr
+	}
+	fn(&ec2.DescribeLaunchTemplatesOutput{LaunchTemplates: fakeLaunchTemplates(f.versions)}, true)
+	return nil
+}
+
+func (f *fakeSecurityGroupEC2) DescribeLaunchTemplateVersionsPages(input *ec2.DescribeLaunchTemplateVersionsInput, fn func(*ec2.DescribeLaunchTemplateVersionsOutput, bool) bool) error {
+	f.versionInputs = append(f.versionInputs, input)
+	versions := fakeTemplateVersions(f.versions, aws.StringValue(input.LaunchTemplateId))
+	var token *string
+	for {
+		f.calls++
+		start, end, next := f.page(len(versions), token)
+		if !fn(&ec2.DescribeLaunchTemplateVersionsOutput{LaunchTemplateVersions: versions[start:end], NextToken: next}, next == nil) || next == nil {
+			return nil
+		}
+		token = next
+	}
+}
+
+// fakeLaunchTemplates returns one launch template per distinct template ID in versions
+func fakeLaunchTemplates(versions []*ec2.LaunchTemplateVersion) []*ec2.LaunchTemplate {
+	var templates []*ec2.LaunchTemplate
+	seen := make(map[string]bool)
+	for _, version := range versions {
+		id := aws.StringValue(version.LaunchTemplateId)
+		if !seen[id] {
+			seen[id] = true
+			templates = append(templates, &ec2.LaunchTemplate{LaunchTemplateId: version.LaunchTemplateId})
+		}
+	}
+	return templates
+}
+
+// fakeTemplateVersions returns the versions belonging to one launch template
+func fakeTemplateVersions(versions []*ec2.LaunchTemplateVersion, templateID string) []*ec2.LaunchTemplateVersion {
+	var matched []*ec2.LaunchTemplateVersion
+	for _, version := range versions {
+		if aws.StringValue(version.LaunchTemplateId) == templateID {
+			matched = append(matched, version)
+		}
+	}
+	return matched
+}
+
+// newFakeRegion builds a region with one instance and one ENI per group, where every
+// group allows ingress from the previous group
+func newFakeRegion(groupCount, pageSize int) *fakeSecurityGroupEC2 {
+	f := &fakeSecurityGroupEC2{pageSize: pageSize}
+	for i := 0; i < groupCount; i++ {
+		groupID := fmt.Sprintf("sg-%04d", i)
+		sg := &ec2.SecurityGroup{GroupId: aws.String(groupID), GroupName: aws.String(groupID), VpcId: aws.String("vpc-1")}
+		if i > 0 {
+			sg.IpPermissions = []*ec2.IpPermission{{
+				IpProtocol:       aws.String("tcp"),
+				FromPort:         aws.Int64(443),
+				ToPort:           aws.Int64(443),
+				UserIdGroupPairs: []*ec2.UserIdGroupPair{{GroupId: aws.String(fmt.Sprintf("sg-%04d", i-1))}},
+			}}
+		}
+		f.groups = append(f.groups, sg)
+
+		instanceID := fmt.Sprintf("i-%04d", i)
+		f.instances = append(f.instances, &ec2.Instance{
+			InstanceId:     aws.String(instanceID),
+			State:          &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameRunning)},
+			SecurityGroups: []*ec2.GroupIdentifier{{GroupId: aws.String(groupID)}},
+		})
+		f.enis = append(f.enis, &ec2.NetworkInterface{
+			NetworkInterfaceId: aws.String(fmt.Sprintf("eni-%04d", i)),
+			Attachment:         &ec2.NetworkInterfaceAttachment{InstanceId: aws.String(instanceID)},
+			Groups:             []*ec2.GroupIdentifier{{GroupId: aws.String(groupID)}},
+		})
+	}
+	return f
+}
+
+func TestScanSecurityGroupInventory(t *testing.T) {
+	f := newFakeRegion(3, 2)
+	f.groups = append(f.groups, &ec2.SecurityGroup{GroupId: aws.String("sg-unused"), GroupName: aws.String("unused")})
+	f.instances = append(f.instances, &ec2.Instance{
+		InstanceId:     aws.String("i-gone"),
+		State:          &ec2.InstanceState{Name: aws.String(ec2.InstanceStateNameTerminated)},
+		SecurityGroups: []*ec2.GroupIdentifier{{GroupId: aws.String("sg-unused")}},
+	})
+	f.versions = []*ec2.LaunchTemplateVersion{{
+		LaunchTemplateId:   aws.String("lt-1"),
+		VersionNumber:      aws.Int64(2),
+		LaunchTemplateData: &ec2.ResponseLaunchTemplateData{SecurityGroupIds: aws.StringSlice([]string{"sg-0002"})},
+	}}
+
+	inv, err := scanSecurityGroupInventory(f, "")
+	require.NoError(t, err)
+	assert.Len(t, inv.groups, 4)
+	assert.Len(t, inv.instances, 4)
+
+	first := inv.usageFor("sg-0000")
+	assert.Equal(t, []string{"i-0000"}, first.AttachedToInstances)
+	assert.Equal(t, []string{"eni-0000"}, first.AttachedToNetworkInterfaces)
+	assert.Equal(t, []string{"sg-0001"}, first.ReferencedBySecurityGroups)
+	assert.Equal(t, 3, first.TotalAttachments)
+
+	last := inv.usageFor("sg-0002")
+	assert.Empty(t, last.ReferencedBySecurityGroups)
+	assert.Equal(t, []string{"lt-1:2"}, last.ReferencedByLaunchTemplates)
+	assert.Equal(t, 3, last.TotalAttachments)
+
+	assert.Equal(t, 0, inv.usageFor("sg-unused").TotalAttachments, "terminated instances do not count")
+	assert.Equal(t, 0, inv.usageFor("sg-missing").TotalAttachments)
+}
+
+func TestScanSecurityGroupInventoryReadsEveryLaunchTemplateVersion(t *testing.T) {
+	f := newFakeRegion(3, sgScanPageSize)
+	f.instances, f.enis = nil, nil
+	// An Auto Scaling group can pin version 1 after later versions moved to another group
+	f.versions = []*ec2.LaunchTemplateVersion{
+		{LaunchTemplateId: aws.String("lt-1"), VersionNumber: aws.Int64(1), LaunchTemplateData: &ec2.ResponseLaunchTemplateData{SecurityGroupIds: aws.StringSlice([]string{"sg-0000"})}},
+		{LaunchTemplateId: aws.String("lt-1"), VersionNumber: aws.Int64(7), LaunchTemplateData: &ec2.ResponseLaunchTemplateData{SecurityGroupIds: aws.StringSlice([]string{"sg-0001"})}},
+		{LaunchTemplateId: aws.String("lt-2"), VersionNumber: aws.Int64(1), LaunchTemplateData: &ec2.ResponseLaunchTemplateData{SecurityGroupIds: aws.StringSlice([]string{"sg-0002"})}},
+	}
+
+	inv, err := scanSecurityGroupInventory(f, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lt-1:1"}, inv.usageFor("sg-0000").ReferencedByLaunchTemplates)
+	assert.Equal(t, []string{"lt-1:7"}, inv.usageFor("sg-0001").ReferencedByLaunchTemplates)
+	assert.Equal(t, []string{"lt-2:1"}, inv.usageFor("sg-0002").ReferencedByLaunchTemplates)
+
+	require.Len(t, f.versionInputs, 2)
+	for _, input := range f.versionInputs {
+		assert.Empty(t, input.Versions, "every version is requested")
+	}
+}
+
+func TestScanSecurityGroupInventoryMarksUsageIncompleteWithoutLaunchTemplates(t *testing.T) {
+	f := newFakeRegion(3, sgScanPageSize)
+	f.instances, f.enis = nil, nil
+	f.templateErr = errors.New("UnauthorizedOperation")
+
+	inv, err := scanSecurityGroupInventory(f, "")
+	require.NoError(t, err)
+
+	s := &AWSService{}
+	for _, sg := range inv.groups {
+		usage := inv.usageFor(aws.StringValue(sg.GroupId))
+		assert.Equal(t, "launch template usage could not be determined", usage.IncompleteReason)
+		group := s.newSecurityGroupModel(sg, "111111111111", "test", "us-east-1", usage)
+		assert.False(t, group.IsUnused, "%s must not be reported unused", group.GroupID)
+	}
+}
+
+func TestScanSecurityGroupInventoryCallsDoNotGrowWithGroups(t *testing.T) {
+	small := newFakeRegion(10, sgScanPageSize)
+	_, err := scanSecurityGroupInventory(small, "")
+	require.NoError(t, err)
+
+	large := newFakeRegion(900, sgScanPageSize)
+	_, err = scanSecurityGroupInventory(large, "")
+	require.NoError(t, err)
+
+	// One page each of groups, instances, ENIs and launch templates
+	assert.Equal(t, 4, small.calls)
+	assert.Equal(t, 4, large.calls)
+}
+
+func TestCheckSecurityGroupUsageFiltersToGroup(t *testing.T) {
+	f := newFakeRegion(5, 2)
+
+	usage, err := (&AWSService{}).checkSecurityGroupUsage(f, "sg-0003")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"i-0003"}, usage.AttachedToInstances)
+	assert.Equal(t, []string{"eni-0003"}, usage.AttachedToNetworkInterfaces)
+	assert.Equal(t, []string{"sg-0004"}, usage.ReferencedBySecurityGroups)
+	assert.Equal(t, 3, usage.TotalAttachments)
+}
+
+// benchmarkRegionScan reports the EC2 API calls needed to work out usage for every group in a region
+func benchmarkRegionScan(b *testing.B, groupCount int, perGroup bool) {
+	s := &AWSService{}
+	calls := 0
+	for i := 0; i < b.N; i++ {
+		f := newFakeRegion(groupCount, sgScanPageSize)
+		if perGroup {
+			// The previous approach: one usage check per group
+			f.calls++ // The initial DescribeSecurityGroups
+			for _, sg := range f.groups {
+				if _, err := s.checkSecurityGroupUsage(f, aws.StringValue(sg.GroupId)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		} else if _, err := scanSecurityGroupInventory(f, ""); err != nil {
+			b.Fatal(err)
+		}
+		calls += f.calls
+	}
+	b.ReportMetric(float64(calls)/float64(b.N), "api-calls/op")
+}
+
+func BenchmarkRegionScanPerGroup100(b *testing.B)  { benchmarkRegionScan(b, 100, true) }
+func BenchmarkRegionScanBatched100(b *testing.B)   { benchmarkRegionScan(b, 100, false) }
+func BenchmarkRegionScanPerGroup1000(b *testing.B) { benchmarkRegionScan(b, 1000, true) }
+func BenchmarkRegionScanBatched1000(b *testing.B)  { benchmarkRegionScan(b, 1000, false) }
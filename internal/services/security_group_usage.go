@@ -0,0 +1,265 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// ============================================================================
+// SECURITY GROUP USAGE ATTRIBUTION
+// ============================================================================
+//
+// Managed services attach security groups through ENIs they own. The owning service is
+// recognised from the ENI's InterfaceType, RequesterId and description, for example:
+//   ALB/NLB/CLB  "ELB app/my-alb/50dc6c495c0c9188", "ELB net/...", "ELB my-classic-lb"
+//   Lambda       InterfaceType "lambda", "AWS Lambda VPC ENI-my-function-<uuid>"
+//   Endpoints    InterfaceType "vpc_endpoint", "VPC Endpoint Interface vpce-0123"
+//   EFS          "EFS mount target for fs-0123 (fsmt-0456)"
+//   RDS          RequesterId "amazon-rds", "RDSNetworkInterface"
+//   ECS tasks    "arn:aws:ecs:us-east-1:123456789012:attachment/<uuid>"
+
+// Services an ENI can be attributed to
+const (
+	eniOwnerInstance     = "ec2_instance"
+	eniOwnerLoadBalancer = "load_balancer"
+	eniOwnerLambda       = "lambda"
+	eniOwnerVPCEndpoint  = "vpc_endpoint"
+	eniOwnerEFS          = "efs"
+	eniOwnerRDS          = "rds"
+	eniOwnerECS          = "ecs_task"
+	eniOwnerOther        = "other"
+)
+
+// classifyNetworkInterface returns the service that owns an ENI and the resource it belongs to.
+// Unattributed ENIs return an empty service and the ENI ID.
+func classifyNetworkInterface(eni *ec2.NetworkInterface) (string, string) {
+	interfaceType := aws.StringValue(eni.InterfaceType)
+	requester := aws.StringValue(eni.RequesterId)
+	description := aws.StringValue(eni.Description)
+	eniID := aws.StringValue(eni.NetworkInterfaceId)
+
+	switch {
+	case interfaceType == "network_load_balancer" || interfaceType == "gateway_load_balancer" ||
+		strings.HasPrefix(description, "ELB "):
+		name := strings.TrimPrefix(description, "ELB ")
+		// ELBv2 descriptions are "ELB <type>/<name>/<id>"; classic ones are "ELB <name>"
+		if parts := strings.Split(name, "/"); len(parts) == 3 {
+			name = parts[1]
+		}
+		if name == "" {
+			name = eniID
+		}
+		return eniOwnerLoadBalancer, name
+
+	case interfaceType == "lambda" || strings.HasPrefix(description, "AWS Lambda VPC ENI"):
+		name := strings.TrimPrefix(strings.TrimPrefix(description, "AWS Lambda VPC ENI"), "-")
+		// Strip the trailing "-<uuid>"
+		if len(name) > 37 && name[len(name)-37] == '-' {
+			name = name[:len(name)-37]
+		}
+		if name == "" {
+			name = eniID
+		}
+		return eniOwnerLambda, name
+
+	case interfaceType == "vpc_endpoint" || strings.HasPrefix(description, "VPC Endpoint Interface"):
+		if fields := strings.Fields(description); len(fields) > 0 && strings.HasPrefix(fields[len(fields)-1], "vpce-") {
+			return eniOwnerVPCEndpoint, fields[len(fields)-1]
+		}
+		return eniOwnerVPCEndpoint, eniID
+
+	case interfaceType == "efs" || strings.HasPrefix(description, "EFS mount target"):
+		if start, end := strings.Index(description, "(fsmt-"), strings.LastIndex(description, ")"); start != -1 && end > start {
+			return eniOwnerEFS, description[start+1 : end]
+		}
+		return eniOwnerEFS, eniID
+
+	case requester == "amazon-rds" || description == "RDSNetworkInterface":
+		return eniOwnerRDS, eniID
+
+	case strings.HasPrefix(description, "arn:aws") && strings.Contains(description, ":ecs:"):
+		return eniOwnerECS, description
+
+	case eni.Attachment != nil && eni.Attachment.InstanceId != nil && requester == "":
+		return eniOwnerInstance, aws.StringValue(eni.Attachment.InstanceId)
+
+	case requester != "" || aws.BoolValue(eni.RequesterManaged):
+		owner := requester
+		if owner == "" {
+			owner = interfaceType
+		}
+		return eniOwnerOther, fmt.Sprintf("%s: %s", owner, eniID)
+	}
+
+	return "", eniID
+}
+
+// recordNetworkInterfaceUsage adds an ENI and its owning resource to a group's usage
+func recordNetworkInterfaceUsage(usage *models.SecurityGroupUsage, eni *ec2.NetworkInterface) {
+	eniID := aws.StringValue(eni.NetworkInterfaceId)
+	if eniID == "" {
+		return
+	}
+
+	usage.AttachedToNetworkInterfaces = appendUnique(usage.AttachedToNetworkInterfaces, eniID)
+	usage.TotalAttachments++
+	if eni.Association != nil && eni.Association.PublicIp != nil {
+		usage.PublicNetworkInterfaces = appendUnique(usage.PublicNetworkInterfaces, eniID)
+	}
+
+	owner, resource := classifyNetworkInterface(eni)
+	switch owner {
+	case eniOwnerLoadBalancer:
+		usage.AttachedToLoadBalancers = appendUnique(usage.AttachedToLoadBalancers, resource)
+	case eniOwnerLambda:
+		usage.AttachedToLambdaFunctions = appendUnique(usage.AttachedToLambdaFunctions, resource)
+	case eniOwnerVPCEndpoint:
+		usage.AttachedToVPCEndpoints = appendUnique(usage.AttachedToVPCEndpoints, resource)
+	case eniOwnerEFS:
+		usage.AttachedToEFSMountTargets = appendUnique(usage.AttachedToEFSMountTargets, resource)
+	case eniOwnerRDS:
+		usage.AttachedToRDS = appendUnique(usage.AttachedToRDS, resource)
+	case eniOwnerECS:
+		usage.AttachedToECSTasks = appendUnique(usage.AttachedToECSTasks, resource)
+	case eniOwnerOther:
+		usage.AttachedToOtherServices = appendUnique(usage.AttachedToOtherServices, resource)
+	}
+}
+
+// launchTemplateReferencesByGroup maps each group to "lt-id:version" for every launch template version that uses it.
+// Launch templates reference groups without any ENI existing until an instance is launched.
+func launchTemplateReferencesByGroup(versions []*ec2.LaunchTemplateVersion) map[string][]string {
+	refs := make(map[string][]string)
+	for _, version := range versions {
+		data := version.LaunchTemplateData
+		if data == nil {
+			continue
+		}
+
+		groupIDs := aws.StringValueSlice(data.SecurityGroupIds)
+		for _, eni := range data.NetworkInterfaces {
+			groupIDs = append(groupIDs, aws.StringValueSlice(eni.Groups)...)
+		}
+
+		ref := fmt.Sprintf("%s:%d", aws.StringValue(version.LaunchTemplateId), aws.Int64Value(version.VersionNumber))
+		for _, groupID := range groupIDs {
+			refs[groupID] = appendUnique(refs[groupID], ref)
+		}
+	}
+	return refs
+}
+
+// describeLaunchTemplateVersions returns every version of every launch template in a region.
+// Auto Scaling groups and instances can pin any version, not only $Default or $Latest.
+func describeLaunchTemplateVersions(ec2Client ec2iface.EC2API) ([]*ec2.LaunchTemplateVersion, error) {
+	var templateIDs []string
+	err := ec2Client.DescribeLaunchTemplatesPages(&ec2.DescribeLaunchTemplatesInput{
+		MaxResults: aws.Int64(sgScanLaunchTemplatePageSize),
+	}, func(page *ec2.DescribeLaunchTemplatesOutput, lastPage bool) bool {
+		for _, template := range page.LaunchTemplates {
+			templateIDs = append(templateIDs, aws.StringValue(template.LaunchTemplateId))
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe launch templates: %v", err)
+	}
+
+	var versions []*ec2.LaunchTemplateVersion
+	for _, templateID := range templateIDs {
+		err := ec2Client.DescribeLaunchTemplateVersionsPages(&ec2.DescribeLaunchTemplateVersionsInput{
+			LaunchTemplateId: aws.String(templateID),
+			MaxResults:       aws.Int64(sgScanLaunchTemplatePageSize),
+		}, func(page *ec2.DescribeLaunchTemplateVersionsOutput, lastPage bool) bool {
+			versions = append(versions, page.LaunchTemplateVersions...)
+			return true
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe versions of launch template %s: %v", templateID, err)
+		}
+	}
+	return versions, nil
+}
+
+// StartUnusedSecurityGroupCleanup deletes unused, non-default security groups as a background job.
+// Each group's usage is checked again right before deletion.
+func (s *AWSService) StartUnusedSecurityGroupCleanup(input models.SecurityGroupCleanupInput) (models.Job, error) {
+	var groups []models.SecurityGroup
+	if len(input.AccountIDs) > 0 {
+		for _, accountID := range input.AccountIDs {
+			accountGroups, err := s.ListSecurityGroupsByAccount(accountID)
+			if err != nil {
+				return models.Job{}, err
+			}
+			groups = append(groups, accountGroups...)
+		}
+	} else {
+		var err error
+		if groups, err = s.ListSecurityGroups(); err != nil {
+			return models.Job{}, err
+		}
+	}
+
+	var targets []models.SecurityGroup
+	for _, group := range groups {
+		if !group.IsUnused || group.IsDefault {
+			continue
+		}
+		if len(input.GroupIDs) > 0 && !containsString(input.GroupIDs, group.GroupID) {
+			continue
+		}
+		targets = append(targets, group)
+	}
+
+	description := fmt.Sprintf("Delete %d unused security group(s)", len(targets))
+	if input.DryRun {
+		description = "Dry run: " + description
+	}
+
+	job := s.jobs.start("security_group_cleanup", description, func(jc *jobContext) (any, error) {
+		jc.AddTotal(len(targets))
+
+		for _, group := range targets {
+			result := models.JobItemResult{
+				AccountID:  group.AccountID,
+				Region:     group.Region,
+				ResourceID: group.GroupID,
+				Status:     "succeeded",
+				Message:    fmt.Sprintf("Deleted %s", group.GroupName),
+			}
+
+			if input.DryRun {
+				result.Status = "skipped"
+				result.Message = fmt.Sprintf("Would delete %s", group.GroupName)
+				jc.AddResult(result)
+				continue
+			}
+
+			if err := s.DeleteSecurityGroup(group.AccountID, group.Region, group.GroupID); err != nil {
+				result.Status = "failed"
+				if strings.Contains(err.Error(), "still in use") || strings.Contains(err.Error(), "not found") {
+					result.Status = "skipped"
+				}
+				result.Message = err.Error()
+			}
+			jc.AddResult(result)
+		}
+		return nil, nil
+	})
+
+	return job, nil
+}
+
+// appendUnique appends a value if it is not already present
+func appendUnique(values []string, value string) []string {
+	if containsString(values, value) {
+		return values
+	}
+	return append(values, value)
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyNetworkInterface(t *testing.T) {
+	tests := []struct {
+		name     string
+		eni      *ec2.NetworkInterface
+		owner    string
+		resource string
+	}{
+		{"alb", &ec2.NetworkInterface{Description: aws.String("ELB app/web-alb/50dc6c495c0c9188"), RequesterId: aws.String("amazon-elb")}, eniOwnerLoadBalancer, "web-alb"},
+		{"nlb", &ec2.NetworkInterface{InterfaceType: aws.String("network_load_balancer"), Description: aws.String("ELB net/api-nlb/a1b2c3")}, eniOwnerLoadBalancer, "api-nlb"},
+		{"classic elb", &ec2.NetworkInterface{Description: aws.String("ELB legacy-lb")}, eniOwnerLoadBalancer, "legacy-lb"},
+		{"lambda", &ec2.NetworkInterface{InterfaceType: aws.String("lambda"), Description: aws.String("AWS Lambda VPC ENI-order-processor-6f2c0e1a-7b3d-4c5e-9f8a-1b2c3d4e5f60")}, eniOwnerLambda, "order-processor"},
+		{"vpc endpoint", &ec2.NetworkInterface{InterfaceType: aws.String("vpc_endpoint"), Description: aws.String("VPC Endpoint Interface vpce-0abc123")}, eniOwnerVPCEndpoint, "vpce-0abc123"},
+		{"efs", &ec2.NetworkInterface{Description: aws.String("EFS mount target for fs-0123 (fsmt-0456)")}, eniOwnerEFS, "fsmt-0456"},
+		{"rds", &ec2.NetworkInterface{NetworkInterfaceId: aws.String("eni-rds"), RequesterId: aws.String("amazon-rds"), Description: aws.String("RDSNetworkInterface")}, eniOwnerRDS, "eni-rds"},
+		{"ecs task", &ec2.NetworkInterface{Description: aws.String("arn:aws:ecs:us-east-1:123456789012:attachment/abcd")}, eniOwnerECS, "arn:aws:ecs:us-east-1:123456789012:attachment/abcd"},
+		{"instance", &ec2.NetworkInterface{Attachment: &ec2.NetworkInterfaceAttachment{InstanceId: aws.String("i-123")}}, eniOwnerInstance, "i-123"},
+		{"other managed", &ec2.NetworkInterface{NetworkInterfaceId: aws.String("eni-cache"), RequesterId: aws.String("amazon-elasticache")}, eniOwnerOther, "amazon-elasticache: eni-cache"},
+		{"unattributed", &ec2.NetworkInterface{NetworkInterfaceId: aws.String("eni-free")}, "", "eni-free"},
+	}
+
+	for _, tt := range tests {
+		owner, resource := classifyNetworkInterface(tt.eni)
+		assert.Equal(t, tt.owner, owner, tt.name)
+		assert.Equal(t, tt.resource, resource, tt.name)
+	}
+}
+
+func TestRecordNetworkInterfaceUsage(t *testing.T) {
+	usage := models.SecurityGroupUsage{}
+	recordNetworkInterfaceUsage(&usage, &ec2.NetworkInterface{
+		NetworkInterfaceId: aws.String("eni-1"),
+		Description:        aws.String("ELB app/web-alb/50dc6c495c0c9188"),
+		Association:        &ec2.NetworkInterfaceAssociation{PublicIp: aws.String("203.0.113.10")},
+	})
+	recordNetworkInterfaceUsage(&usage, &ec2.NetworkInterface{
+		NetworkInterfaceId: aws.String("eni-2"),
+		Description:        aws.String("ELB app/web-alb/50dc6c495c0c9188"),
+	})
+
+	assert.Equal(t, []string{"eni-1", "eni-2"}, usage.AttachedToNetworkInterfaces)
+	assert.Equal(t, []string{"web-alb"}, usage.AttachedToLoadBalancers)
+	assert.Equal(t, []string{"eni-1"}, usage.PublicNetworkInterfaces)
+	assert.Equal(t, 2, usage.TotalAttachments)
+}
+
+func TestLaunchTemplateReferencesByGroup(t *testing.T) {
+	versions := []*ec2.LaunchTemplateVersion{
+		{
+			LaunchTemplateId: aws.String("lt-1"),
+			VersionNumber:    aws.Int64(3),
+			LaunchTemplateData: &ec2.ResponseLaunchTemplateData{
+				SecurityGroupIds: aws.StringSlice([]string{"sg-web"}),
+			},
+		},
+		{
+			LaunchTemplateId: aws.String("lt-2"),
+			VersionNumber:    aws.Int64(1),
+			LaunchTemplateData: &ec2.ResponseLaunchTemplateData{
+				NetworkInterfaces: []*ec2.LaunchTemplateInstanceNetworkInterfaceSpecification{
+					{Groups: aws.StringSlice([]string{"sg-db", "sg-web"})},
+				},
+			},
+		},
+		// $Default and $Latest are often the same version
+		{
+			LaunchTemplateId: aws.String("lt-1"),
+			VersionNumber:    aws.Int64(3),
+			LaunchTemplateData: &ec2.ResponseLaunchTemplateData{
+				SecurityGroupIds: aws.StringSlice([]string{"sg-web"}),
+			},
+		},
+	}
+
+	refs := launchTemplateReferencesByGroup(versions)
+	assert.Equal(t, []string{"lt-1:3", "lt-2:1"}, refs["sg-web"])
+	assert.Equal(t, []string{"lt-2:1"}, refs["sg-db"])
+	assert.Empty(t, refs["sg-other"])
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 )
 
 // ============================================================================
@@ -21,14 +22,13 @@ func (s *AWSService) getSecurityGroupsForAccount(account models.Account) ([]mode
 		return nil, fmt.Errorf("cannot access account %s: %w", account.ID, err)
 	}
 
-	// Get all regions
-	ec2Client := ec2.New(sess)
-	regionsResult, err := ec2Client.DescribeRegions(&ec2.DescribeRegionsInput{})
+	scan, err := s.startRegionScan(scannerSecurityGroups, account.ID, sess)
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe regions: %v", err)
+		return nil, err
 	}
-
-	if len(regionsResult.Regions) == 0 {
+	regions := scan.Regions()
+	if len(regions) == 0 {
+		scan.Finish()
 		return []models.SecurityGroup{}, nil
 	}
 
@@ -38,11 +38,11 @@ func (s *AWSService) getSecurityGroupsForAccount(account models.Account) ([]mode
 		regionName string
 	}
 
-	resultChan := make(chan regionResult, len(regionsResult.Regions))
+	resultChan := make(chan regionResult, len(regions))
 	var wg sync.WaitGroup
 
 	// Process each region in parallel
-	for _, region := range regionsResult.Regions {
+	for _, region := range regions {
 		wg.Add(1)
 		go func(regionName string) {
 			defer wg.Done()
@@ -52,6 +52,7 @@ func (s *AWSService) getSecurityGroupsForAccount(account models.Account) ([]mode
 			regionSGs, err := s.getSecurityGroupsForRegion(regionSess, account, regionName)
 			if err != nil {
 				fmt.Printf("[WARNING] Failed to get security groups in region %s for account %s: %v\n", regionName, account.ID, err)
+				scan.Fail(regionName, err)
 				regionSGs = []models.SecurityGroup{}
 			}
 
@@ -59,12 +60,13 @@ func (s *AWSService) getSecurityGroupsForAccount(account models.Account) ([]mode
 				sgs:        regionSGs,
 				regionName: regionName,
 			}
-		}(*region.RegionName)
+		}(region)
 	}
 
 	// Wait for all goroutines to complete and close channel
 	go func() {
 		wg.Wait()
+		scan.Finish()
 		close(resultChan)
 	}()
 
@@ -79,60 +81,60 @@ func (s *AWSService) getSecurityGroupsForAccount(account models.Account) ([]mode
 
 func (s *AWSService) getSecurityGroupsForRegion(sess *session.Session, account models.Account, region string) ([]models.SecurityGroup, error) {
 	ec2Client := ec2.New(sess)
-	var sgs []models.SecurityGroup
 
-	result, err := ec2Client.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{})
+	// Fetch groups, instances and ENIs once and work out usage for all groups in memory
+	inventory, err := scanSecurityGroupInventory(ec2Client, "")
 	if err != nil {
 		return nil, err
 	}
 
-	for _, sg := range result.SecurityGroups {
-		// Convert ingress rules
-		var ingressRules []models.SecurityGroupRule
-		for _, rule := range sg.IpPermissions {
-			rules := s.convertEC2RuleToModel(rule)
-			ingressRules = append(ingressRules, rules...)
-		}
+	sgs := make([]models.SecurityGroup, 0, len(inventory.groups))
+	for _, sg := range inventory.groups {
+		usageInfo := inventory.usageFor(aws.StringValue(sg.GroupId))
+		sgs = append(sgs, s.newSecurityGroupModel(sg, account.ID, account.Name, region, usageInfo))
+	}
 
-		// Convert egress rules
-		var egressRules []models.SecurityGroupRule
-		for _, rule := range sg.IpPermissionsEgress {
-			rules := s.convertEC2RuleToModel(rule)
-			egressRules = append(egressRules, rules...)
-		}
+	return sgs, nil
+}
 
-		// Check for open ports to internet
-		hasOpenPorts, openPortsInfo := s.checkForOpenPorts(ingressRules)
+// newSecurityGroupModel converts an EC2 security group with its usage and scores its risk
+func (s *AWSService) newSecurityGroupModel(sg *ec2.SecurityGroup, accountID, accountName, region string, usageInfo models.SecurityGroupUsage) models.SecurityGroup {
+	// Convert ingress rules
+	var ingressRules []models.SecurityGroupRule
+	for _, rule := range sg.IpPermissions {
+		rules := s.convertEC2RuleToModel(rule)
+		ingressRules = append(ingressRules, rules...)
+	}
 
-		// Check usage of security group
-		usageInfo, err := s.checkSecurityGroupUsage(ec2Client, *sg.GroupId)
-		if err != nil {
-			fmt.Printf("[WARNING] Failed to check usage for security group %s: %v\n", *sg.GroupId, err)
-			usageInfo = models.SecurityGroupUsage{}
-		}
-		isUnused := usageInfo.TotalAttachments == 0
-
-		securityGroup := models.SecurityGroup{
-			GroupID:       *sg.GroupId,
-			GroupName:     *sg.GroupName,
-			Description:   *sg.Description,
-			AccountID:     account.ID,
-			AccountName:   account.Name,
-			Region:        region,
-			VpcID:         aws.StringValue(sg.VpcId),
-			IsDefault:     *sg.GroupName == "default",
-			IngressRules:  ingressRules,
-			EgressRules:   egressRules,
-			HasOpenPorts:  hasOpenPorts,
-			OpenPortsInfo: openPortsInfo,
-			IsUnused:      isUnused,
-			UsageInfo:     usageInfo,
-		}
+	// Convert egress rules
+	var egressRules []models.SecurityGroupRule
+	for _, rule := range sg.IpPermissionsEgress {
+		rules := s.convertEC2RuleToModel(rule)
+		egressRules = append(egressRules, rules...)
+	}
+
+	// Check for open ports to internet
+	hasOpenPorts, openPortsInfo := s.checkForOpenPorts(ingressRules)
 
-		sgs = append(sgs, securityGroup)
+	securityGroup := models.SecurityGroup{
+		GroupID:       aws.StringValue(sg.GroupId),
+		GroupName:     aws.StringValue(sg.GroupName),
+		Description:   aws.StringValue(sg.Description),
+		AccountID:     accountID,
+		AccountName:   accountName,
+		Region:        region,
+		VpcID:         aws.StringValue(sg.VpcId),
+		IsDefault:     aws.StringValue(sg.GroupName) == "default",
+		IngressRules:  ingressRules,
+		EgressRules:   egressRules,
+		HasOpenPorts:  hasOpenPorts,
+		OpenPortsInfo: openPortsInfo,
+		IsUnused:      usageInfo.TotalAttachments == 0 && usageInfo.IncompleteReason == "",
+		UsageInfo:     usageInfo,
 	}
+	assessSecurityGroupRisk(&securityGroup, s.portRiskCatalogue())
 
-	return sgs, nil
+	return securityGroup
 }
 
 func (s *AWSService) convertEC2RuleToModel(rule *ec2.IpPermission) []models.SecurityGroupRule {
@@ -145,36 +147,50 @@ func (s *AWSService) convertEC2RuleToModel(rule *ec2.IpPermission) []models.Secu
 	// Handle IPv4 CIDR blocks
 	for _, ipRange := range rule.IpRanges {
 		rules = append(rules, models.SecurityGroupRule{
-			IpProtocol: protocol,
-			FromPort:   fromPort,
-			ToPort:     toPort,
-			CidrIPv4:   aws.StringValue(ipRange.CidrIp),
+			IpProtocol:  protocol,
+			FromPort:    fromPort,
+			ToPort:      toPort,
+			CidrIPv4:    aws.StringValue(ipRange.CidrIp),
+			Description: aws.StringValue(ipRange.Description),
 		})
 	}
 
 	// Handle IPv6 CIDR blocks
 	for _, ipv6Range := range rule.Ipv6Ranges {
 		rules = append(rules, models.SecurityGroupRule{
-			IpProtocol: protocol,
-			FromPort:   fromPort,
-			ToPort:     toPort,
-			CidrIPv6:   aws.StringValue(ipv6Range.CidrIpv6),
+			IpProtocol:  protocol,
+			FromPort:    fromPort,
+			ToPort:      toPort,
+			CidrIPv6:    aws.StringValue(ipv6Range.CidrIpv6),
+			Description: aws.StringValue(ipv6Range.Description),
 		})
 	}
 
 	// Handle security group references
 	for _, sgRef := range rule.UserIdGroupPairs {
 		rules = append(rules, models.SecurityGroupRule{
-			IpProtocol: protocol,
-			FromPort:   fromPort,
-			ToPort:     toPort,
-			GroupID:    aws.StringValue(sgRef.GroupId),
-			GroupOwner: aws.StringValue(sgRef.UserId),
+			IpProtocol:  protocol,
+			FromPort:    fromPort,
+			ToPort:      toPort,
+			GroupID:     aws.StringValue(sgRef.GroupId),
+			GroupOwner:  aws.StringValue(sgRef.UserId),
+			Description: aws.StringValue(sgRef.Description),
+		})
+	}
+
+	// Handle managed prefix lists
+	for _, prefixList := range rule.PrefixListIds {
+		rules = append(rules, models.SecurityGroupRule{
+			IpProtocol:   protocol,
+			FromPort:     fromPort,
+			ToPort:       toPort,
+			PrefixListID: aws.StringValue(prefixList.PrefixListId),
+			Description:  aws.StringValue(prefixList.Description),
 		})
 	}
 
 	// If no specific ranges are defined, create a rule without CIDR
-	if len(rule.IpRanges) == 0 && len(rule.Ipv6Ranges) == 0 && len(rule.UserIdGroupPairs) == 0 {
+	if len(rules) == 0 {
 		rules = append(rules, models.SecurityGroupRule{
 			IpProtocol: protocol,
 			FromPort:   fromPort,
@@ -240,107 +256,14 @@ func (s *AWSService) checkForOpenPorts(ingressRules []models.SecurityGroupRule)
 	return hasOpenPorts, openPortsInfo
 }
 
-// checkSecurityGroupUsage checks if a security group is being used by any resources
-func (s *AWSService) checkSecurityGroupUsage(ec2Client *ec2.EC2, groupID string) (models.SecurityGroupUsage, error) {
-	usage := models.SecurityGroupUsage{
-		AttachedToInstances:       []string{},
-		AttachedToNetworkInterfaces: []string{},
-		AttachedToLoadBalancers:   []string{},
-		ReferencedBySecurityGroups: []string{},
-		TotalAttachments:          0,
-	}
-
-	// Check EC2 instances
-	instancesResult, err := ec2Client.DescribeInstances(&ec2.DescribeInstancesInput{
-		Filters: []*ec2.Filter{
-			{
-				Name:   aws.String("instance.group-id"),
-				Values: []*string{aws.String(groupID)},
-			},
-		},
-	})
-	if err != nil {
-		return usage, fmt.Errorf("failed to describe instances: %v", err)
-	}
-
-	for _, reservation := range instancesResult.Reservations {
-		for _, instance := range reservation.Instances {
-			if instance.InstanceId != nil {
-				usage.AttachedToInstances = append(usage.AttachedToInstances, *instance.InstanceId)
-				usage.TotalAttachments++
-			}
-		}
-	}
-
-	// Check network interfaces
-	eniResult, err := ec2Client.DescribeNetworkInterfaces(&ec2.DescribeNetworkInterfacesInput{
-		Filters: []*ec2.Filter{
-			{
-				Name:   aws.String("group-id"),
-				Values: []*string{aws.String(groupID)},
-			},
-		},
-	})
-	if err != nil {
-		return usage, fmt.Errorf("failed to describe network interfaces: %v", err)
-	}
-
-	for _, eni := range eniResult.NetworkInterfaces {
-		if eni.NetworkInterfaceId != nil {
-			usage.AttachedToNetworkInterfaces = append(usage.AttachedToNetworkInterfaces, *eni.NetworkInterfaceId)
-			usage.TotalAttachments++
-		}
-	}
-
-	// Check load balancers (both Classic and Application/Network Load Balancers)
-	// Note: For ALB/NLB, we need to use ELBv2 API, but for simplicity, we'll check through ENIs
-	// Load balancers are typically attached through ENIs, so they should be caught above
-
-	// Check if this security group is referenced by other security groups
-	allSGsResult, err := ec2Client.DescribeSecurityGroups(&ec2.DescribeSecurityGroupsInput{})
+// checkSecurityGroupUsage checks if a security group is being used by any resources.
+// Instances and ENIs are filtered to the group; region scans use scanSecurityGroupInventory directly.
+func (s *AWSService) checkSecurityGroupUsage(ec2Client ec2iface.EC2API, groupID string) (models.SecurityGroupUsage, error) {
+	inventory, err := scanSecurityGroupInventory(ec2Client, groupID)
 	if err != nil {
-		return usage, fmt.Errorf("failed to describe all security groups: %v", err)
+		return models.SecurityGroupUsage{}, err
 	}
-
-	for _, sg := range allSGsResult.SecurityGroups {
-		if *sg.GroupId == groupID {
-			continue // Skip self
-		}
-
-		// Check ingress rules
-		for _, rule := range sg.IpPermissions {
-			for _, userIdGroupPair := range rule.UserIdGroupPairs {
-				if userIdGroupPair.GroupId != nil && *userIdGroupPair.GroupId == groupID {
-					usage.ReferencedBySecurityGroups = append(usage.ReferencedBySecurityGroups, *sg.GroupId)
-					usage.TotalAttachments++
-					break
-				}
-			}
-		}
-
-		// Check egress rules
-		for _, rule := range sg.IpPermissionsEgress {
-			for _, userIdGroupPair := range rule.UserIdGroupPairs {
-				if userIdGroupPair.GroupId != nil && *userIdGroupPair.GroupId == groupID {
-					// Only add if not already added from ingress rules
-					found := false
-					for _, existingRef := range usage.ReferencedBySecurityGroups {
-						if existingRef == *sg.GroupId {
-							found = true
-							break
-						}
-					}
-					if !found {
-						usage.ReferencedBySecurityGroups = append(usage.ReferencedBySecurityGroups, *sg.GroupId)
-						usage.TotalAttachments++
-					}
-					break
-				}
-			}
-		}
-	}
-
-	return usage, nil
+	return inventory.usageFor(groupID), nil
 }
 
 // DeleteSecurityGroup deletes a security group
@@ -376,10 +299,12 @@ func (s *AWSService) DeleteSecurityGroup(accountID, region, groupID string) erro
 	// Check if the security group is in use before attempting deletion
 	usage, err := s.checkSecurityGroupUsage(ec2Client, groupID)
 	if err != nil {
-		// Log warning but proceed with deletion attempt
-		fmt.Printf("[WARNING] Failed to check usage for security group %s: %v\n", groupID, err)
+		// Never delete a group whose usage is unknown
+		return fmt.Errorf("security group %s may still be in use: usage check failed: %v", groupID, err)
 	} else if usage.TotalAttachments > 0 {
 		return fmt.Errorf("security group %s is still in use (attached to %d resources)", groupID, usage.TotalAttachments)
+	} else if usage.IncompleteReason != "" {
+		return fmt.Errorf("security group %s may still be in use: %s", groupID, usage.IncompleteReason)
 	}
 
 	// Attempt to delete the security group
@@ -582,48 +507,14 @@ func (s *AWSService) fetchSecurityGroupDirect(accountID, region, groupID string)
 
 	sg := result.SecurityGroups[0]
 
-	// Convert ingress rules
-	var ingressRules []models.SecurityGroupRule
-	for _, rule := range sg.IpPermissions {
-		rules := s.convertEC2RuleToModel(rule)
-		ingressRules = append(ingressRules, rules...)
-	}
-
-	// Convert egress rules
-	var egressRules []models.SecurityGroupRule
-	for _, rule := range sg.IpPermissionsEgress {
-		rules := s.convertEC2RuleToModel(rule)
-		egressRules = append(egressRules, rules...)
-	}
-
-	// Check for open ports to internet
-	hasOpenPorts, openPortsInfo := s.checkForOpenPorts(ingressRules)
-
 	// Check usage of security group
-	usageInfo, err := s.checkSecurityGroupUsage(ec2Client, *sg.GroupId)
+	usageInfo, err := s.checkSecurityGroupUsage(ec2Client, groupID)
 	if err != nil {
-		fmt.Printf("[WARNING] Failed to check usage for security group %s: %v\n", *sg.GroupId, err)
+		fmt.Printf("[WARNING] Failed to check usage for security group %s: %v\n", groupID, err)
 		usageInfo = models.SecurityGroupUsage{}
 	}
-	isUnused := usageInfo.TotalAttachments == 0
-
-	securityGroup := &models.SecurityGroup{
-		GroupID:       *sg.GroupId,
-		GroupName:     *sg.GroupName,
-		Description:   *sg.Description,
-		AccountID:     accountID,
-		AccountName:   accountName,
-		Region:        region,
-		VpcID:         aws.StringValue(sg.VpcId),
-		IsDefault:     *sg.GroupName == "default",
-		IngressRules:  ingressRules,
-		EgressRules:   egressRules,
-		HasOpenPorts:  hasOpenPorts,
-		OpenPortsInfo: openPortsInfo,
-		IsUnused:      isUnused,
-		UsageInfo:     usageInfo,
-	}
 
-	return securityGroup, nil
+	securityGroup := s.newSecurityGroupModel(sg, accountID, accountName, region, usageInfo)
+	return &securityGroup, nil
 }
 
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+)
+
+// ============================================================================
+// IAM POLICY SIMULATION
+// ============================================================================
+
+// errPrincipalNotFound marks a principal that does not exist in an account, so batch
+// simulations can skip that account
+var errPrincipalNotFound = errors.New("principal not found")
+
+// SimulatePrincipalPolicy evaluates whether a user or role in an account can perform the requested actions
+func (s *AWSService) SimulatePrincipalPolicy(accountID, principalType, principalName string, req models.SimulationRequest) (*models.SimulationResult, error) {
+	if len(req.Actions) == 0 {
+		return nil, fmt.Errorf("invalid simulation request: at least one action is required")
+	}
+
+	result, err := s.simulateInAccount(models.Account{ID: accountID, Name: s.lookupAccountName(accountID)}, principalType, principalName, req)
+	if err != nil {
+		if errors.Is(err, errPrincipalNotFound) {
+			return nil, fmt.Errorf("%s %s not found in account %s", principalType, principalName, accountID)
+		}
+		return nil, err
+	}
+	return result, nil
+}
+
+// SimulatePrincipalPolicyAcrossAccounts evaluates the same request in every accessible account
+// where a user or role with the given name exists
+func (s *AWSService) SimulatePrincipalPolicyAcrossAccounts(principalType, principalName string, req models.SimulationRequest) ([]models.SimulationResult, error) {
+	if len(req.Actions) == 0 {
+		return nil, fmt.Errorf("invalid simulation request: at least one action is required")
+	}
+
+	accounts, err := s.listAccessibleAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	type accountResult struct {
+		result  *models.SimulationResult
+		err     error
+		account models.Account
+	}
+
+	resultChan := make(chan accountResult, len(accounts))
+	var wg sync.WaitGroup
+
+	for _, account := range accounts {
+		wg.Add(1)
+		go func(acc models.Account) {
+			defer wg.Done()
+			result, err := s.simulateInAccount(acc, principalType, principalName, req)
+			resultChan <- accountResult{result: result, err: err, account: acc}
+		}(account)
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultChan)
+	}()
+
+	results := []models.SimulationResult{}
+	for result := range resultChan {
+		switch {
+		case errors.Is(result.err, errPrincipalNotFound):
+			continue
+		case result.err != nil:
+			fmt.Printf("[WARNING] Policy simulation failed for %s %s in account %s: %v\n", principalType, principalName, result.account.ID, result.err)
+			results = append(results, models.SimulationResult{
+				AccountID:     result.account.ID,
+				AccountName:   result.account.Name,
+				PrincipalType: principalType,
+				PrincipalName: principalName,
+				Error:         result.err.Error(),
+			})
+		default:
+			results = append(results, *result.result)
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].AccountID < results[j].AccountID
+	})
+
+	return results, nil
+}
+
+// simulateInAccount resolves the principal in an account and runs the simulation
+func (s *AWSService) simulateInAccount(account models.Account, principalType, principalName string, req models.SimulationRequest) (*models.SimulationResult, error) {
+	sess, err := s.getSessionForAccount(account.ID)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access account %s: %w", account.ID, err)
+	}
+	iamClient := iam.New(sess)
+
+	principalArn, err := resolvePrincipalArn(iamClient, principalType, principalName)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &iam.SimulatePrincipalPolicyInput{
+		PolicySourceArn: aws.String(principalArn),
+		ActionNames:     aws.StringSlice(req.Actions),
+	}
+	for _, resource := range req.ResourceArns {
+		input.ResourceArns = append(input.ResourceArns, aws.String(strings.ReplaceAll(resource, "${AccountId}", account.ID)))
+	}
+	for _, entry := range req.ContextEntries {
+		input.ContextEntries = append(input.ContextEntries, &iam.ContextEntry{
+			ContextKeyName:   aws.String(entry.Key),
+			ContextKeyType:   aws.String(entry.Type),
+			ContextKeyValues: aws.StringSlice(entry.Values),
+		})
+	}
+
+	var evaluations []*iam.EvaluationResult
+	err = iamClient.SimulatePrincipalPolicyPages(input, func(page *iam.SimulatePolicyResponse, lastPage bool) bool {
+		evaluations = append(evaluations, page.EvaluationResults...)
+		return true
+	})
+	if err != nil {
+		if strings.Contains(err.Error(), iam.ErrCodeInvalidInputException) {
+			return nil, fmt.Errorf("invalid simulation request: %v", err)
+		}
+		return nil, fmt.Errorf("failed to simulate policy: %w", err)
+	}
+
+	result := &models.SimulationResult{
+		AccountID:     account.ID,
+		AccountName:   account.Name,
+		PrincipalType: principalType,
+		PrincipalName: principalName,
+		PrincipalArn:  principalArn,
+		Results:       convertEvaluationResults(evaluations),
+	}
+	result.AllAllowed = len(result.Results) > 0
+	for _, action := range result.Results {
+		if !action.Allowed {
+			result.AllAllowed = false
+			break
+		}
+	}
+
+	return result, nil
+}
+
+// resolvePrincipalArn looks up the ARN of a user or role
+func resolvePrincipalArn(iamClient *iam.IAM, principalType, principalName string) (string, error) {
+	switch principalType {
+	case "user":
+		result, err := iamClient.GetUser(&iam.GetUserInput{UserName: aws.String(principalName)})
+		if err != nil {
+			if strings.Contains(err.Error(), iam.ErrCodeNoSuchEntityException) {
+				return "", errPrincipalNotFound
+			}
+			return "", fmt.Errorf("failed to get user: %w", err)
+		}
+		return aws.StringValue(result.User.Arn), nil
+	case "role":
+		result, err := iamClient.GetRole(&iam.GetRoleInput{RoleName: aws.String(principalName)})
+		if err != nil {
+			if strings.Contains(err.Error(), iam.ErrCodeNoSuchEntityException) {
+				return "", errPrincipalNotFound
+			}
+			return "", fmt.Errorf("failed to get role: %w", err)
+		}
+		return aws.StringValue(result.Role.Arn), nil
+	}
+	return "", fmt.Errorf("invalid principal type %q", principalType)
+}
+
+// convertEvaluationResults flattens simulator results to one entry per action and resource
+func convertEvaluationResults(evaluations []*iam.EvaluationResult) []models.ActionSimulation {
+	var results []models.ActionSimulation
+	for _, evaluation := range evaluations {
+		base := models.ActionSimulation{
+			Action:   aws.StringValue(evaluation.EvalActionName),
+			Resource: aws.StringValue(evaluation.EvalResourceName),
+			Decision: aws.StringValue(evaluation.EvalDecision),
+		}
+		if detail := evaluation.OrganizationsDecisionDetail; detail != nil && !aws.BoolValue(detail.AllowedByOrganizations) {
+			base.DeniedByOrganization = true
+		}
+		if detail := evaluation.PermissionsBoundaryDecisionDetail; detail != nil && !aws.BoolValue(detail.AllowedByPermissionsBoundary) {
+			base.DeniedByBoundary = true
+		}
+
+		if len(evaluation.ResourceSpecificResults) == 0 {
+			base.MatchedStatements = convertMatchedStatements(evaluation.MatchedStatements)
+			base.MissingContextValues = aws.StringValueSlice(evaluation.MissingContextValues)
+			base.Allowed = base.Decision == models.SimulationAllowed
+			results = append(results, base)
+			continue
+		}
+
+		for _, resourceResult := range evaluation.ResourceSpecificResults {
+			action := base
+			action.Resource = aws.StringValue(resourceResult.EvalResourceName)
+			action.Decision = aws.StringValue(resourceResult.EvalResourceDecision)
+			action.Allowed = action.Decision == models.SimulationAllowed
+			action.MatchedStatements = convertMatchedStatements(resourceResult.MatchedStatements)
+			action.MissingContextValues = aws.StringValueSlice(resourceResult.MissingContextValues)
+			results = append(results, action)
+		}
+	}
+	return results
+}
+
+// convertMatchedStatements converts simulator statement references
+func convertMatchedStatements(statements []*iam.Statement) []models.MatchedStatement {
+	var matched []models.MatchedStatement
+	for _, statement := range statements {
+		entry := models.MatchedStatement{
+			SourcePolicyID:   aws.StringValue(statement.SourcePolicyId),
+			SourcePolicyType: aws.StringValue(statement.SourcePolicyType),
+		}
+		if statement.StartPosition != nil {
+			entry.StartLine = aws.Int64Value(statement.StartPosition.Line)
+		}
+		if statement.EndPosition != nil {
+			entry.EndLine = aws.Int64Value(statement.EndPosition.Line)
+		}
+		matched = append(matched, entry)
+	}
+	return matched
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertEvaluationResults(t *testing.T) {
+	evaluations := []*iam.EvaluationResult{
+		{
+			EvalActionName:   aws.String("s3:GetObject"),
+			EvalResourceName: aws.String("*"),
+			EvalDecision:     aws.String("allowed"),
+			MatchedStatements: []*iam.Statement{{
+				SourcePolicyId:   aws.String("ReadOnly"),
+				SourcePolicyType: aws.String("IAM Policy"),
+				StartPosition:    &iam.Position{Line: aws.Int64(3), Column: aws.Int64(5)},
+				EndPosition:      &iam.Position{Line: aws.Int64(9), Column: aws.Int64(6)},
+			}},
+		},
+		{
+			EvalActionName:              aws.String("s3:DeleteObject"),
+			EvalResourceName:            aws.String("*"),
+			EvalDecision:                aws.String("explicitDeny"),
+			OrganizationsDecisionDetail: &iam.OrganizationsDecisionDetail{AllowedByOrganizations: aws.Bool(false)},
+		},
+	}
+
+	results := convertEvaluationResults(evaluations)
+	require.Len(t, results, 2)
+
+	assert.True(t, results[0].Allowed)
+	assert.Equal(t, []models.MatchedStatement{{
+		SourcePolicyID:   "ReadOnly",
+		SourcePolicyType: "IAM Policy",
+		StartLine:        3,
+		EndLine:          9,
+	}}, results[0].MatchedStatements)
+
+	assert.False(t, results[1].Allowed)
+	assert.Equal(t, models.SimulationExplicitDeny, results[1].Decision)
+	assert.True(t, results[1].DeniedByOrganization)
+	assert.False(t, results[1].DeniedByBoundary)
+}
+
+func TestConvertEvaluationResultsPerResource(t *testing.T) {
+	evaluations := []*iam.EvaluationResult{{
+		EvalActionName:   aws.String("s3:PutObject"),
+		EvalResourceName: aws.String("*"),
+		EvalDecision:     aws.String("implicitDeny"),
+		ResourceSpecificResults: []*iam.ResourceSpecificResult{
+			{
+				EvalResourceName:     aws.String("arn:aws:s3:::logs/*"),
+				EvalResourceDecision: aws.String("allowed"),
+			},
+			{
+				EvalResourceName:     aws.String("arn:aws:s3:::secrets/*"),
+				EvalResourceDecision: aws.String("implicitDeny"),
+				MissingContextValues: aws.StringSlice([]string{"aws:SourceIp"}),
+			},
+		},
+	}}
+
+	results := convertEvaluationResults(evaluations)
+	require.Len(t, results, 2)
+
+	assert.Equal(t, "arn:aws:s3:::logs/*", results[0].Resource)
+	assert.True(t, results[0].Allowed)
+	assert.Equal(t, "arn:aws:s3:::secrets/*", results[1].Resource)
+	assert.False(t, results[1].Allowed)
+	assert.Equal(t, []string{"aws:SourceIp"}, results[1].MissingContextValues)
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// ============================================================================
+// SNAPSHOT CLASSIFICATION
+// ============================================================================
+//
+// Snapshots are enriched with whether their source volume still exists, which registered
+// AMIs they back and whether AWS Backup or Data Lifecycle Manager manages them. AMI-backed
+// and managed snapshots are protected from bulk deletion: deleting an AMI's snapshot fails,
+// and managed snapshots are expired by their own lifecycle.
+
+// regionSnapshotInventory is what a region's snapshots are classified against
+type regionSnapshotInventory struct {
+	snapshots       []*ec2.Snapshot
+	existingVolumes map[string]bool
+	amisBySnapshot  map[string][]string
+}
+
+// describeRegionSnapshots lists the account's snapshots in a region together with its volumes and AMIs
+func describeRegionSnapshots(ec2Client ec2iface.EC2API, accountID string) (*regionSnapshotInventory, error) {
+	inv := &regionSnapshotInventory{
+		existingVolumes: make(map[string]bool),
+		amisBySnapshot:  make(map[string][]string),
+	}
+
+	err := ec2Client.DescribeSnapshotsPages(&ec2.DescribeSnapshotsInput{
+		OwnerIds:   aws.StringSlice([]string{accountID}),
+		MaxResults: aws.Int64(sgScanPageSize),
+	}, func(page *ec2.DescribeSnapshotsOutput, lastPage bool) bool {
+		inv.snapshots = append(inv.snapshots, page.Snapshots...)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe snapshots: %v", err)
+	}
+	if len(inv.snapshots) == 0 {
+		return inv, nil
+	}
+
+	err = ec2Client.DescribeVolumesPages(&ec2.DescribeVolumesInput{
+		MaxResults: aws.Int64(sgScanPageSize),
+	}, func(page *ec2.DescribeVolumesOutput, lastPage bool) bool {
+		for _, volume := range page.Volumes {
+			inv.existingVolumes[aws.StringValue(volume.VolumeId)] = true
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe volumes: %v", err)
+	}
+
+	images, err := ec2Client.DescribeImages(&ec2.DescribeImagesInput{
+		Owners: aws.StringSlice([]string{"self"}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe images: %v", err)
+	}
+	inv.amisBySnapshot = amisBySnapshot(images.Images)
+
+	return inv, nil
+}
+
+// amisBySnapshot maps each snapshot ID to the AMIs whose block device mappings use it
+func amisBySnapshot(images []*ec2.Image) map[string][]string {
+	amis := make(map[string][]string)
+	for _, image := range images {
+		for _, mapping := range image.BlockDeviceMappings {
+			if mapping.Ebs == nil || mapping.Ebs.SnapshotId == nil {
+				continue
+			}
+			snapshotID := aws.StringValue(mapping.Ebs.SnapshotId)
+			amis[snapshotID] = appendUnique(amis[snapshotID], aws.StringValue(image.ImageId))
+		}
+	}
+	return amis
+}
+
+// classifySnapshot fills in volume existence, AMI references, lifecycle manager and protection
+func classifySnapshot(snapshot *models.Snapshot, existingVolumes map[string]bool, amis map[string][]string) {
+	snapshot.VolumeExists = existingVolumes[snapshot.VolumeID]
+	snapshot.AMIIDs = amis[snapshot.SnapshotID]
+	snapshot.ManagedBy = snapshotManager(snapshot.Tags, snapshot.Description)
+	snapshot.Orphaned = !snapshot.VolumeExists && len(snapshot.AMIIDs) == 0
+	snapshot.ProtectedReason = snapshotProtectionReason(*snapshot)
+}
+
+// snapshotManager recognises AWS Backup and Data Lifecycle Manager snapshots by their system tags or description
+func snapshotManager(tags []models.Tag, description string) string {
+	for _, tag := range tags {
+		switch {
+		case strings.HasPrefix(tag.Key, "aws:backup:"):
+			return models.SnapshotManagedByBackup
+		case strings.HasPrefix(tag.Key, "aws:dlm:"):
+			return models.SnapshotManagedByDLM
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(description, "This snapshot is created by the AWS Backup service"):
+		return models.SnapshotManagedByBackup
+	case strings.HasPrefix(description, "Created for policy: policy-"):
+		return models.SnapshotManagedByDLM
+	}
+	return ""
+}
+
+// snapshotProtectionReason explains why bulk deletion must skip a snapshot, or returns "" when it may be deleted
+func snapshotProtectionReason(snapshot models.Snapshot) string {
+	switch {
+	case len(snapshot.AMIIDs) > 0:
+		return fmt.Sprintf("backs registered AMI %s", strings.Join(snapshot.AMIIDs, ", "))
+	case snapshot.ManagedBy == models.SnapshotManagedByBackup:
+		return "managed by AWS Backup"
+	case snapshot.ManagedBy == models.SnapshotManagedByDLM:
+		return "managed by Data Lifecycle Manager"
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAMIsBySnapshot(t *testing.T) {
+	images := []*ec2.Image{
+		{ImageId: aws.String("ami-1"), BlockDeviceMappings: []*ec2.BlockDeviceMapping{
+			{Ebs: &ec2.EbsBlockDevice{SnapshotId: aws.String("snap-root")}},
+			{Ebs: &ec2.EbsBlockDevice{SnapshotId: aws.String("snap-data")}},
+			{VirtualName: aws.String("ephemeral0")},
+		}},
+		{ImageId: aws.String("ami-2"), BlockDeviceMappings: []*ec2.BlockDeviceMapping{
+			{Ebs: &ec2.EbsBlockDevice{SnapshotId: aws.String("snap-root")}},
+		}},
+	}
+
+	amis := amisBySnapshot(images)
+	assert.Equal(t, []string{"ami-1", "ami-2"}, amis["snap-root"])
+	assert.Equal(t, []string{"ami-1"}, amis["snap-data"])
+	assert.Len(t, amis, 2)
+}
+
+func TestSnapshotManager(t *testing.T) {
+	assert.Equal(t, models.SnapshotManagedByBackup, snapshotManager([]models.Tag{{Key: "aws:backup:source-resource", Value: "vol-1"}}, ""))
+	assert.Equal(t, models.SnapshotManagedByDLM, snapshotManager([]models.Tag{{Key: "aws:dlm:lifecycle-policy-id", Value: "policy-1"}}, ""))
+	assert.Equal(t, models.SnapshotManagedByBackup, snapshotManager(nil, "This snapshot is created by the AWS Backup service."))
+	assert.Equal(t, models.SnapshotManagedByDLM, snapshotManager(nil, "Created for policy: policy-0123 schedule: Daily"))
+	assert.Equal(t, "", snapshotManager([]models.Tag{{Key: "Name", Value: "nightly"}}, "manual backup"))
+}
+
+func TestClassifySnapshot(t *testing.T) {
+	volumes := map[string]bool{"vol-live": true}
+	amis := map[string][]string{"snap-ami": {"ami-1"}}
+
+	tests := []struct {
+		name      string
+		snapshot  models.Snapshot
+		exists    bool
+		orphaned  bool
+		protected string
+	}{
+		{"live volume", models.Snapshot{SnapshotID: "snap-1", VolumeID: "vol-live"}, true, false, ""},
+		{"orphaned", models.Snapshot{SnapshotID: "snap-2", VolumeID: "vol-gone"}, false, true, ""},
+		{"ami backed", models.Snapshot{SnapshotID: "snap-ami", VolumeID: "vol-gone"}, false, false, "backs registered AMI ami-1"},
+		{"copied", models.Snapshot{SnapshotID: "snap-3", VolumeID: "vol-ffffffff"}, false, true, ""},
+		{"backup", models.Snapshot{SnapshotID: "snap-4", VolumeID: "vol-live", Tags: []models.Tag{{Key: "aws:backup:source-resource"}}}, true, false, "managed by AWS Backup"},
+	}
+
+	for _, tt := range tests {
+		snapshot := tt.snapshot
+		classifySnapshot(&snapshot, volumes, amis)
+		assert.Equal(t, tt.exists, snapshot.VolumeExists, tt.name)
+		assert.Equal(t, tt.orphaned, snapshot.Orphaned, tt.name)
+		assert.Equal(t, tt.protected, snapshot.ProtectedReason, tt.name)
+	}
+}
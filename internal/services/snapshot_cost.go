@@ -0,0 +1,289 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ebs"
+	"github.com/aws/aws-sdk-go/service/ebs/ebsiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+)
+
+// ============================================================================
+// SNAPSHOT COST AND ARCHIVE TIER
+// ============================================================================
+
+// Snapshot storage pricing per GB-month (US East N. Virginia baseline):
+// - standard: $0.05, billed for blocks changed since the previous snapshot of the volume
+// - archive:  $0.0125, billed for all blocks written to the snapshot, minimum 90 days
+//
+// The price list does not carry snapshot prices yet, so snapshots in every region are priced
+// at these US East rates.
+const (
+	snapshotStandardPricePerGB = 0.05
+	snapshotArchivePricePerGB  = 0.0125
+)
+
+// calculateSnapshotMonthlyCost returns the monthly storage cost of a snapshot of the given size
+func calculateSnapshotMonthlyCost(storageTier string, sizeGiB float64) float64 {
+	price := snapshotStandardPricePerGB
+	if storageTier == models.SnapshotTierArchive {
+		price = snapshotArchivePricePerGB
+	}
+	return math.Round(sizeGiB*price*100) / 100 // Round to 2 decimal places
+}
+
+// applySnapshotCost estimates a snapshot's size from its volume and prices it. Without the EBS
+// direct APIs the volume size is the best available figure, but only an upper bound: archived
+// snapshots and the first snapshot of a volume are billed for the blocks actually written,
+// later standard-tier snapshots only for the blocks changed since the previous one.
+func applySnapshotCost(snapshot *models.Snapshot) {
+	if snapshot.StorageTier == "" {
+		snapshot.StorageTier = models.SnapshotTierStandard
+	}
+	snapshot.EstimatedSizeGiB = float64(snapshot.VolumeSize)
+	snapshot.SizeEstimate = models.SnapshotSizeVolume
+	snapshot.MonthlyCost = calculateSnapshotMonthlyCost(snapshot.StorageTier, snapshot.EstimatedSizeGiB)
+}
+
+// GetSnapshotCostReport totals estimated snapshot storage cost per account and region, for one
+// account or the whole organization. With estimateIncremental, standard-tier sizes are measured
+// from changed blocks with the EBS direct APIs, which takes one or more calls per snapshot.
+func (s *AWSService) GetSnapshotCostReport(accountID string, estimateIncremental bool) (*models.SnapshotCostReport, error) {
+	cacheKey := fmt.Sprintf("snapshots:costs:%s:%t", accountID, estimateIncremental)
+	if cached, found := s.cache.Get(cacheKey); found {
+		if report, ok := cached.(*models.SnapshotCostReport); ok {
+			return report, nil
+		}
+	}
+
+	var snapshots []models.Snapshot
+	var err error
+	if accountID != "" {
+		snapshots, err = s.ListSnapshotsByAccount(accountID)
+	} else {
+		snapshots, err = s.ListSnapshots()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Work on a copy so measured sizes do not leak into the cached listing
+	snapshots = append([]models.Snapshot(nil), snapshots...)
+	if estimateIncremental {
+		s.measureIncrementalSnapshotSizes(snapshots)
+	}
+
+	report := &models.SnapshotCostReport{
+		Totals:              aggregateSnapshotCosts(snapshots),
+		TotalSnapshots:      len(snapshots),
+		IncrementalEstimate: estimateIncremental,
+		GeneratedAt:         time.Now(),
+	}
+	for _, total := range report.Totals {
+		report.TotalMonthlyCost += total.MonthlyCost
+	}
+	report.TotalMonthlyCost = math.Round(report.TotalMonthlyCost*100) / 100
+
+	s.cache.Set(cacheKey, report, s.cacheTTL)
+	return report, nil
+}
+
+// measureIncrementalSnapshotSizes measures standard-tier snapshots per account and region in parallel
+func (s *AWSService) measureIncrementalSnapshotSizes(snapshots []models.Snapshot) {
+	type regionKey struct{ accountID, region string }
+	byRegion := make(map[regionKey][]*models.Snapshot)
+	for i := range snapshots {
+		key := regionKey{snapshots[i].AccountID, snapshots[i].Region}
+		byRegion[key] = append(byRegion[key], &snapshots[i])
+	}
+
+	var wg sync.WaitGroup
+	for key, regionSnapshots := range byRegion {
+		sess, err := s.getSessionForAccount(key.accountID)
+		if err != nil {
+			fmt.Printf("[WARNING] Cannot access account %s, keeping volume size estimates: %v\n", key.accountID, err)
+			continue
+		}
+
+		wg.Add(1)
+		go func(region string, regionSnapshots []*models.Snapshot) {
+			defer wg.Done()
+			ebsClient := ebs.New(sess.Copy(&aws.Config{Region: aws.String(region)}))
+			estimateIncrementalSizes(ebsClient, regionSnapshots)
+		}(key.region, regionSnapshots)
+	}
+	wg.Wait()
+}
+
+// estimateIncrementalSizes sets each completed standard-tier snapshot's size to the blocks it adds over
+// the previous snapshot of the same volume. Snapshots that cannot be measured keep their volume size.
+func estimateIncrementalSizes(ebsClient ebsiface.EBSAPI, snapshots []*models.Snapshot) {
+	byVolume := make(map[string][]*models.Snapshot)
+	for _, snapshot := range snapshots {
+		if snapshot.StorageTier == models.SnapshotTierArchive || snapshot.State != "completed" {
+			continue
+		}
+		byVolume[snapshot.VolumeID] = append(byVolume[snapshot.VolumeID], snapshot)
+	}
+
+	for _, volumeSnapshots := range byVolume {
+		sort.Slice(volumeSnapshots, func(i, j int) bool {
+			return volumeSnapshots[i].StartTime.Before(volumeSnapshots[j].StartTime)
+		})
+
+		for i, snapshot := range volumeSnapshots {
+			var bytes int64
+			var err error
+			if i == 0 {
+				bytes, err = snapshotBlockBytes(ebsClient, snapshot.SnapshotID)
+			} else {
+				bytes, err = changedBlockBytes(ebsClient, volumeSnapshots[i-1].SnapshotID, snapshot.SnapshotID)
+			}
+			if err != nil {
+				fmt.Printf("[WARNING] Failed to measure snapshot %s, keeping volume size estimate: %v\n", snapshot.SnapshotID, err)
+				continue
+			}
+
+			snapshot.EstimatedSizeGiB = math.Round(float64(bytes)/(1<<30)*100) / 100
+			snapshot.SizeEstimate = models.SnapshotSizeChangedBlocks
+			snapshot.MonthlyCost = calculateSnapshotMonthlyCost(snapshot.StorageTier, snapshot.EstimatedSizeGiB)
+		}
+	}
+}
+
+// snapshotBlockBytes returns the bytes written in a snapshot
+func snapshotBlockBytes(ebsClient ebsiface.EBSAPI, snapshotID string) (int64, error) {
+	var total int64
+	err := ebsClient.ListSnapshotBlocksPages(&ebs.ListSnapshotBlocksInput{
+		SnapshotId: aws.String(snapshotID),
+	}, func(page *ebs.ListSnapshotBlocksOutput, lastPage bool) bool {
+		total += int64(len(page.Blocks)) * aws.Int64Value(page.BlockSize)
+		return true
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list blocks of snapshot %s: %v", snapshotID, err)
+	}
+	return total, nil
+}
+
+// changedBlockBytes returns the bytes that differ between two snapshots of the same volume
+func changedBlockBytes(ebsClient ebsiface.EBSAPI, firstSnapshotID, secondSnapshotID string) (int64, error) {
+	var total int64
+	err := ebsClient.ListChangedBlocksPages(&ebs.ListChangedBlocksInput{
+		FirstSnapshotId:  aws.String(firstSnapshotID),
+		SecondSnapshotId: aws.String(secondSnapshotID),
+	}, func(page *ebs.ListChangedBlocksOutput, lastPage bool) bool {
+		total += int64(len(page.ChangedBlocks)) * aws.Int64Value(page.BlockSize)
+		return true
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list changed blocks of snapshot %s: %v", secondSnapshotID, err)
+	}
+	return total, nil
+}
+
+// aggregateSnapshotCosts totals snapshots per account and region, ordered by cost
+func aggregateSnapshotCosts(snapshots []models.Snapshot) []models.SnapshotCostTotal {
+	type regionKey struct{ accountID, region string }
+	totals := make(map[regionKey]*models.SnapshotCostTotal)
+	for _, snapshot := range snapshots {
+		key := regionKey{snapshot.AccountID, snapshot.Region}
+		total, ok := totals[key]
+		if !ok {
+			total = &models.SnapshotCostTotal{AccountID: snapshot.AccountID, AccountName: snapshot.AccountName, Region: snapshot.Region}
+			totals[key] = total
+		}
+
+		total.SnapshotCount++
+		if snapshot.StorageTier == models.SnapshotTierArchive {
+			total.ArchiveCount++
+			total.ArchiveGiB += snapshot.EstimatedSizeGiB
+		} else {
+			total.StandardCount++
+			total.StandardGiB += snapshot.EstimatedSizeGiB
+		}
+		total.MonthlyCost += snapshot.MonthlyCost
+	}
+
+	result := make([]models.SnapshotCostTotal, 0, len(totals))
+	for _, total := range totals {
+		total.StandardGiB = math.Round(total.StandardGiB*100) / 100
+		total.ArchiveGiB = math.Round(total.ArchiveGiB*100) / 100
+		total.MonthlyCost = math.Round(total.MonthlyCost*100) / 100
+		result = append(result, *total)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].MonthlyCost != result[j].MonthlyCost {
+			return result[i].MonthlyCost > result[j].MonthlyCost
+		}
+		if result[i].AccountID != result[j].AccountID {
+			return result[i].AccountID < result[j].AccountID
+		}
+		return result[i].Region < result[j].Region
+	})
+	return result
+}
+
+// ArchiveSnapshot moves a completed snapshot to the archive tier
+func (s *AWSService) ArchiveSnapshot(accountID, region, snapshotID string) error {
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+
+	ec2Client := ec2.New(sess.Copy(&aws.Config{Region: aws.String(region)}))
+	_, err = ec2Client.ModifySnapshotTier(&ec2.ModifySnapshotTierInput{
+		SnapshotId:  aws.String(snapshotID),
+		StorageTier: aws.String(ec2.TargetStorageTierArchive),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to archive snapshot %s: %v", snapshotID, err)
+	}
+
+	s.invalidateAccountSnapshotCache(accountID)
+	return nil
+}
+
+// RestoreArchivedSnapshot restores an archived snapshot to the standard tier, permanently or for a number of days
+func (s *AWSService) RestoreArchivedSnapshot(accountID, region, snapshotID string, input models.SnapshotRestoreInput) error {
+	if !input.Permanent && input.TemporaryRestoreDays <= 0 {
+		return fmt.Errorf("invalid restore: set permanent or temporary_restore_days")
+	}
+	if input.Permanent && input.TemporaryRestoreDays > 0 {
+		return fmt.Errorf("invalid restore: permanent and temporary_restore_days are mutually exclusive")
+	}
+
+	sess, err := s.getSessionForAccount(accountID)
+	if err != nil {
+		return fmt.Errorf("cannot access account %s: %w", accountID, err)
+	}
+
+	restoreInput := &ec2.RestoreSnapshotTierInput{SnapshotId: aws.String(snapshotID)}
+	if input.Permanent {
+		restoreInput.PermanentRestore = aws.Bool(true)
+	} else {
+		restoreInput.TemporaryRestoreDays = aws.Int64(input.TemporaryRestoreDays)
+	}
+
+	ec2Client := ec2.New(sess.Copy(&aws.Config{Region: aws.String(region)}))
+	if _, err := ec2Client.RestoreSnapshotTier(restoreInput); err != nil {
+		return fmt.Errorf("failed to restore snapshot %s: %v", snapshotID, err)
+	}
+
+	s.invalidateAccountSnapshotCache(accountID)
+	return nil
+}
+
+// invalidateAccountSnapshotCache drops cached snapshot listings and cost reports that include an account
+func (s *AWSService) invalidateAccountSnapshotCache(accountID string) {
+	s.cache.Delete(fmt.Sprintf("snapshots:%s", accountID))
+	s.cache.Delete("snapshots")
+	s.cache.DeletePattern("snapshots:costs:")
+}
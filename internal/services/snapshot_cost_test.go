@@ -0,0 +1,99 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ebs"
+	"github.com/aws/aws-sdk-go/service/ebs/ebsiface"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEBS returns a fixed number of 512 KiB blocks per snapshot or snapshot pair
+type fakeEBS struct {
+	ebsiface.EBSAPI
+	blocks  map[string]int // Snapshot ID, or "first:second" for changed blocks
+	failing string
+}
+
+func (f *fakeEBS) ListSnapshotBlocksPages(input *ebs.ListSnapshotBlocksInput, fn func(*ebs.ListSnapshotBlocksOutput, bool) bool) error {
+	id := aws.StringValue(input.SnapshotId)
+	if id == f.failing {
+		return errors.New("AccessDeniedException")
+	}
+	fn(&ebs.ListSnapshotBlocksOutput{Blocks: make([]*ebs.Block, f.blocks[id]), BlockSize: aws.Int64(512 * 1024)}, true)
+	return nil
+}
+
+func (f *fakeEBS) ListChangedBlocksPages(input *ebs.ListChangedBlocksInput, fn func(*ebs.ListChangedBlocksOutput, bool) bool) error {
+	id := aws.StringValue(input.SecondSnapshotId)
+	if id == f.failing {
+		return errors.New("AccessDeniedException")
+	}
+	key := aws.StringValue(input.FirstSnapshotId) + ":" + id
+	fn(&ebs.ListChangedBlocksOutput{ChangedBlocks: make([]*ebs.ChangedBlock, f.blocks[key]), BlockSize: aws.Int64(512 * 1024)}, true)
+	return nil
+}
+
+func TestApplySnapshotCost(t *testing.T) {
+	standard := models.Snapshot{VolumeSize: 100}
+	applySnapshotCost(&standard)
+	assert.Equal(t, models.SnapshotTierStandard, standard.StorageTier)
+	assert.Equal(t, models.SnapshotSizeVolume, standard.SizeEstimate)
+	assert.Equal(t, 5.0, standard.MonthlyCost)
+
+	archived := models.Snapshot{VolumeSize: 100, StorageTier: models.SnapshotTierArchive}
+	applySnapshotCost(&archived)
+	assert.Equal(t, models.SnapshotSizeVolume, archived.SizeEstimate)
+	assert.Equal(t, 1.25, archived.MonthlyCost)
+}
+
+func TestEstimateIncrementalSizes(t *testing.T) {
+	base := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	snapshots := []*models.Snapshot{
+		{SnapshotID: "snap-2", VolumeID: "vol-1", VolumeSize: 100, State: "completed", StartTime: base.AddDate(0, 0, 1)},
+		{SnapshotID: "snap-1", VolumeID: "vol-1", VolumeSize: 100, State: "completed", StartTime: base},
+		{SnapshotID: "snap-3", VolumeID: "vol-1", VolumeSize: 100, State: "completed", StartTime: base.AddDate(0, 0, 2)},
+		{SnapshotID: "snap-archived", VolumeID: "vol-1", VolumeSize: 100, State: "completed", StartTime: base.AddDate(0, 0, -1), StorageTier: models.SnapshotTierArchive},
+	}
+	for _, snapshot := range snapshots {
+		applySnapshotCost(snapshot)
+	}
+
+	estimateIncrementalSizes(&fakeEBS{
+		blocks:  map[string]int{"snap-1": 40960, "snap-1:snap-2": 2048}, // 20 GiB, then 1 GiB
+		failing: "snap-3",
+	}, snapshots)
+
+	assert.Equal(t, 1.0, snapshots[0].EstimatedSizeGiB)
+	assert.Equal(t, models.SnapshotSizeChangedBlocks, snapshots[0].SizeEstimate)
+	assert.Equal(t, 0.05, snapshots[0].MonthlyCost)
+	assert.Equal(t, 20.0, snapshots[1].EstimatedSizeGiB)
+	assert.Equal(t, 1.0, snapshots[1].MonthlyCost)
+	assert.Equal(t, models.SnapshotSizeVolume, snapshots[2].SizeEstimate, "unmeasurable snapshots keep the volume size")
+	assert.Equal(t, models.SnapshotSizeVolume, snapshots[3].SizeEstimate, "archived snapshots cannot be measured")
+}
+
+func TestAggregateSnapshotCosts(t *testing.T) {
+	snapshots := []models.Snapshot{
+		{AccountID: "1", Region: "us-east-1", StorageTier: models.SnapshotTierStandard, EstimatedSizeGiB: 10, MonthlyCost: 0.5},
+		{AccountID: "1", Region: "us-east-1", StorageTier: models.SnapshotTierArchive, EstimatedSizeGiB: 100, MonthlyCost: 1.25},
+		{AccountID: "2", Region: "eu-west-1", StorageTier: models.SnapshotTierStandard, EstimatedSizeGiB: 200, MonthlyCost: 10},
+	}
+
+	totals := aggregateSnapshotCosts(snapshots)
+	assert.Equal(t, []models.SnapshotCostTotal{
+		{AccountID: "2", Region: "eu-west-1", SnapshotCount: 1, StandardCount: 1, StandardGiB: 200, MonthlyCost: 10},
+		{AccountID: "1", Region: "us-east-1", SnapshotCount: 2, StandardCount: 1, ArchiveCount: 1, StandardGiB: 10, ArchiveGiB: 100, MonthlyCost: 1.75},
+	}, totals)
+}
+
+func TestRestoreArchivedSnapshotValidation(t *testing.T) {
+	s := &AWSService{}
+	assert.ErrorContains(t, s.RestoreArchivedSnapshot("1", "us-east-1", "snap-1", models.SnapshotRestoreInput{}), "invalid restore")
+	assert.ErrorContains(t, s.RestoreArchivedSnapshot("1", "us-east-1", "snap-1", models.SnapshotRestoreInput{Permanent: true, TemporaryRestoreDays: 5}), "mutually exclusive")
+}
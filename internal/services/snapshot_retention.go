@@ -0,0 +1,517 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/organizations"
+	"github.com/google/uuid"
+)
+
+// ============================================================================
+// SNAPSHOT RETENTION POLICIES
+// ============================================================================
+//
+// A policy is an ordered list of rules and each snapshot is governed by the first rule it
+// matches. Within a rule, snapshots are grouped by source volume and kept when they are
+// among the newest keep_last, or the newest of one of the last keep_daily days, keep_weekly
+// ISO weeks or keep_monthly months (grandfather-father-son). max_age_days deletes anything
+// older regardless. Protected snapshots (AMI-backed or managed by AWS Backup or DLM),
+// snapshots with a never-delete tag and snapshots no rule matches are always kept.
+
+// ErrRetentionPolicyNotFound is returned for unknown policy IDs
+var ErrRetentionPolicyNotFound = errors.New("retention policy not found")
+
+// retentionPolicyStore holds retention policies and persists them to the state directory
+type retentionPolicyStore struct {
+	mu       sync.Mutex
+	policies map[string]*models.SnapshotRetentionPolicy
+	file     *stateFile
+}
+
+func newRetentionPolicyStore(stateDir string) *retentionPolicyStore {
+	store := &retentionPolicyStore{
+		policies: make(map[string]*models.SnapshotRetentionPolicy),
+		file:     newStateFile(stateDir, "snapshot-retention-policies.json"),
+	}
+
+	var stored []*models.SnapshotRetentionPolicy
+	if err := store.file.Load(&stored); err != nil {
+		fmt.Printf("[WARNING] Failed to load snapshot retention policies: %v\n", err)
+	}
+	for _, policy := range stored {
+		store.policies[policy.ID] = policy
+	}
+
+	return store
+}
+
+// save persists all policies; callers must hold mu
+func (st *retentionPolicyStore) save() {
+	list := make([]*models.SnapshotRetentionPolicy, 0, len(st.policies))
+	for _, policy := range st.policies {
+		list = append(list, policy)
+	}
+	if err := st.file.Save(list); err != nil {
+		fmt.Printf("[WARNING] Failed to persist snapshot retention policies: %v\n", err)
+	}
+}
+
+// ListRetentionPolicies returns all retention policies ordered by name
+func (s *AWSService) ListRetentionPolicies() []models.SnapshotRetentionPolicy {
+	s.retentionPolicies.mu.Lock()
+	defer s.retentionPolicies.mu.Unlock()
+
+	policies := make([]models.SnapshotRetentionPolicy, 0, len(s.retentionPolicies.policies))
+	for _, policy := range s.retentionPolicies.policies {
+		policies = append(policies, *policy)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return policies[i].Name < policies[j].Name
+	})
+	return policies
+}
+
+// GetRetentionPolicy returns a retention policy by ID
+func (s *AWSService) GetRetentionPolicy(policyID string) (*models.SnapshotRetentionPolicy, error) {
+	s.retentionPolicies.mu.Lock()
+	defer s.retentionPolicies.mu.Unlock()
+
+	policy, ok := s.retentionPolicies.policies[policyID]
+	if !ok {
+		return nil, ErrRetentionPolicyNotFound
+	}
+	copied := *policy
+	return &copied, nil
+}
+
+// CreateRetentionPolicy validates and stores a new retention policy
+func (s *AWSService) CreateRetentionPolicy(policy models.SnapshotRetentionPolicy) (*models.SnapshotRetentionPolicy, error) {
+	if _, err := compileRetentionPolicy(policy); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	policy.ID = uuid.New().String()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+
+	s.retentionPolicies.mu.Lock()
+	defer s.retentionPolicies.mu.Unlock()
+	s.retentionPolicies.policies[policy.ID] = &policy
+	s.retentionPolicies.save()
+
+	copied := policy
+	return &copied, nil
+}
+
+// UpdateRetentionPolicy replaces the name, description and rules of a retention policy
+func (s *AWSService) UpdateRetentionPolicy(policyID string, policy models.SnapshotRetentionPolicy) (*models.SnapshotRetentionPolicy, error) {
+	if _, err := compileRetentionPolicy(policy); err != nil {
+		return nil, err
+	}
+
+	s.retentionPolicies.mu.Lock()
+	defer s.retentionPolicies.mu.Unlock()
+
+	existing, ok := s.retentionPolicies.policies[policyID]
+	if !ok {
+		return nil, ErrRetentionPolicyNotFound
+	}
+	existing.Name = policy.Name
+	existing.Description = policy.Description
+	existing.Rules = policy.Rules
+	existing.UpdatedAt = time.Now()
+	s.retentionPolicies.save()
+
+	copied := *existing
+	return &copied, nil
+}
+
+// DeleteRetentionPolicy removes a retention policy
+func (s *AWSService) DeleteRetentionPolicy(policyID string) error {
+	s.retentionPolicies.mu.Lock()
+	defer s.retentionPolicies.mu.Unlock()
+
+	if _, ok := s.retentionPolicies.policies[policyID]; !ok {
+		return ErrRetentionPolicyNotFound
+	}
+	delete(s.retentionPolicies.policies, policyID)
+	s.retentionPolicies.save()
+	return nil
+}
+
+// PreviewRetentionPolicy returns the keep or delete decision for every snapshot in scope
+func (s *AWSService) PreviewRetentionPolicy(policyID string, accountIDs []string) (*models.SnapshotRetentionPreview, error) {
+	policy, err := s.GetRetentionPolicy(policyID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := compileRetentionPolicy(*policy)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []models.Snapshot
+	if len(accountIDs) > 0 {
+		for _, accountID := range accountIDs {
+			accountSnapshots, err := s.ListSnapshotsByAccount(accountID)
+			if err != nil {
+				return nil, err
+			}
+			snapshots = append(snapshots, accountSnapshots...)
+		}
+	} else if snapshots, err = s.ListSnapshots(); err != nil {
+		return nil, err
+	}
+
+	// Resolve organizational units only when a rule needs them
+	accountOUs := make(map[string][]string)
+	for _, rule := range policy.Rules {
+		if len(rule.Match.OUIDs) == 0 {
+			continue
+		}
+		for _, snapshot := range snapshots {
+			if _, done := accountOUs[snapshot.AccountID]; done {
+				continue
+			}
+			ous, err := s.accountOrganizationalUnits(snapshot.AccountID)
+			if err != nil {
+				return nil, err
+			}
+			accountOUs[snapshot.AccountID] = ous
+		}
+		break
+	}
+
+	now := time.Now()
+	preview := &models.SnapshotRetentionPreview{
+		PolicyID:    policyID,
+		GeneratedAt: now,
+		Decisions:   evaluateRetentionRules(rules, snapshots, accountOUs, now),
+	}
+	for _, decision := range preview.Decisions {
+		if decision.Action == models.RetentionDelete {
+			preview.DeleteCount++
+		} else {
+			preview.KeepCount++
+		}
+	}
+	return preview, nil
+}
+
+// StartRetentionPolicyRun deletes the snapshots a policy's preview marks for deletion as a background job
+func (s *AWSService) StartRetentionPolicyRun(policyID string, input models.SnapshotRetentionRunInput) (models.Job, error) {
+	preview, err := s.PreviewRetentionPolicy(policyID, input.AccountIDs)
+	if err != nil {
+		return models.Job{}, err
+	}
+
+	var targets []models.SnapshotRetentionDecision
+	for _, decision := range preview.Decisions {
+		if decision.Action == models.RetentionDelete {
+			targets = append(targets, decision)
+		}
+	}
+
+	description := fmt.Sprintf("Apply retention policy %s: delete %d snapshot(s)", policyID, len(targets))
+	if input.DryRun {
+		description = "Dry run: " + description
+	}
+
+	job := s.jobs.start("snapshot_retention", description, func(jc *jobContext) (any, error) {
+		jc.AddTotal(len(targets))
+
+		for _, target := range targets {
+			reasons := strings.Join(target.Reasons, "; ")
+			result := models.JobItemResult{
+				AccountID:  target.AccountID,
+				Region:     target.Region,
+				ResourceID: target.SnapshotID,
+				Status:     "succeeded",
+				Message:    "Deleted: " + reasons,
+			}
+
+			if input.DryRun {
+				result.Status = "skipped"
+				result.Message = "Would delete: " + reasons
+				jc.AddResult(result)
+				continue
+			}
+
+			if err := s.DeleteSnapshot(target.AccountID, target.Region, target.SnapshotID); err != nil {
+				result.Status = "failed"
+				if strings.Contains(err.Error(), "NotFound") {
+					result.Status = "skipped"
+				}
+				result.Message = err.Error()
+			}
+			jc.AddResult(result)
+		}
+		return preview, nil
+	})
+
+	return job, nil
+}
+
+// accountOrganizationalUnits returns every OU containing an account, from its direct parent up to the root
+func (s *AWSService) accountOrganizationalUnits(accountID string) ([]string, error) {
+	cacheKey := fmt.Sprintf("account-ous:%s", accountID)
+	if cached, found := s.cache.Get(cacheKey); found {
+		if ous, ok := cached.([]string); ok {
+			return ous, nil
+		}
+	}
+
+	orgClient := organizations.New(s.masterSession)
+	ous := []string{}
+	childID := accountID
+	for {
+		result, err := orgClient.ListParents(&organizations.ListParentsInput{ChildId: aws.String(childID)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list parents of %s: %v", childID, err)
+		}
+		if len(result.Parents) == 0 || aws.StringValue(result.Parents[0].Type) != organizations.ParentTypeOrganizationalUnit {
+			break
+		}
+		childID = aws.StringValue(result.Parents[0].Id)
+		ous = append(ous, childID)
+	}
+
+	s.cache.Set(cacheKey, ous, s.cacheTTL)
+	return ous, nil
+}
+
+// compiledRetentionRule is a rule with its description pattern compiled
+type compiledRetentionRule struct {
+	models.SnapshotRetentionRule
+	description *regexp.Regexp
+}
+
+// compileRetentionPolicy validates a policy and compiles its rules
+func compileRetentionPolicy(policy models.SnapshotRetentionPolicy) ([]compiledRetentionRule, error) {
+	if strings.TrimSpace(policy.Name) == "" {
+		return nil, fmt.Errorf("invalid retention policy: name is required")
+	}
+	if len(policy.Rules) == 0 {
+		return nil, fmt.Errorf("invalid retention policy: at least one rule is required")
+	}
+
+	rules := make([]compiledRetentionRule, 0, len(policy.Rules))
+	for i := range policy.Rules {
+		// Unnamed rules are named by position; this also names them in the caller's policy
+		if policy.Rules[i].Name == "" {
+			policy.Rules[i].Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		rule := policy.Rules[i]
+		if rule.KeepLast < 0 || rule.KeepDaily < 0 || rule.KeepWeekly < 0 || rule.KeepMonthly < 0 || rule.MaxAgeDays < 0 {
+			return nil, fmt.Errorf("invalid retention rule %q: counts must not be negative", rule.Name)
+		}
+		if !retentionRuleHasKeepCounts(rule) && rule.MaxAgeDays == 0 {
+			return nil, fmt.Errorf("invalid retention rule %q: set keep_last, keep_daily, keep_weekly, keep_monthly or max_age_days", rule.Name)
+		}
+
+		compiled := compiledRetentionRule{SnapshotRetentionRule: rule}
+		if rule.Match.DescriptionPattern != "" {
+			re, err := regexp.Compile(rule.Match.DescriptionPattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid retention rule %q: description_pattern: %v", rule.Name, err)
+			}
+			compiled.description = re
+		}
+		rules = append(rules, compiled)
+	}
+	return rules, nil
+}
+
+func retentionRuleHasKeepCounts(rule models.SnapshotRetentionRule) bool {
+	return rule.KeepLast > 0 || rule.KeepDaily > 0 || rule.KeepWeekly > 0 || rule.KeepMonthly > 0
+}
+
+// matches reports whether a snapshot meets all of a rule's match criteria
+func (r compiledRetentionRule) matches(snapshot models.Snapshot, accountOUs map[string][]string) bool {
+	match := r.Match
+	if len(match.AccountIDs) > 0 && !containsString(match.AccountIDs, snapshot.AccountID) {
+		return false
+	}
+	if len(match.VolumeIDs) > 0 && !containsString(match.VolumeIDs, snapshot.VolumeID) {
+		return false
+	}
+	if r.description != nil && !r.description.MatchString(snapshot.Description) {
+		return false
+	}
+	if len(match.OUIDs) > 0 {
+		found := false
+		for _, ou := range accountOUs[snapshot.AccountID] {
+			if containsString(match.OUIDs, ou) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	for key, value := range match.Tags {
+		tagValue, ok := snapshotTag(snapshot.Tags, key)
+		if !ok || (value != "*" && value != tagValue) {
+			return false
+		}
+	}
+	return true
+}
+
+// snapshotTag returns the value of a tag
+func snapshotTag(tags []models.Tag, key string) (string, bool) {
+	for _, tag := range tags {
+		if tag.Key == key {
+			return tag.Value, true
+		}
+	}
+	return "", false
+}
+
+// neverDeleteTag returns the first never-delete tag ("key" or "key=value") a snapshot carries
+func neverDeleteTag(tags []models.Tag, neverDelete []string) string {
+	for _, entry := range neverDelete {
+		key, value, hasValue := strings.Cut(entry, "=")
+		tagValue, ok := snapshotTag(tags, key)
+		if ok && (!hasValue || tagValue == value) {
+			return entry
+		}
+	}
+	return ""
+}
+
+// unknownSnapshotVolumeID is the volume ID EC2 reports for copied snapshots and others whose
+// source volume is not known
+const unknownSnapshotVolumeID = "vol-ffffffff"
+
+// retentionSource returns the key a snapshot's keep counts are grouped by. Snapshots without a
+// known source volume are unrelated to each other, so each one is its own group.
+func retentionSource(snapshot models.Snapshot) string {
+	if snapshot.VolumeID == "" || snapshot.VolumeID == unknownSnapshotVolumeID {
+		return "snapshot:" + snapshot.SnapshotID
+	}
+	return "volume:" + snapshot.VolumeID
+}
+
+// evaluateRetentionRules decides the fate of every snapshot
+func evaluateRetentionRules(rules []compiledRetentionRule, snapshots []models.Snapshot, accountOUs map[string][]string, now time.Time) []models.SnapshotRetentionDecision {
+	decisions := make([]models.SnapshotRetentionDecision, 0, len(snapshots))
+
+	// Snapshots each rule's keep counts apply to, grouped by source volume
+	type volumeKey struct {
+		rule                      int
+		accountID, region, source string
+	}
+	groups := make(map[volumeKey][]int) // Indexes into decisions
+
+	for _, snapshot := range snapshots {
+		decision := models.SnapshotRetentionDecision{
+			SnapshotID: snapshot.SnapshotID,
+			AccountID:  snapshot.AccountID,
+			Region:     snapshot.Region,
+			VolumeID:   snapshot.VolumeID,
+			StartTime:  snapshot.StartTime,
+			Action:     models.RetentionKeep,
+		}
+
+		ruleIndex := -1
+		for i, rule := range rules {
+			if rule.matches(snapshot, accountOUs) {
+				ruleIndex = i
+				decision.Rule = rule.Name
+				break
+			}
+		}
+
+		switch {
+		case snapshot.State != "" && snapshot.State != "completed":
+			decision.Reasons = []string{fmt.Sprintf("snapshot is %s", snapshot.State)}
+		case ruleIndex == -1:
+			decision.Reasons = []string{"no rule matches"}
+		case snapshot.ProtectedReason != "":
+			decision.Reasons = []string{snapshot.ProtectedReason}
+		case neverDeleteTag(snapshot.Tags, rules[ruleIndex].NeverDeleteTags) != "":
+			decision.Reasons = []string{fmt.Sprintf("has never-delete tag %s", neverDeleteTag(snapshot.Tags, rules[ruleIndex].NeverDeleteTags))}
+		default:
+			key := volumeKey{ruleIndex, snapshot.AccountID, snapshot.Region, retentionSource(snapshot)}
+			groups[key] = append(groups[key], len(decisions))
+		}
+		decisions = append(decisions, decision)
+	}
+
+	for key, indexes := range groups {
+		rule := rules[key.rule]
+		sort.Slice(indexes, func(i, j int) bool {
+			return decisions[indexes[i]].StartTime.After(decisions[indexes[j]].StartTime)
+		})
+
+		days := make(map[string]bool)
+		weeks := make(map[string]bool)
+		months := make(map[string]bool)
+		for position, idx := range indexes {
+			decision := &decisions[idx]
+			startTime := decision.StartTime.UTC()
+			var reasons []string
+
+			keepLast := position < rule.KeepLast
+			if keepLast {
+				reasons = append(reasons, fmt.Sprintf("one of the last %d for the volume", rule.KeepLast))
+			}
+			if day := startTime.Format("2006-01-02"); !days[day] && len(days) < rule.KeepDaily {
+				days[day] = true
+				reasons = append(reasons, fmt.Sprintf("daily generation for %s", day))
+			}
+			year, week := startTime.ISOWeek()
+			if w := fmt.Sprintf("%d-W%02d", year, week); !weeks[w] && len(weeks) < rule.KeepWeekly {
+				weeks[w] = true
+				reasons = append(reasons, fmt.Sprintf("weekly generation for %s", w))
+			}
+			if month := startTime.Format("2006-01"); !months[month] && len(months) < rule.KeepMonthly {
+				months[month] = true
+				reasons = append(reasons, fmt.Sprintf("monthly generation for %s", month))
+			}
+
+			// keep_last outranks max age so a volume whose backups stopped never loses its last snapshots
+			switch {
+			case keepLast:
+				decision.Reasons = reasons
+			case rule.MaxAgeDays > 0 && now.Sub(decision.StartTime) > time.Duration(rule.MaxAgeDays)*24*time.Hour:
+				decision.Action = models.RetentionDelete
+				decision.Reasons = []string{fmt.Sprintf("older than max age of %d days", rule.MaxAgeDays)}
+			case len(reasons) > 0:
+				decision.Reasons = reasons
+			case retentionRuleHasKeepCounts(rule.SnapshotRetentionRule):
+				decision.Action = models.RetentionDelete
+				decision.Reasons = []string{"not retained by keep_last or daily/weekly/monthly generations"}
+			default:
+				decision.Reasons = []string{fmt.Sprintf("within max age of %d days", rule.MaxAgeDays)}
+			}
+		}
+	}
+
+	sort.SliceStable(decisions, func(i, j int) bool {
+		a, b := decisions[i], decisions[j]
+		if a.AccountID != b.AccountID {
+			return a.AccountID < b.AccountID
+		}
+		if a.Region != b.Region {
+			return a.Region < b.Region
+		}
+		if a.VolumeID != b.VolumeID {
+			return a.VolumeID < b.VolumeID
+		}
+		return a.StartTime.After(b.StartTime)
+	})
+	return decisions
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rusik69/aws-iam-manager/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func retentionDecisions(t *testing.T, policy models.SnapshotRetentionPolicy, snapshots []models.Snapshot, accountOUs map[string][]string, now time.Time) map[string]models.SnapshotRetentionDecision {
+	rules, err := compileRetentionPolicy(policy)
+	require.NoError(t, err)
+
+	byID := make(map[string]models.SnapshotRetentionDecision)
+	for _, decision := range evaluateRetentionRules(rules, snapshots, accountOUs, now) {
+		byID[decision.SnapshotID] = decision
+	}
+	return byID
+}
+
+func TestCompileRetentionPolicy(t *testing.T) {
+	_, err := compileRetentionPolicy(models.SnapshotRetentionPolicy{Rules: []models.SnapshotRetentionRule{{KeepLast: 1}}})
+	assert.ErrorContains(t, err, "name is required")
+
+	_, err = compileRetentionPolicy(models.SnapshotRetentionPolicy{Name: "p"})
+	assert.ErrorContains(t, err, "at least one rule")
+
+	_, err = compileRetentionPolicy(models.SnapshotRetentionPolicy{Name: "p", Rules: []models.SnapshotRetentionRule{{Name: "empty"}}})
+	assert.ErrorContains(t, err, `invalid retention rule "empty"`)
+
+	_, err = compileRetentionPolicy(models.SnapshotRetentionPolicy{Name: "p", Rules: []models.SnapshotRetentionRule{
+		{KeepLast: 1, Match: models.SnapshotRetentionMatch{DescriptionPattern: "("}},
+	}})
+	assert.ErrorContains(t, err, "description_pattern")
+
+	policy := models.SnapshotRetentionPolicy{Name: "p", Rules: []models.SnapshotRetentionRule{{MaxAgeDays: 30}}}
+	_, err = compileRetentionPolicy(policy)
+	require.NoError(t, err)
+	assert.Equal(t, "rule-1", policy.Rules[0].Name)
+}
+
+func TestEvaluateRetentionRulesGFS(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	// Two snapshots a day for the last 60 days of one volume
+	var snapshots []models.Snapshot
+	for day := 0; day < 60; day++ {
+		for _, hour := range []int{1, 13} {
+			start := time.Date(2026, 10, 18, hour, 0, 0, 0, time.UTC).AddDate(0, 0, -day)
+			if start.After(now) {
+				continue
+			}
+			snapshots = append(snapshots, models.Snapshot{
+				SnapshotID: "snap-" + start.Format("0102-15"),
+				AccountID:  "111111111111",
+				Region:     "us-east-1",
+				VolumeID:   "vol-1",
+				State:      "completed",
+				StartTime:  start,
+			})
+		}
+	}
+
+	policy := models.SnapshotRetentionPolicy{Name: "gfs", Rules: []models.SnapshotRetentionRule{
+		{Name: "gfs", KeepLast: 2, KeepDaily: 7, KeepWeekly: 4, KeepMonthly: 3},
+	}}
+	decisions := retentionDecisions(t, policy, snapshots, nil, now)
+
+	kept := 0
+	for _, decision := range decisions {
+		if decision.Action == models.RetentionKeep {
+			kept++
+		}
+	}
+	// 2026-10-18 is a Sunday, so ISO week 42 is Oct 12-18
+	assert.Equal(t, models.RetentionKeep, decisions["snap-1018-01"].Action)
+	assert.Equal(t, []string{
+		"one of the last 2 for the volume", "daily generation for 2026-10-18",
+		"weekly generation for 2026-W42", "monthly generation for 2026-10",
+	}, decisions["snap-1018-01"].Reasons)
+	assert.Equal(t, models.RetentionKeep, decisions["snap-1017-13"].Action)
+	assert.Equal(t, models.RetentionDelete, decisions["snap-1017-01"].Action, "not the newest of its day")
+	assert.Equal(t, models.RetentionKeep, decisions["snap-1012-13"].Action, "seventh daily generation")
+	assert.Equal(t, []string{"weekly generation for 2026-W41"}, decisions["snap-1011-13"].Reasons)
+	assert.Equal(t, models.RetentionDelete, decisions["snap-1010-13"].Action)
+	assert.Equal(t, []string{"not retained by keep_last or daily/weekly/monthly generations"}, decisions["snap-1010-13"].Reasons)
+	assert.Equal(t, []string{"monthly generation for 2026-09"}, decisions["snap-0930-13"].Reasons)
+	assert.Equal(t, []string{"weekly generation for 2026-W39"}, decisions["snap-0927-13"].Reasons)
+	assert.Equal(t, []string{"monthly generation for 2026-08"}, decisions["snap-0831-13"].Reasons)
+
+	// 7 daily (including the last 2), weeks 41, 40 and 39, and the September and August months
+	assert.Equal(t, 12, kept)
+}
+
+func TestEvaluateRetentionRulesOrderAndExceptions(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	old := now.AddDate(0, 0, -100)
+
+	snapshots := []models.Snapshot{
+		{SnapshotID: "snap-prod", AccountID: "1", VolumeID: "vol-1", State: "completed", StartTime: old, Tags: []models.Tag{{Key: "env", Value: "prod"}}},
+		{SnapshotID: "snap-legal", AccountID: "1", VolumeID: "vol-2", State: "completed", StartTime: old, Tags: []models.Tag{{Key: "env", Value: "dev"}, {Key: "legal-hold", Value: "yes"}}},
+		{SnapshotID: "snap-dev", AccountID: "1", VolumeID: "vol-3", State: "completed", StartTime: old, Tags: []models.Tag{{Key: "env", Value: "dev"}}},
+		{SnapshotID: "snap-ami", AccountID: "1", VolumeID: "vol-4", State: "completed", StartTime: old, Tags: []models.Tag{{Key: "env", Value: "dev"}}, ProtectedReason: "backs registered AMI ami-1"},
+		{SnapshotID: "snap-pending", AccountID: "1", VolumeID: "vol-5", State: "pending", StartTime: old, Tags: []models.Tag{{Key: "env", Value: "dev"}}},
+		{SnapshotID: "snap-nightly", AccountID: "2", VolumeID: "vol-6", State: "completed", StartTime: old, Description: "nightly backup"},
+		{SnapshotID: "snap-fresh", AccountID: "3", VolumeID: "vol-7", State: "completed", StartTime: now.AddDate(0, 0, -1)},
+		{SnapshotID: "snap-other", AccountID: "4", VolumeID: "vol-8", State: "completed", StartTime: old},
+	}
+
+	policy := models.SnapshotRetentionPolicy{Name: "p", Rules: []models.SnapshotRetentionRule{
+		{Name: "prod", Match: models.SnapshotRetentionMatch{Tags: map[string]string{"env": "prod"}}, KeepMonthly: 12},
+		{Name: "dev", Match: models.SnapshotRetentionMatch{Tags: map[string]string{"env": "*"}}, MaxAgeDays: 30, NeverDeleteTags: []string{"legal-hold=yes"}},
+		{Name: "nightly", Match: models.SnapshotRetentionMatch{DescriptionPattern: "^nightly"}, MaxAgeDays: 30},
+		{Name: "ou", Match: models.SnapshotRetentionMatch{OUIDs: []string{"ou-parent"}}, MaxAgeDays: 30},
+	}}
+	decisions := retentionDecisions(t, policy, snapshots, map[string][]string{"3": {"ou-child", "ou-parent"}}, now)
+
+	assert.Equal(t, models.SnapshotRetentionDecision{
+		SnapshotID: "snap-prod", AccountID: "1", VolumeID: "vol-1", StartTime: old,
+		Rule: "prod", Action: models.RetentionKeep, Reasons: []string{"monthly generation for 2026-07"},
+	}, decisions["snap-prod"])
+	assert.Equal(t, []string{"has never-delete tag legal-hold=yes"}, decisions["snap-legal"].Reasons)
+	assert.Equal(t, models.RetentionDelete, decisions["snap-dev"].Action)
+	assert.Equal(t, []string{"older than max age of 30 days"}, decisions["snap-dev"].Reasons)
+	assert.Equal(t, models.RetentionKeep, decisions["snap-ami"].Action)
+	assert.Equal(t, []string{"backs registered AMI ami-1"}, decisions["snap-ami"].Reasons)
+	assert.Equal(t, []string{"snapshot is pending"}, decisions["snap-pending"].Reasons)
+	assert.Equal(t, "nightly", decisions["snap-nightly"].Rule)
+	assert.Equal(t, models.RetentionDelete, decisions["snap-nightly"].Action)
+	assert.Equal(t, "ou", decisions["snap-fresh"].Rule)
+	assert.Equal(t, []string{"within max age of 30 days"}, decisions["snap-fresh"].Reasons)
+	assert.Equal(t, models.RetentionKeep, decisions["snap-other"].Action)
+	assert.Equal(t, []string{"no rule matches"}, decisions["snap-other"].Reasons)
+}
+
+func TestEvaluateRetentionRulesKeepsSnapshotsWithoutSourceVolumeApart(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	// Copies of unrelated volumes all report the placeholder volume ID
+	snapshots := []models.Snapshot{
+		{SnapshotID: "snap-copy-1", AccountID: "1", VolumeID: unknownSnapshotVolumeID, State: "completed", StartTime: now.AddDate(0, 0, -1)},
+		{SnapshotID: "snap-copy-2", AccountID: "1", VolumeID: unknownSnapshotVolumeID, State: "completed", StartTime: now.AddDate(0, 0, -2)},
+		{SnapshotID: "snap-none", AccountID: "1", State: "completed", StartTime: now.AddDate(0, 0, -3)},
+		{SnapshotID: "snap-vol-new", AccountID: "1", VolumeID: "vol-1", State: "completed", StartTime: now.AddDate(0, 0, -1)},
+		{SnapshotID: "snap-vol-old", AccountID: "1", VolumeID: "vol-1", State: "completed", StartTime: now.AddDate(0, 0, -2)},
+	}
+
+	policy := models.SnapshotRetentionPolicy{Name: "p", Rules: []models.SnapshotRetentionRule{{Name: "last", KeepLast: 1}}}
+	decisions := retentionDecisions(t, policy, snapshots, nil, now)
+
+	for _, snapshotID := range []string{"snap-copy-1", "snap-copy-2", "snap-none", "snap-vol-new"} {
+		assert.Equal(t, models.RetentionKeep, decisions[snapshotID].Action, snapshotID)
+	}
+	assert.Equal(t, models.RetentionDelete, decisions["snap-vol-old"].Action)
+}
+
+func TestEvaluateRetentionRulesKeepLastOverridesMaxAge(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+
+	// The volume's backups stopped 31 days ago
+	var snapshots []models.Snapshot
+	for i := 0; i < 5; i++ {
+		snapshots = append(snapshots, models.Snapshot{
+			SnapshotID: fmt.Sprintf("snap-%d", i),
+			AccountID:  "1",
+			VolumeID:   "vol-1",
+			State:      "completed",
+			StartTime:  now.AddDate(0, 0, -31-i),
+		})
+	}
+
+	policy := models.SnapshotRetentionPolicy{Name: "p", Rules: []models.SnapshotRetentionRule{
+		{Name: "stale", KeepLast: 3, KeepDaily: 5, MaxAgeDays: 30},
+	}}
+	decisions := retentionDecisions(t, policy, snapshots, nil, now)
+
+	for _, snapshotID := range []string{"snap-0", "snap-1", "snap-2"} {
+		assert.Equal(t, models.RetentionKeep, decisions[snapshotID].Action, snapshotID)
+		assert.Contains(t, decisions[snapshotID].Reasons, "one of the last 3 for the volume")
+	}
+	// Daily generations do not outrank max age
+	for _, snapshotID := range []string{"snap-3", "snap-4"} {
+		assert.Equal(t, models.RetentionDelete, decisions[snapshotID].Action, snapshotID)
+		assert.Equal(t, []string{"older than max age of 30 days"}, decisions[snapshotID].Reasons)
+	}
+}
+
+func TestRetentionPolicyCRUD(t *testing.T) {
+	s := &AWSService{retentionPolicies: newRetentionPolicyStore("")}
+
+	created, err := s.CreateRetentionPolicy(models.SnapshotRetentionPolicy{Name: "daily", Rules: []models.SnapshotRetentionRule{{KeepDaily: 7}}})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, "rule-1", created.Rules[0].Name)
+
+	updated, err := s.UpdateRetentionPolicy(created.ID, models.SnapshotRetentionPolicy{Name: "weekly", Rules: []models.SnapshotRetentionRule{{Name: "w", KeepWeekly: 4}}})
+	require.NoError(t, err)
+	assert.Equal(t, "weekly", updated.Name)
+	assert.Equal(t, created.CreatedAt, updated.CreatedAt)
+
+	assert.Len(t, s.ListRetentionPolicies(), 1)
+	require.NoError(t, s.DeleteRetentionPolicy(created.ID))
+	_, err = s.GetRetentionPolicy(created.ID)
+	assert.ErrorIs(t, err, ErrRetentionPolicyNotFound)
+}
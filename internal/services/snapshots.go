@@ -116,12 +116,9 @@ func (s *AWSService) ListSnapshotsByAccount(accountID string) ([]models.Snapshot
 		}
 	}
 
-	// Get all regions
-	regions := []string{
-		"us-east-1", "us-east-2", "us-west-1", "us-west-2",
-		"eu-west-1", "eu-west-2", "eu-west-3", "eu-central-1", "eu-north-1",
-		"ap-southeast-1", "ap-southeast-2", "ap-northeast-1", "ap-northeast-2", "ap-south-1",
-		"sa-east-1", "ca-central-1",
+	scan, err := s.startRegionScan(scannerSnapshots, accountID, sess)
+	if err != nil {
+		return nil, err
 	}
 
 	var allSnapshots []models.Snapshot
@@ -129,7 +126,7 @@ func (s *AWSService) ListSnapshotsByAccount(accountID string) ([]models.Snapshot
 	var wg sync.WaitGroup
 
 	// Process each region in parallel
-	for _, region := range regions {
+	for _, region := range scan.Regions() {
 		wg.Add(1)
 		go func(r string) {
 			defer wg.Done()
@@ -137,19 +134,16 @@ func (s *AWSService) ListSnapshotsByAccount(accountID string) ([]models.Snapshot
 			regionSess := sess.Copy(&aws.Config{Region: aws.String(r)})
 			ec2Client := ec2.New(regionSess)
 
-			// List snapshots owned by this account
-			input := &ec2.DescribeSnapshotsInput{
-				OwnerIds: []*string{aws.String(accountID)},
-			}
-
-			result, err := ec2Client.DescribeSnapshots(input)
+			// List snapshots owned by this account with the volumes and AMIs they are classified against
+			inventory, err := describeRegionSnapshots(ec2Client, accountID)
 			if err != nil {
 				fmt.Printf("[WARNING] Failed to list snapshots in %s for account %s: %v\n", r, accountID, err)
+				scan.Fail(r, err)
 				return
 			}
 
 			var regionSnapshots []models.Snapshot
-			for _, snap := range result.Snapshots {
+			for _, snap := range inventory.snapshots {
 				snapshot := models.Snapshot{
 					SnapshotID:  aws.StringValue(snap.SnapshotId),
 					VolumeID:    aws.StringValue(snap.VolumeId),
@@ -176,6 +170,11 @@ func (s *AWSService) ListSnapshotsByAccount(accountID string) ([]models.Snapshot
 					})
 				}
 
+				snapshot.StorageTier = aws.StringValue(snap.StorageTier)
+				snapshot.RestoreExpiryTime = snap.RestoreExpiryTime
+
+				classifySnapshot(&snapshot, inventory.existingVolumes, inventory.amisBySnapshot)
+				applySnapshotCost(&snapshot)
 				regionSnapshots = append(regionSnapshots, snapshot)
 			}
 
@@ -186,6 +185,7 @@ func (s *AWSService) ListSnapshotsByAccount(accountID string) ([]models.Snapshot
 	}
 
 	wg.Wait()
+	scan.Finish()
 
 	// Cache the result
 	s.cache.Set(cacheKey, allSnapshots, s.cacheTTL)
@@ -218,6 +218,9 @@ func (s *AWSService) DeleteSnapshot(accountID, region, snapshotID string) error
 
 // updateSnapshotCache removes a deleted snapshot from the cache
 func (s *AWSService) updateSnapshotCache(accountID, snapshotID string) {
+	// Cost reports are recomputed rather than patched
+	s.cache.DeletePattern("snapshots:costs:")
+
 	// Update account-specific cache
 	cacheKey := fmt.Sprintf("snapshots:%s", accountID)
 	if cached, found := s.cache.Get(cacheKey); found {
@@ -246,8 +249,9 @@ func (s *AWSService) updateSnapshotCache(accountID, snapshotID string) {
 	}
 }
 
-// DeleteOldSnapshots deletes all snapshots older than the specified number of months for an account
-func (s *AWSService) DeleteOldSnapshots(accountID string, olderThanMonths int) ([]string, error) {
+// DeleteOldSnapshots deletes all snapshots older than the specified number of months for an account.
+// Snapshots backing AMIs or managed by AWS Backup or DLM are skipped and reported with the reason.
+func (s *AWSService) DeleteOldSnapshots(accountID string, olderThanMonths int) (*models.SnapshotCleanupResult, error) {
 	// Get all snapshots for the account
 	snapshots, err := s.ListSnapshotsByAccount(accountID)
 	if err != nil {
@@ -257,16 +261,26 @@ func (s *AWSService) DeleteOldSnapshots(accountID string, olderThanMonths int) (
 	// Calculate cutoff date
 	cutoffDate := time.Now().AddDate(0, -olderThanMonths, 0)
 
-	// Filter snapshots older than cutoff
+	// Filter snapshots older than cutoff, leaving protected ones alone
+	result := &models.SnapshotCleanupResult{Deleted: []string{}, Skipped: []models.SkippedSnapshot{}}
 	var oldSnapshots []models.Snapshot
 	for _, snap := range snapshots {
-		if snap.StartTime.Before(cutoffDate) && snap.State == "completed" {
-			oldSnapshots = append(oldSnapshots, snap)
+		if !snap.StartTime.Before(cutoffDate) || snap.State != "completed" {
+			continue
+		}
+		if snap.ProtectedReason != "" {
+			result.Skipped = append(result.Skipped, models.SkippedSnapshot{
+				SnapshotID: snap.SnapshotID,
+				Region:     snap.Region,
+				Reason:     snap.ProtectedReason,
+			})
+			continue
 		}
+		oldSnapshots = append(oldSnapshots, snap)
 	}
 
 	if len(oldSnapshots) == 0 {
-		return []string{}, nil
+		return result, nil
 	}
 
 	// Delete snapshots in parallel
@@ -296,26 +310,25 @@ func (s *AWSService) DeleteOldSnapshots(accountID string, olderThanMonths int) (
 	}()
 
 	// Collect results
-	var deletedSnapshots []string
 	var errors []error
-	for result := range resultChan {
-		if result.err != nil {
-			errors = append(errors, fmt.Errorf("failed to delete snapshot %s: %w", result.snapshotID, result.err))
+	for deleted := range resultChan {
+		if deleted.err != nil {
+			errors = append(errors, fmt.Errorf("failed to delete snapshot %s: %w", deleted.snapshotID, deleted.err))
 		} else {
-			deletedSnapshots = append(deletedSnapshots, result.snapshotID)
+			result.Deleted = append(result.Deleted, deleted.snapshotID)
 		}
 	}
 
 	// If there were any errors, return them
 	if len(errors) > 0 {
-		errMsg := fmt.Sprintf("deleted %d snapshots, but encountered %d errors", len(deletedSnapshots), len(errors))
+		errMsg := fmt.Sprintf("deleted %d snapshots, but encountered %d errors", len(result.Deleted), len(errors))
 		for _, e := range errors {
 			errMsg += "; " + e.Error()
 		}
-		return deletedSnapshots, fmt.Errorf(errMsg)
+		return result, fmt.Errorf("%s", errMsg)
 	}
 
-	return deletedSnapshots, nil
+	return result, nil
 }
 
 // InvalidateSnapshotsCache invalidates the snapshots cache
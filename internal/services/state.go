@@ -0,0 +1,75 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// stateFile persists a single JSON document under the configured state directory.
+// When no state directory is configured, Load and Save are no-ops and state lives in memory only.
+type stateFile struct {
+	mu   sync.Mutex
+	path string
+}
+
+// newStateFile returns a state file named name inside dir
+func newStateFile(dir, name string) *stateFile {
+	if dir == "" {
+		return &stateFile{}
+	}
+	return &stateFile{path: filepath.Join(dir, name)}
+}
+
+// Load decodes the stored document into v. A missing file leaves v untouched.
+func (f *stateFile) Load(v any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.path == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read state file %s: %w", f.path, err)
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode state file %s: %w", f.path, err)
+	}
+	return nil
+}
+
+// Save atomically replaces the stored document with v
+func (f *stateFile) Save(v any) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %w", err)
+	}
+
+	tmpPath := f.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write state file %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		return fmt.Errorf("failed to replace state file %s: %w", f.path, err)
+	}
+	return nil
+}
@@ -81,6 +81,7 @@ func (s *AWSService) ListUsers(accountID string) ([]models.User, error) {
 			PasswordSet:      passwordSet,
 			PasswordLastUsed: passwordLastUsed,
 			AccessKeys:       accessKeys,
+			Tags:             getTagsForUser(iamClient, user.UserName),
 		})
 		}
 
@@ -244,6 +245,7 @@ func (s *AWSService) GetUser(accountID, username string) (*models.User, error) {
 		PasswordSet:      passwordSet,
 		PasswordLastUsed: passwordLastUsed,
 		AccessKeys:       accessKeys,
+		Tags:             getTagsForUser(iamClient, user.UserName),
 	}
 
 	// Cache the result
@@ -266,18 +268,31 @@ func (s *AWSService) CreateAccessKey(accountID, username string) (map[string]any
 		return nil, err
 	}
 
+	key := result.AccessKey
+	secretRef, err := s.secrets.Store("access_key", accountID, username, map[string]string{
+		"access_key_id":     *key.AccessKeyId,
+		"secret_access_key": *key.SecretAccessKey,
+	})
+	if err != nil {
+		// Without a way to hand over the secret the new key is useless
+		_, _ = iamClient.DeleteAccessKey(&iam.DeleteAccessKeyInput{
+			UserName:    aws.String(username),
+			AccessKeyId: key.AccessKeyId,
+		})
+		return nil, fmt.Errorf("failed to store new key secret: %v", err)
+	}
+
 	// Invalidate related cache entries
 	s.cache.Delete(fmt.Sprintf("user:%s:%s", accountID, username))
 	s.cache.Delete(fmt.Sprintf("users:%s", accountID))
 	// Also invalidate accounts cache in case it includes user/key counts
 	s.cache.Delete("accounts")
 
-	key := result.AccessKey
 	response := map[string]any{
-		"access_key_id":     *key.AccessKeyId,
-		"secret_access_key": *key.SecretAccessKey,
-		"status":            *key.Status,
-		"create_date":       *key.CreateDate,
+		"access_key_id":    *key.AccessKeyId,
+		"status":           *key.Status,
+		"create_date":      *key.CreateDate,
+		"secret_reference": secretRef,
 	}
 
 	return response, nil
@@ -322,6 +337,21 @@ func (s *AWSService) RotateAccessKey(accountID, username, keyID string) (map[str
 		return nil, err
 	}
 
+	key := createResult.AccessKey
+
+	// Store the secret before the old key goes away so the user is never left without a usable key
+	secretRef, err := s.secrets.Store("access_key", accountID, username, map[string]string{
+		"access_key_id":     *key.AccessKeyId,
+		"secret_access_key": *key.SecretAccessKey,
+	})
+	if err != nil {
+		_, _ = iamClient.DeleteAccessKey(&iam.DeleteAccessKeyInput{
+			UserName:    aws.String(username),
+			AccessKeyId: key.AccessKeyId,
+		})
+		return nil, fmt.Errorf("failed to store new key secret: %v", err)
+	}
+
 	// Delete old key
 	_, err = iamClient.DeleteAccessKey(&iam.DeleteAccessKeyInput{
 		UserName:    aws.String(username),
@@ -331,7 +361,7 @@ func (s *AWSService) RotateAccessKey(accountID, username, keyID string) (map[str
 		// If deletion fails, try to delete the new key we just created
 		_, _ = iamClient.DeleteAccessKey(&iam.DeleteAccessKeyInput{
 			UserName:    aws.String(username),
-			AccessKeyId: createResult.AccessKey.AccessKeyId,
+			AccessKeyId: key.AccessKeyId,
 		})
 		return nil, fmt.Errorf("failed to delete old key: %v", err)
 	}
@@ -342,13 +372,12 @@ func (s *AWSService) RotateAccessKey(accountID, username, keyID string) (map[str
 	// Also invalidate accounts cache in case it includes user/key counts
 	s.cache.Delete("accounts")
 
-	key := createResult.AccessKey
 	response := map[string]any{
-		"access_key_id":     *key.AccessKeyId,
-		"secret_access_key": *key.SecretAccessKey,
-		"status":            *key.Status,
-		"create_date":       *key.CreateDate,
-		"message":           "Access key rotated successfully",
+		"access_key_id":    *key.AccessKeyId,
+		"status":           *key.Status,
+		"create_date":      *key.CreateDate,
+		"secret_reference": secretRef,
+		"message":          "Access key rotated successfully",
 	}
 
 	return response, nil
@@ -613,8 +642,14 @@ func (s *AWSService) RotateUserPassword(accountID, username string) (map[string]
 		}
 	}
 
-	// Generate a new password
+	// Generate a new password and store it before IAM sees it, so a password that is set can always be retrieved
 	newPassword := s.generatePassword()
+	secretRef, err := s.secrets.Store("password", accountID, username, map[string]string{
+		"password": newPassword,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store new password, password not changed: %v", err)
+	}
 
 	if hasLoginProfile {
 		// Update existing login profile
@@ -623,6 +658,7 @@ func (s *AWSService) RotateUserPassword(accountID, username string) (map[string]
 			Password: aws.String(newPassword),
 		})
 		if err != nil {
+			s.secrets.Discard(secretRef)
 			return nil, fmt.Errorf("failed to update user password: %v", err)
 		}
 	} else {
@@ -632,6 +668,7 @@ func (s *AWSService) RotateUserPassword(accountID, username string) (map[string]
 			Password: aws.String(newPassword),
 		})
 		if err != nil {
+			s.secrets.Discard(secretRef)
 			return nil, fmt.Errorf("failed to create user password: %v", err)
 		}
 	}
@@ -643,9 +680,9 @@ func (s *AWSService) RotateUserPassword(accountID, username string) (map[string]
 	s.cache.Delete("accounts")
 
 	response := map[string]any{
-		"message":      "User password rotated successfully",
-		"new_password": newPassword,
-		"username":     username,
+		"message":          "User password rotated successfully",
+		"username":         username,
+		"secret_reference": secretRef,
 	}
 
 	return response, nil
@@ -689,6 +726,23 @@ func (s *AWSService) getAccessKeysForUser(iamClient *iam.IAM, userName *string)
 	return accessKeys, nil
 }
 
+// getTagsForUser returns a user's tags; ListUsers does not include them
+func getTagsForUser(iamClient *iam.IAM, userName *string) []models.Tag {
+	var tags []models.Tag
+	err := iamClient.ListUserTagsPages(&iam.ListUserTagsInput{
+		UserName: userName,
+	}, func(page *iam.ListUserTagsOutput, lastPage bool) bool {
+		for _, tag := range page.Tags {
+			tags = append(tags, models.Tag{Key: aws.StringValue(tag.Key), Value: aws.StringValue(tag.Value)})
+		}
+		return true
+	})
+	if err != nil {
+		fmt.Printf("[WARNING] Failed to get tags for user %s: %v\n", aws.StringValue(userName), err)
+	}
+	return tags
+}
+
 // DeleteInactiveUsers deletes users that have been inactive for 6+ months
 // A user is considered inactive if:
 // - PasswordLastUsed is nil or older than 6 months, AND
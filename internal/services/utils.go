@@ -6,7 +6,6 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/ec2"
 )
 
 // generatePassword generates a secure password meeting AWS requirements
@@ -46,24 +45,6 @@ func (s *AWSService) generatePassword() string {
 	return string(password)
 }
 
-
-// getRegions gets all available AWS regions
-func (s *AWSService) getRegions(sess *session.Session) ([]string, error) {
-	ec2Client := ec2.New(sess)
-	result, err := ec2Client.DescribeRegions(&ec2.DescribeRegionsInput{})
-	if err != nil {
-		return nil, err
-	}
-
-	var regions []string
-	for _, region := range result.Regions {
-		if region.RegionName != nil {
-			regions = append(regions, *region.RegionName)
-		}
-	}
-	return regions, nil
-}
-
 // getSessionForAccountAndRegion gets a session for a specific account and region
 func (s *AWSService) getSessionForAccountAndRegion(accountID, region string) (*session.Session, error) {
 	sess, err := s.getSessionForAccount(accountID)
@@ -116,19 +116,18 @@ func (s *AWSService) ListVPCsByAccount(accountID string) ([]models.VPC, error) {
 		return nil, fmt.Errorf("cannot access account %s: %v", accountID, err)
 	}
 
-	// Get all regions
-	ec2Client := ec2.New(sess)
-	regionsResult, err := ec2Client.DescribeRegions(&ec2.DescribeRegionsInput{})
+	scan, err := s.startRegionScan(scannerVPCs, accountID, sess)
 	if err != nil {
-		return nil, fmt.Errorf("failed to describe regions: %v", err)
+		return nil, err
 	}
+	regions := scan.Regions()
 
 	var allVPCs []models.VPC
 	var mu sync.Mutex
 	var wg sync.WaitGroup
 
 	// Process all regions in parallel
-	for _, region := range regionsResult.Regions {
+	for _, region := range regions {
 		wg.Add(1)
 		go func(regionName string) {
 			defer wg.Done()
@@ -141,16 +140,18 @@ func (s *AWSService) ListVPCsByAccount(accountID string) ([]models.VPC, error) {
 				if !strings.Contains(errStr, "AuthFailure") && !strings.Contains(errStr, "not authorized") {
 					fmt.Printf("[WARNING] Failed to get VPCs in region %s for account %s: %v\n", regionName, accountID, err)
 				}
+				scan.Fail(regionName, err)
 				return
 			}
 
 			mu.Lock()
 			allVPCs = append(allVPCs, vpcs...)
 			mu.Unlock()
-		}(*region.RegionName)
+		}(region)
 	}
 
 	wg.Wait()
+	scan.Finish()
 
 	// Cache the result
 	s.cache.Set(cacheKey, allVPCs, s.cacheTTL)